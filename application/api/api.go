@@ -0,0 +1,244 @@
+// Package api exposes the zakat chaincode as a REST API. Every request is
+// authenticated with a bearer token and submitted with the Fabric identity the
+// token belongs to.
+package api
+
+import (
+	_ "embed"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"sync"
+
+	"github.com/izzuddinafif/fabric-zakat/application/client"
+)
+
+// maxBodySize limits the size of request bodies
+const maxBodySize = 1 << 20
+
+//go:embed openapi.yaml
+var openAPISpec []byte
+
+// Ledger is the chaincode as seen by one identity; *client.Client implements it
+type Ledger interface {
+	AddZakat(input client.ZakatInput) error
+	QueryZakat(id string) (client.Zakat, error)
+	GetAllZakat() ([]client.Zakat, error)
+	ZakatExists(id string) (bool, error)
+	DistributeZakat(input client.DistributionInput) error
+	QueryDistribution(id string) (client.Distribution, error)
+	Close() error
+}
+
+// Connector opens a ledger connection as an identity
+type Connector func(identity Identity) (Ledger, error)
+
+// Server serves the REST API, keeping one ledger connection per identity
+type Server struct {
+	users   *Users
+	connect Connector
+
+	mu      sync.Mutex
+	ledgers map[Identity]Ledger
+}
+
+// NewServer returns a server that authenticates against users and connects with connect
+func NewServer(users *Users, connect Connector) *Server {
+	return &Server{users: users, connect: connect, ledgers: map[Identity]Ledger{}}
+}
+
+// Handler returns the routes of the API
+func (s *Server) Handler() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /openapi.yaml", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/yaml")
+		w.Write(openAPISpec)
+	})
+	mux.HandleFunc("POST /zakat", s.authenticated(s.addZakat))
+	mux.HandleFunc("GET /zakat", s.authenticated(s.getAllZakat))
+	mux.HandleFunc("GET /zakat/{id}", s.authenticated(s.queryZakat))
+	mux.HandleFunc("HEAD /zakat/{id}", s.authenticated(s.zakatExists))
+	mux.HandleFunc("POST /distributions", s.authenticated(s.distributeZakat))
+	mux.HandleFunc("GET /distributions/{id}", s.authenticated(s.queryDistribution))
+	return mux
+}
+
+// Close closes the ledger connections
+func (s *Server) Close() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for identity, ledger := range s.ledgers {
+		ledger.Close()
+		delete(s.ledgers, identity)
+	}
+}
+
+// handler is an API handler called with the caller's identity and ledger connection
+type handler func(w http.ResponseWriter, r *http.Request, identity Identity, ledger Ledger)
+
+// authenticated resolves the caller's bearer token to an identity and its ledger
+// connection before calling next
+func (s *Server) authenticated(next handler) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		identity, ok := s.users.Authenticate(r)
+		if !ok {
+			w.Header().Set("WWW-Authenticate", `Bearer realm="zakat"`)
+			writeError(w, http.StatusUnauthorized, errors.New("missing or unknown bearer token"))
+			return
+		}
+		ledger, err := s.ledger(identity)
+		if err != nil {
+			log.Printf("connecting as %s of %s: %v", identity.User, identity.Organization, err)
+			writeError(w, http.StatusBadGateway, errors.New("the ledger is unavailable"))
+			return
+		}
+		next(w, r, identity, ledger)
+	}
+}
+
+// ledger returns the connection of an identity, connecting on first use
+func (s *Server) ledger(identity Identity) (Ledger, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if ledger, ok := s.ledgers[identity]; ok {
+		return ledger, nil
+	}
+	ledger, err := s.connect(identity)
+	if err != nil {
+		return nil, err
+	}
+	s.ledgers[identity] = ledger
+	return ledger, nil
+}
+
+func (s *Server) addZakat(w http.ResponseWriter, r *http.Request, identity Identity, ledger Ledger) {
+	var input client.ZakatInput
+	if err := readJSON(w, r, &input); err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+	if input.Organization == "" {
+		input.Organization = identity.Organization
+	}
+	if input.Organization != identity.Organization {
+		writeError(w, http.StatusForbidden, fmt.Errorf("users of %s cannot record donations for %s", identity.Organization, input.Organization))
+		return
+	}
+
+	if err := ledger.AddZakat(input); err != nil {
+		writeLedgerError(w, err)
+		return
+	}
+	zakat, err := ledger.QueryZakat(input.ID)
+	if err != nil {
+		writeLedgerError(w, err)
+		return
+	}
+	w.Header().Set("Location", "/zakat/"+zakat.ID)
+	writeJSON(w, http.StatusCreated, zakat)
+}
+
+func (s *Server) getAllZakat(w http.ResponseWriter, r *http.Request, identity Identity, ledger Ledger) {
+	zakats, err := ledger.GetAllZakat()
+	if err != nil {
+		writeLedgerError(w, err)
+		return
+	}
+	if zakats == nil {
+		zakats = []client.Zakat{}
+	}
+	writeJSON(w, http.StatusOK, zakats)
+}
+
+func (s *Server) queryZakat(w http.ResponseWriter, r *http.Request, identity Identity, ledger Ledger) {
+	zakat, err := ledger.QueryZakat(r.PathValue("id"))
+	if err != nil {
+		writeLedgerError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, zakat)
+}
+
+func (s *Server) zakatExists(w http.ResponseWriter, r *http.Request, identity Identity, ledger Ledger) {
+	exists, err := ledger.ZakatExists(r.PathValue("id"))
+	if err != nil {
+		w.WriteHeader(statusOf(err))
+		return
+	}
+	if !exists {
+		w.WriteHeader(http.StatusNotFound)
+		return
+	}
+	w.WriteHeader(http.StatusOK)
+}
+
+func (s *Server) distributeZakat(w http.ResponseWriter, r *http.Request, identity Identity, ledger Ledger) {
+	var input client.DistributionInput
+	if err := readJSON(w, r, &input); err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	if err := ledger.DistributeZakat(input); err != nil {
+		writeLedgerError(w, err)
+		return
+	}
+	distribution, err := ledger.QueryDistribution(input.ID)
+	if err != nil {
+		writeLedgerError(w, err)
+		return
+	}
+	w.Header().Set("Location", "/distributions/"+distribution.ID)
+	writeJSON(w, http.StatusCreated, distribution)
+}
+
+func (s *Server) queryDistribution(w http.ResponseWriter, r *http.Request, identity Identity, ledger Ledger) {
+	distribution, err := ledger.QueryDistribution(r.PathValue("id"))
+	if err != nil {
+		writeLedgerError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, distribution)
+}
+
+// readJSON decodes a JSON request body, rejecting unknown fields
+func readJSON(w http.ResponseWriter, r *http.Request, v interface{}) error {
+	decoder := json.NewDecoder(http.MaxBytesReader(w, r.Body, maxBodySize))
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(v); err != nil {
+		return fmt.Errorf("invalid request body: %v", err)
+	}
+	return nil
+}
+
+// writeJSON writes v as the JSON response body
+func writeJSON(w http.ResponseWriter, status int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(v)
+}
+
+// errorResponse is the body of every error response
+type errorResponse struct {
+	Error string `json:"error"`
+}
+
+// writeError writes an error response
+func writeError(w http.ResponseWriter, status int, err error) {
+	writeJSON(w, status, errorResponse{Error: err.Error()})
+}
+
+// writeLedgerError writes the error of a chaincode call. The chaincode's message is
+// returned to the caller; other failures are logged and reported generically.
+func writeLedgerError(w http.ResponseWriter, err error) {
+	status := statusOf(err)
+	var chaincodeErr *client.Error
+	if errors.As(err, &chaincodeErr) && chaincodeErr.Message != "" {
+		writeError(w, status, errors.New(chaincodeErr.Message))
+		return
+	}
+	log.Print(err)
+	writeError(w, status, errors.New(http.StatusText(status)))
+}
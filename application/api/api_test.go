@@ -0,0 +1,195 @@
+package api
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/hyperledger/fabric-gateway/pkg/client"
+	"github.com/hyperledger/fabric-protos-go-apiv2/peer"
+	zakat "github.com/izzuddinafif/fabric-zakat/application/client"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// fakeLedger keeps donations in memory and fails like the chaincode
+type fakeLedger struct {
+	zakats map[string]zakat.Zakat
+	err    error // returned by every call when set
+}
+
+// chaincodeError returns the error a chaincode call fails with for a message
+func chaincodeError(transaction string, message string) error {
+	return zakat.NewError(transaction, status.Error(codes.Unknown, "evaluate call to endorser returned error: chaincode response 500, "+message))
+}
+
+func (l *fakeLedger) AddZakat(input zakat.ZakatInput) error {
+	if l.err != nil {
+		return l.err
+	}
+	if _, ok := l.zakats[input.ID]; ok {
+		return chaincodeError("AddZakat", fmt.Sprintf("the zakat %s already exists", input.ID))
+	}
+	l.zakats[input.ID] = zakat.Zakat{ID: input.ID, Muzakki: input.Muzakki, Amount: input.Amount, Type: input.Type,
+		Status: "collected", Organization: input.Organization, Timestamp: input.Timestamp}
+	return nil
+}
+
+func (l *fakeLedger) QueryZakat(id string) (zakat.Zakat, error) {
+	if l.err != nil {
+		return zakat.Zakat{}, l.err
+	}
+	z, ok := l.zakats[id]
+	if !ok {
+		return zakat.Zakat{}, chaincodeError("QueryZakat", fmt.Sprintf("the zakat transaction %s does not exist", id))
+	}
+	return z, nil
+}
+
+func (l *fakeLedger) GetAllZakat() ([]zakat.Zakat, error) {
+	var zakats []zakat.Zakat
+	for _, z := range l.zakats {
+		zakats = append(zakats, z)
+	}
+	return zakats, l.err
+}
+
+func (l *fakeLedger) ZakatExists(id string) (bool, error) {
+	_, ok := l.zakats[id]
+	return ok, l.err
+}
+
+func (l *fakeLedger) DistributeZakat(input zakat.DistributionInput) error {
+	return chaincodeError("DistributeZakat", fmt.Sprintf("amount %f exceeds pool %s balance %f", input.Amount, input.PoolID, 0.0))
+}
+
+func (l *fakeLedger) QueryDistribution(id string) (zakat.Distribution, error) {
+	return zakat.Distribution{}, chaincodeError("QueryDistribution", fmt.Sprintf("the distribution %s does not exist", id))
+}
+
+func (l *fakeLedger) Close() error {
+	return nil
+}
+
+const malangToken = "malang-secret"
+
+func newTestServer(t *testing.T, ledger *fakeLedger) (*httptest.Server, *[]Identity) {
+	users, err := NewUsers([]User{{Name: "web-malang", TokenSHA256: HashToken(malangToken), Organization: "YDSF Malang", User: "User1"}})
+	require.NoError(t, err)
+
+	var connected []Identity
+	server := NewServer(users, func(identity Identity) (Ledger, error) {
+		connected = append(connected, identity)
+		return ledger, nil
+	})
+	ts := httptest.NewServer(server.Handler())
+	t.Cleanup(ts.Close)
+	return ts, &connected
+}
+
+func request(t *testing.T, ts *httptest.Server, method string, path string, token string, body string) (*http.Response, map[string]interface{}) {
+	req, err := http.NewRequest(method, ts.URL+path, strings.NewReader(body))
+	require.NoError(t, err)
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+	resp, err := ts.Client().Do(req)
+	require.NoError(t, err)
+	defer resp.Body.Close()
+
+	var decoded map[string]interface{}
+	json.NewDecoder(resp.Body).Decode(&decoded)
+	return resp, decoded
+}
+
+func TestAPI(t *testing.T) {
+	ledger := &fakeLedger{zakats: map[string]zakat.Zakat{}}
+	ts, connected := newTestServer(t, ledger)
+	donation := `{"ID": "ZKT-YDSF-MLG-202403-0001", "muzakki": "Ahmad", "amount": 45000, "type": "fitrah", "timestamp": "2024-03-30T08:00:00Z"}`
+
+	// Authentication
+	resp, _ := request(t, ts, "GET", "/zakat", "", "")
+	require.Equal(t, http.StatusUnauthorized, resp.StatusCode)
+	resp, _ = request(t, ts, "GET", "/zakat", "wrong", "")
+	require.Equal(t, http.StatusUnauthorized, resp.StatusCode)
+	require.Empty(t, *connected)
+
+	// The donation is recorded for the caller's organization
+	resp, body := request(t, ts, "POST", "/zakat", malangToken, donation)
+	require.Equal(t, http.StatusCreated, resp.StatusCode)
+	require.Equal(t, "/zakat/ZKT-YDSF-MLG-202403-0001", resp.Header.Get("Location"))
+	require.Equal(t, "YDSF Malang", body["organization"])
+	require.Equal(t, []Identity{{Organization: "YDSF Malang", User: "User1"}}, *connected)
+
+	resp, body = request(t, ts, "POST", "/zakat", malangToken, donation)
+	require.Equal(t, http.StatusConflict, resp.StatusCode)
+	require.Equal(t, "the zakat ZKT-YDSF-MLG-202403-0001 already exists", body["error"])
+
+	resp, _ = request(t, ts, "POST", "/zakat", malangToken, `{"ID": "ZKT-YDSF-JTM-202403-0001", "organization": "YDSF Jatim"}`)
+	require.Equal(t, http.StatusForbidden, resp.StatusCode)
+	resp, _ = request(t, ts, "POST", "/zakat", malangToken, `{"ID": "ZKT-YDSF-MLG-202403-0002", "donor": "Ahmad"}`)
+	require.Equal(t, http.StatusBadRequest, resp.StatusCode)
+
+	// Queries
+	resp, body = request(t, ts, "GET", "/zakat/ZKT-YDSF-MLG-202403-0001", malangToken, "")
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	require.Equal(t, "Ahmad", body["muzakki"])
+	resp, _ = request(t, ts, "GET", "/zakat/ZKT-YDSF-MLG-202403-0009", malangToken, "")
+	require.Equal(t, http.StatusNotFound, resp.StatusCode)
+	resp, _ = request(t, ts, "HEAD", "/zakat/ZKT-YDSF-MLG-202403-0001", malangToken, "")
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	resp, _ = request(t, ts, "HEAD", "/zakat/ZKT-YDSF-MLG-202403-0009", malangToken, "")
+	require.Equal(t, http.StatusNotFound, resp.StatusCode)
+
+	// Business rule violations
+	resp, body = request(t, ts, "POST", "/distributions", malangToken, `{"ID": "DST-YDSF-MLG-202404-0001", "poolId": "POOL-YDSF-MLG-FITRAH", "mustahik": "Budi", "amount": 90000, "timestamp": "2024-04-01T08:00:00Z"}`)
+	require.Equal(t, http.StatusUnprocessableEntity, resp.StatusCode)
+	require.Contains(t, body["error"], "exceeds pool")
+
+	// Failures outside the chaincode are not passed through
+	ledger.err = zakat.NewError("GetAllZakat", status.Error(codes.Unavailable, "connection refused"))
+	resp, body = request(t, ts, "GET", "/zakat", malangToken, "")
+	require.Equal(t, http.StatusBadGateway, resp.StatusCode)
+	require.Equal(t, "Bad Gateway", body["error"])
+
+	// One connection per identity
+	require.Len(t, *connected, 1)
+
+	resp, _ = request(t, ts, "GET", "/openapi.yaml", "", "")
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+}
+
+func TestStatusOf(t *testing.T) {
+	tests := map[string]int{
+		"the pool POOL-YDSF-MLG-FITRAH does not exist":                                             http.StatusNotFound,
+		"the organization YDSF Surabaya is already registered":                                     http.StatusConflict,
+		"the payment qris BSI 240330123456 is already recorded for zakat ZKT-YDSF-MLG-202403-0001": http.StatusConflict,
+		"only organization admins can manage the organization registry":                            http.StatusForbidden,
+		"program PRG-YDSF-MLG-2024-0001 is not active at 2025-01-01T00:00:00Z":                     http.StatusUnprocessableEntity,
+		"invalid organization. YDSF Surabaya is inactive":                                          http.StatusUnprocessableEntity,
+		"invalid amount. Must be greater than 0":                                                   http.StatusBadRequest,
+		"failed to read from world state: timeout":                                                 http.StatusInternalServerError,
+	}
+	for message, expected := range tests {
+		require.Equal(t, expected, statusOf(chaincodeError("AddZakat", message)), message)
+	}
+
+	commitErr := &client.CommitError{TransactionID: "tx1", Code: peer.TxValidationCode_MVCC_READ_CONFLICT}
+	require.Equal(t, http.StatusConflict, statusOf(zakat.NewError("AddZakat", commitErr)))
+	commitErr = &client.CommitError{TransactionID: "tx1", Code: peer.TxValidationCode_ENDORSEMENT_POLICY_FAILURE}
+	require.Equal(t, http.StatusForbidden, statusOf(zakat.NewError("DistributeZakat", commitErr)))
+}
+
+func TestNewUsers(t *testing.T) {
+	_, err := NewUsers([]User{{Name: "web", TokenSHA256: "abc", Organization: "YDSF Malang", User: "User1"}})
+	require.ErrorContains(t, err, "SHA-256")
+	_, err = NewUsers([]User{
+		{Name: "web", TokenSHA256: HashToken("t"), Organization: "YDSF Malang", User: "User1"},
+		{Name: "mobile", TokenSHA256: HashToken("t"), Organization: "YDSF Jatim", User: "User1"},
+	})
+	require.ErrorContains(t, err, "already used")
+}
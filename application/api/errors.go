@@ -0,0 +1,56 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strings"
+
+	"github.com/hyperledger/fabric-gateway/pkg/client"
+	"github.com/hyperledger/fabric-protos-go-apiv2/peer"
+	zakat "github.com/izzuddinafif/fabric-zakat/application/client"
+)
+
+// statusRule maps chaincode messages containing any of its phrases to an HTTP status
+type statusRule struct {
+	status  int
+	phrases []string
+}
+
+// statusRules are checked in order against the chaincode's message
+var statusRules = []statusRule{
+	{http.StatusNotFound, []string{"does not exist"}},
+	{http.StatusConflict, []string{"already exists", "already registered", "already in use", "already recorded"}},
+	{http.StatusForbidden, []string{"only organization admins", "client MSP"}},
+	{http.StatusUnprocessableEntity, []string{"exceeds", "is not active", "does not belong", "does not match", "serves asnaf", "is inactive"}},
+	{http.StatusBadRequest, []string{"invalid", "must", "cannot"}},
+}
+
+// statusOf returns the HTTP status for the error of a chaincode call
+func statusOf(err error) int {
+	var chaincodeErr *zakat.Error
+	if errors.As(err, &chaincodeErr) && chaincodeErr.Message != "" {
+		for _, rule := range statusRules {
+			for _, phrase := range rule.phrases {
+				if strings.Contains(chaincodeErr.Message, phrase) {
+					return rule.status
+				}
+			}
+		}
+		return http.StatusInternalServerError
+	}
+
+	var commitErr *client.CommitError
+	if errors.As(err, &commitErr) {
+		switch commitErr.Code {
+		case peer.TxValidationCode_MVCC_READ_CONFLICT, peer.TxValidationCode_PHANTOM_READ_CONFLICT:
+			return http.StatusConflict
+		case peer.TxValidationCode_ENDORSEMENT_POLICY_FAILURE:
+			return http.StatusForbidden
+		}
+	}
+	if errors.Is(err, context.DeadlineExceeded) {
+		return http.StatusGatewayTimeout
+	}
+	return http.StatusBadGateway
+}
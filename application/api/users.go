@@ -0,0 +1,80 @@
+package api
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"strings"
+)
+
+// Identity is the Fabric identity requests are submitted with
+type Identity struct {
+	Organization string // Organization name as recorded on the ledger, e.g. YDSF Malang
+	User         string // User of the organization in organizations/, e.g. User1
+}
+
+// User is an API user, identified by the SHA-256 of its bearer token
+type User struct {
+	Name         string `json:"name"`
+	TokenSHA256  string `json:"tokenSha256"`
+	Organization string `json:"organization"`
+	User         string `json:"user"`
+}
+
+// Users resolves bearer tokens to Fabric identities
+type Users struct {
+	byToken map[string]Identity
+}
+
+// NewUsers indexes users by token hash
+func NewUsers(users []User) (*Users, error) {
+	u := &Users{byToken: map[string]Identity{}}
+	for _, user := range users {
+		hash := strings.ToLower(user.TokenSHA256)
+		if len(hash) != sha256.Size*2 {
+			return nil, fmt.Errorf("user %s: tokenSha256 must be a hex SHA-256 hash", user.Name)
+		}
+		if user.Organization == "" || user.User == "" {
+			return nil, fmt.Errorf("user %s: organization and user are required", user.Name)
+		}
+		if _, ok := u.byToken[hash]; ok {
+			return nil, fmt.Errorf("user %s: token is already used by another user", user.Name)
+		}
+		u.byToken[hash] = Identity{Organization: user.Organization, User: user.User}
+	}
+	return u, nil
+}
+
+// LoadUsers reads users from a JSON file of the form {"users": [...]}
+func LoadUsers(path string) (*Users, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var file struct {
+		Users []User `json:"users"`
+	}
+	if err := json.Unmarshal(data, &file); err != nil {
+		return nil, fmt.Errorf("failed to parse %s: %w", path, err)
+	}
+	return NewUsers(file.Users)
+}
+
+// Authenticate returns the identity of the request's bearer token
+func (u *Users) Authenticate(r *http.Request) (Identity, bool) {
+	token, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+	if !ok || token == "" {
+		return Identity{}, false
+	}
+	identity, ok := u.byToken[HashToken(token)]
+	return identity, ok
+}
+
+// HashToken returns the hex SHA-256 of a token, as stored in the users file
+func HashToken(token string) string {
+	hash := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(hash[:])
+}
@@ -0,0 +1,89 @@
+// Package baznas renders the BAZNAS collection and distribution report in the
+// formats submitted to BAZNAS.
+package baznas
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math"
+	"strconv"
+
+	"github.com/izzuddinafif/fabric-zakat/application/client"
+)
+
+// Report sections and the category of their total rows
+const (
+	SectionCollection   = "collection"
+	SectionDistribution = "distribution"
+	CategoryTotal       = "total"
+)
+
+// csvHeader is the header row of the CSV export
+// column. jiwa counts the persons fitrah was paid for and is empty for distributions.
+var csvHeader = []string{"organization", "period", "section", "category", "transactions", "persons", "jiwa", "amount_idr"}
+
+// Check verifies that the report's lines add up to its totals
+func Check(report client.BaznasReport) error {
+	var collection, distribution float64
+	for _, line := range report.Collection {
+		collection += line.Amount
+	}
+	for _, line := range report.Distribution {
+		distribution += line.Amount
+	}
+	if math.Abs(collection-report.TotalCollection) >= 0.005 {
+		return fmt.Errorf("collection lines add up to %.2f, total is %.2f", collection, report.TotalCollection)
+	}
+	if math.Abs(distribution-report.TotalDistribution) >= 0.005 {
+		return fmt.Errorf("distribution lines add up to %.2f, total is %.2f", distribution, report.TotalDistribution)
+	}
+	return nil
+}
+
+// WriteJSON writes the report as indented JSON
+func WriteJSON(w io.Writer, report client.BaznasReport) error {
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(report)
+}
+
+// WriteCSV writes the report as one row per collection and distribution line, each
+// section followed by its total row
+func WriteCSV(w io.Writer, report client.BaznasReport) error {
+	writer := csv.NewWriter(w)
+	row := func(section string, category string, transactions int, persons int, jiwa string, amount float64) []string {
+		return []string{
+			report.Organization,
+			report.Period,
+			section,
+			category,
+			strconv.Itoa(transactions),
+			strconv.Itoa(persons),
+			jiwa,
+			strconv.FormatFloat(amount, 'f', 2, 64),
+		}
+	}
+
+	rows := [][]string{csvHeader}
+	donations, jiwa := 0, 0
+	for _, line := range report.Collection {
+		rows = append(rows, row(SectionCollection, line.Type, line.Donations, line.Muzakki, strconv.Itoa(line.Jiwa), line.Amount))
+		donations += line.Donations
+		jiwa += line.Jiwa
+	}
+	rows = append(rows, row(SectionCollection, CategoryTotal, donations, report.Muzakki, strconv.Itoa(jiwa), report.TotalCollection))
+
+	distributions := 0
+	for _, line := range report.Distribution {
+		rows = append(rows, row(SectionDistribution, line.Asnaf, line.Distributions, line.Mustahik, "", line.Amount))
+		distributions += line.Distributions
+	}
+	rows = append(rows, row(SectionDistribution, CategoryTotal, distributions, report.Mustahik, "", report.TotalDistribution))
+
+	if err := writer.WriteAll(rows); err != nil {
+		return fmt.Errorf("failed to write CSV: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,59 @@
+package baznas
+
+import (
+	"bytes"
+	"encoding/json"
+	"testing"
+
+	"github.com/izzuddinafif/fabric-zakat/application/client"
+	"github.com/stretchr/testify/require"
+)
+
+var report = client.BaznasReport{
+	Organization: "YDSF Malang",
+	Period:       "202403",
+	Collection: []client.BaznasCollectionLine{
+		{Type: "fitrah", Donations: 2, Muzakki: 2, Jiwa: 5, Amount: 90000},
+		{Type: "maal", Donations: 1, Muzakki: 1, Amount: 1000000},
+	},
+	Distribution: []client.BaznasDistributionLine{
+		{Asnaf: "amil", Distributions: 1, Mustahik: 1, Amount: 125000},
+		{Asnaf: "miskin", Distributions: 2, Mustahik: 2, Amount: 500000},
+	},
+	TotalCollection:   1090000,
+	TotalDistribution: 625000,
+	Muzakki:           2,
+	Mustahik:          3,
+}
+
+func TestCheck(t *testing.T) {
+	require.NoError(t, Check(report))
+
+	unbalanced := report
+	unbalanced.TotalDistribution = 600000
+	err := Check(unbalanced)
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "distribution lines add up to 625000.00")
+}
+
+func TestWriteCSV(t *testing.T) {
+	var buf bytes.Buffer
+	require.NoError(t, WriteCSV(&buf, report))
+	require.Equal(t, `organization,period,section,category,transactions,persons,jiwa,amount_idr
+YDSF Malang,202403,collection,fitrah,2,2,5,90000.00
+YDSF Malang,202403,collection,maal,1,1,0,1000000.00
+YDSF Malang,202403,collection,total,3,2,5,1090000.00
+YDSF Malang,202403,distribution,amil,1,1,,125000.00
+YDSF Malang,202403,distribution,miskin,2,2,,500000.00
+YDSF Malang,202403,distribution,total,3,3,,625000.00
+`, buf.String())
+}
+
+func TestWriteJSON(t *testing.T) {
+	var buf bytes.Buffer
+	require.NoError(t, WriteJSON(&buf, report))
+
+	var decoded client.BaznasReport
+	require.NoError(t, json.Unmarshal(buf.Bytes(), &decoded))
+	require.Equal(t, report, decoded)
+}
@@ -0,0 +1,34 @@
+package client
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+)
+
+// AnonymousMuzakki is the name anonymous donations are recorded under
+const AnonymousMuzakki = "Hamba Allah"
+
+// NewDonorSecret returns a random secret for an anonymous donor to keep, 128 bits in hex
+func NewDonorSecret() (string, error) {
+	secret := make([]byte, 16)
+	if _, err := rand.Read(secret); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(secret), nil
+}
+
+// DonorCommitment returns the commitment an anonymous donation records instead of the
+// donor's name: the SHA-256 hash of the zakat ID, a colon and the donor's secret, in hex
+func DonorCommitment(zakatID string, secret string) string {
+	sum := sha256.Sum256([]byte(zakatID + ":" + secret))
+	return hex.EncodeToString(sum[:])
+}
+
+// VerifyDonor reports whether the secret is the one an anonymous donation was committed
+// to. It is evaluated, so the secret is not written to the ledger.
+func (c *Client) VerifyDonor(id string, secret string) (bool, error) {
+	var verified bool
+	err := c.evaluate(&verified, "VerifyDonor", id, secret)
+	return verified, err
+}
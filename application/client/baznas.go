@@ -0,0 +1,38 @@
+package client
+
+// BaznasReport is the BAZNAS collection and distribution report returned by the chaincode
+type BaznasReport struct {
+	Organization      string                   `json:"organization"`
+	Period            string                   `json:"period"`
+	Collection        []BaznasCollectionLine   `json:"collection"`
+	Distribution      []BaznasDistributionLine `json:"distribution"`
+	TotalCollection   float64                  `json:"totalCollection"`
+	TotalDistribution float64                  `json:"totalDistribution"`
+	Muzakki           int                      `json:"muzakki"`
+	Mustahik          int                      `json:"mustahik"`
+}
+
+// BaznasCollectionLine totals the donations of one zakat type
+type BaznasCollectionLine struct {
+	Type      string  `json:"type"`
+	Donations int     `json:"donations"`
+	Muzakki   int     `json:"muzakki"`
+	Jiwa      int     `json:"jiwa"`
+	Amount    float64 `json:"amount"`
+}
+
+// BaznasDistributionLine totals the distributions to one asnaf
+type BaznasDistributionLine struct {
+	Asnaf         string  `json:"asnaf"`
+	Distributions int     `json:"distributions"`
+	Mustahik      int     `json:"mustahik"`
+	Amount        float64 `json:"amount"`
+}
+
+// GetBaznasReport returns the BAZNAS report of an organization for a year (YYYY) or month
+// (YYYYMM), or a Hijri year (YYYYH) or month (YYYYHMM)
+func (c *Client) GetBaznasReport(organization string, period string) (BaznasReport, error) {
+	var report BaznasReport
+	err := c.evaluate(&report, "GetBaznasReport", organization, period)
+	return report, err
+}
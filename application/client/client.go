@@ -0,0 +1,218 @@
+// Package client connects off-chain tools to the zakat chaincode through the Fabric Gateway.
+package client
+
+import (
+	"context"
+	"crypto/x509"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+
+	"github.com/hyperledger/fabric-gateway/pkg/client"
+	"github.com/hyperledger/fabric-gateway/pkg/hash"
+	"github.com/hyperledger/fabric-gateway/pkg/identity"
+	"github.com/hyperledger/fabric-protos-go-apiv2/common"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
+)
+
+// Client is a connection to the zakat chaincode as one identity
+type Client struct {
+	mspID     string
+	chaincode string
+	conn      *grpc.ClientConn
+	gateway   *client.Gateway
+	network   *client.Network
+	contract  *client.Contract
+}
+
+// Connect opens a gateway connection with the given configuration
+func Connect(cfg Config) (*Client, error) {
+	conn, err := newGrpcConnection(cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	id, err := newIdentity(cfg)
+	if err != nil {
+		conn.Close()
+		return nil, err
+	}
+	sign, err := newSign(cfg)
+	if err != nil {
+		conn.Close()
+		return nil, err
+	}
+
+	gateway, err := client.Connect(
+		id,
+		client.WithSign(sign),
+		client.WithHash(hash.SHA256),
+		client.WithClientConnection(conn),
+		client.WithEvaluateTimeout(5*time.Second),
+		client.WithEndorseTimeout(15*time.Second),
+		client.WithSubmitTimeout(5*time.Second),
+		client.WithCommitStatusTimeout(1*time.Minute),
+	)
+	if err != nil {
+		conn.Close()
+		return nil, fmt.Errorf("failed to connect to gateway: %w", err)
+	}
+
+	network := gateway.GetNetwork(cfg.Channel)
+	return &Client{
+		mspID:     cfg.MSPID,
+		chaincode: cfg.Chaincode,
+		conn:      conn,
+		gateway:   gateway,
+		network:   network,
+		contract:  network.GetContract(cfg.Chaincode),
+	}, nil
+}
+
+// Close releases the gateway and its gRPC connection
+func (c *Client) Close() error {
+	c.gateway.Close()
+	return c.conn.Close()
+}
+
+// Chaincode returns the name of the chaincode the client calls
+func (c *Client) Chaincode() string {
+	return c.chaincode
+}
+
+// BlockEvents streams the channel's blocks from startBlock until ctx is done or the
+// stream fails, when the channel is closed
+func (c *Client) BlockEvents(ctx context.Context, startBlock uint64) (<-chan *common.Block, error) {
+	return c.network.BlockEvents(ctx, client.WithStartBlock(startBlock))
+}
+
+// ChaincodeEvents streams the chaincode's events after a checkpoint until ctx is done
+// or the stream fails, when the channel is closed. A new checkpoint starts at the
+// next committed block.
+func (c *Client) ChaincodeEvents(ctx context.Context, checkpoint client.Checkpoint) (<-chan *client.ChaincodeEvent, error) {
+	return c.network.ChaincodeEvents(ctx, c.chaincode, client.WithCheckpoint(checkpoint))
+}
+
+// submit runs a transaction endorsed by the client's organization, waits for it to
+// commit and unmarshals its JSON result into result unless result is nil. The
+// client's organization owns the pools and programs the transaction writes, whose
+// key-level policies require its peers.
+func (c *Client) submit(result interface{}, name string, args ...string) error {
+	return c.submitEndorsed(result, name, []string{c.mspID}, args...)
+}
+
+// submitShared runs a transaction writing a record shared by the members, such as the
+// Hijri calendar, endorsed by every active member organization. The record's
+// key-level policy requires a majority of the members, which the client's
+// organization alone does not make once a second organization has joined.
+func (c *Client) submitShared(name string, args ...string) error {
+	organizations, err := c.GetAllOrganizations()
+	if err != nil {
+		return err
+	}
+	return c.submitEndorsed(nil, name, sharedEndorsers(organizations), args...)
+}
+
+// sharedEndorsers returns the MSP IDs of the active member organizations, sorted
+func sharedEndorsers(organizations []Organization) []string {
+	seen := map[string]bool{}
+	var mspIDs []string
+	for _, organization := range organizations {
+		if organization.Status == "active" && !seen[organization.MSPID] {
+			seen[organization.MSPID] = true
+			mspIDs = append(mspIDs, organization.MSPID)
+		}
+	}
+	sort.Strings(mspIDs)
+	return mspIDs
+}
+
+// submitEndorsed runs a transaction endorsed by the given organizations, waits for it
+// to commit and unmarshals its JSON result into result unless result is nil
+func (c *Client) submitEndorsed(result interface{}, name string, mspIDs []string, args ...string) error {
+	resultJSON, err := c.contract.Submit(name,
+		client.WithArguments(args...),
+		client.WithEndorsingOrganizations(mspIDs...),
+	)
+	if err != nil {
+		return NewError(name, err)
+	}
+	if result == nil || len(resultJSON) == 0 {
+		return nil
+	}
+	if err := json.Unmarshal(resultJSON, result); err != nil {
+		return fmt.Errorf("failed to unmarshal %s result: %w", name, err)
+	}
+	return nil
+}
+
+// evaluate runs a query transaction and unmarshals its JSON result into result
+func (c *Client) evaluate(result interface{}, name string, args ...string) error {
+	resultJSON, err := c.contract.EvaluateTransaction(name, args...)
+	if err != nil {
+		return NewError(name, err)
+	}
+	if err := json.Unmarshal(resultJSON, result); err != nil {
+		return fmt.Errorf("failed to unmarshal %s result: %w", name, err)
+	}
+	return nil
+}
+
+// newGrpcConnection dials the gateway peer over TLS
+func newGrpcConnection(cfg Config) (*grpc.ClientConn, error) {
+	certificatePEM, err := os.ReadFile(cfg.TLSCertPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read TLS certificate: %w", err)
+	}
+	certificate, err := identity.CertificateFromPEM(certificatePEM)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse TLS certificate: %w", err)
+	}
+
+	certPool := x509.NewCertPool()
+	certPool.AddCert(certificate)
+	transportCredentials := credentials.NewClientTLSFromCert(certPool, cfg.PeerHostname)
+
+	conn, err := grpc.NewClient(cfg.PeerEndpoint, grpc.WithTransportCredentials(transportCredentials))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create gRPC connection: %w", err)
+	}
+	return conn, nil
+}
+
+// newIdentity reads the X.509 identity of the configured user
+func newIdentity(cfg Config) (*identity.X509Identity, error) {
+	certificatePEM, err := os.ReadFile(cfg.CertPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read certificate: %w", err)
+	}
+	certificate, err := identity.CertificateFromPEM(certificatePEM)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse certificate: %w", err)
+	}
+	return identity.NewX509Identity(cfg.MSPID, certificate)
+}
+
+// newSign creates a signing function from the first private key in the keystore
+func newSign(cfg Config) (identity.Sign, error) {
+	files, err := os.ReadDir(cfg.KeyDir)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read keystore: %w", err)
+	}
+	if len(files) == 0 {
+		return nil, fmt.Errorf("keystore %s is empty", cfg.KeyDir)
+	}
+	privateKeyPEM, err := os.ReadFile(filepath.Join(cfg.KeyDir, files[0].Name()))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read private key: %w", err)
+	}
+	privateKey, err := identity.PrivateKeyFromPEM(privateKeyPEM)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse private key: %w", err)
+	}
+	return identity.NewPrivateKeySign(privateKey)
+}
@@ -0,0 +1,18 @@
+package client
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestSharedEndorsers(t *testing.T) {
+	// Shared records need a majority of the active members, so every active member endorses
+	organizations := []Organization{
+		{Name: "YDSF Malang", MSPID: "YDSFMalangMSP", Code: "MLG", Status: "active"},
+		{Name: "YDSF Surabaya", MSPID: "YDSFSurabayaMSP", Code: "SBY", Status: "inactive"},
+		{Name: "YDSF Jatim", MSPID: "YDSFJatimMSP", Code: "JTM", Status: "active"},
+	}
+	require.Equal(t, []string{"YDSFJatimMSP", "YDSFMalangMSP"}, sharedEndorsers(organizations))
+	require.Empty(t, sharedEndorsers(nil))
+}
@@ -0,0 +1,101 @@
+package client
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+// Default network settings of the development network in config/dev
+const (
+	DefaultChannel   = "zakat-channel"
+	DefaultChaincode = "zakat"
+	DefaultUser      = "User1"
+)
+
+// Config describes how to reach the zakat chaincode as one organization's identity
+type Config struct {
+	MSPID        string // MSP ID of the identity, e.g. YDSFMalangMSP
+	CertPath     string // Signing certificate (PEM)
+	KeyDir       string // Keystore directory holding the private key (PEM)
+	TLSCertPath  string // TLS root certificate of the gateway peer
+	PeerEndpoint string // Address of the gateway peer, e.g. localhost:7051
+	PeerHostname string // TLS server name of the gateway peer
+	Channel      string // Channel the chaincode is deployed on
+	Chaincode    string // Chaincode name
+}
+
+// Profile describes how to reach a member organization: its MSP, the domain its crypto
+// material is generated under by generate.sh and the address of its gateway peer
+type Profile struct {
+	MSPID    string `json:"mspId"`    // e.g. YDSFMalangMSP
+	Domain   string `json:"domain"`   // e.g. ydsfmalang.example.local
+	Endpoint string `json:"endpoint"` // e.g. localhost:7051
+}
+
+// devProfiles are the member organizations of the development network in config/dev
+var devProfiles = map[string]Profile{
+	"YDSF Malang": {MSPID: "YDSFMalangMSP", Domain: "ydsfmalang.example.local", Endpoint: "localhost:7051"},
+	"YDSF Jatim":  {MSPID: "YDSFJatimMSP", Domain: "ydsfjatim.example.local", Endpoint: "localhost:8051"},
+}
+
+// LoadProfiles returns the connection profiles of the development network's
+// organizations, added to or overridden by those in the JSON file at path, an object
+// keyed by organization name as recorded on the ledger, e.g.
+//
+//	{"YDSF Surabaya": {"mspId": "YDSFSurabayaMSP", "domain": "ydsfsurabaya.example.local", "endpoint": "localhost:9051"}}
+//
+// An empty path returns the development network's profiles.
+func LoadProfiles(path string) (map[string]Profile, error) {
+	profiles := map[string]Profile{}
+	for name, profile := range devProfiles {
+		profiles[name] = profile
+	}
+	if path == "" {
+		return profiles, nil
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read connection profiles: %w", err)
+	}
+	var loaded map[string]Profile
+	if err := json.Unmarshal(data, &loaded); err != nil {
+		return nil, fmt.Errorf("failed to parse connection profiles %s: %w", path, err)
+	}
+	for name, profile := range loaded {
+		if profile.MSPID == "" || profile.Domain == "" || profile.Endpoint == "" {
+			return nil, fmt.Errorf("connection profile of %q in %s needs mspId, domain and endpoint", name, path)
+		}
+		profiles[name] = profile
+	}
+	return profiles, nil
+}
+
+// OrgConfig returns the configuration for a user of an organization, reading its
+// crypto material from the organizations directory generated by generate.sh
+func OrgConfig(profiles map[string]Profile, organizationsDir string, organizationName string, user string) (Config, error) {
+	org, ok := profiles[organizationName]
+	if !ok {
+		return Config{}, fmt.Errorf("unknown organization %q: add its connection profile to the profiles file", organizationName)
+	}
+	if user == "" {
+		user = DefaultUser
+	}
+
+	orgDir := filepath.Join(organizationsDir, "peerOrganizations", org.Domain)
+	userName := fmt.Sprintf("%s@%s", user, org.Domain)
+	peerHostname := "peer0." + org.Domain
+
+	return Config{
+		MSPID:        org.MSPID,
+		CertPath:     filepath.Join(orgDir, "users", userName, "msp", "signcerts", userName+"-cert.pem"),
+		KeyDir:       filepath.Join(orgDir, "users", userName, "msp", "keystore"),
+		TLSCertPath:  filepath.Join(orgDir, "peers", peerHostname, "tls", "ca.crt"),
+		PeerEndpoint: org.Endpoint,
+		PeerHostname: peerHostname,
+		Channel:      DefaultChannel,
+		Chaincode:    DefaultChaincode,
+	}, nil
+}
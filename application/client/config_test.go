@@ -0,0 +1,43 @@
+package client
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestLoadProfiles(t *testing.T) {
+	// Without a profiles file only the development network is known
+	profiles, err := LoadProfiles("")
+	require.NoError(t, err)
+	require.Len(t, profiles, 2)
+	_, err = OrgConfig(profiles, "organizations", "YDSF Surabaya", "")
+	require.ErrorContains(t, err, `unknown organization "YDSF Surabaya"`)
+
+	// An organization onboarded through the registry is reached through its profile
+	path := filepath.Join(t.TempDir(), "profiles.json")
+	require.NoError(t, os.WriteFile(path, []byte(`{"YDSF Surabaya": {"mspId": "YDSFSurabayaMSP", "domain": "ydsfsurabaya.example.local", "endpoint": "localhost:9051"}}`), 0o600))
+	profiles, err = LoadProfiles(path)
+	require.NoError(t, err)
+	require.Len(t, profiles, 3)
+
+	cfg, err := OrgConfig(profiles, "organizations", "YDSF Surabaya", "")
+	require.NoError(t, err)
+	require.Equal(t, Config{
+		MSPID:        "YDSFSurabayaMSP",
+		CertPath:     filepath.Join("organizations", "peerOrganizations", "ydsfsurabaya.example.local", "users", "User1@ydsfsurabaya.example.local", "msp", "signcerts", "User1@ydsfsurabaya.example.local-cert.pem"),
+		KeyDir:       filepath.Join("organizations", "peerOrganizations", "ydsfsurabaya.example.local", "users", "User1@ydsfsurabaya.example.local", "msp", "keystore"),
+		TLSCertPath:  filepath.Join("organizations", "peerOrganizations", "ydsfsurabaya.example.local", "peers", "peer0.ydsfsurabaya.example.local", "tls", "ca.crt"),
+		PeerEndpoint: "localhost:9051",
+		PeerHostname: "peer0.ydsfsurabaya.example.local",
+		Channel:      DefaultChannel,
+		Chaincode:    DefaultChaincode,
+	}, cfg)
+
+	// An incomplete profile is refused
+	require.NoError(t, os.WriteFile(path, []byte(`{"YDSF Surabaya": {"mspId": "YDSFSurabayaMSP"}}`), 0o600))
+	_, err = LoadProfiles(path)
+	require.ErrorContains(t, err, "needs mspId, domain and endpoint")
+}
@@ -0,0 +1,28 @@
+package client
+
+import (
+	"math"
+	"strings"
+)
+
+// Conversion records the foreign currency a donation was given in and the rate it was
+// converted to IDR at. The donation's amount is the IDR amount.
+type Conversion struct {
+	Currency string  `json:"currency"` // ISO 4217 code, e.g. MYR
+	Amount   float64 `json:"amount"`   // Amount given in the currency
+	Rate     float64 `json:"rate"`     // IDR per unit of the currency
+	Source   string  `json:"source"`   // Where the rate was taken from, e.g. JISDOR
+}
+
+// Normalize trims the conversion's fields and upper-cases the currency, as the
+// chaincode stores it
+func (c Conversion) Normalize() Conversion {
+	c.Currency = strings.ToUpper(strings.TrimSpace(c.Currency))
+	c.Source = strings.TrimSpace(c.Source)
+	return c
+}
+
+// IDRAmount returns the IDR amount the chaincode records for the conversion, to the sen
+func (c Conversion) IDRAmount() float64 {
+	return math.Round(c.Amount*c.Rate*100) / 100
+}
@@ -0,0 +1,46 @@
+package client
+
+import (
+	"strings"
+
+	"github.com/hyperledger/fabric-protos-go-apiv2/gateway"
+	"google.golang.org/grpc/status"
+)
+
+// chaincodeResponse precedes the chaincode's own message in the errors peers return
+const chaincodeResponse = "chaincode response 500, "
+
+// Error is a failed chaincode call
+type Error struct {
+	Transaction string // Chaincode function called
+	Message     string // Message the chaincode returned, empty if the call failed before or after the chaincode ran
+	err         error
+}
+
+func (e *Error) Error() string {
+	return "failed to call " + e.Transaction + ": " + e.err.Error()
+}
+
+func (e *Error) Unwrap() error {
+	return e.err
+}
+
+// NewError wraps the error of a gateway call, extracting the chaincode's message from
+// the gRPC status and the error details of the endorsing peers
+func NewError(transaction string, err error) *Error {
+	messages := []string{status.Convert(err).Message()}
+	for _, detail := range status.Convert(err).Details() {
+		if detail, ok := detail.(*gateway.ErrorDetail); ok {
+			messages = append(messages, detail.GetMessage())
+		}
+	}
+
+	e := &Error{Transaction: transaction, err: err}
+	for _, message := range messages {
+		if i := strings.Index(message, chaincodeResponse); i >= 0 {
+			e.Message = message[i+len(chaincodeResponse):]
+			break
+		}
+	}
+	return e
+}
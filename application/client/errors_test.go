@@ -0,0 +1,33 @@
+package client
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/hyperledger/fabric-protos-go-apiv2/gateway"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func TestNewError(t *testing.T) {
+	// Evaluate: the message is in the status
+	err := NewError("QueryZakat", status.Error(codes.Unknown, "evaluate call to endorser returned error: chaincode response 500, the zakat transaction ZKT-YDSF-MLG-202403-0009 does not exist"))
+	require.Equal(t, "the zakat transaction ZKT-YDSF-MLG-202403-0009 does not exist", err.Message)
+	require.Contains(t, err.Error(), "failed to call QueryZakat")
+
+	// Submit: the message is in the endorsing peers' error details
+	st, detailsErr := status.New(codes.Aborted, "failed to endorse transaction, see attached details for more info").WithDetails(&gateway.ErrorDetail{
+		Address: "peer0.ydsfmalang.example.local:7051",
+		MspId:   "YDSFMalangMSP",
+		Message: "chaincode response 500, the zakat ZKT-YDSF-MLG-202403-0001 already exists",
+	})
+	require.NoError(t, detailsErr)
+	err = NewError("AddZakat", st.Err())
+	require.Equal(t, "the zakat ZKT-YDSF-MLG-202403-0001 already exists", err.Message)
+
+	// A failure outside the chaincode has no message
+	err = NewError("AddZakat", status.Error(codes.Unavailable, "connection refused"))
+	require.Empty(t, err.Message)
+	require.Equal(t, codes.Unavailable, status.Code(errors.Unwrap(err)))
+}
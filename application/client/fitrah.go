@@ -0,0 +1,79 @@
+package client
+
+import "strconv"
+
+// Late fitrah policies of SetEid
+const (
+	LatePolicyFlag    = "flag"
+	LatePolicySadaqah = "sadaqah"
+)
+
+// Eid is the Eid al-Fitr prayer that closes the fitrah of a Hijri year
+type Eid struct {
+	HijriYear int    `json:"hijriYear"`
+	Prayer    string `json:"prayer"`
+	Policy    string `json:"policy"`
+	Set       bool   `json:"set"`
+}
+
+// PendingFitrah is the undistributed part of a fitrah donation
+type PendingFitrah struct {
+	ZakatID    string  `json:"zakatId"`
+	TransferID string  `json:"transferId,omitempty"`
+	Muzakki    string  `json:"muzakki"`
+	Timestamp  string  `json:"timestamp"`
+	Remaining  float64 `json:"remaining"`
+	Unit       string  `json:"unit,omitempty"`
+}
+
+// FitrahStatus is the fitrah an organization still has to distribute before an Eid prayer
+type FitrahStatus struct {
+	Organization      string             `json:"organization"`
+	Eid               Eid                `json:"eid"`
+	Overdue           bool               `json:"overdue"`
+	Outstanding       float64            `json:"outstanding"`
+	OutstandingInKind map[string]float64 `json:"outstandingInKind,omitempty"`
+	Donations         []PendingFitrah    `json:"donations"`
+}
+
+// FitrahRate is the fitrah due in IDR for one person, set by an organization for a Hijri year
+type FitrahRate struct {
+	Organization string  `json:"organization"`
+	HijriYear    int     `json:"hijriYear"`
+	Amount       float64 `json:"amount"`
+}
+
+// FitrahInKindPerPerson is the fitrah due for one person in the in-kind units it can be paid in
+var FitrahInKindPerPerson = map[string]float64{"kg_beras": 2.5, "liter_beras": 3.5}
+
+// GetFitrahRate returns an organization's per-person fitrah rate for a Hijri year
+func (c *Client) GetFitrahRate(organization string, hijriYear int) (FitrahRate, error) {
+	var rate FitrahRate
+	err := c.evaluate(&rate, "GetFitrahRate", organization, strconv.Itoa(hijriYear))
+	return rate, err
+}
+
+// SetFitrahRate sets an organization's per-person fitrah rate for a Hijri year
+func (c *Client) SetFitrahRate(organization string, hijriYear int, amount float64) error {
+	return c.submit(nil, "SetFitrahRate", organization, strconv.Itoa(hijriYear), formatAmount(amount))
+}
+
+// GetEid returns the Eid prayer of a Hijri year and its late fitrah policy
+func (c *Client) GetEid(hijriYear int) (Eid, error) {
+	var eid Eid
+	err := c.evaluate(&eid, "GetEid", strconv.Itoa(hijriYear))
+	return eid, err
+}
+
+// SetEid sets the Eid prayer of a Hijri year and its late fitrah policy, endorsed by
+// every active member as the Eid is shared
+func (c *Client) SetEid(hijriYear int, prayer string, policy string) error {
+	return c.submitShared("SetEid", strconv.Itoa(hijriYear), prayer, policy)
+}
+
+// GetPendingFitrah returns the fitrah of a Hijri year an organization has not yet distributed
+func (c *Client) GetPendingFitrah(organization string, hijriYear int) (FitrahStatus, error) {
+	var status FitrahStatus
+	err := c.evaluate(&status, "GetPendingFitrah", organization, strconv.Itoa(hijriYear))
+	return status, err
+}
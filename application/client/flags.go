@@ -0,0 +1,42 @@
+package client
+
+import (
+	"flag"
+	"os"
+)
+
+// Flags are the command-line flags that select the identity and peer a command connects with
+type Flags struct {
+	OrganizationsDir string
+	Profiles         string
+	Organization     string
+	User             string
+	Peer             string
+}
+
+// AddFlags registers the connection flags on a flag set
+func AddFlags(fs *flag.FlagSet) *Flags {
+	f := &Flags{}
+	fs.StringVar(&f.OrganizationsDir, "organizations", "organizations", "directory of the crypto material generated by generate.sh")
+	fs.StringVar(&f.Profiles, "profiles", os.Getenv("ZAKAT_PROFILES"), "JSON file of connection profiles by organization name, for organizations beyond the development network's (default $ZAKAT_PROFILES)")
+	fs.StringVar(&f.Organization, "org", "YDSF Malang", "organization to connect as")
+	fs.StringVar(&f.User, "user", DefaultUser, "user of the organization to connect as")
+	fs.StringVar(&f.Peer, "peer", "", "gateway peer address (default: the organization's peer)")
+	return f
+}
+
+// Connect connects with the identity and peer selected by the flags
+func (f *Flags) Connect() (*Client, error) {
+	profiles, err := LoadProfiles(f.Profiles)
+	if err != nil {
+		return nil, err
+	}
+	cfg, err := OrgConfig(profiles, f.OrganizationsDir, f.Organization, f.User)
+	if err != nil {
+		return nil, err
+	}
+	if f.Peer != "" {
+		cfg.PeerEndpoint = f.Peer
+	}
+	return Connect(cfg)
+}
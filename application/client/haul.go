@@ -0,0 +1,43 @@
+package client
+
+import "strconv"
+
+// Donor is a muzakki's entry in an organization's donor registry
+type Donor struct {
+	Muzakki         string `json:"muzakki"`
+	Organization    string `json:"organization"`
+	HaulStart       string `json:"haulStart"`
+	LastMaalPayment string `json:"lastMaalPayment,omitempty"`
+	LastMaalZakatID string `json:"lastMaalZakatId,omitempty"`
+}
+
+// HaulDue is a donor whose haul falls due, or fell due without a maal donation
+type HaulDue struct {
+	Muzakki         string    `json:"muzakki"`
+	HaulStart       string    `json:"haulStart"`
+	LastMaalPayment string    `json:"lastMaalPayment,omitempty"`
+	DueDate         string    `json:"dueDate"`
+	Hijri           HijriDate `json:"hijri"`
+	DaysLeft        int       `json:"daysLeft"` // Negative once the due date has passed
+}
+
+// SetHaulStart sets the date a muzakki's wealth reached the nisab, from which the haul
+// is counted
+func (c *Client) SetHaulStart(organization string, muzakki string, haulStart string) error {
+	return c.submit(nil, "SetHaulStart", organization, muzakki, haulStart)
+}
+
+// QueryDonor returns a muzakki's entry in an organization's donor registry
+func (c *Client) QueryDonor(organization string, muzakki string) (Donor, error) {
+	var donor Donor
+	err := c.evaluate(&donor, "QueryDonor", organization, muzakki)
+	return donor, err
+}
+
+// GetHaulsDue returns the donors of an organization whose haul falls due within the
+// given number of days, or fell due without a maal donation since
+func (c *Client) GetHaulsDue(organization string, days int) ([]HaulDue, error) {
+	var due []HaulDue
+	err := c.evaluate(&due, "GetHaulsDue", organization, strconv.Itoa(days))
+	return due, err
+}
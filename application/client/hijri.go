@@ -0,0 +1,57 @@
+package client
+
+import (
+	"fmt"
+	"strconv"
+)
+
+// HijriDate is the Hijri date a record is stamped with
+type HijriDate struct {
+	Year  int `json:"year"`
+	Month int `json:"month"`
+	Day   int `json:"day"`
+}
+
+// hijriMonths are the Indonesian names of the Hijri months
+var hijriMonths = [12]string{
+	"Muharram", "Safar", "Rabiul Awal", "Rabiul Akhir", "Jumadil Awal", "Jumadil Akhir",
+	"Rajab", "Syaban", "Ramadan", "Syawal", "Dzulqaidah", "Dzulhijjah",
+}
+
+// MonthName returns the Indonesian name of the date's month, e.g. Ramadan
+func (d HijriDate) MonthName() string {
+	if d.Month < 1 || d.Month > 12 {
+		return strconv.Itoa(d.Month)
+	}
+	return hijriMonths[d.Month-1]
+}
+
+// String formats the date as e.g. "1 Ramadan 1445 H"
+func (d HijriDate) String() string {
+	return fmt.Sprintf("%d %s %d H", d.Day, d.MonthName(), d.Year)
+}
+
+// HijriCalendar holds the Hijri month starts announced after the isbat
+type HijriCalendar struct {
+	MonthStarts map[string]string `json:"monthStarts"`
+}
+
+// GetHijriDate returns the Hijri date the chaincode stamps a timestamp with
+func (c *Client) GetHijriDate(timestamp string) (HijriDate, error) {
+	var date HijriDate
+	err := c.evaluate(&date, "GetHijriDate", timestamp)
+	return date, err
+}
+
+// GetHijriCalendar returns the announced Hijri month starts
+func (c *Client) GetHijriCalendar() (HijriCalendar, error) {
+	var calendar HijriCalendar
+	err := c.evaluate(&calendar, "GetHijriCalendar")
+	return calendar, err
+}
+
+// SetHijriMonthStart records the announced first day (YYYY-MM-DD) of a Hijri month,
+// endorsed by every active member as the calendar is shared
+func (c *Client) SetHijriMonthStart(year int, month int, date string) error {
+	return c.submitShared("SetHijriMonthStart", strconv.Itoa(year), strconv.Itoa(month), date)
+}
@@ -0,0 +1,63 @@
+package client
+
+import (
+	"strconv"
+	"strings"
+)
+
+// UnitIDR is the unit of donations paid in rupiah, the default
+const UnitIDR = "IDR"
+
+// InKindUnits are the units donations can be given in besides rupiah
+var InKindUnits = []string{"kg_beras", "liter_beras", "kg_gabah", "gram_emas", "ekor_kambing", "ekor_sapi"}
+
+// ReferencePrice is the IDR value of one unit of an in-kind donation from a day on
+type ReferencePrice struct {
+	Unit  string  `json:"unit"`
+	Price float64 `json:"price"`
+	Since string  `json:"since"`
+}
+
+// NormalizeUnit returns a unit in the form the chaincode stores, IDR for an empty unit
+func NormalizeUnit(unit string) string {
+	unit = strings.ToLower(strings.TrimSpace(unit))
+	if unit == "" || unit == "idr" {
+		return UnitIDR
+	}
+	return unit
+}
+
+// IsIDR reports whether amounts in a unit are rupiah; rupiah records carry no unit
+func IsIDR(unit string) bool {
+	return unit == "" || unit == UnitIDR
+}
+
+// IDRValue returns the IDR value of a donation: its amount, or for an in-kind donation
+// its valuation at the reference price
+func (z Zakat) IDRValue() float64 {
+	if IsIDR(z.Unit) {
+		return z.Amount
+	}
+	return z.Value
+}
+
+// IDRValue returns the IDR value of a distribution
+func (d Distribution) IDRValue() float64 {
+	if IsIDR(d.Unit) {
+		return d.Amount
+	}
+	return d.Value
+}
+
+// GetReferencePrices returns the reference prices of the in-kind units, oldest first
+func (c *Client) GetReferencePrices() ([]ReferencePrice, error) {
+	var prices []ReferencePrice
+	err := c.evaluate(&prices, "GetReferencePrices")
+	return prices, err
+}
+
+// SetReferencePrice sets the IDR price of one unit of an in-kind donation from a day
+// on, endorsed by every active member as the price list is shared
+func (c *Client) SetReferencePrice(unit string, price float64, since string) error {
+	return c.submitShared("SetReferencePrice", unit, strconv.FormatFloat(price, 'f', -1, 64), since)
+}
@@ -0,0 +1,87 @@
+package client
+
+// Pledge frequencies of CreatePledge
+const (
+	FrequencyMonthly   = "monthly"
+	FrequencyQuarterly = "quarterly"
+	FrequencyYearly    = "yearly"
+)
+
+// Installment statuses
+const (
+	InstallmentPaid     = "paid"
+	InstallmentDue      = "due"
+	InstallmentOverdue  = "overdue"
+	InstallmentUpcoming = "upcoming"
+)
+
+// Pledge is a muzakki's standing commitment to pay a fixed amount at a regular frequency
+type Pledge struct {
+	ID           string   `json:"ID"`
+	Muzakki      string   `json:"muzakki"`
+	Organization string   `json:"organization"`
+	Type         string   `json:"type"`
+	Amount       float64  `json:"amount"`
+	Frequency    string   `json:"frequency"`
+	StartDate    string   `json:"startDate"`
+	EndDate      string   `json:"endDate"`
+	Total        float64  `json:"total"`
+	Paid         float64  `json:"paid"`
+	Status       string   `json:"status"`
+	Donations    []string `json:"donations,omitempty"`
+}
+
+// PledgeInput holds the arguments of CreatePledge
+type PledgeInput struct {
+	ID           string  `json:"ID"`
+	Muzakki      string  `json:"muzakki"`
+	Organization string  `json:"organization"`
+	Type         string  `json:"type"`
+	Amount       float64 `json:"amount"`    // Amount of each installment in IDR
+	Frequency    string  `json:"frequency"` // "monthly", "quarterly" or "yearly"
+	StartDate    string  `json:"startDate"` // Due date of the first installment
+	EndDate      string  `json:"endDate"`   // No installment falls due after it
+}
+
+// Installment is one payment of a pledge
+type Installment struct {
+	Number  int     `json:"number"`
+	DueDate string  `json:"dueDate"`
+	Amount  float64 `json:"amount"`
+	Paid    float64 `json:"paid"`
+	Status  string  `json:"status"`
+}
+
+// DueInstallment is an unpaid installment of a pledge that has fallen due
+type DueInstallment struct {
+	PledgeID    string      `json:"pledgeId"`
+	Muzakki     string      `json:"muzakki"`
+	Installment Installment `json:"installment"`
+}
+
+// CreatePledge records a muzakki's pledge
+func (c *Client) CreatePledge(input PledgeInput) error {
+	return c.submit(nil, "CreatePledge", input.ID, input.Muzakki, input.Organization, input.Type, formatAmount(input.Amount), input.Frequency,
+		input.StartDate, input.EndDate)
+}
+
+// QueryPledge returns a pledge
+func (c *Client) QueryPledge(id string) (Pledge, error) {
+	var pledge Pledge
+	err := c.evaluate(&pledge, "QueryPledge", id)
+	return pledge, err
+}
+
+// GetPledgeInstallments returns the installments of a pledge with their status now
+func (c *Client) GetPledgeInstallments(id string) ([]Installment, error) {
+	var installments []Installment
+	err := c.evaluate(&installments, "GetPledgeInstallments", id)
+	return installments, err
+}
+
+// GetDueInstallments returns the due and overdue installments of an organization's pledges
+func (c *Client) GetDueInstallments(organization string) ([]DueInstallment, error) {
+	var due []DueInstallment
+	err := c.evaluate(&due, "GetDueInstallments", organization)
+	return due, err
+}
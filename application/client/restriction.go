@@ -0,0 +1,62 @@
+package client
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Kinds of purpose a donor can restrict a donation to
+const (
+	RestrictionProgram = "program"
+	RestrictionAsnaf   = "asnaf"
+	RestrictionRegion  = "region"
+)
+
+// Restriction is the purpose a donor restricted a donation to. Restricted funds are
+// only spent by distributions under the program, to the asnaf or in the region.
+type Restriction struct {
+	Kind  string `json:"kind"`  // "program", "asnaf" or "region"
+	Value string `json:"value"` // Program ID, asnaf or region, e.g. Lumajang
+}
+
+// ParseRestriction parses a restriction written as kind:value, e.g. region:Lumajang
+func ParseRestriction(s string) (Restriction, error) {
+	kind, value, ok := strings.Cut(s, ":")
+	if !ok {
+		return Restriction{}, fmt.Errorf("invalid restriction %q. Must be kind:value, e.g. region:Lumajang", s)
+	}
+	return Restriction{Kind: kind, Value: value}.Normalize(), nil
+}
+
+// Normalize trims the restriction's fields and lower-cases its kind, and the value of
+// an asnaf, as the chaincode stores it
+func (r Restriction) Normalize() Restriction {
+	r.Kind = strings.ToLower(strings.TrimSpace(r.Kind))
+	r.Value = strings.TrimSpace(r.Value)
+	if r.Kind == RestrictionAsnaf {
+		r.Value = strings.ToLower(r.Value)
+	}
+	return r
+}
+
+// String formats the restriction as kind:value
+func (r Restriction) String() string {
+	return r.Kind + ":" + r.Value
+}
+
+// RestrictedBalance is the part of a pool restricted to one purpose and not yet spent
+type RestrictedBalance struct {
+	Restriction Restriction `json:"restriction"`
+	PoolID      string      `json:"poolId"`
+	Unit        string      `json:"unit,omitempty"`
+	Balance     float64     `json:"balance"`
+	Donations   int         `json:"donations"`
+}
+
+// GetRestrictedBalances returns the balances an organization holds for restricted
+// purposes, per restriction and pool
+func (c *Client) GetRestrictedBalances(organization string) ([]RestrictedBalance, error) {
+	var balances []RestrictedBalance
+	err := c.evaluate(&balances, "GetRestrictedBalances", organization)
+	return balances, err
+}
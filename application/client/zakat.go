@@ -0,0 +1,316 @@
+package client
+
+import (
+	"encoding/json"
+	"strconv"
+	"strings"
+)
+
+// Zakat is a donation recorded on the ledger
+type Zakat struct {
+	ID            string       `json:"ID"`
+	Muzakki       string       `json:"muzakki"`
+	Amount        float64      `json:"amount"`
+	Unit          string       `json:"unit,omitempty"`
+	Value         float64      `json:"value,omitempty"`
+	Type          string       `json:"type"`
+	Status        string       `json:"status"`
+	Organization  string       `json:"organization"`
+	Timestamp     string       `json:"timestamp"`
+	Mustahik      string       `json:"mustahik"`
+	Distribution  float64      `json:"distribution"`
+	DistributedAt string       `json:"distributedAt"`
+	Distributions []string     `json:"distributions,omitempty"`
+	Payment       *Payment     `json:"payment,omitempty"`
+	Hijri         *HijriDate   `json:"hijri,omitempty"`
+	Persons       []string     `json:"persons,omitempty"`
+	Jiwa          int          `json:"jiwa,omitempty"`
+	Conversion    *Conversion  `json:"conversion,omitempty"`
+	Restriction   *Restriction `json:"restriction,omitempty"`
+	PledgeID      string       `json:"pledgeId,omitempty"`
+	// Anonymous donations only, recorded as Hamba Allah: see DonorCommitment
+	DonorCommitment string `json:"donorCommitment,omitempty"`
+}
+
+// Payment identifies the bank transfer, QRIS or online payment a donation was received by
+type Payment struct {
+	Channel   string `json:"channel"`
+	Bank      string `json:"bank"`
+	Reference string `json:"reference"`
+}
+
+// Payment channels
+const (
+	PaymentBankTransfer = "bank_transfer"
+	PaymentQRIS         = "qris"
+	PaymentGateway      = "gateway"
+)
+
+// Normalize trims the payment's fields and upper-cases the bank, as the chaincode
+// does before it checks that the reference is unique
+func (p Payment) Normalize() Payment {
+	return Payment{
+		Channel:   strings.ToLower(strings.TrimSpace(p.Channel)),
+		Bank:      strings.ToUpper(strings.TrimSpace(p.Bank)),
+		Reference: strings.TrimSpace(p.Reference),
+	}
+}
+
+// ZakatInput holds the details of a donation to be recorded
+type ZakatInput struct {
+	ID           string   `json:"ID"`
+	Muzakki      string   `json:"muzakki"`
+	Amount       float64  `json:"amount"`
+	Unit         string   `json:"unit,omitempty"`
+	Type         string   `json:"type"`
+	Organization string   `json:"organization"`
+	Timestamp    string   `json:"timestamp"`
+	Payment      *Payment `json:"payment,omitempty"`
+	Persons      []string `json:"persons,omitempty"` // Fitrah only: names of the persons covered
+	Jiwa         int      `json:"jiwa,omitempty"`    // Fitrah only: number of persons covered
+	// Foreign currency the donation was given in; Amount is its IDR amount
+	Conversion *Conversion `json:"conversion,omitempty"`
+	// Purpose the donor restricted the donation to
+	Restriction *Restriction `json:"restriction,omitempty"`
+	// Pledge the donation is paid towards; the muzakki, type and organization may be
+	// left to the pledge
+	PledgeID string `json:"pledgeId,omitempty"`
+	// Commitment of an anonymous donor, who is recorded as Hamba Allah; the muzakki
+	// may be left empty
+	DonorCommitment string `json:"donorCommitment,omitempty"`
+}
+
+// ZakatHistory is one committed version of a donation
+type ZakatHistory struct {
+	TxID      string `json:"txId"`
+	Timestamp string `json:"timestamp"`
+	Zakat     Zakat  `json:"zakat"`
+}
+
+// BatchResult is the outcome of one entry of AddZakatBatch
+type BatchResult struct {
+	Index  int    `json:"index"`
+	ID     string `json:"ID"`
+	Status string `json:"status"`
+	Error  string `json:"error,omitempty"`
+}
+
+// Allocation is the amount drawn from a single donation
+type Allocation struct {
+	ZakatID     string       `json:"zakatId"`
+	TransferID  string       `json:"transferId,omitempty"`
+	Amount      float64      `json:"amount"`
+	Restriction *Restriction `json:"restriction,omitempty"`
+}
+
+// Distribution is a disbursement from a fund pool to a mustahik
+type Distribution struct {
+	ID           string       `json:"ID"`
+	PoolID       string       `json:"poolId"`
+	ProgramID    string       `json:"programId"`
+	Asnaf        string       `json:"asnaf"`
+	Organization string       `json:"organization"`
+	Type         string       `json:"type"`
+	Mustahik     string       `json:"mustahik"`
+	Amount       float64      `json:"amount"`
+	Unit         string       `json:"unit,omitempty"`
+	Value        float64      `json:"value,omitempty"`
+	Timestamp    string       `json:"timestamp"`
+	Sources      []Allocation `json:"sources"`
+	Hijri        *HijriDate   `json:"hijri,omitempty"`
+	Late         bool         `json:"late,omitempty"`
+	Sadaqah      bool         `json:"sadaqah,omitempty"`
+	Region       string       `json:"region,omitempty"`
+}
+
+// DistributionInput holds the arguments of DistributeZakat
+type DistributionInput struct {
+	ID        string  `json:"ID"`
+	PoolID    string  `json:"poolId"`
+	ProgramID string  `json:"programId"`
+	Mustahik  string  `json:"mustahik"`
+	Amount    float64 `json:"amount"`
+	Timestamp string  `json:"timestamp"`
+	Region    string  `json:"region,omitempty"` // Region the mustahik is in, if given
+	Asnaf     string  `json:"asnaf,omitempty"`  // Asnaf of the mustahik, taken from the program if empty
+}
+
+// DistributionEntry is one mustahik of DistributeZakatBatch
+type DistributionEntry struct {
+	ID       string  `json:"ID"`
+	Mustahik string  `json:"mustahik"`
+	Amount   float64 `json:"amount"`
+	Asnaf    string  `json:"asnaf,omitempty"`
+	Region   string  `json:"region,omitempty"`
+}
+
+// Organization is a collecting organization registered on the ledger
+type Organization struct {
+	Name   string `json:"name"`
+	MSPID  string `json:"mspId"`
+	Code   string `json:"code"`
+	Status string `json:"status"`
+}
+
+// Batch modes of AddZakatBatch and its maximum size
+const (
+	BatchAtomic  = "atomic"
+	BatchPartial = "partial"
+	MaxBatchSize = 500
+)
+
+// AddZakat records a donation, with its payment reference if it has one, or in kind
+// when it has a unit other than IDR. Fitrah covering persons is recorded with
+// AddZakatFitrah, a donation in a foreign currency with AddZakatInCurrency, a
+// restricted donation with AddZakatRestricted, a payment towards a pledge that
+// leaves the muzakki and type to it with AddZakatForPledge and an anonymous donation
+// with AddZakatAnonymous, or as a one-entry atomic batch when they also have a payment
+// or one another.
+func (c *Client) AddZakat(input ZakatInput) error {
+	household := len(input.Persons) > 0 || input.Jiwa != 0
+	// No single function takes a payment along with persons, a conversion, a
+	// restriction or a commitment, or checks a pledged donation's muzakki and type, but
+	// a batch entry does it all
+	combined := household && input.Payment != nil ||
+		input.Conversion != nil && (input.Payment != nil || household || !IsIDR(NormalizeUnit(input.Unit))) ||
+		input.Restriction != nil && (input.Payment != nil || household || input.Conversion != nil || !IsIDR(NormalizeUnit(input.Unit))) ||
+		input.PledgeID != "" && (input.Muzakki != "" || input.Type != "" || input.Payment != nil || household || input.Conversion != nil ||
+			input.Restriction != nil || !IsIDR(NormalizeUnit(input.Unit))) ||
+		input.DonorCommitment != "" && (input.Payment != nil || household || input.Conversion != nil || input.Restriction != nil ||
+			input.PledgeID != "" || !IsIDR(NormalizeUnit(input.Unit)))
+	if combined {
+		_, err := c.AddZakatBatch([]ZakatInput{input}, BatchAtomic)
+		return err
+	}
+	if input.DonorCommitment != "" {
+		return c.submit(nil, "AddZakatAnonymous", input.ID, input.DonorCommitment, formatAmount(input.Amount), input.Type, input.Organization, input.Timestamp)
+	}
+	if input.PledgeID != "" {
+		return c.submit(nil, "AddZakatForPledge", input.ID, input.PledgeID, formatAmount(input.Amount), input.Timestamp)
+	}
+	if input.Conversion != nil {
+		return c.submit(nil, "AddZakatInCurrency", input.ID, input.Muzakki, formatAmount(input.Conversion.Amount), input.Conversion.Currency,
+			formatAmount(input.Conversion.Rate), input.Conversion.Source, input.Type, input.Organization, input.Timestamp)
+	}
+	if input.Restriction != nil {
+		return c.submit(nil, "AddZakatRestricted", input.ID, input.Muzakki, formatAmount(input.Amount), input.Type, input.Organization, input.Timestamp,
+			input.Restriction.Kind, input.Restriction.Value)
+	}
+	if household {
+		persons, err := json.Marshal(input.Persons)
+		if err != nil {
+			return err
+		}
+		return c.submit(nil, "AddZakatFitrah", input.ID, input.Muzakki, formatAmount(input.Amount), NormalizeUnit(input.Unit), string(persons),
+			strconv.Itoa(input.Jiwa), input.Organization, input.Timestamp)
+	}
+	if !IsIDR(NormalizeUnit(input.Unit)) {
+		return c.submit(nil, "AddZakatInKind", input.ID, input.Muzakki, formatAmount(input.Amount), NormalizeUnit(input.Unit), input.Type, input.Organization, input.Timestamp)
+	}
+	if input.Payment != nil {
+		return c.submit(nil, "AddZakatWithPayment", input.ID, input.Muzakki, formatAmount(input.Amount), input.Type, input.Organization, input.Timestamp,
+			input.Payment.Channel, input.Payment.Bank, input.Payment.Reference)
+	}
+	return c.submit(nil, "AddZakat", input.ID, input.Muzakki, formatAmount(input.Amount), input.Type, input.Organization, input.Timestamp)
+}
+
+// AddZakatBatch records donations in one transaction
+func (c *Client) AddZakatBatch(entries []ZakatInput, mode string) ([]BatchResult, error) {
+	entriesJSON, err := json.Marshal(entries)
+	if err != nil {
+		return nil, err
+	}
+	var results []BatchResult
+	err = c.submit(&results, "AddZakatBatch", string(entriesJSON), mode)
+	return results, err
+}
+
+// DistributeZakat disburses an amount from a fund pool to a mustahik, with
+// DistributeZakatInRegion when the mustahik's region is given, or as a one-entry batch
+// when their asnaf is
+func (c *Client) DistributeZakat(input DistributionInput) error {
+	if input.Asnaf != "" {
+		return c.DistributeZakatBatch(input.PoolID, input.ProgramID, []DistributionEntry{input.Entry()}, input.Timestamp)
+	}
+	if input.Region != "" {
+		return c.submit(nil, "DistributeZakatInRegion", input.ID, input.PoolID, input.ProgramID, input.Mustahik, input.Region, formatAmount(input.Amount), input.Timestamp)
+	}
+	return c.submit(nil, "DistributeZakat", input.ID, input.PoolID, input.ProgramID, input.Mustahik, formatAmount(input.Amount), input.Timestamp)
+}
+
+// DistributeZakatBatch disburses from one fund pool to many mustahik in one
+// transaction, under the same program and timestamp. The entries are validated
+// together against the pool balance and none is recorded if any fails.
+func (c *Client) DistributeZakatBatch(poolID string, programID string, entries []DistributionEntry, timestamp string) error {
+	entriesJSON, err := json.Marshal(entries)
+	if err != nil {
+		return err
+	}
+	return c.submit(nil, "DistributeZakatBatch", poolID, programID, string(entriesJSON), timestamp)
+}
+
+// Entry returns the distribution as an entry of DistributeZakatBatch
+func (d DistributionInput) Entry() DistributionEntry {
+	return DistributionEntry{ID: d.ID, Mustahik: d.Mustahik, Amount: d.Amount, Asnaf: d.Asnaf, Region: d.Region}
+}
+
+// QueryZakat returns a donation
+func (c *Client) QueryZakat(id string) (Zakat, error) {
+	var zakat Zakat
+	err := c.evaluate(&zakat, "QueryZakat", id)
+	return zakat, err
+}
+
+// GetZakatByPayment returns the donation a payment was recorded for
+func (c *Client) GetZakatByPayment(payment Payment) (Zakat, error) {
+	var zakat Zakat
+	err := c.evaluate(&zakat, "GetZakatByPayment", payment.Channel, payment.Bank, payment.Reference)
+	return zakat, err
+}
+
+// ZakatExists reports whether a donation is recorded
+func (c *Client) ZakatExists(id string) (bool, error) {
+	var exists bool
+	err := c.evaluate(&exists, "ZakatExists", id)
+	return exists, err
+}
+
+// GetZakatHistory returns every committed version of a donation, oldest first
+func (c *Client) GetZakatHistory(id string) ([]ZakatHistory, error) {
+	var history []ZakatHistory
+	err := c.evaluate(&history, "GetZakatHistory", id)
+	return history, err
+}
+
+// GetAllZakat returns all donations on the ledger
+func (c *Client) GetAllZakat() ([]Zakat, error) {
+	var zakats []Zakat
+	err := c.evaluate(&zakats, "GetAllZakat")
+	return zakats, err
+}
+
+// QueryDistribution returns a distribution
+func (c *Client) QueryDistribution(id string) (Distribution, error) {
+	var distribution Distribution
+	err := c.evaluate(&distribution, "QueryDistribution", id)
+	return distribution, err
+}
+
+// GetAllDistributions returns all distributions on the ledger
+func (c *Client) GetAllDistributions() ([]Distribution, error) {
+	var distributions []Distribution
+	err := c.evaluate(&distributions, "GetAllDistributions")
+	return distributions, err
+}
+
+// GetAllOrganizations returns the organizations in the ledger's registry
+func (c *Client) GetAllOrganizations() ([]Organization, error) {
+	var organizations []Organization
+	err := c.evaluate(&organizations, "GetAllOrganizations")
+	return organizations, err
+}
+
+// formatAmount formats an amount as a transaction argument
+func formatAmount(amount float64) string {
+	return strconv.FormatFloat(amount, 'f', -1, 64)
+}
@@ -0,0 +1,65 @@
+// Command baznas-report exports the BAZNAS collection and distribution report of an
+// organization for a period from the ledger, as CSV or JSON.
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"os"
+
+	"github.com/izzuddinafif/fabric-zakat/application/baznas"
+	"github.com/izzuddinafif/fabric-zakat/application/client"
+)
+
+func main() {
+	connection := client.AddFlags(flag.CommandLine)
+	period := flag.String("period", "", "report period, YYYY or YYYYMM, or YYYYH or YYYYHMM for a Hijri year or month, e.g. 1447H09 for Ramadan 1447")
+	format := flag.String("format", "csv", "output format, csv or json")
+	output := flag.String("out", "", "output file (default: stdout)")
+	flag.Parse()
+
+	if *period == "" {
+		log.Fatal("-period is required")
+	}
+	if *format != "csv" && *format != "json" {
+		log.Fatalf("unknown format %q, expected csv or json", *format)
+	}
+
+	c, err := connection.Connect()
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer c.Close()
+
+	report, err := c.GetBaznasReport(connection.Organization, *period)
+	if err != nil {
+		log.Fatal(err)
+	}
+	if err := baznas.Check(report); err != nil {
+		log.Fatalf("report does not reconcile: %v", err)
+	}
+
+	var w io.Writer = os.Stdout
+	if *output != "" {
+		f, err := os.Create(*output)
+		if err != nil {
+			log.Fatal(err)
+		}
+		defer f.Close()
+		w = f
+	}
+
+	if *format == "json" {
+		err = baznas.WriteJSON(w, report)
+	} else {
+		err = baznas.WriteCSV(w, report)
+	}
+	if err != nil {
+		log.Fatal(err)
+	}
+	if *output != "" {
+		fmt.Fprintf(os.Stderr, "BAZNAS report for %s %s written to %s\n", connection.Organization, *period, *output)
+	}
+}
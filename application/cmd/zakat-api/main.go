@@ -0,0 +1,69 @@
+// Command zakat-api serves the zakat chaincode as a REST API for web and mobile
+// clients. Bearer tokens are mapped to Fabric identities by a users file:
+//
+//	{"users": [{"name": "web-malang", "tokenSha256": "<hex SHA-256 of the token>", "organization": "YDSF Malang", "user": "User1"}]}
+//
+// The OpenAPI specification is served at /openapi.yaml.
+package main
+
+import (
+	"context"
+	"flag"
+	"log"
+	"net/http"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"github.com/izzuddinafif/fabric-zakat/application/api"
+	"github.com/izzuddinafif/fabric-zakat/application/client"
+)
+
+func main() {
+	addr := flag.String("addr", ":8080", "address to listen on")
+	usersFile := flag.String("users", "users.json", "JSON file mapping token hashes to Fabric identities")
+	organizationsDir := flag.String("organizations", "organizations", "directory of the crypto material generated by generate.sh")
+	profiles := flag.String("profiles", os.Getenv("ZAKAT_PROFILES"), "JSON file of connection profiles by organization name, for organizations beyond the development network's (default $ZAKAT_PROFILES)")
+	hashToken := flag.String("hash-token", "", "print the hash of a token for the users file and exit")
+	flag.Parse()
+
+	if *hashToken != "" {
+		log.SetFlags(0)
+		log.Print(api.HashToken(*hashToken))
+		return
+	}
+
+	users, err := api.LoadUsers(*usersFile)
+	if err != nil {
+		log.Fatal(err)
+	}
+	server := api.NewServer(users, func(identity api.Identity) (api.Ledger, error) {
+		connection := client.Flags{OrganizationsDir: *organizationsDir, Profiles: *profiles, Organization: identity.Organization, User: identity.User}
+		return connection.Connect()
+	})
+	defer server.Close()
+
+	httpServer := &http.Server{
+		Addr:              *addr,
+		Handler:           server.Handler(),
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		<-ctx.Done()
+		shutdown, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+		defer cancel()
+		httpServer.Shutdown(shutdown)
+	}()
+
+	log.Printf("zakat API listening on %s", *addr)
+	if err := httpServer.ListenAndServe(); err != http.ErrServerClosed {
+		log.Fatal(err)
+	}
+	<-done
+}
@@ -0,0 +1,322 @@
+// Command zakat-csv imports donations or distributions from a CSV file into the
+// ledger, and exports ledger data back to CSV.
+//
+//	zakat-csv import -kind zakat -file donations.csv [-mode atomic|partial] [-map field=column,...] [-dry-run]
+//	zakat-csv import -kind distribution -file distributions.csv [-map field=column,...] [-dry-run]
+//	zakat-csv export -kind zakat|distribution [-filter-org "YDSF Malang"] [-out file.csv]
+//
+// Rows are validated with the chaincode's rules before anything is submitted.
+// Donations are submitted with AddZakatBatch in batches of up to 500 rows;
+// distributions from the same pool under the same program and timestamp, such as a
+// morning's rice fitrah, are submitted together with DistributeZakatBatch in batches
+// of up to 500 rows, each recorded whole or not at all.
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"os"
+
+	"github.com/izzuddinafif/fabric-zakat/application/client"
+	"github.com/izzuddinafif/fabric-zakat/application/spreadsheet"
+	"github.com/izzuddinafif/fabric-zakat/application/validate"
+)
+
+const (
+	kindZakat        = "zakat"
+	kindDistribution = "distribution"
+)
+
+func main() {
+	log.SetFlags(0)
+	if len(os.Args) < 2 {
+		log.Fatal("usage: zakat-csv import|export [flags]")
+	}
+
+	var err error
+	switch os.Args[1] {
+	case "import":
+		err = runImport(os.Args[2:])
+	case "export":
+		err = runExport(os.Args[2:])
+	default:
+		err = fmt.Errorf("unknown command %q, expected import or export", os.Args[1])
+	}
+	if err != nil {
+		log.Fatal(err)
+	}
+}
+
+func runImport(args []string) error {
+	fs := flag.NewFlagSet("import", flag.ExitOnError)
+	connection := client.AddFlags(fs)
+	kind := fs.String("kind", kindZakat, "what the file holds, zakat or distribution")
+	file := fs.String("file", "", "CSV file to import")
+	mode := fs.String("mode", client.BatchAtomic, "for donations, atomic to import all rows or none, partial to skip invalid rows")
+	columns := fs.String("map", "", "column mapping, e.g. muzakki=Nama Donatur,amount=Nominal")
+	dryRun := fs.Bool("dry-run", false, "validate the file without submitting it")
+	fs.Parse(args)
+
+	if *file == "" {
+		return fmt.Errorf("-file is required")
+	}
+	if *mode != client.BatchAtomic && *mode != client.BatchPartial {
+		return fmt.Errorf("unknown mode %q, expected atomic or partial", *mode)
+	}
+	mapping, err := spreadsheet.ParseMapping(*columns)
+	if err != nil {
+		return err
+	}
+	f, err := os.Open(*file)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	c, err := connection.Connect()
+	if err != nil {
+		return err
+	}
+	defer c.Close()
+
+	organizations, err := c.GetAllOrganizations()
+	if err != nil {
+		return err
+	}
+	registry := validate.NewRegistry(organizations)
+
+	switch *kind {
+	case kindZakat:
+		return importZakat(c, registry, f, mapping, *mode, *dryRun)
+	case kindDistribution:
+		return importDistributions(c, registry, f, mapping, *dryRun)
+	default:
+		return fmt.Errorf("unknown kind %q, expected zakat or distribution", *kind)
+	}
+}
+
+func importZakat(c *client.Client, registry *validate.Registry, r io.Reader, mapping spreadsheet.Mapping, mode string, dryRun bool) error {
+	rows, err := spreadsheet.ReadZakat(r, mapping)
+	if err != nil {
+		return err
+	}
+
+	// Validate every row before submitting any
+	var valid []client.ZakatInput
+	var lines []int
+	invalid := 0
+	seen := map[string]int{}
+	payments := map[client.Payment]int{}
+	for _, row := range rows {
+		err := row.Err
+		if err == nil {
+			err = registry.Zakat(row.Input)
+		}
+		if err == nil {
+			if line, ok := seen[row.Input.ID]; ok {
+				err = fmt.Errorf("the zakat %s is also on line %d", row.Input.ID, line)
+			}
+		}
+		if err == nil && row.Input.Payment != nil {
+			if line, ok := payments[*row.Input.Payment]; ok {
+				err = fmt.Errorf("the payment %s is also on line %d", row.Input.Payment.Reference, line)
+			}
+		}
+		if err != nil {
+			fmt.Printf("line %d: %v\n", row.Line, err)
+			invalid++
+			continue
+		}
+		seen[row.Input.ID] = row.Line
+		if row.Input.Payment != nil {
+			payments[*row.Input.Payment] = row.Line
+		}
+		valid = append(valid, row.Input)
+		lines = append(lines, row.Line)
+	}
+	fmt.Printf("%d rows read, %d valid, %d invalid\n", len(rows), len(valid), invalid)
+	if dryRun {
+		return checked(invalid)
+	}
+
+	if invalid > 0 && mode == client.BatchAtomic {
+		return fmt.Errorf("nothing imported: fix the invalid rows or use -mode partial")
+	}
+	if mode == client.BatchAtomic && len(valid) > client.MaxBatchSize {
+		return fmt.Errorf("an atomic import is limited to %d rows, split the file or use -mode partial", client.MaxBatchSize)
+	}
+	if len(valid) == 0 {
+		return nil
+	}
+
+	added, failed := 0, 0
+	for start := 0; start < len(valid); start += client.MaxBatchSize {
+		end := start + client.MaxBatchSize
+		if end > len(valid) {
+			end = len(valid)
+		}
+		results, err := c.AddZakatBatch(valid[start:end], mode)
+		if err != nil {
+			return fmt.Errorf("rows %d to %d were not imported: %w", lines[start], lines[end-1], err)
+		}
+		for _, result := range results {
+			line := lines[start+result.Index]
+			if result.Status == "added" {
+				added++
+				continue
+			}
+			failed++
+			fmt.Printf("line %d: %s\n", line, result.Error)
+		}
+	}
+	fmt.Printf("%d donations added, %d rejected by the ledger\n", added, failed)
+	if failed > 0 || invalid > 0 {
+		return fmt.Errorf("%d rows were not imported", failed+invalid)
+	}
+	return nil
+}
+
+func importDistributions(c *client.Client, registry *validate.Registry, r io.Reader, mapping spreadsheet.Mapping, dryRun bool) error {
+	rows, err := spreadsheet.ReadDistributions(r, mapping)
+	if err != nil {
+		return err
+	}
+
+	var valid []spreadsheet.DistributionRow
+	seen := map[string]int{}
+	for _, row := range rows {
+		err := row.Err
+		if err == nil {
+			err = registry.Distribution(row.Input)
+		}
+		if err == nil {
+			if line, ok := seen[row.Input.ID]; ok {
+				err = fmt.Errorf("the distribution %s is also on line %d", row.Input.ID, line)
+			}
+		}
+		if err != nil {
+			fmt.Printf("line %d: %v\n", row.Line, err)
+			continue
+		}
+		seen[row.Input.ID] = row.Line
+		valid = append(valid, row)
+	}
+	invalid := len(rows) - len(valid)
+	fmt.Printf("%d rows read, %d valid, %d invalid\n", len(rows), len(valid), invalid)
+	if dryRun {
+		return checked(invalid)
+	}
+
+	recorded, failed := 0, 0
+	for _, batch := range distributionBatches(valid) {
+		first := batch[0].Input
+		entries := make([]client.DistributionEntry, len(batch))
+		for i, row := range batch {
+			entries[i] = row.Input.Entry()
+		}
+		if err := c.DistributeZakatBatch(first.PoolID, first.ProgramID, entries, first.Timestamp); err != nil {
+			fmt.Printf("lines %d to %d: %v\n", batch[0].Line, batch[len(batch)-1].Line, err)
+			failed += len(batch)
+			continue
+		}
+		recorded += len(batch)
+	}
+	fmt.Printf("%d distributions recorded, %d rejected by the ledger\n", recorded, failed)
+	if failed > 0 || invalid > 0 {
+		return fmt.Errorf("%d rows were not imported", failed+invalid)
+	}
+	return nil
+}
+
+// distributionBatches groups distributions from the same pool under the same program
+// and timestamp, in the order of their first row, into batches of at most
+// client.MaxBatchSize rows
+func distributionBatches(rows []spreadsheet.DistributionRow) [][]spreadsheet.DistributionRow {
+	type group struct{ poolID, programID, timestamp string }
+	var order []group
+	groups := map[group][]spreadsheet.DistributionRow{}
+	for _, row := range rows {
+		g := group{row.Input.PoolID, row.Input.ProgramID, row.Input.Timestamp}
+		if _, ok := groups[g]; !ok {
+			order = append(order, g)
+		}
+		groups[g] = append(groups[g], row)
+	}
+
+	var batches [][]spreadsheet.DistributionRow
+	for _, g := range order {
+		grouped := groups[g]
+		for start := 0; start < len(grouped); start += client.MaxBatchSize {
+			end := start + client.MaxBatchSize
+			if end > len(grouped) {
+				end = len(grouped)
+			}
+			batches = append(batches, grouped[start:end])
+		}
+	}
+	return batches
+}
+
+// checked reports the outcome of a dry run
+func checked(invalid int) error {
+	if invalid > 0 {
+		return fmt.Errorf("%d invalid rows", invalid)
+	}
+	return nil
+}
+
+func runExport(args []string) error {
+	fs := flag.NewFlagSet("export", flag.ExitOnError)
+	connection := client.AddFlags(fs)
+	kind := fs.String("kind", kindZakat, "what to export, zakat or distribution")
+	organization := fs.String("filter-org", "", "export only the records of this organization")
+	output := fs.String("out", "", "output file (default: stdout)")
+	fs.Parse(args)
+
+	c, err := connection.Connect()
+	if err != nil {
+		return err
+	}
+	defer c.Close()
+
+	var w io.Writer = os.Stdout
+	if *output != "" {
+		f, err := os.Create(*output)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		w = f
+	}
+
+	switch *kind {
+	case kindZakat:
+		zakats, err := c.GetAllZakat()
+		if err != nil {
+			return err
+		}
+		var selected []client.Zakat
+		for _, zakat := range zakats {
+			if *organization == "" || zakat.Organization == *organization {
+				selected = append(selected, zakat)
+			}
+		}
+		return spreadsheet.WriteZakat(w, selected)
+	case kindDistribution:
+		distributions, err := c.GetAllDistributions()
+		if err != nil {
+			return err
+		}
+		var selected []client.Distribution
+		for _, d := range distributions {
+			if *organization == "" || d.Organization == *organization {
+				selected = append(selected, d)
+			}
+		}
+		return spreadsheet.WriteDistributions(w, selected)
+	default:
+		return fmt.Errorf("unknown kind %q, expected zakat or distribution", *kind)
+	}
+}
@@ -0,0 +1,102 @@
+// Command zakat-notifier notifies donors when their zakat has been distributed, and
+// the organization's staff of every distribution that completes donations, from the
+// chaincode's ZakatDistributed events. It resumes after the last handled event.
+//
+// Contacts are read from a JSON file:
+//
+//	{
+//	  "donors": {"Ahmad": [{"channel": "whatsapp", "address": "6281234567890"}]},
+//	  "staff": {"YDSF Malang": [{"channel": "email", "address": "amil@ydsfmalang.example"}]}
+//	}
+//
+// The SMTP credentials and WhatsApp gateway token are read from ZAKAT_SMTP_USER,
+// ZAKAT_SMTP_PASSWORD and ZAKAT_WHATSAPP_TOKEN.
+package main
+
+import (
+	"context"
+	"flag"
+	"log"
+	"net"
+	"net/http"
+	"net/smtp"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	gateway "github.com/hyperledger/fabric-gateway/pkg/client"
+	"github.com/izzuddinafif/fabric-zakat/application/client"
+	"github.com/izzuddinafif/fabric-zakat/application/notifier"
+)
+
+func main() {
+	connection := client.AddFlags(flag.CommandLine)
+	contacts := flag.String("contacts", "contacts.json", "JSON file of donor and staff contacts")
+	checkpointFile := flag.String("checkpoint", "notifier-checkpoint.json", "file recording the last handled event")
+	templatesFile := flag.String("templates", "", "text/template file overriding donor.subject, donor.body, staff.subject and staff.body")
+	smtpAddr := flag.String("smtp", "", "SMTP server for the email channel, host:port")
+	smtpFrom := flag.String("smtp-from", "", "sender address of emails")
+	whatsappURL := flag.String("whatsapp-url", "", "URL of the WhatsApp gateway for the whatsapp channel")
+	standIn := flag.Bool("stand-in", false, "print every message to stdout instead of sending it")
+	flag.Parse()
+
+	directory, err := notifier.LoadDirectory(*contacts)
+	if err != nil {
+		log.Fatal(err)
+	}
+	templates, err := notifier.Templates(*templatesFile)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	senders := map[string]notifier.Sender{}
+	if *standIn {
+		stdout := &notifier.LogSender{W: os.Stdout}
+		for _, channel := range []string{notifier.ChannelWebhook, notifier.ChannelEmail, notifier.ChannelWhatsApp} {
+			senders[channel] = stdout
+		}
+	} else {
+		httpClient := &http.Client{Timeout: 30 * time.Second}
+		senders[notifier.ChannelWebhook] = notifier.WebhookSender{Client: httpClient}
+		if *smtpAddr != "" {
+			var auth smtp.Auth
+			if user := os.Getenv("ZAKAT_SMTP_USER"); user != "" {
+				host, _, err := net.SplitHostPort(*smtpAddr)
+				if err != nil {
+					log.Fatal(err)
+				}
+				auth = smtp.PlainAuth("", user, os.Getenv("ZAKAT_SMTP_PASSWORD"), host)
+			}
+			senders[notifier.ChannelEmail] = notifier.EmailSender{Addr: *smtpAddr, From: *smtpFrom, Auth: auth}
+		}
+		if *whatsappURL != "" {
+			senders[notifier.ChannelWhatsApp] = notifier.WhatsAppSender{URL: *whatsappURL, Token: os.Getenv("ZAKAT_WHATSAPP_TOKEN"), Client: httpClient}
+		}
+	}
+
+	n, err := notifier.New(directory, senders, templates)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	checkpointer, err := gateway.NewFileCheckpointer(*checkpointFile)
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer checkpointer.Close()
+
+	c, err := connection.Connect()
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer c.Close()
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	log.Printf("notifying from block %d", checkpointer.BlockNumber())
+	if err := notifier.Run(ctx, c.ChaincodeEvents, checkpointer, n); err != nil {
+		log.Fatal(err)
+	}
+}
@@ -0,0 +1,134 @@
+// Command zakat-payments records donations paid online. It receives the signed
+// callbacks of the payment gateway and submits AddZakatWithPayment for each paid
+// payment, at most once per payment ID.
+//
+//	zakat-payments serve [-addr :8090] [-gateway MIDTRANS] [connection flags]
+//	zakat-payments mock -url http://localhost:8090/callback -amount 2500000 -type maal [-organization "YDSF Malang"] [-muzakki Ahmad] [-payment-id PAY-1]
+//
+// The secret shared with the gateway is read from ZAKAT_CALLBACK_SECRET. The mock
+// command plays the gateway for local testing: it signs a callback with the same
+// secret and posts it, retrying like a gateway does.
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"log"
+	"net/http"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"github.com/izzuddinafif/fabric-zakat/application/client"
+	"github.com/izzuddinafif/fabric-zakat/application/payments"
+	"github.com/izzuddinafif/fabric-zakat/application/validate"
+)
+
+func main() {
+	log.SetFlags(0)
+	if len(os.Args) < 2 {
+		log.Fatal("usage: zakat-payments serve|mock [flags]")
+	}
+	secret := []byte(os.Getenv("ZAKAT_CALLBACK_SECRET"))
+	if len(secret) == 0 {
+		log.Fatal("ZAKAT_CALLBACK_SECRET is not set")
+	}
+
+	var err error
+	switch os.Args[1] {
+	case "serve":
+		err = runServe(os.Args[2:], secret)
+	case "mock":
+		err = runMock(os.Args[2:], secret)
+	default:
+		err = fmt.Errorf("unknown command %q, expected serve or mock", os.Args[1])
+	}
+	if err != nil {
+		log.Fatal(err)
+	}
+}
+
+func runServe(args []string, secret []byte) error {
+	fs := flag.NewFlagSet("serve", flag.ExitOnError)
+	connection := client.AddFlags(fs)
+	addr := fs.String("addr", ":8090", "address to listen on")
+	gateway := fs.String("gateway", "MIDTRANS", "name of the payment gateway, recorded as the bank of each payment")
+	fs.Parse(args)
+
+	// The registry is read once; restart the service after registering an organization
+	c, err := connection.Connect()
+	if err != nil {
+		return err
+	}
+	organizations, err := c.GetAllOrganizations()
+	c.Close()
+	if err != nil {
+		return err
+	}
+
+	recorder := payments.NewRecorder(*gateway, validate.NewRegistry(organizations), func(organization string) (payments.Ledger, error) {
+		connection := client.Flags{OrganizationsDir: connection.OrganizationsDir, Profiles: connection.Profiles, Organization: organization, User: connection.User}
+		return connection.Connect()
+	})
+	defer recorder.Close()
+
+	httpServer := &http.Server{
+		Addr:              *addr,
+		Handler:           payments.Handler(recorder, secret),
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		<-ctx.Done()
+		shutdown, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+		defer cancel()
+		httpServer.Shutdown(shutdown)
+	}()
+
+	log.Printf("payment callbacks listening on %s", *addr)
+	if err := httpServer.ListenAndServe(); err != http.ErrServerClosed {
+		return err
+	}
+	<-done
+	return nil
+}
+
+func runMock(args []string, secret []byte) error {
+	fs := flag.NewFlagSet("mock", flag.ExitOnError)
+	url := fs.String("url", "http://localhost:8090/callback", "callback URL of zakat-payments serve")
+	paymentID := fs.String("payment-id", fmt.Sprintf("MOCK-%d", time.Now().UnixNano()), "gateway payment ID; repeat one to test idempotency")
+	status := fs.String("status", payments.StatusPaid, "payment status, paid, pending, expired or failed")
+	amount := fs.Float64("amount", 0, "amount paid in IDR")
+	method := fs.String("method", "qris", "payment method")
+	paidAt := fs.String("paid-at", time.Now().UTC().Format(time.RFC3339), "settlement time, ISO 8601")
+	muzakki := fs.String("muzakki", "", "donor's name, empty to give anonymously")
+	zakatType := fs.String("type", "maal", "fitrah or maal")
+	organization := fs.String("organization", "YDSF Malang", "organization the donation is for")
+	retries := fs.Int("retries", 3, "retries after a 5xx response")
+	fs.Parse(args)
+
+	gateway := &payments.MockGateway{URL: *url, Secret: secret, Retries: *retries, RetryDelay: 2 * time.Second}
+	code, body, err := gateway.Send(context.Background(), payments.Callback{
+		PaymentID: *paymentID,
+		Status:    *status,
+		Amount:    *amount,
+		Currency:  "IDR",
+		Method:    *method,
+		PaidAt:    *paidAt,
+		Order:     payments.Order{Muzakki: *muzakki, Type: *zakatType, Organization: *organization},
+	})
+	if err != nil {
+		return err
+	}
+	fmt.Printf("%d %s", code, body)
+	if code != http.StatusOK {
+		return fmt.Errorf("the callback was not accepted")
+	}
+	return nil
+}
@@ -0,0 +1,61 @@
+// Command zakat-projector keeps a SQLite read model of the zakat transactions up
+// to date from the channel's block events and serves read queries on it. It
+// resumes from the last projected block after a restart.
+package main
+
+import (
+	"context"
+	"flag"
+	"log"
+	"net/http"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"github.com/izzuddinafif/fabric-zakat/application/client"
+	"github.com/izzuddinafif/fabric-zakat/application/projector"
+)
+
+func main() {
+	connection := client.AddFlags(flag.CommandLine)
+	database := flag.String("db", "zakat.db", "SQLite database of the read model")
+	addr := flag.String("addr", ":8081", "address to serve queries on")
+	flag.Parse()
+
+	store, err := projector.Open(*database)
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer store.Close()
+
+	c, err := connection.Connect()
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer c.Close()
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	httpServer := &http.Server{
+		Addr:              *addr,
+		Handler:           projector.Handler(store),
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+	go func() {
+		log.Printf("serving read queries on %s", *addr)
+		if err := httpServer.ListenAndServe(); err != http.ErrServerClosed {
+			log.Print(err)
+			stop()
+		}
+	}()
+
+	err = projector.Run(ctx, c.BlockEvents, c.Chaincode(), store)
+	shutdown, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	httpServer.Shutdown(shutdown)
+	if err != nil {
+		log.Fatal(err)
+	}
+}
@@ -0,0 +1,135 @@
+// Command zakat-reconcile matches a bank statement or QRIS settlement report,
+// exported as CSV, against the donations recorded on the ledger and reports the
+// credits and donations found on one side only.
+//
+//	zakat-reconcile -statement mutasi.csv -bank BSI [-channel bank_transfer|qris|gateway] [-map field=column,...]
+//	    [-filter-org "YDSF Malang"] [-from 2024-03-01] [-to 2024-03-31] [-unreferenced] [-output table|json|csv] [-out report.csv]
+//
+// The command exits with an error when any entry is unmatched, so that it can run
+// unattended after each statement download.
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"sort"
+
+	"github.com/izzuddinafif/fabric-zakat/application/client"
+	"github.com/izzuddinafif/fabric-zakat/application/output"
+	"github.com/izzuddinafif/fabric-zakat/application/reconcile"
+	"github.com/izzuddinafif/fabric-zakat/application/spreadsheet"
+)
+
+const formatCSV = "csv"
+
+func main() {
+	log.SetFlags(0)
+	if err := run(os.Args[1:]); err != nil {
+		log.Fatal(err)
+	}
+}
+
+func run(args []string) error {
+	fs := flag.NewFlagSet("zakat-reconcile", flag.ExitOnError)
+	connection := client.AddFlags(fs)
+	statement := fs.String("statement", "", "bank statement or QRIS settlement CSV file")
+	channel := fs.String("channel", client.PaymentBankTransfer, "payment channel of the statement, bank_transfer, qris or gateway, unless the file has a channel column")
+	bank := fs.String("bank", "", "bank or QRIS acquirer of the statement, e.g. BSI, unless the file has a bank column")
+	columns := fs.String("map", "", "column mapping, e.g. amount=Kredit,reference=No Referensi")
+	organization := fs.String("filter-org", "", "expect only the donations of this organization, defaults to all")
+	from := fs.String("from", "", "first day the statement covers, YYYY-MM-DD; defaults to its first credit")
+	to := fs.String("to", "", "last day the statement covers, YYYY-MM-DD; defaults to its last credit")
+	unreferenced := fs.Bool("unreferenced", false, "also list donations of the period recorded without a payment reference")
+	format := fs.String("output", output.FormatTable, "report format, table, json or csv")
+	out := fs.String("out", "", "file to write the report to, defaults to stdout")
+	fs.Parse(args)
+
+	if *statement == "" {
+		return fmt.Errorf("-statement is required")
+	}
+	if *format != formatCSV {
+		if err := output.CheckFormat(*format); err != nil {
+			return fmt.Errorf("unknown output format %q, expected table, json or csv", *format)
+		}
+	}
+	for _, date := range []string{*from, *to} {
+		if date == "" {
+			continue
+		}
+		if _, err := spreadsheet.ParseDate(date); err != nil {
+			return err
+		}
+	}
+	mapping, err := spreadsheet.ParseMapping(*columns)
+	if err != nil {
+		return err
+	}
+
+	f, err := os.Open(*statement)
+	if err != nil {
+		return err
+	}
+	credits, err := spreadsheet.ReadStatement(f, mapping, client.Payment{Channel: *channel, Bank: *bank})
+	f.Close()
+	if err != nil {
+		return err
+	}
+
+	c, err := connection.Connect()
+	if err != nil {
+		return err
+	}
+	defer c.Close()
+
+	zakats, err := c.GetAllZakat()
+	if err != nil {
+		return err
+	}
+	report, err := reconcile.Reconcile(credits, zakats, reconcile.Scope{
+		Organization: *organization,
+		From:         *from,
+		To:           *to,
+		Unreferenced: *unreferenced,
+	})
+	if err != nil {
+		return err
+	}
+
+	var w io.Writer = os.Stdout
+	if *out != "" {
+		file, err := os.Create(*out)
+		if err != nil {
+			return err
+		}
+		defer file.Close()
+		w = file
+	}
+	if *format == formatCSV {
+		err = reconcile.WriteCSV(w, report)
+	} else {
+		err = output.Write(w, *format, report, reconcile.Table(report))
+	}
+	if err != nil {
+		return err
+	}
+
+	counts := report.Counts()
+	statuses := make([]string, 0, len(counts))
+	for status := range counts {
+		statuses = append(statuses, status)
+	}
+	sort.Strings(statuses)
+	summary := fmt.Sprintf("%s to %s, %d credits read:", report.From, report.To, len(credits))
+	for _, status := range statuses {
+		summary += fmt.Sprintf(" %d %s", counts[status], status)
+	}
+	fmt.Fprintln(os.Stderr, summary)
+
+	if unmatched := report.Unmatched(); unmatched > 0 {
+		return fmt.Errorf("%d entries do not reconcile", unmatched)
+	}
+	return nil
+}
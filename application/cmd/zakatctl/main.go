@@ -0,0 +1,847 @@
+// Command zakatctl records and looks up donations and distributions on the ledger
+// through the Fabric Gateway, as a user of an organization.
+//
+//	zakatctl add -id ZKT-YDSF-MLG-202403-0001 -muzakki Ahmad -amount 45000 -type fitrah [-timestamp 2024-03-30T08:00:00Z] [-channel bank_transfer|qris -bank BSI -reference FT24090ABC123]
+//	zakatctl add -id ZKT-YDSF-MLG-202403-0002 -muzakki Budi -amount 2,5 -unit kg_beras -type fitrah
+//	zakatctl add -id ZKT-YDSF-MLG-202403-0003 -muzakki Citra -amount 135000 -type fitrah [-persons "Citra,Dimas,Eka"] [-jiwa 3]
+//	zakatctl add -id ZKT-YDSF-MLG-202403-0004 -muzakki Dimas -amount 100 -currency MYR -rate 3350,55 -rate-source "BSI kurs beli" -type maal
+//	zakatctl add -id ZKT-YDSF-MLG-202403-0005 -muzakki Eka -amount 1000000 -type maal -restrict region:Lumajang|program:PRG-YDSF-MLG-2024-0001|asnaf:fakir
+//	zakatctl add -id ZKT-YDSF-MLG-202602-0001 -pledge PLG-YDSF-MLG-2026-0001 -amount 500000
+//	zakatctl add -id ZKT-YDSF-MLG-202603-0006 -anonymous|-commitment HASH -amount 2500000 -type maal
+//	zakatctl query [-kind zakat|distribution] ID
+//	zakatctl query -kind payment [-channel bank_transfer|qris] -bank BSI REFERENCE
+//	zakatctl list [-kind zakat|distribution] [-filter-org "YDSF Malang"] [-status collected|distributed]
+//	zakatctl distribute -id DST-YDSF-MLG-202404-0001 -pool POOL-YDSF-MLG-FITRAH [-program ID] -mustahik Budi -amount 90000 [-region Lumajang] [-timestamp ...]
+//	zakatctl distribute -file recipients.csv
+//	zakatctl history ZKT-YDSF-MLG-202403-0001
+//	zakatctl verify -secret SECRET ZKT-YDSF-MLG-202603-0006
+//	zakatctl hijri [TIMESTAMP]
+//	zakatctl hijri -set-start 1445-09 -date 2024-03-12
+//	zakatctl fitrah [-year 1447] [-filter-org "YDSF Malang"]
+//	zakatctl fitrah -year 1447 -set-eid 2026-03-20T06:30:00+07:00 [-policy flag|sadaqah]
+//	zakatctl fitrah -year 1447 -set-rate 45000
+//	zakatctl prices [-set kg_beras -price 15000 -since 2026-02-18]
+//	zakatctl restricted [-filter-org "YDSF Malang"]
+//	zakatctl pledges [-filter-org "YDSF Malang"] [PLEDGE-ID]
+//	zakatctl pledges -create PLG-YDSF-MLG-2026-0001 -muzakki Ahmad -amount 500000 [-type maal] [-frequency monthly|quarterly|yearly] -start 2026-01-25T00:00:00+07:00 -end 2026-12-31T23:59:59+07:00
+//	zakatctl haul [-filter-org "YDSF Malang"] [-days 30]
+//	zakatctl haul -muzakki Ahmad [-set-start 2025-06-01T00:00:00+07:00]
+//
+// Every command takes the connection flags and -output table|json. Donations are
+// recorded for the organization the command connects as.
+package main
+
+import (
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/izzuddinafif/fabric-zakat/application/client"
+	"github.com/izzuddinafif/fabric-zakat/application/output"
+	"github.com/izzuddinafif/fabric-zakat/application/spreadsheet"
+	"github.com/izzuddinafif/fabric-zakat/application/validate"
+)
+
+const (
+	kindZakat        = "zakat"
+	kindDistribution = "distribution"
+	kindPayment      = "payment"
+)
+
+// commands maps each command to its implementation
+var commands = map[string]func(args []string) error{
+	"add":        runAdd,
+	"query":      runQuery,
+	"list":       runList,
+	"distribute": runDistribute,
+	"history":    runHistory,
+	"hijri":      runHijri,
+	"fitrah":     runFitrah,
+	"prices":     runPrices,
+	"restricted": runRestricted,
+	"pledges":    runPledges,
+	"haul":       runHaul,
+	"verify":     runVerify,
+}
+
+func main() {
+	log.SetFlags(0)
+	if len(os.Args) < 2 {
+		log.Fatal("usage: zakatctl add|query|list|distribute|history|hijri|fitrah|prices|restricted|pledges|haul|verify [flags]")
+	}
+	run, ok := commands[os.Args[1]]
+	if !ok {
+		log.Fatalf("unknown command %q, expected add, query, list, distribute, history, hijri, fitrah, prices, restricted, pledges, haul or verify", os.Args[1])
+	}
+	if err := run(os.Args[2:]); err != nil {
+		log.Fatal(err)
+	}
+}
+
+// command holds the flags every command takes
+type command struct {
+	fs         *flag.FlagSet
+	connection *client.Flags
+	format     *string
+}
+
+func newCommand(name string) *command {
+	fs := flag.NewFlagSet(name, flag.ExitOnError)
+	return &command{
+		fs:         fs,
+		connection: client.AddFlags(fs),
+		format:     fs.String("output", output.FormatTable, "output format, table or json"),
+	}
+}
+
+// parse parses the arguments and checks the output format
+func (c *command) parse(args []string) error {
+	c.fs.Parse(args)
+	return output.CheckFormat(*c.format)
+}
+
+// id returns the single positional argument
+func (c *command) id() (string, error) {
+	if c.fs.NArg() != 1 {
+		return "", fmt.Errorf("usage: zakatctl %s [flags] ID", c.fs.Name())
+	}
+	return c.fs.Arg(0), nil
+}
+
+// timestampFlag registers a -timestamp flag that defaults to the current time
+func timestampFlag(fs *flag.FlagSet) *string {
+	return fs.String("timestamp", time.Now().UTC().Format(time.RFC3339), "timestamp, ISO 8601")
+}
+
+// registry reads the organization registry to validate input against
+func registry(c *client.Client) (*validate.Registry, error) {
+	organizations, err := c.GetAllOrganizations()
+	if err != nil {
+		return nil, err
+	}
+	return validate.NewRegistry(organizations), nil
+}
+
+func runAdd(args []string) error {
+	cmd := newCommand("add")
+	id := cmd.fs.String("id", "", "zakat ID, ZKT-YDSF-{ORG}-YYYYMM-NNNN")
+	muzakki := cmd.fs.String("muzakki", "", "donor's name (default with -pledge: the pledge's)")
+	amount := cmd.fs.String("amount", "", "amount in IDR, e.g. 45000 or \"Rp 45.000\", or quantity in -unit, e.g. 2,5")
+	unit := cmd.fs.String("unit", client.UnitIDR, "IDR, or the unit of a donation in kind, e.g. kg_beras or ekor_kambing")
+	zakatType := cmd.fs.String("type", "", "fitrah or maal (default with -pledge: the pledge's)")
+	timestamp := timestampFlag(cmd.fs)
+	channel := cmd.fs.String("channel", client.PaymentBankTransfer, "payment channel, bank_transfer or qris")
+	bank := cmd.fs.String("bank", "", "receiving bank or QRIS acquirer, e.g. BSI")
+	reference := cmd.fs.String("reference", "", "bank reference number or QRIS RRN the donation was paid with")
+	persons := cmd.fs.String("persons", "", "fitrah only: comma-separated names of the persons the donation covers")
+	jiwa := cmd.fs.Int("jiwa", 0, "fitrah only: number of persons the donation covers, if not named")
+	currency := cmd.fs.String("currency", client.UnitIDR, "currency the donation was given in, e.g. MYR, SAR or HKD; -amount is in this currency")
+	rate := cmd.fs.String("rate", "", "with -currency, IDR per unit of the currency the donation was converted at, e.g. 3350,55")
+	rateSource := cmd.fs.String("rate-source", "", "with -currency, where the rate was taken from, e.g. JISDOR or \"BSI kurs beli\"")
+	restrict := cmd.fs.String("restrict", "", "purpose the donor restricted the donation to, as program:ID, asnaf:NAME or region:NAME")
+	pledge := cmd.fs.String("pledge", "", "pledge the donation is paid towards, e.g. PLG-YDSF-MLG-2026-0001")
+	anonymous := cmd.fs.Bool("anonymous", false, "record the donor as Hamba Allah, with a new secret printed on stderr for the donor to keep")
+	commitment := cmd.fs.String("commitment", "", "record the donor as Hamba Allah, with this commitment the donor computed from their own secret")
+	if err := cmd.parse(args); err != nil {
+		return err
+	}
+
+	value, err := spreadsheet.ParseAmount(*amount)
+	if err != nil {
+		return err
+	}
+	input := client.ZakatInput{
+		ID:           *id,
+		Muzakki:      *muzakki,
+		Amount:       value,
+		Unit:         *unit,
+		Type:         *zakatType,
+		Organization: cmd.connection.Organization,
+		Timestamp:    *timestamp,
+		Jiwa:         *jiwa,
+		PledgeID:     *pledge,
+	}
+	for _, person := range strings.Split(*persons, ",") {
+		if person = strings.TrimSpace(person); person != "" {
+			input.Persons = append(input.Persons, person)
+		}
+	}
+	if *reference != "" {
+		payment := client.Payment{Channel: *channel, Bank: *bank, Reference: *reference}.Normalize()
+		input.Payment = &payment
+	}
+	if !client.IsIDR(strings.ToUpper(*currency)) {
+		perUnit, err := spreadsheet.ParseAmount(*rate)
+		if err != nil {
+			return fmt.Errorf("invalid rate %q", *rate)
+		}
+		conversion := client.Conversion{Currency: *currency, Amount: value, Rate: perUnit, Source: *rateSource}.Normalize()
+		input.Conversion, input.Amount = &conversion, conversion.IDRAmount()
+	}
+	if *restrict != "" {
+		restriction, err := client.ParseRestriction(*restrict)
+		if err != nil {
+			return err
+		}
+		input.Restriction = &restriction
+	}
+	secret := ""
+	if *anonymous {
+		if secret, err = client.NewDonorSecret(); err != nil {
+			return err
+		}
+		*commitment = client.DonorCommitment(input.ID, secret)
+	}
+	input.DonorCommitment = *commitment
+
+	c, err := cmd.connection.Connect()
+	if err != nil {
+		return err
+	}
+	defer c.Close()
+
+	r, err := registry(c)
+	if err != nil {
+		return err
+	}
+	if err := r.Zakat(input); err != nil {
+		return err
+	}
+	if err := c.AddZakat(input); err != nil {
+		return err
+	}
+	if secret != "" {
+		fmt.Fprintf(os.Stderr, "Donor secret for %s: %s\nGive it to the donor only. It is not stored anywhere and proves the donation is theirs.\n", input.ID, secret)
+	}
+
+	zakat, err := c.QueryZakat(input.ID)
+	if err != nil {
+		return err
+	}
+	return output.Write(os.Stdout, *cmd.format, zakat, output.ZakatTable([]client.Zakat{zakat}))
+}
+
+func runQuery(args []string) error {
+	cmd := newCommand("query")
+	kind := cmd.fs.String("kind", kindZakat, "what to look up, zakat, distribution or payment")
+	channel := cmd.fs.String("channel", client.PaymentBankTransfer, "for payments, the payment channel, bank_transfer or qris")
+	bank := cmd.fs.String("bank", "", "for payments, the receiving bank or QRIS acquirer")
+	if err := cmd.parse(args); err != nil {
+		return err
+	}
+	id, err := cmd.id()
+	if err != nil {
+		return err
+	}
+
+	c, err := cmd.connection.Connect()
+	if err != nil {
+		return err
+	}
+	defer c.Close()
+
+	switch *kind {
+	case kindZakat:
+		zakat, err := c.QueryZakat(id)
+		if err != nil {
+			return err
+		}
+		return output.Write(os.Stdout, *cmd.format, zakat, output.ZakatTable([]client.Zakat{zakat}))
+	case kindDistribution:
+		distribution, err := c.QueryDistribution(id)
+		if err != nil {
+			return err
+		}
+		return output.Write(os.Stdout, *cmd.format, distribution, output.DistributionTable([]client.Distribution{distribution}))
+	case kindPayment:
+		zakat, err := c.GetZakatByPayment(client.Payment{Channel: *channel, Bank: *bank, Reference: id}.Normalize())
+		if err != nil {
+			return err
+		}
+		return output.Write(os.Stdout, *cmd.format, zakat, output.ZakatTable([]client.Zakat{zakat}))
+	default:
+		return fmt.Errorf("unknown kind %q, expected zakat, distribution or payment", *kind)
+	}
+}
+
+func runList(args []string) error {
+	cmd := newCommand("list")
+	kind := cmd.fs.String("kind", kindZakat, "what to list, zakat or distribution")
+	organization := cmd.fs.String("filter-org", "", "list only the records of this organization")
+	status := cmd.fs.String("status", "", "for donations, list only those with this status, collected or distributed")
+	if err := cmd.parse(args); err != nil {
+		return err
+	}
+
+	c, err := cmd.connection.Connect()
+	if err != nil {
+		return err
+	}
+	defer c.Close()
+
+	switch *kind {
+	case kindZakat:
+		zakats, err := c.GetAllZakat()
+		if err != nil {
+			return err
+		}
+		selected := []client.Zakat{}
+		for _, zakat := range zakats {
+			if (*organization == "" || zakat.Organization == *organization) && (*status == "" || zakat.Status == *status) {
+				selected = append(selected, zakat)
+			}
+		}
+		return output.Write(os.Stdout, *cmd.format, selected, output.ZakatTable(selected))
+	case kindDistribution:
+		distributions, err := c.GetAllDistributions()
+		if err != nil {
+			return err
+		}
+		selected := []client.Distribution{}
+		for _, d := range distributions {
+			if *organization == "" || d.Organization == *organization {
+				selected = append(selected, d)
+			}
+		}
+		return output.Write(os.Stdout, *cmd.format, selected, output.DistributionTable(selected))
+	default:
+		return fmt.Errorf("unknown kind %q, expected zakat or distribution", *kind)
+	}
+}
+
+func runDistribute(args []string) error {
+	cmd := newCommand("distribute")
+	id := cmd.fs.String("id", "", "distribution ID, DST-YDSF-{ORG}-YYYYMM-NNNN")
+	poolID := cmd.fs.String("pool", "", "pool to draw from, e.g. POOL-YDSF-MLG-FITRAH")
+	programID := cmd.fs.String("program", "", "program to charge the distribution to (default: none)")
+	mustahik := cmd.fs.String("mustahik", "", "recipient's name")
+	amount := cmd.fs.String("amount", "", "amount in IDR, or quantity in the unit of an in-kind pool")
+	region := cmd.fs.String("region", "", "region the mustahik is in, which also spends funds restricted to it (default: none)")
+	asnaf := cmd.fs.String("asnaf", "", "asnaf of the mustahik, e.g. miskin (default: the program's)")
+	timestamp := timestampFlag(cmd.fs)
+	file := cmd.fs.String("file", "", "CSV file of distributions from one pool under one program and timestamp, recorded in one transaction")
+	if err := cmd.parse(args); err != nil {
+		return err
+	}
+	if *file != "" {
+		return distributeFile(cmd, *file)
+	}
+
+	value, err := spreadsheet.ParseAmount(*amount)
+	if err != nil {
+		return err
+	}
+	input := client.DistributionInput{
+		ID:        *id,
+		PoolID:    *poolID,
+		ProgramID: *programID,
+		Mustahik:  *mustahik,
+		Amount:    value,
+		Timestamp: *timestamp,
+		Region:    strings.TrimSpace(*region),
+		Asnaf:     *asnaf,
+	}
+
+	c, err := cmd.connection.Connect()
+	if err != nil {
+		return err
+	}
+	defer c.Close()
+
+	r, err := registry(c)
+	if err != nil {
+		return err
+	}
+	if err := r.Distribution(input); err != nil {
+		return err
+	}
+	if err := c.DistributeZakat(input); err != nil {
+		return err
+	}
+
+	distribution, err := c.QueryDistribution(input.ID)
+	if err != nil {
+		return err
+	}
+	return output.Write(os.Stdout, *cmd.format, distribution, output.DistributionTable([]client.Distribution{distribution}))
+}
+
+// distributeFile records the distributions of a CSV file, in the columns zakat-csv
+// imports, in one DistributeZakatBatch transaction, so either every mustahik on the
+// list is recorded or none is
+func distributeFile(cmd *command, file string) error {
+	f, err := os.Open(file)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	rows, err := spreadsheet.ReadDistributions(f, nil)
+	if err != nil {
+		return err
+	}
+	if len(rows) == 0 {
+		return fmt.Errorf("%s has no distributions", file)
+	}
+	if len(rows) > client.MaxBatchSize {
+		return fmt.Errorf("%s has %d distributions, more than the %d of one transaction; import it with zakat-csv", file, len(rows), client.MaxBatchSize)
+	}
+
+	c, err := cmd.connection.Connect()
+	if err != nil {
+		return err
+	}
+	defer c.Close()
+
+	r, err := registry(c)
+	if err != nil {
+		return err
+	}
+	first := rows[0].Input
+	entries := make([]client.DistributionEntry, len(rows))
+	seen := map[string]int{}
+	for i, row := range rows {
+		err := row.Err
+		if err == nil {
+			err = r.Distribution(row.Input)
+		}
+		if err == nil && (row.Input.PoolID != first.PoolID || row.Input.ProgramID != first.ProgramID || row.Input.Timestamp != first.Timestamp) {
+			err = fmt.Errorf("not from the pool, program and timestamp of line %d; import mixed distributions with zakat-csv", rows[0].Line)
+		}
+		if line, ok := seen[row.Input.ID]; ok && err == nil {
+			err = fmt.Errorf("the distribution %s is also on line %d", row.Input.ID, line)
+		}
+		if err != nil {
+			return fmt.Errorf("line %d: %w", row.Line, err)
+		}
+		seen[row.Input.ID] = row.Line
+		entries[i] = row.Input.Entry()
+	}
+	if err := c.DistributeZakatBatch(first.PoolID, first.ProgramID, entries, first.Timestamp); err != nil {
+		return err
+	}
+	fmt.Printf("%d distributions recorded from %s\n", len(entries), first.PoolID)
+	return nil
+}
+
+func runHistory(args []string) error {
+	cmd := newCommand("history")
+	if err := cmd.parse(args); err != nil {
+		return err
+	}
+	id, err := cmd.id()
+	if err != nil {
+		return err
+	}
+
+	c, err := cmd.connection.Connect()
+	if err != nil {
+		return err
+	}
+	defer c.Close()
+
+	history, err := c.GetZakatHistory(id)
+	if err != nil {
+		return err
+	}
+	return output.Write(os.Stdout, *cmd.format, history, output.HistoryTable(history))
+}
+
+// runHijri shows the Hijri date the chaincode stamps a timestamp with, or records
+// the start of a Hijri month announced after the isbat
+func runHijri(args []string) error {
+	cmd := newCommand("hijri")
+	month := cmd.fs.String("set-start", "", "Hijri month to set the start of, YYYY-MM, e.g. 1445-09 for Ramadan 1445 (organization admins only)")
+	date := cmd.fs.String("date", "", "with -set-start, the announced first day of the month, YYYY-MM-DD")
+	if err := cmd.parse(args); err != nil {
+		return err
+	}
+
+	c, err := cmd.connection.Connect()
+	if err != nil {
+		return err
+	}
+	defer c.Close()
+
+	if *month != "" {
+		var year, m int
+		if _, err := fmt.Sscanf(*month, "%d-%d", &year, &m); err != nil {
+			return fmt.Errorf("invalid Hijri month %q, expected YYYY-MM", *month)
+		}
+		if err := c.SetHijriMonthStart(year, m, *date); err != nil {
+			return err
+		}
+		calendar, err := c.GetHijriCalendar()
+		if err != nil {
+			return err
+		}
+		table := output.Table{Header: []string{"MONTH", "STARTS"}}
+		for key, start := range calendar.MonthStarts {
+			table.Rows = append(table.Rows, []string{key, start})
+		}
+		sort.Slice(table.Rows, func(i, j int) bool { return table.Rows[i][0] < table.Rows[j][0] })
+		return output.Write(os.Stdout, *cmd.format, calendar, table)
+	}
+
+	timestamp := time.Now().UTC().Format(time.RFC3339)
+	if cmd.fs.NArg() > 1 {
+		return fmt.Errorf("usage: zakatctl hijri [flags] [TIMESTAMP]")
+	}
+	if cmd.fs.NArg() == 1 {
+		timestamp = cmd.fs.Arg(0)
+	}
+	hijri, err := c.GetHijriDate(timestamp)
+	if err != nil {
+		return err
+	}
+	table := output.Table{
+		Header: []string{"TIMESTAMP", "HIJRI", "PERIOD"},
+		Rows:   [][]string{{timestamp, hijri.String(), fmt.Sprintf("%04dH%02d", hijri.Year, hijri.Month)}},
+	}
+	return output.Write(os.Stdout, *cmd.format, hijri, table)
+}
+
+// runFitrah lists the fitrah still to be distributed before the Eid prayer, or sets
+// the prayer of a Hijri year and the policy for fitrah distributed after it, or the
+// organization's per-person fitrah rate for the year
+func runFitrah(args []string) error {
+	cmd := newCommand("fitrah")
+	year := cmd.fs.Int("year", 0, "Hijri year, e.g. 1447 (default: the current one)")
+	organization := cmd.fs.String("filter-org", "", "organization to list, defaults to the -org organization")
+	prayer := cmd.fs.String("set-eid", "", "set the Eid prayer of the year, ISO 8601, e.g. 2026-03-20T06:30:00+07:00 (organization admins only)")
+	policy := cmd.fs.String("policy", client.LatePolicyFlag, "with -set-eid, how fitrah distributed after the prayer is recorded, flag or sadaqah")
+	rate := cmd.fs.String("set-rate", "", "set the organization's fitrah per person for the year in IDR, e.g. 45000 (organization admins only)")
+	if err := cmd.parse(args); err != nil {
+		return err
+	}
+	if *organization == "" {
+		*organization = cmd.connection.Organization
+	}
+
+	c, err := cmd.connection.Connect()
+	if err != nil {
+		return err
+	}
+	defer c.Close()
+
+	if *year == 0 {
+		today, err := c.GetHijriDate(time.Now().UTC().Format(time.RFC3339))
+		if err != nil {
+			return err
+		}
+		*year = today.Year
+	}
+
+	if *prayer != "" {
+		if err := c.SetEid(*year, *prayer, *policy); err != nil {
+			return err
+		}
+		eid, err := c.GetEid(*year)
+		if err != nil {
+			return err
+		}
+		table := output.Table{
+			Header: []string{"HIJRI YEAR", "PRAYER", "POLICY"},
+			Rows:   [][]string{{strconv.Itoa(eid.HijriYear), eid.Prayer, eid.Policy}},
+		}
+		return output.Write(os.Stdout, *cmd.format, eid, table)
+	}
+
+	if *rate != "" {
+		amount, err := spreadsheet.ParseAmount(*rate)
+		if err != nil {
+			return err
+		}
+		if err := c.SetFitrahRate(*organization, *year, amount); err != nil {
+			return err
+		}
+		fitrahRate, err := c.GetFitrahRate(*organization, *year)
+		if err != nil {
+			return err
+		}
+		table := output.Table{
+			Header: []string{"ORGANIZATION", "HIJRI YEAR", "PER PERSON"},
+			Rows:   [][]string{{fitrahRate.Organization, strconv.Itoa(fitrahRate.HijriYear), output.Amount(fitrahRate.Amount)}},
+		}
+		return output.Write(os.Stdout, *cmd.format, fitrahRate, table)
+	}
+
+	status, err := c.GetPendingFitrah(*organization, *year)
+	if err != nil {
+		return err
+	}
+	table := output.Table{Header: []string{"ZAKAT", "MUZAKKI", "RECEIVED", "REMAINING", "TRANSFER"}}
+	for _, d := range status.Donations {
+		table.Rows = append(table.Rows, []string{d.ZakatID, d.Muzakki, d.Timestamp, output.Quantity(d.Remaining, d.Unit), d.TransferID})
+	}
+	if err := output.Write(os.Stdout, *cmd.format, status, table); err != nil {
+		return err
+	}
+
+	left := "Rp " + output.Amount(status.Outstanding)
+	units := make([]string, 0, len(status.OutstandingInKind))
+	for unit := range status.OutstandingInKind {
+		units = append(units, unit)
+	}
+	sort.Strings(units)
+	for _, unit := range units {
+		left += ", " + output.Quantity(status.OutstandingInKind[unit], unit)
+	}
+	summary := fmt.Sprintf("%s: %s of fitrah %dH left to distribute, Eid prayer %s (%s policy)",
+		status.Organization, left, status.Eid.HijriYear, status.Eid.Prayer, status.Eid.Policy)
+	if prayer, err := time.Parse(time.RFC3339, status.Eid.Prayer); err == nil && len(status.Donations) > 0 {
+		if left := time.Until(prayer); left > 0 {
+			summary += fmt.Sprintf(", %s from now", left.Truncate(time.Minute))
+		} else {
+			summary += ", overdue"
+		}
+	}
+	fmt.Fprintln(os.Stderr, summary)
+	return nil
+}
+
+// runPrices lists the reference prices in-kind donations are valued at, or sets the
+// price of a unit from a day on
+func runPrices(args []string) error {
+	cmd := newCommand("prices")
+	unit := cmd.fs.String("set", "", "unit to set the price of, e.g. kg_beras (organization admins only)")
+	price := cmd.fs.String("price", "", "with -set, the price of one unit in IDR, e.g. 15000")
+	since := cmd.fs.String("since", time.Now().In(time.FixedZone("WIB", 7*60*60)).Format("2006-01-02"), "with -set, first day the price applies to, YYYY-MM-DD")
+	if err := cmd.parse(args); err != nil {
+		return err
+	}
+
+	c, err := cmd.connection.Connect()
+	if err != nil {
+		return err
+	}
+	defer c.Close()
+
+	if *unit != "" {
+		value, err := spreadsheet.ParseAmount(*price)
+		if err != nil {
+			return err
+		}
+		if err := c.SetReferencePrice(*unit, value, *since); err != nil {
+			return err
+		}
+	}
+
+	prices, err := c.GetReferencePrices()
+	if err != nil {
+		return err
+	}
+	table := output.Table{Header: []string{"UNIT", "PRICE", "SINCE"}}
+	for _, p := range prices {
+		table.Rows = append(table.Rows, []string{p.Unit, output.Amount(p.Price), p.Since})
+	}
+	return output.Write(os.Stdout, *cmd.format, prices, table)
+}
+
+// runRestricted lists the balances an organization holds for the purposes donors
+// restricted their donations to
+func runRestricted(args []string) error {
+	cmd := newCommand("restricted")
+	organization := cmd.fs.String("filter-org", "", "organization to list the balances of (default: the organization connected as)")
+	if err := cmd.parse(args); err != nil {
+		return err
+	}
+	if *organization == "" {
+		*organization = cmd.connection.Organization
+	}
+
+	c, err := cmd.connection.Connect()
+	if err != nil {
+		return err
+	}
+	defer c.Close()
+
+	balances, err := c.GetRestrictedBalances(*organization)
+	if err != nil {
+		return err
+	}
+	table := output.Table{Header: []string{"RESTRICTION", "POOL", "BALANCE", "DONATIONS"}}
+	for _, b := range balances {
+		table.Rows = append(table.Rows, []string{b.Restriction.String(), b.PoolID, output.Quantity(b.Balance, b.Unit), strconv.Itoa(b.Donations)})
+	}
+	return output.Write(os.Stdout, *cmd.format, balances, table)
+}
+
+// runPledges lists the due and overdue installments of an organization's pledges, or
+// the installments of one pledge, or creates a pledge
+func runPledges(args []string) error {
+	cmd := newCommand("pledges")
+	organization := cmd.fs.String("filter-org", "", "organization to list, defaults to the -org organization")
+	create := cmd.fs.String("create", "", "create a pledge with this ID, PLG-YDSF-{ORG}-YYYY-NNNN")
+	muzakki := cmd.fs.String("muzakki", "", "with -create, the donor's name")
+	zakatType := cmd.fs.String("type", "maal", "with -create, fitrah or maal")
+	amount := cmd.fs.String("amount", "", "with -create, the amount of each installment in IDR, e.g. 500000")
+	frequency := cmd.fs.String("frequency", client.FrequencyMonthly, "with -create, monthly, quarterly or yearly")
+	start := cmd.fs.String("start", "", "with -create, due date of the first installment, ISO 8601")
+	end := cmd.fs.String("end", "", "with -create, no installment falls due after this timestamp, ISO 8601")
+	if err := cmd.parse(args); err != nil {
+		return err
+	}
+	if *organization == "" {
+		*organization = cmd.connection.Organization
+	}
+
+	c, err := cmd.connection.Connect()
+	if err != nil {
+		return err
+	}
+	defer c.Close()
+
+	if *create != "" {
+		value, err := spreadsheet.ParseAmount(*amount)
+		if err != nil {
+			return err
+		}
+		input := client.PledgeInput{
+			ID:           *create,
+			Muzakki:      *muzakki,
+			Organization: cmd.connection.Organization,
+			Type:         *zakatType,
+			Amount:       value,
+			Frequency:    *frequency,
+			StartDate:    *start,
+			EndDate:      *end,
+		}
+		r, err := registry(c)
+		if err != nil {
+			return err
+		}
+		if err := r.Pledge(input); err != nil {
+			return err
+		}
+		if err := c.CreatePledge(input); err != nil {
+			return err
+		}
+		pledge, err := c.QueryPledge(input.ID)
+		if err != nil {
+			return err
+		}
+		table := output.Table{
+			Header: []string{"ID", "MUZAKKI", "AMOUNT", "FREQUENCY", "START", "END", "TOTAL", "PAID", "STATUS"},
+			Rows: [][]string{{pledge.ID, pledge.Muzakki, output.Amount(pledge.Amount), pledge.Frequency, pledge.StartDate, pledge.EndDate,
+				output.Amount(pledge.Total), output.Amount(pledge.Paid), pledge.Status}},
+		}
+		return output.Write(os.Stdout, *cmd.format, pledge, table)
+	}
+
+	if cmd.fs.NArg() == 1 {
+		installments, err := c.GetPledgeInstallments(cmd.fs.Arg(0))
+		if err != nil {
+			return err
+		}
+		table := output.Table{Header: []string{"NUMBER", "DUE", "AMOUNT", "PAID", "STATUS"}}
+		for _, i := range installments {
+			table.Rows = append(table.Rows, []string{strconv.Itoa(i.Number), i.DueDate, output.Amount(i.Amount), output.Amount(i.Paid), i.Status})
+		}
+		return output.Write(os.Stdout, *cmd.format, installments, table)
+	}
+
+	due, err := c.GetDueInstallments(*organization)
+	if err != nil {
+		return err
+	}
+	table := output.Table{Header: []string{"PLEDGE", "MUZAKKI", "NUMBER", "DUE", "AMOUNT", "PAID", "STATUS"}}
+	for _, d := range due {
+		i := d.Installment
+		table.Rows = append(table.Rows, []string{d.PledgeID, d.Muzakki, strconv.Itoa(i.Number), i.DueDate, output.Amount(i.Amount), output.Amount(i.Paid), i.Status})
+	}
+	return output.Write(os.Stdout, *cmd.format, due, table)
+}
+
+// runHaul lists the donors of an organization whose haul falls due within some days,
+// or prints a donor's registry entry, or sets the date their haul started
+func runHaul(args []string) error {
+	cmd := newCommand("haul")
+	organization := cmd.fs.String("filter-org", "", "organization to list the donors of (default: the organization connected as)")
+	days := cmd.fs.Int("days", 30, "list the donors whose haul falls due within this many days, or fell due unpaid")
+	muzakki := cmd.fs.String("muzakki", "", "print this donor's registry entry")
+	setStart := cmd.fs.String("set-start", "", "with -muzakki, set the date the donor's wealth reached the nisab, ISO 8601")
+	if err := cmd.parse(args); err != nil {
+		return err
+	}
+	if *organization == "" {
+		*organization = cmd.connection.Organization
+	}
+	if *setStart != "" && *muzakki == "" {
+		return fmt.Errorf("-set-start requires -muzakki")
+	}
+
+	c, err := cmd.connection.Connect()
+	if err != nil {
+		return err
+	}
+	defer c.Close()
+
+	if *muzakki != "" {
+		if *setStart != "" {
+			if err := validate.Timestamp(*setStart); err != nil {
+				return err
+			}
+			if err := c.SetHaulStart(cmd.connection.Organization, *muzakki, *setStart); err != nil {
+				return err
+			}
+			*organization = cmd.connection.Organization
+		}
+		donor, err := c.QueryDonor(*organization, *muzakki)
+		if err != nil {
+			return err
+		}
+		table := output.Table{
+			Header: []string{"MUZAKKI", "HAUL START", "LAST MAAL PAYMENT", "ZAKAT ID"},
+			Rows:   [][]string{{donor.Muzakki, donor.HaulStart, donor.LastMaalPayment, donor.LastMaalZakatID}},
+		}
+		return output.Write(os.Stdout, *cmd.format, donor, table)
+	}
+
+	due, err := c.GetHaulsDue(*organization, *days)
+	if err != nil {
+		return err
+	}
+	table := output.Table{Header: []string{"MUZAKKI", "DUE", "HIJRI", "DAYS LEFT", "HAUL START", "LAST MAAL PAYMENT"}}
+	for _, d := range due {
+		table.Rows = append(table.Rows, []string{d.Muzakki, d.DueDate, d.Hijri.String(), strconv.Itoa(d.DaysLeft), d.HaulStart, d.LastMaalPayment})
+	}
+	return output.Write(os.Stdout, *cmd.format, due, table)
+}
+
+// runVerify checks that a donor's secret is the one an anonymous donation was committed
+// to, and prints the donation if it is
+func runVerify(args []string) error {
+	cmd := newCommand("verify")
+	secret := cmd.fs.String("secret", "", "the donor's secret")
+	if err := cmd.parse(args); err != nil {
+		return err
+	}
+	id, err := cmd.id()
+	if err != nil {
+		return err
+	}
+
+	c, err := cmd.connection.Connect()
+	if err != nil {
+		return err
+	}
+	defer c.Close()
+
+	verified, err := c.VerifyDonor(id, *secret)
+	if err != nil {
+		return err
+	}
+	if !verified {
+		return fmt.Errorf("the secret does not prove donation %s", id)
+	}
+	zakat, err := c.QueryZakat(id)
+	if err != nil {
+		return err
+	}
+	return output.Write(os.Stdout, *cmd.format, zakat, output.ZakatTable([]client.Zakat{zakat}))
+}
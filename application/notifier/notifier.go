@@ -0,0 +1,194 @@
+// Package notifier tells donors and staff when donations are distributed, from the
+// chaincode's ZakatDistributed events. Messages are rendered from templates and
+// handed to a sender per channel (webhook, email, WhatsApp gateway).
+package notifier
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"text/template"
+
+	"github.com/izzuddinafif/fabric-zakat/application/client"
+	"github.com/izzuddinafif/fabric-zakat/application/output"
+)
+
+// EventZakatDistributed is the chaincode event emitted when a distribution uses up
+// the rest of one or more donations
+const EventZakatDistributed = "ZakatDistributed"
+
+// Event is the payload of the ZakatDistributed event
+type Event struct {
+	DistributionID string           `json:"distributionId"` // The first distribution of a batch
+	Organization   string           `json:"organization"`
+	ProgramID      string           `json:"programId"`
+	Asnaf          string           `json:"asnaf"`
+	Timestamp      string           `json:"timestamp"`
+	Zakats         []CompletedZakat `json:"zakats"`
+}
+
+// CompletedZakat is a donation that moved to distributed, with the distribution that
+// completed it. Events emitted before donations carried it leave both empty, for the
+// event's own.
+type CompletedZakat struct {
+	client.Zakat
+	DistributionID string `json:"distributionId,omitempty"`
+	Asnaf          string `json:"asnaf,omitempty"`
+}
+
+// Contact is where a person is notified
+type Contact struct {
+	Channel string `json:"channel"` // "webhook", "email" or "whatsapp"
+	Address string `json:"address"` // URL, email address or phone number
+}
+
+// Directory holds the contacts to notify, kept off the ledger
+type Directory struct {
+	Donors map[string][]Contact `json:"donors"` // By muzakki name, as recorded on the ledger
+	Staff  map[string][]Contact `json:"staff"`  // By organization
+}
+
+// LoadDirectory reads a directory from a JSON file
+func LoadDirectory(path string) (Directory, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return Directory{}, err
+	}
+	var directory Directory
+	if err := json.Unmarshal(data, &directory); err != nil {
+		return Directory{}, fmt.Errorf("failed to parse %s: %w", path, err)
+	}
+	return directory, nil
+}
+
+// defaultTemplates are the messages sent unless a templates file overrides them. The
+// donor templates are executed with a DonorData, the staff templates with an Event.
+const defaultTemplates = `
+{{define "donor.subject"}}Your zakat {{.Zakat.ID}} has been distributed{{end}}
+{{define "donor.body"}}Assalamu'alaikum {{.Zakat.Muzakki}},
+
+Your {{.Zakat.Type}} zakat of {{quantity .Zakat.Amount .Zakat.Unit}} ({{.Zakat.ID}}) paid to {{.Zakat.Organization}} on {{.Zakat.Timestamp}} has been fully distributed to the mustahik{{with .Asnaf}} ({{.}}){{end}}, the last part by {{.DistributionID}} on {{.Event.Timestamp}}.{{with .Zakat.Conversion}} It was given as {{converted .}}.{{end}}
+
+Jazakumullahu khairan.{{end}}
+{{define "staff.subject"}}{{.DistributionID}} completed {{len .Zakats}} donation(s){{end}}
+{{define "staff.body"}}Distribution {{.DistributionID}} of {{.Organization}}{{with .ProgramID}} under program {{.}}{{end}} on {{.Timestamp}} completed:
+{{range .Zakats}}- {{.ID}}, {{.Muzakki}}, {{quantity .Amount .Unit}}{{with .DistributionID}} by {{.}}{{end}}
+{{end}}{{end}}
+`
+
+// templateFuncs are the functions available to templates: idr formats an IDR amount,
+// quantity an amount in its unit, with the "Rp" prefix for rupiah, and converted the
+// foreign currency a donation was given in with the rate it was converted at
+var templateFuncs = template.FuncMap{
+	"idr": output.Amount,
+	"quantity": func(amount float64, unit string) string {
+		if client.IsIDR(unit) {
+			return "Rp " + output.Amount(amount)
+		}
+		return output.Quantity(amount, unit)
+	},
+	"converted": func(c client.Conversion) string {
+		return fmt.Sprintf("%s %s, converted at Rp %s per %s (%s)", c.Currency, output.Amount(c.Amount), output.Amount(c.Rate), c.Currency, c.Source)
+	},
+}
+
+// Templates returns the default templates, overridden by the definitions in the
+// file at path if it is not empty
+func Templates(path string) (*template.Template, error) {
+	templates := template.Must(template.New("notifier").Funcs(templateFuncs).Parse(defaultTemplates))
+	if path == "" {
+		return templates, nil
+	}
+	return templates.ParseFiles(path)
+}
+
+// DonorData is what the donor templates are executed with
+type DonorData struct {
+	Zakat          client.Zakat // The donation that was distributed
+	Event          Event        // The event of the transaction that completed it
+	DistributionID string       // The distribution that completed it
+	Asnaf          string       // Asnaf of that distribution's mustahik, if known
+}
+
+// Message is a rendered notification
+type Message struct {
+	Channel string `json:"channel"`
+	To      string `json:"to"`
+	Subject string `json:"subject"`
+	Body    string `json:"body"`
+}
+
+// Sender delivers messages of one channel
+type Sender interface {
+	Send(ctx context.Context, message Message) error
+}
+
+// Notifier renders and sends the notifications of ZakatDistributed events
+type Notifier struct {
+	directory Directory
+	senders   map[string]Sender
+	templates *template.Template
+}
+
+// New returns a notifier, checking that every contact's channel has a sender
+func New(directory Directory, senders map[string]Sender, templates *template.Template) (*Notifier, error) {
+	for _, contacts := range []map[string][]Contact{directory.Donors, directory.Staff} {
+		for name, list := range contacts {
+			for _, contact := range list {
+				if _, ok := senders[contact.Channel]; !ok {
+					return nil, fmt.Errorf("contact %s of %s: no sender for channel %q", contact.Address, name, contact.Channel)
+				}
+			}
+		}
+	}
+	return &Notifier{directory: directory, senders: senders, templates: templates}, nil
+}
+
+// Notify tells the donor of every completed donation, and the distributing
+// organization's staff, about a distribution. Anonymous donors are not looked up by the
+// name they are recorded under. Every contact is tried; the errors of those that
+// failed are returned together.
+func (n *Notifier) Notify(ctx context.Context, event Event) error {
+	var errs []error
+	for _, zakat := range event.Zakats {
+		if zakat.DonorCommitment != "" {
+			continue
+		}
+		data := DonorData{Zakat: zakat.Zakat, Event: event, DistributionID: zakat.DistributionID, Asnaf: zakat.Asnaf}
+		if data.DistributionID == "" {
+			data.DistributionID, data.Asnaf = event.DistributionID, event.Asnaf
+		}
+		for _, contact := range n.directory.Donors[zakat.Muzakki] {
+			errs = append(errs, n.send(ctx, contact, "donor", data))
+		}
+	}
+	for _, contact := range n.directory.Staff[event.Organization] {
+		errs = append(errs, n.send(ctx, contact, "staff", event))
+	}
+	return errors.Join(errs...)
+}
+
+// send renders the subject and body templates of a kind of message and sends it
+func (n *Notifier) send(ctx context.Context, contact Contact, kind string, data interface{}) error {
+	var subject, body bytes.Buffer
+	if err := n.templates.ExecuteTemplate(&subject, kind+".subject", data); err != nil {
+		return err
+	}
+	if err := n.templates.ExecuteTemplate(&body, kind+".body", data); err != nil {
+		return err
+	}
+	message := Message{
+		Channel: contact.Channel,
+		To:      contact.Address,
+		Subject: strings.TrimSpace(subject.String()),
+		Body:    strings.TrimSpace(body.String()),
+	}
+	if err := n.senders[contact.Channel].Send(ctx, message); err != nil {
+		return fmt.Errorf("failed to notify %s by %s: %w", contact.Address, contact.Channel, err)
+	}
+	return nil
+}
@@ -0,0 +1,153 @@
+package notifier
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"testing"
+
+	gateway "github.com/hyperledger/fabric-gateway/pkg/client"
+	"github.com/izzuddinafif/fabric-zakat/application/client"
+	"github.com/stretchr/testify/require"
+)
+
+// recorder is a sender that keeps the messages it is given
+type recorder struct {
+	messages []Message
+	err      error
+}
+
+func (r *recorder) Send(ctx context.Context, message Message) error {
+	r.messages = append(r.messages, message)
+	return r.err
+}
+
+var event = Event{
+	DistributionID: "DST-YDSF-MLG-202404-0001",
+	Organization:   "YDSF Malang",
+	Asnaf:          "fakir",
+	Timestamp:      "2024-04-01T08:00:00Z",
+	Zakats: []CompletedZakat{{Zakat: client.Zakat{
+		ID: "ZKT-YDSF-MLG-202403-0001", Muzakki: "Ahmad", Amount: 45000, Type: "fitrah", Status: "distributed",
+		Organization: "YDSF Malang", Timestamp: "2024-03-30T08:00:00Z",
+	}}, {Zakat: client.Zakat{
+		ID: "ZKT-YDSF-MLG-202403-0002", Muzakki: "Siti", Amount: 2500000, Type: "maal", Status: "distributed",
+		Organization: "YDSF Malang", Timestamp: "2024-03-30T09:00:00Z",
+	}}},
+}
+
+var directory = Directory{
+	Donors: map[string][]Contact{
+		"Ahmad": {{Channel: ChannelWhatsApp, Address: "6281234567890"}},
+	},
+	Staff: map[string][]Contact{
+		"YDSF Malang": {{Channel: ChannelEmail, Address: "amil@ydsfmalang.example"}},
+	},
+}
+
+func TestNotify(t *testing.T) {
+	templates, err := Templates("")
+	require.NoError(t, err)
+
+	whatsapp, email := &recorder{}, &recorder{}
+	_, err = New(directory, map[string]Sender{ChannelWhatsApp: whatsapp}, templates)
+	require.ErrorContains(t, err, `no sender for channel "email"`)
+
+	notifier, err := New(directory, map[string]Sender{ChannelWhatsApp: whatsapp, ChannelEmail: email}, templates)
+	require.NoError(t, err)
+	require.NoError(t, notifier.Notify(context.Background(), event))
+
+	// Siti has no contact; Ahmad and the staff are notified once each
+	require.Len(t, whatsapp.messages, 1)
+	require.Equal(t, "6281234567890", whatsapp.messages[0].To)
+	require.Equal(t, "Your zakat ZKT-YDSF-MLG-202403-0001 has been distributed", whatsapp.messages[0].Subject)
+	require.Contains(t, whatsapp.messages[0].Body, "Rp 45,000.00")
+	require.Contains(t, whatsapp.messages[0].Body, "(fakir), the last part by DST-YDSF-MLG-202404-0001")
+
+	require.Len(t, email.messages, 1)
+	require.Equal(t, "DST-YDSF-MLG-202404-0001 completed 2 donation(s)", email.messages[0].Subject)
+	require.Contains(t, email.messages[0].Body, "- ZKT-YDSF-MLG-202403-0002, Siti, Rp 2,500,000.00")
+
+	// A failing channel does not stop the others
+	whatsapp.err = errors.New("gateway down")
+	err = notifier.Notify(context.Background(), event)
+	require.ErrorContains(t, err, "gateway down")
+	require.Len(t, email.messages, 2)
+
+	// The receipt of a donation given in a foreign currency names the currency and rate
+	whatsapp.err = nil
+	foreign := event
+	foreign.Zakats = []CompletedZakat{event.Zakats[0]}
+	foreign.Zakats[0].Conversion = &client.Conversion{Currency: "MYR", Amount: 15, Rate: 3000, Source: "BSI kurs beli"}
+	require.NoError(t, notifier.Notify(context.Background(), foreign))
+	require.Contains(t, whatsapp.messages[2].Body, "Rp 45,000.00")
+	require.Contains(t, whatsapp.messages[2].Body, "It was given as MYR 15.00, converted at Rp 3,000.00 per MYR (BSI kurs beli).")
+
+	// Only the staff hear of an anonymous donation
+	anonymous := event
+	anonymous.Zakats = []CompletedZakat{event.Zakats[0]}
+	anonymous.Zakats[0].DonorCommitment = "9f2c1e0d4b7a6f5e3d2c1b0a9f8e7d6c5b4a3f2e1d0c9b8a7f6e5d4c3b2a1f0e"
+	require.NoError(t, notifier.Notify(context.Background(), anonymous))
+	require.Len(t, whatsapp.messages, 3)
+	require.Len(t, email.messages, 4)
+
+	// In a batch each donation names the distribution that completed it
+	batch := event
+	batch.Zakats = []CompletedZakat{event.Zakats[1], event.Zakats[0]}
+	batch.Zakats[0].DistributionID, batch.Zakats[0].Asnaf = "DST-YDSF-MLG-202404-0001", "fakir"
+	batch.Zakats[1].DistributionID, batch.Zakats[1].Asnaf = "DST-YDSF-MLG-202404-0002", "miskin"
+	require.NoError(t, notifier.Notify(context.Background(), batch))
+	require.Contains(t, whatsapp.messages[3].Body, "(miskin), the last part by DST-YDSF-MLG-202404-0002")
+	require.Contains(t, email.messages[4].Body, "- ZKT-YDSF-MLG-202403-0001, Ahmad, Rp 45,000.00 by DST-YDSF-MLG-202404-0002")
+}
+
+func TestRun(t *testing.T) {
+	templates, err := Templates("")
+	require.NoError(t, err)
+	whatsapp, email := &recorder{}, &recorder{}
+	notifier, err := New(directory, map[string]Sender{ChannelWhatsApp: whatsapp, ChannelEmail: email}, templates)
+	require.NoError(t, err)
+
+	payload, err := json.Marshal(event)
+	require.NoError(t, err)
+	stream := make(chan *gateway.ChaincodeEvent, 2)
+	stream <- &gateway.ChaincodeEvent{BlockNumber: 7, TransactionID: "tx1", EventName: "Other"}
+	stream <- &gateway.ChaincodeEvent{BlockNumber: 8, TransactionID: "tx2", EventName: EventZakatDistributed, Payload: payload}
+	close(stream)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	checkpointer, err := gateway.NewFileCheckpointer(filepath.Join(t.TempDir(), "checkpoint.json"))
+	require.NoError(t, err)
+	defer checkpointer.Close()
+	err = Run(ctx, func(ctx context.Context, checkpoint gateway.Checkpoint) (<-chan *gateway.ChaincodeEvent, error) {
+		return stream, nil
+	}, checkpointer, notifier)
+	require.NoError(t, err)
+
+	require.Len(t, whatsapp.messages, 1)
+	require.Equal(t, uint64(8), checkpointer.BlockNumber())
+	require.Equal(t, "tx2", checkpointer.TransactionID())
+}
+
+func TestWebhookSender(t *testing.T) {
+	var received Message
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&received))
+		if r.URL.Path == "/fail" {
+			w.WriteHeader(http.StatusServiceUnavailable)
+		}
+	}))
+	defer server.Close()
+
+	sender := WebhookSender{Client: server.Client()}
+	message := Message{Channel: ChannelWebhook, To: server.URL + "/hook", Subject: "s", Body: "b"}
+	require.NoError(t, sender.Send(context.Background(), message))
+	require.Equal(t, message, received)
+
+	err := sender.Send(context.Background(), Message{Channel: ChannelWebhook, To: server.URL + "/fail"})
+	require.ErrorContains(t, err, "503")
+}
@@ -0,0 +1,56 @@
+package notifier
+
+import (
+	"context"
+	"encoding/json"
+	"log"
+	"time"
+
+	"github.com/hyperledger/fabric-gateway/pkg/client"
+)
+
+// retryDelay is the wait before reconnecting after the event stream fails
+const retryDelay = 5 * time.Second
+
+// Checkpointer records the last event handled, so that events are read from there
+// after a restart; the gateway's file checkpointer implements it
+type Checkpointer interface {
+	client.Checkpoint
+	CheckpointChaincodeEvent(event *client.ChaincodeEvent) error
+}
+
+// EventSource streams the chaincode's events after a checkpoint
+type EventSource func(ctx context.Context, checkpoint client.Checkpoint) (<-chan *client.ChaincodeEvent, error)
+
+// Run sends the notifications of the chaincode's ZakatDistributed events until ctx
+// is done, checkpointing each event once it is handled. Failed notifications are
+// logged and not retried, so one unreachable contact does not hold up the others.
+func Run(ctx context.Context, events EventSource, checkpointer Checkpointer, notifier *Notifier) error {
+	for {
+		stream, err := events(ctx, checkpointer)
+		if err != nil {
+			log.Printf("failed to read chaincode events: %v", err)
+		} else {
+			for event := range stream {
+				if event.EventName == EventZakatDistributed {
+					var payload Event
+					if err := json.Unmarshal(event.Payload, &payload); err != nil {
+						log.Printf("transaction %s: invalid %s event: %v", event.TransactionID, event.EventName, err)
+					} else if err := notifier.Notify(ctx, payload); err != nil {
+						log.Printf("transaction %s: %v", event.TransactionID, err)
+					}
+				}
+				if err := checkpointer.CheckpointChaincodeEvent(event); err != nil {
+					return err
+				}
+			}
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-time.After(retryDelay):
+			log.Print("chaincode event stream closed, reconnecting")
+		}
+	}
+}
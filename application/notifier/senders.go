@@ -0,0 +1,106 @@
+package notifier
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/smtp"
+	"strings"
+	"sync"
+)
+
+// Channels of the built-in senders
+const (
+	ChannelWebhook  = "webhook"
+	ChannelEmail    = "email"
+	ChannelWhatsApp = "whatsapp"
+)
+
+// WebhookSender posts each message as JSON to the contact's URL
+type WebhookSender struct {
+	Client *http.Client
+}
+
+// Send posts the message to its recipient URL
+func (s WebhookSender) Send(ctx context.Context, message Message) error {
+	return postJSON(ctx, s.Client, message.To, "", message)
+}
+
+// WhatsAppSender sends the message body through an HTTP WhatsApp gateway, posting
+// {"phone": ..., "message": ...} to its URL with the token as bearer credentials
+type WhatsAppSender struct {
+	URL    string
+	Token  string
+	Client *http.Client
+}
+
+// Send posts the message body to the gateway
+func (s WhatsAppSender) Send(ctx context.Context, message Message) error {
+	payload := map[string]string{"phone": message.To, "message": message.Body}
+	return postJSON(ctx, s.Client, s.URL, s.Token, payload)
+}
+
+// postJSON posts v as JSON and fails unless the response status is 2xx
+func postJSON(ctx context.Context, client *http.Client, url string, token string, v interface{}) error {
+	body, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+	if client == nil {
+		client = http.DefaultClient
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	io.Copy(io.Discard, resp.Body)
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return fmt.Errorf("%s responded %s", url, resp.Status)
+	}
+	return nil
+}
+
+// EmailSender sends messages as plain text email through an SMTP server
+type EmailSender struct {
+	Addr string    // SMTP server, host:port
+	From string    // Sender address
+	Auth smtp.Auth // Optional credentials
+}
+
+// Send mails the message
+func (s EmailSender) Send(ctx context.Context, message Message) error {
+	var mail strings.Builder
+	fmt.Fprintf(&mail, "From: %s\r\n", s.From)
+	fmt.Fprintf(&mail, "To: %s\r\n", message.To)
+	fmt.Fprintf(&mail, "Subject: %s\r\n", message.Subject)
+	mail.WriteString("MIME-Version: 1.0\r\nContent-Type: text/plain; charset=UTF-8\r\n\r\n")
+	mail.WriteString(strings.ReplaceAll(message.Body, "\n", "\r\n"))
+	return smtp.SendMail(s.Addr, s.Auth, s.From, []string{message.To}, []byte(mail.String()))
+}
+
+// LogSender writes messages to a writer instead of delivering them, as a local
+// stand-in for the real channels
+type LogSender struct {
+	mu sync.Mutex
+	W  io.Writer
+}
+
+// Send writes the message
+func (s *LogSender) Send(ctx context.Context, message Message) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	_, err := fmt.Fprintf(s.W, "--- %s to %s\nSubject: %s\n\n%s\n\n", message.Channel, message.To, message.Subject, message.Body)
+	return err
+}
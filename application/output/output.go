@@ -0,0 +1,147 @@
+// Package output renders ledger records for the terminal, as aligned tables or
+// indented JSON.
+package output
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/izzuddinafif/fabric-zakat/application/client"
+)
+
+// Output formats
+const (
+	FormatTable = "table"
+	FormatJSON  = "json"
+)
+
+// Table is a header and rows of cells
+type Table struct {
+	Header []string
+	Rows   [][]string
+}
+
+// CheckFormat rejects an unknown output format
+func CheckFormat(format string) error {
+	if format != FormatTable && format != FormatJSON {
+		return fmt.Errorf("unknown output format %q, expected table or json", format)
+	}
+	return nil
+}
+
+// Write writes v as indented JSON, or table as aligned columns
+func Write(w io.Writer, format string, v interface{}, table Table) error {
+	if format == FormatJSON {
+		encoder := json.NewEncoder(w)
+		encoder.SetIndent("", "  ")
+		return encoder.Encode(v)
+	}
+	return table.Write(w)
+}
+
+// Write writes the table with its columns aligned
+func (t Table) Write(w io.Writer) error {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, strings.Join(t.Header, "\t"))
+	for _, row := range t.Rows {
+		fmt.Fprintln(tw, strings.Join(row, "\t"))
+	}
+	return tw.Flush()
+}
+
+// ZakatTable lists donations, one per row
+func ZakatTable(zakats []client.Zakat) Table {
+	table := Table{Header: []string{"ID", "MUZAKKI", "AMOUNT", "TYPE", "STATUS", "ORGANIZATION", "TIMESTAMP", "HIJRI", "DISTRIBUTED"}}
+	for _, zakat := range zakats {
+		table.Rows = append(table.Rows, []string{
+			zakat.ID, zakat.Muzakki, zakatAmount(zakat), zakat.Type, zakat.Status,
+			zakat.Organization, zakat.Timestamp, hijri(zakat.Hijri), Quantity(zakat.Distribution, zakat.Unit),
+		})
+	}
+	return table
+}
+
+// DistributionTable lists distributions, one per row
+func DistributionTable(distributions []client.Distribution) Table {
+	table := Table{Header: []string{"ID", "POOL", "PROGRAM", "ASNAF", "MUSTAHIK", "AMOUNT", "TIMESTAMP", "SOURCES"}}
+	for _, d := range distributions {
+		var sources []string
+		for _, source := range d.Sources {
+			sources = append(sources, source.ZakatID)
+		}
+		table.Rows = append(table.Rows, []string{
+			d.ID, d.PoolID, orDash(d.ProgramID), orDash(d.Asnaf), d.Mustahik,
+			Quantity(d.Amount, d.Unit), d.Timestamp, orDash(strings.Join(sources, ",")),
+		})
+	}
+	return table
+}
+
+// HistoryTable lists the versions of a donation, oldest first
+func HistoryTable(history []client.ZakatHistory) Table {
+	table := Table{Header: []string{"COMMITTED", "TX", "STATUS", "DISTRIBUTED", "DISTRIBUTIONS"}}
+	for _, version := range history {
+		table.Rows = append(table.Rows, []string{
+			version.Timestamp, version.TxID, version.Zakat.Status, Amount(version.Zakat.Distribution),
+			orDash(strings.Join(version.Zakat.Distributions, ",")),
+		})
+	}
+	return table
+}
+
+// Amount formats an IDR amount with thousands separators, e.g. 1,250,000.00
+func Amount(amount float64) string {
+	s := strconv.FormatFloat(amount, 'f', 2, 64)
+	sign := ""
+	if strings.HasPrefix(s, "-") {
+		sign, s = "-", s[1:]
+	}
+	whole, fraction := s[:len(s)-3], s[len(s)-3:]
+	var b strings.Builder
+	for i, digit := range whole {
+		if i > 0 && (len(whole)-i)%3 == 0 {
+			b.WriteByte(',')
+		}
+		b.WriteRune(digit)
+	}
+	return sign + b.String() + fraction
+}
+
+// Quantity formats an amount in its unit: IDR amounts as Amount does and in-kind
+// quantities with the unit, e.g. 2.5 kg_beras
+func Quantity(amount float64, unit string) string {
+	if client.IsIDR(unit) {
+		return Amount(amount)
+	}
+	return strconv.FormatFloat(amount, 'f', -1, 64) + " " + unit
+}
+
+// zakatAmount formats the amount of a donation, followed by the amount it was given in
+// if it was given in a foreign currency
+func zakatAmount(zakat client.Zakat) string {
+	amount := Quantity(zakat.Amount, zakat.Unit)
+	if zakat.Conversion != nil {
+		amount += " (" + zakat.Conversion.Currency + " " + Amount(zakat.Conversion.Amount) + ")"
+	}
+	return amount
+}
+
+// hijri formats a Hijri date, or a dash for records made before Hijri dating
+func hijri(date *client.HijriDate) string {
+	if date == nil {
+		return "-"
+	}
+	return date.String()
+}
+
+// orDash shows an empty cell as "-"
+func orDash(s string) string {
+	if s == "" {
+		return "-"
+	}
+	return s
+}
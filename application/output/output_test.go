@@ -0,0 +1,69 @@
+package output
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/izzuddinafif/fabric-zakat/application/client"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAmount(t *testing.T) {
+	require.Equal(t, "0.00", Amount(0))
+	require.Equal(t, "45,000.00", Amount(45000))
+	require.Equal(t, "1,250,000.50", Amount(1250000.5))
+	require.Equal(t, "-125,000.00", Amount(-125000))
+}
+
+func TestQuantity(t *testing.T) {
+	require.Equal(t, "45,000.00", Quantity(45000, ""))
+	require.Equal(t, "45,000.00", Quantity(45000, "IDR"))
+	require.Equal(t, "2.5 kg_beras", Quantity(2.5, "kg_beras"))
+}
+
+func TestWriteTable(t *testing.T) {
+	zakats := []client.Zakat{{
+		ID:           "ZKT-YDSF-MLG-202403-0001",
+		Muzakki:      "Ahmad",
+		Amount:       45000,
+		Type:         "fitrah",
+		Status:       "collected",
+		Organization: "YDSF Malang",
+		Timestamp:    "2024-03-30T08:00:00Z",
+		Hijri:        &client.HijriDate{Year: 1445, Month: 9, Day: 20},
+	}, {
+		ID:           "ZKT-YDSF-MLG-202403-0002",
+		Muzakki:      "Budi",
+		Amount:       335055,
+		Type:         "maal",
+		Status:       "collected",
+		Organization: "YDSF Malang",
+		Timestamp:    "2024-03-30T09:00:00Z",
+		Conversion:   &client.Conversion{Currency: "MYR", Amount: 100, Rate: 3350.55, Source: "BSI kurs beli"},
+	}}
+
+	var buf bytes.Buffer
+	require.NoError(t, Write(&buf, FormatTable, zakats, ZakatTable(zakats)))
+	require.Equal(t, ""+
+		"ID                        MUZAKKI  AMOUNT                   TYPE    STATUS     ORGANIZATION  TIMESTAMP             HIJRI              DISTRIBUTED\n"+
+		"ZKT-YDSF-MLG-202403-0001  Ahmad    45,000.00                fitrah  collected  YDSF Malang   2024-03-30T08:00:00Z  20 Ramadan 1445 H  0.00\n"+
+		"ZKT-YDSF-MLG-202403-0002  Budi     335,055.00 (MYR 100.00)  maal    collected  YDSF Malang   2024-03-30T09:00:00Z  -                  0.00\n",
+		buf.String())
+
+	buf.Reset()
+	require.NoError(t, Write(&buf, FormatJSON, zakats, ZakatTable(zakats)))
+	require.Contains(t, buf.String(), `"muzakki": "Ahmad"`)
+
+	require.Error(t, CheckFormat("yaml"))
+}
+
+func TestDistributionTable(t *testing.T) {
+	table := DistributionTable([]client.Distribution{{
+		ID:       "DST-YDSF-MLG-202404-0001",
+		PoolID:   "POOL-YDSF-MLG-FITRAH",
+		Mustahik: "Budi",
+		Amount:   90000,
+		Sources:  []client.Allocation{{ZakatID: "ZKT-YDSF-MLG-202403-0001"}, {ZakatID: "ZKT-YDSF-MLG-202403-0002"}},
+	}})
+	require.Equal(t, []string{"DST-YDSF-MLG-202404-0001", "POOL-YDSF-MLG-FITRAH", "-", "-", "Budi", "90,000.00", "", "ZKT-YDSF-MLG-202403-0001,ZKT-YDSF-MLG-202403-0002"}, table.Rows[0])
+}
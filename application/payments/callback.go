@@ -0,0 +1,59 @@
+// Package payments records donations paid online. A payment gateway posts a signed
+// callback when a payment settles; the callback is verified and recorded with
+// AddZakatWithPayment, keyed on the gateway's payment ID so that the gateway's
+// retries never record a donation twice.
+package payments
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+)
+
+// SignatureHeader carries the hex HMAC-SHA256 of the callback body, keyed with the
+// secret shared with the gateway
+const SignatureHeader = "X-Callback-Signature"
+
+// Payment statuses reported by the gateway. Only paid payments are recorded.
+const (
+	StatusPaid    = "paid"
+	StatusPending = "pending"
+	StatusExpired = "expired"
+	StatusFailed  = "failed"
+)
+
+// Callback is the notification the gateway posts when a payment changes status
+type Callback struct {
+	PaymentID string  `json:"paymentId"` // Gateway's payment ID, unique per payment
+	Status    string  `json:"status"`    // "paid", "pending", "expired" or "failed"
+	Amount    float64 `json:"amount"`    // Gross amount paid by the donor
+	Currency  string  `json:"currency"`  // "IDR"
+	Method    string  `json:"method"`    // How the donor paid, e.g. "qris" or "va_bsi"
+	PaidAt    string  `json:"paidAt"`    // Settlement time, ISO 8601
+	Order     Order   `json:"order"`     // Details the donation form attached at checkout
+}
+
+// Order is what the donor chose on the donation form
+type Order struct {
+	Muzakki      string `json:"muzakki"`      // Donor's name, empty to give anonymously
+	Type         string `json:"type"`         // "fitrah" or "maal"
+	Organization string `json:"organization"` // Organization the donation is for
+}
+
+// Sign returns the signature of a callback body
+func Sign(secret []byte, body []byte) string {
+	mac := hmac.New(sha256.New, secret)
+	mac.Write(body)
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// Verify reports whether signature is the signature of body, in constant time
+func Verify(secret []byte, body []byte, signature string) bool {
+	expected, err := hex.DecodeString(signature)
+	if err != nil {
+		return false
+	}
+	mac := hmac.New(sha256.New, secret)
+	mac.Write(body)
+	return hmac.Equal(mac.Sum(nil), expected)
+}
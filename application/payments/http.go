@@ -0,0 +1,66 @@
+package payments
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"log"
+	"net/http"
+)
+
+// maxBodySize is the largest callback body read
+const maxBodySize = 64 << 10
+
+// Handler serves POST /callback for the gateway. A callback whose signature does
+// not verify is rejected with 401 and one that can never be recorded with 422.
+// Ledger failures return 503, so that the gateway retries the callback later.
+func Handler(recorder *Recorder, secret []byte) http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("POST /callback", func(w http.ResponseWriter, r *http.Request) {
+		body, err := io.ReadAll(http.MaxBytesReader(w, r.Body, maxBodySize))
+		if err != nil {
+			writeError(w, http.StatusBadRequest, err)
+			return
+		}
+		if !Verify(secret, body, r.Header.Get(SignatureHeader)) {
+			writeError(w, http.StatusUnauthorized, errors.New("invalid signature"))
+			return
+		}
+		var callback Callback
+		if err := json.Unmarshal(body, &callback); err != nil {
+			writeError(w, http.StatusBadRequest, err)
+			return
+		}
+
+		result, err := recorder.Record(callback)
+		var invalid *InvalidError
+		switch {
+		case errors.As(err, &invalid):
+			log.Print(err)
+			writeError(w, http.StatusUnprocessableEntity, err)
+		case err != nil:
+			log.Printf("payment %s: %v", callback.PaymentID, err)
+			writeError(w, http.StatusServiceUnavailable, errors.New(http.StatusText(http.StatusServiceUnavailable)))
+		default:
+			if result.Status == ResultRecorded {
+				log.Printf("payment %s recorded as %s", callback.PaymentID, result.ZakatID)
+			}
+			writeJSON(w, http.StatusOK, result)
+		}
+	})
+	return mux
+}
+
+// writeJSON writes v as the JSON response body
+func writeJSON(w http.ResponseWriter, status int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(v)
+}
+
+// writeError writes an error response
+func writeError(w http.ResponseWriter, status int, err error) {
+	writeJSON(w, status, struct {
+		Error string `json:"error"`
+	}{err.Error()})
+}
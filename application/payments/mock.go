@@ -0,0 +1,73 @@
+package payments
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+)
+
+// MockGateway posts signed callbacks the way a payment gateway does, retrying
+// until the receiver answers 2xx or 4xx. It stands in for the gateway in tests
+// and local demos.
+type MockGateway struct {
+	URL        string        // Callback URL, e.g. http://localhost:8090/callback
+	Secret     []byte        // Secret shared with the receiver
+	Retries    int           // Retries after a 5xx or a connection failure
+	RetryDelay time.Duration // Delay between attempts
+	Client     *http.Client  // Defaults to http.DefaultClient
+}
+
+// Send posts a callback and returns the last response's status and body
+func (m *MockGateway) Send(ctx context.Context, callback Callback) (int, []byte, error) {
+	body, err := json.Marshal(callback)
+	if err != nil {
+		return 0, nil, err
+	}
+	httpClient := m.Client
+	if httpClient == nil {
+		httpClient = http.DefaultClient
+	}
+
+	for attempt := 0; ; attempt++ {
+		status, response, err := m.post(ctx, httpClient, body)
+		if err == nil && status < http.StatusInternalServerError {
+			return status, response, nil
+		}
+		if attempt >= m.Retries {
+			if err != nil {
+				return 0, nil, err
+			}
+			return status, response, nil
+		}
+		select {
+		case <-ctx.Done():
+			return 0, nil, ctx.Err()
+		case <-time.After(m.RetryDelay):
+		}
+	}
+}
+
+// post makes one attempt at delivering a signed body
+func (m *MockGateway) post(ctx context.Context, httpClient *http.Client, body []byte) (int, []byte, error) {
+	request, err := http.NewRequestWithContext(ctx, http.MethodPost, m.URL, bytes.NewReader(body))
+	if err != nil {
+		return 0, nil, err
+	}
+	request.Header.Set("Content-Type", "application/json")
+	request.Header.Set(SignatureHeader, Sign(m.Secret, body))
+
+	response, err := httpClient.Do(request)
+	if err != nil {
+		return 0, nil, fmt.Errorf("failed to post callback: %w", err)
+	}
+	defer response.Body.Close()
+	data, err := io.ReadAll(response.Body)
+	if err != nil {
+		return 0, nil, err
+	}
+	return response.StatusCode, data, nil
+}
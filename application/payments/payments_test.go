@@ -0,0 +1,230 @@
+package payments
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+
+	zakat "github.com/izzuddinafif/fabric-zakat/application/client"
+	"github.com/izzuddinafif/fabric-zakat/application/validate"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+var secret = []byte("s3cret")
+
+// fakeLedger keeps donations in memory and rejects repeated IDs and payments like
+// the chaincode does
+type fakeLedger struct {
+	mu     sync.Mutex
+	zakats map[string]zakat.Zakat
+	adds   int
+	err    error
+}
+
+func newFakeLedger(zakats ...zakat.Zakat) *fakeLedger {
+	l := &fakeLedger{zakats: map[string]zakat.Zakat{}}
+	for _, z := range zakats {
+		l.zakats[z.ID] = z
+	}
+	return l
+}
+
+func chaincodeError(transaction string, message string) error {
+	return zakat.NewError(transaction, status.Error(codes.Unknown, "evaluate call to endorser returned error: chaincode response 500, "+message))
+}
+
+func (l *fakeLedger) AddZakat(input zakat.ZakatInput) error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.adds++
+	if l.err != nil {
+		return l.err
+	}
+	if _, ok := l.zakats[input.ID]; ok {
+		return chaincodeError("AddZakatWithPayment", fmt.Sprintf("the zakat %s already exists", input.ID))
+	}
+	for _, z := range l.zakats {
+		if z.Payment != nil && *z.Payment == *input.Payment {
+			return chaincodeError("AddZakatWithPayment", fmt.Sprintf("the payment is already recorded for zakat %s", z.ID))
+		}
+	}
+	l.zakats[input.ID] = zakat.Zakat{ID: input.ID, Muzakki: input.Muzakki, Amount: input.Amount, Type: input.Type,
+		Status: "collected", Organization: input.Organization, Timestamp: input.Timestamp, Payment: input.Payment}
+	return nil
+}
+
+func (l *fakeLedger) GetZakatByPayment(payment zakat.Payment) (zakat.Zakat, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	for _, z := range l.zakats {
+		if z.Payment != nil && *z.Payment == payment {
+			return z, nil
+		}
+	}
+	return zakat.Zakat{}, chaincodeError("GetZakatByPayment", "the payment does not exist")
+}
+
+func (l *fakeLedger) GetAllZakat() ([]zakat.Zakat, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	var zakats []zakat.Zakat
+	for _, z := range l.zakats {
+		zakats = append(zakats, z)
+	}
+	return zakats, nil
+}
+
+func (l *fakeLedger) Close() error { return nil }
+
+var registry = validate.NewRegistry([]zakat.Organization{
+	{Name: "YDSF Malang", MSPID: "YDSFMalangMSP", Code: "MLG", Status: "active"},
+	{Name: "YDSF Jatim", MSPID: "YDSFJatimMSP", Code: "JTM", Status: "inactive"},
+})
+
+func newRecorder(ledger *fakeLedger) *Recorder {
+	return NewRecorder("midtrans", registry, func(organization string) (Ledger, error) { return ledger, nil })
+}
+
+func paidCallback(paymentID string) Callback {
+	return Callback{
+		PaymentID: paymentID,
+		Status:    StatusPaid,
+		Amount:    2500000,
+		Currency:  "IDR",
+		Method:    "qris",
+		PaidAt:    "2024-03-31T20:15:00Z",
+		Order:     Order{Muzakki: "Ahmad", Type: "Maal", Organization: "YDSF Malang"},
+	}
+}
+
+func TestSignature(t *testing.T) {
+	body := []byte(`{"paymentId":"PAY-1"}`)
+	signature := Sign(secret, body)
+	require.True(t, Verify(secret, body, signature))
+	require.False(t, Verify([]byte("other"), body, signature))
+	require.False(t, Verify(secret, []byte(`{"paymentId":"PAY-2"}`), signature))
+	require.False(t, Verify(secret, body, "not hex"))
+}
+
+func TestRecord(t *testing.T) {
+	ledger := newFakeLedger(zakat.Zakat{ID: "ZKT-YDSF-MLG-202404-0007"}, zakat.Zakat{ID: "ZKT-YDSF-MLG-202403-0099"})
+	recorder := newRecorder(ledger)
+
+	// Paid in the evening of 31 March UTC, which is April in WIB
+	result, err := recorder.Record(paidCallback("PAY-1"))
+	require.NoError(t, err)
+	require.Equal(t, Result{Status: ResultRecorded, ZakatID: "ZKT-YDSF-MLG-202404-0008"}, result)
+	require.Equal(t, zakat.Zakat{
+		ID: "ZKT-YDSF-MLG-202404-0008", Muzakki: "Ahmad", Amount: 2500000, Type: "maal", Status: "collected",
+		Organization: "YDSF Malang", Timestamp: "2024-03-31T20:15:00Z",
+		Payment: &zakat.Payment{Channel: "gateway", Bank: "MIDTRANS", Reference: "PAY-1"},
+	}, ledger.zakats["ZKT-YDSF-MLG-202404-0008"])
+
+	// A retried callback finds the donation
+	result, err = recorder.Record(paidCallback("PAY-1"))
+	require.NoError(t, err)
+	require.Equal(t, Result{Status: ResultDuplicate, ZakatID: "ZKT-YDSF-MLG-202404-0008"}, result)
+	require.Equal(t, 1, ledger.adds)
+
+	// An ID taken by another writer is skipped
+	ledger.zakats["ZKT-YDSF-MLG-202404-0009"] = zakat.Zakat{ID: "ZKT-YDSF-MLG-202404-0009"}
+	anonymous := paidCallback("PAY-2")
+	anonymous.Order.Muzakki = " "
+	result, err = recorder.Record(anonymous)
+	require.NoError(t, err)
+	require.Equal(t, Result{Status: ResultRecorded, ZakatID: "ZKT-YDSF-MLG-202404-0010"}, result)
+	require.Equal(t, "Hamba Allah", ledger.zakats["ZKT-YDSF-MLG-202404-0010"].Muzakki)
+
+	// Unpaid payments are not recorded
+	pending := paidCallback("PAY-3")
+	pending.Status = StatusPending
+	result, err = recorder.Record(pending)
+	require.NoError(t, err)
+	require.Equal(t, Result{Status: ResultIgnored}, result)
+
+	// Ledger failures are returned for the gateway to retry
+	ledger.err = errors.New("connection refused")
+	_, err = recorder.Record(paidCallback("PAY-4"))
+	require.EqualError(t, err, "connection refused")
+}
+
+func TestRecordInvalid(t *testing.T) {
+	for name, test := range map[string]struct {
+		change func(*Callback)
+		err    string
+	}{
+		"Currency":     {func(c *Callback) { c.Currency = "USD" }, "payment PAY-1: unsupported currency USD"},
+		"Paid at":      {func(c *Callback) { c.PaidAt = "31/03/2024" }, `payment PAY-1: invalid paidAt "31/03/2024"`},
+		"Type":         {func(c *Callback) { c.Order.Type = "infaq" }, "invalid zakat type"},
+		"Amount":       {func(c *Callback) { c.Amount = 0 }, "invalid amount"},
+		"Inactive":     {func(c *Callback) { c.Order.Organization = "YDSF Jatim" }, "YDSF Jatim is inactive"},
+		"Unregistered": {func(c *Callback) { c.Order.Organization = "YDSF Surabaya" }, "YDSF Surabaya is not registered"},
+		"Payment ID":   {func(c *Callback) { c.PaymentID = "" }, "the callback has no payment ID"},
+	} {
+		t.Run(name, func(t *testing.T) {
+			callback := paidCallback("PAY-1")
+			test.change(&callback)
+			_, err := newRecorder(newFakeLedger()).Record(callback)
+			var invalid *InvalidError
+			require.ErrorAs(t, err, &invalid)
+			require.ErrorContains(t, err, test.err)
+		})
+	}
+}
+
+func TestHandlerWithMockGateway(t *testing.T) {
+	ledger := newFakeLedger()
+	recorder := newRecorder(ledger)
+	server := httptest.NewServer(Handler(recorder, secret))
+	defer server.Close()
+	gateway := &MockGateway{URL: server.URL + "/callback", Secret: secret, Retries: 2}
+
+	// The gateway may deliver the same callback several times, concurrently
+	var wg sync.WaitGroup
+	for i := 0; i < 5; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			status, _, err := gateway.Send(context.Background(), paidCallback("PAY-1"))
+			assert.NoError(t, err)
+			assert.Equal(t, http.StatusOK, status)
+		}()
+	}
+	wg.Wait()
+	require.Len(t, ledger.zakats, 1)
+	require.Equal(t, 1, ledger.adds)
+
+	status, body, err := gateway.Send(context.Background(), paidCallback("PAY-1"))
+	require.NoError(t, err)
+	require.Equal(t, http.StatusOK, status)
+	var result Result
+	require.NoError(t, json.Unmarshal(body, &result))
+	require.Equal(t, Result{Status: ResultDuplicate, ZakatID: "ZKT-YDSF-MLG-202404-0001"}, result)
+
+	// A forged callback is rejected
+	forger := &MockGateway{URL: server.URL + "/callback", Secret: []byte("guess")}
+	status, _, err = forger.Send(context.Background(), paidCallback("PAY-2"))
+	require.NoError(t, err)
+	require.Equal(t, http.StatusUnauthorized, status)
+
+	invalid := paidCallback("PAY-3")
+	invalid.Order.Type = "infaq"
+	status, _, err = gateway.Send(context.Background(), invalid)
+	require.NoError(t, err)
+	require.Equal(t, http.StatusUnprocessableEntity, status)
+
+	// Ledger failures are retried by the gateway
+	ledger.err = errors.New("connection refused")
+	status, _, err = gateway.Send(context.Background(), paidCallback("PAY-4"))
+	require.NoError(t, err)
+	require.Equal(t, http.StatusServiceUnavailable, status)
+	require.Equal(t, 1+3, ledger.adds)
+}
@@ -0,0 +1,241 @@
+package payments
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/hyperledger/fabric-gateway/pkg/client"
+	"github.com/hyperledger/fabric-protos-go-apiv2/peer"
+	zakat "github.com/izzuddinafif/fabric-zakat/application/client"
+	"github.com/izzuddinafif/fabric-zakat/application/validate"
+)
+
+const (
+	// anonymousMuzakki is recorded for donors who leave their name empty
+	anonymousMuzakki = "Hamba Allah"
+	// maxAttempts bounds the retries when a zakat ID is taken by another writer
+	maxAttempts = 5
+	// maxCounter is the last counter a monthly zakat ID can carry
+	maxCounter = 9999
+)
+
+// wib is the time zone the month of a zakat ID is taken in
+var wib = time.FixedZone("WIB", 7*60*60)
+
+// Ledger is the part of the chaincode the recorder uses
+type Ledger interface {
+	AddZakat(input zakat.ZakatInput) error
+	GetZakatByPayment(payment zakat.Payment) (zakat.Zakat, error)
+	GetAllZakat() ([]zakat.Zakat, error)
+	Close() error
+}
+
+// Connector opens a ledger connection as a user of an organization
+type Connector func(organization string) (Ledger, error)
+
+// Result outcomes
+const (
+	ResultRecorded  = "recorded"  // The donation was recorded by this callback
+	ResultDuplicate = "duplicate" // The payment was recorded by an earlier callback
+	ResultIgnored   = "ignored"   // The payment has not been paid
+)
+
+// Result is the outcome of a callback
+type Result struct {
+	Status  string `json:"status"`
+	ZakatID string `json:"zakatId,omitempty"`
+}
+
+// Recorder records paid callbacks as donations, one at a time, with zakat IDs
+// numbered on from the last ID of the organization and month on the ledger
+type Recorder struct {
+	gateway  string
+	registry *validate.Registry
+	connect  Connector
+
+	mu       sync.Mutex
+	ledgers  map[string]Ledger // Per organization
+	counters map[string]int    // Last counter used per zakat ID prefix
+}
+
+// NewRecorder returns a recorder for the callbacks of a gateway, whose name is
+// recorded as the bank of each payment, e.g. MIDTRANS
+func NewRecorder(gateway string, registry *validate.Registry, connect Connector) *Recorder {
+	return &Recorder{
+		gateway:  strings.ToUpper(strings.TrimSpace(gateway)),
+		registry: registry,
+		connect:  connect,
+		ledgers:  map[string]Ledger{},
+		counters: map[string]int{},
+	}
+}
+
+// Close closes the ledger connections
+func (r *Recorder) Close() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, ledger := range r.ledgers {
+		ledger.Close()
+	}
+	r.ledgers = map[string]Ledger{}
+}
+
+// InvalidError is a callback that cannot be recorded as a donation, whatever the
+// state of the ledger
+type InvalidError struct {
+	PaymentID string
+	err       error
+}
+
+func (e *InvalidError) Error() string {
+	if e.PaymentID == "" {
+		return e.err.Error()
+	}
+	return "payment " + e.PaymentID + ": " + e.err.Error()
+}
+
+func (e *InvalidError) Unwrap() error {
+	return e.err
+}
+
+// input checks a paid callback and returns the donation it records, with the prefix
+// of its zakat ID, e.g. ZKT-YDSF-MLG-202403-
+func (r *Recorder) input(callback Callback) (zakat.ZakatInput, string, error) {
+	invalid := func(err error) (zakat.ZakatInput, string, error) {
+		return zakat.ZakatInput{}, "", &InvalidError{PaymentID: callback.PaymentID, err: err}
+	}
+	if callback.PaymentID == "" {
+		return invalid(fmt.Errorf("the callback has no payment ID"))
+	}
+	if callback.Currency != "" && callback.Currency != "IDR" {
+		return invalid(fmt.Errorf("unsupported currency %s", callback.Currency))
+	}
+	paidAt, err := time.Parse(time.RFC3339, callback.PaidAt)
+	if err != nil {
+		return invalid(fmt.Errorf("invalid paidAt %q", callback.PaidAt))
+	}
+
+	muzakki := strings.TrimSpace(callback.Order.Muzakki)
+	if muzakki == "" {
+		muzakki = anonymousMuzakki
+	}
+	payment := zakat.Payment{Channel: zakat.PaymentGateway, Bank: r.gateway, Reference: callback.PaymentID}.Normalize()
+	input := zakat.ZakatInput{
+		Muzakki:      muzakki,
+		Amount:       callback.Amount,
+		Type:         strings.ToLower(strings.TrimSpace(callback.Order.Type)),
+		Organization: strings.TrimSpace(callback.Order.Organization),
+		Timestamp:    paidAt.UTC().Format(time.RFC3339),
+		Payment:      &payment,
+	}
+	code, err := r.registry.Code(input.Organization)
+	if err != nil {
+		return invalid(err)
+	}
+	prefix := fmt.Sprintf("ZKT-YDSF-%s-%s-", code, paidAt.In(wib).Format("200601"))
+	input.ID = prefix + "0001"
+	if err := r.registry.Zakat(input); err != nil {
+		return invalid(err)
+	}
+	return input, prefix, nil
+}
+
+// Record records the donation of a callback unless its payment is already recorded.
+// Callbacks of unpaid payments are ignored, and a callback that can never be
+// recorded fails with an *InvalidError.
+func (r *Recorder) Record(callback Callback) (Result, error) {
+	if callback.Status != StatusPaid {
+		return Result{Status: ResultIgnored}, nil
+	}
+	input, prefix, err := r.input(callback)
+	if err != nil {
+		return Result{}, err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	ledger, err := r.ledger(input.Organization)
+	if err != nil {
+		return Result{}, err
+	}
+
+	for attempt := 0; attempt < maxAttempts; attempt++ {
+		recorded, err := ledger.GetZakatByPayment(*input.Payment)
+		if err == nil {
+			return Result{Status: ResultDuplicate, ZakatID: recorded.ID}, nil
+		}
+		if !isChaincodeError(err, "does not exist") {
+			return Result{}, err
+		}
+
+		if input.ID, err = r.nextID(ledger, prefix); err != nil {
+			return Result{}, err
+		}
+		err = ledger.AddZakat(input)
+		if err == nil {
+			return Result{Status: ResultRecorded, ZakatID: input.ID}, nil
+		}
+		if isChaincodeError(err, "already exists") {
+			// Another writer took the ID: count again from the ledger
+			delete(r.counters, prefix)
+			continue
+		}
+		if !isChaincodeError(err, "is already recorded") && !isConflict(err) {
+			return Result{}, err
+		}
+		// Another transaction recorded the payment or took the ID first
+	}
+	return Result{}, fmt.Errorf("payment %s: no free zakat ID after %d attempts", callback.PaymentID, maxAttempts)
+}
+
+// ledger returns the connection of an organization, opening it on first use
+func (r *Recorder) ledger(organization string) (Ledger, error) {
+	if ledger, ok := r.ledgers[organization]; ok {
+		return ledger, nil
+	}
+	ledger, err := r.connect(organization)
+	if err != nil {
+		return nil, err
+	}
+	r.ledgers[organization] = ledger
+	return ledger, nil
+}
+
+// nextID returns the next zakat ID with a prefix such as ZKT-YDSF-MLG-202403-,
+// reading the last counter used from the ledger the first time a prefix is seen
+func (r *Recorder) nextID(ledger Ledger, prefix string) (string, error) {
+	last, ok := r.counters[prefix]
+	if !ok {
+		zakats, err := ledger.GetAllZakat()
+		if err != nil {
+			return "", err
+		}
+		for _, z := range zakats {
+			if counter, err := strconv.Atoi(strings.TrimPrefix(z.ID, prefix)); err == nil && strings.HasPrefix(z.ID, prefix) && counter > last {
+				last = counter
+			}
+		}
+	}
+	if last >= maxCounter {
+		return "", fmt.Errorf("no zakat IDs are left for %sNNNN", prefix)
+	}
+	r.counters[prefix] = last + 1
+	return fmt.Sprintf("%s%04d", prefix, last+1), nil
+}
+
+// isChaincodeError reports whether err is a chaincode error containing phrase
+func isChaincodeError(err error, phrase string) bool {
+	var chaincodeErr *zakat.Error
+	return errors.As(err, &chaincodeErr) && strings.Contains(chaincodeErr.Message, phrase)
+}
+
+// isConflict reports whether err is a transaction invalidated by a concurrent write
+func isConflict(err error) bool {
+	var commitErr *client.CommitError
+	return errors.As(err, &commitErr) &&
+		(commitErr.Code == peer.TxValidationCode_MVCC_READ_CONFLICT || commitErr.Code == peer.TxValidationCode_PHANTOM_READ_CONFLICT)
+}
@@ -0,0 +1,111 @@
+package projector
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/hyperledger/fabric-protos-go-apiv2/common"
+	"github.com/hyperledger/fabric-protos-go-apiv2/ledger/rwset"
+	"github.com/hyperledger/fabric-protos-go-apiv2/ledger/rwset/kvrwset"
+	"github.com/hyperledger/fabric-protos-go-apiv2/peer"
+	"google.golang.org/protobuf/proto"
+)
+
+// zakatKeyPrefix starts the world state key of every zakat transaction, which is its ID
+const zakatKeyPrefix = "ZKT-"
+
+// Write is a committed write to the key of a zakat transaction
+type Write struct {
+	TxID     string
+	Key      string
+	Value    []byte
+	IsDelete bool
+}
+
+// ZakatWrites returns the writes of a block's valid transactions to zakat keys of a
+// chaincode, in commit order
+func ZakatWrites(block *common.Block, chaincode string) ([]Write, error) {
+	var filter []byte
+	if metadata := block.GetMetadata().GetMetadata(); len(metadata) > int(common.BlockMetadataIndex_TRANSACTIONS_FILTER) {
+		filter = metadata[common.BlockMetadataIndex_TRANSACTIONS_FILTER]
+	}
+
+	var writes []Write
+	for i, envelopeBytes := range block.GetData().GetData() {
+		if i < len(filter) && peer.TxValidationCode(filter[i]) != peer.TxValidationCode_VALID {
+			continue
+		}
+		txWrites, err := transactionWrites(envelopeBytes, chaincode)
+		if err != nil {
+			return nil, fmt.Errorf("block %d transaction %d: %w", block.GetHeader().GetNumber(), i, err)
+		}
+		writes = append(writes, txWrites...)
+	}
+	return writes, nil
+}
+
+// transactionWrites returns the zakat key writes of one transaction envelope
+func transactionWrites(envelopeBytes []byte, chaincode string) ([]Write, error) {
+	envelope := &common.Envelope{}
+	if err := proto.Unmarshal(envelopeBytes, envelope); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal envelope: %w", err)
+	}
+	payload := &common.Payload{}
+	if err := proto.Unmarshal(envelope.GetPayload(), payload); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal payload: %w", err)
+	}
+	channelHeader := &common.ChannelHeader{}
+	if err := proto.Unmarshal(payload.GetHeader().GetChannelHeader(), channelHeader); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal channel header: %w", err)
+	}
+	if channelHeader.GetType() != int32(common.HeaderType_ENDORSER_TRANSACTION) {
+		return nil, nil
+	}
+
+	transaction := &peer.Transaction{}
+	if err := proto.Unmarshal(payload.GetData(), transaction); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal transaction: %w", err)
+	}
+
+	var writes []Write
+	for _, action := range transaction.GetActions() {
+		actionPayload := &peer.ChaincodeActionPayload{}
+		if err := proto.Unmarshal(action.GetPayload(), actionPayload); err != nil {
+			return nil, fmt.Errorf("failed to unmarshal action payload: %w", err)
+		}
+		responsePayload := &peer.ProposalResponsePayload{}
+		if err := proto.Unmarshal(actionPayload.GetAction().GetProposalResponsePayload(), responsePayload); err != nil {
+			return nil, fmt.Errorf("failed to unmarshal proposal response: %w", err)
+		}
+		chaincodeAction := &peer.ChaincodeAction{}
+		if err := proto.Unmarshal(responsePayload.GetExtension(), chaincodeAction); err != nil {
+			return nil, fmt.Errorf("failed to unmarshal chaincode action: %w", err)
+		}
+		txRWSet := &rwset.TxReadWriteSet{}
+		if err := proto.Unmarshal(chaincodeAction.GetResults(), txRWSet); err != nil {
+			return nil, fmt.Errorf("failed to unmarshal read-write set: %w", err)
+		}
+
+		for _, nsRWSet := range txRWSet.GetNsRwset() {
+			if nsRWSet.GetNamespace() != chaincode {
+				continue
+			}
+			kvRWSet := &kvrwset.KVRWSet{}
+			if err := proto.Unmarshal(nsRWSet.GetRwset(), kvRWSet); err != nil {
+				return nil, fmt.Errorf("failed to unmarshal %s writes: %w", chaincode, err)
+			}
+			for _, write := range kvRWSet.GetWrites() {
+				if !strings.HasPrefix(write.GetKey(), zakatKeyPrefix) {
+					continue
+				}
+				writes = append(writes, Write{
+					TxID:     channelHeader.GetTxId(),
+					Key:      write.GetKey(),
+					Value:    write.GetValue(),
+					IsDelete: write.GetIsDelete(),
+				})
+			}
+		}
+	}
+	return writes, nil
+}
@@ -0,0 +1,119 @@
+package projector
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"regexp"
+	"strconv"
+)
+
+// Handler serves read queries on the store:
+//
+//	GET /zakat?organization=&type=&status=&from=&to=&hijri=&limit=
+//	GET /zakat/{id}
+//	GET /summary?by=organization|type|status|unit|currency|restriction|month|year|hijri_month|hijri_year&organization=&type=&status=&from=&to=&hijri=
+//	GET /checkpoint
+func Handler(store *Store) http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /zakat", func(w http.ResponseWriter, r *http.Request) {
+		limit := 0
+		if value := r.URL.Query().Get("limit"); value != "" {
+			var err error
+			if limit, err = strconv.Atoi(value); err != nil || limit < 0 {
+				writeError(w, http.StatusBadRequest, "limit must be a positive number")
+				return
+			}
+		}
+		filter, err := filterOf(r)
+		if err != nil {
+			writeError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+		zakats, err := store.ListZakat(filter, limit)
+		if err != nil {
+			writeError(w, http.StatusInternalServerError, err.Error())
+			return
+		}
+		writeJSON(w, http.StatusOK, zakats)
+	})
+	mux.HandleFunc("GET /zakat/{id}", func(w http.ResponseWriter, r *http.Request) {
+		zakat, ok, err := store.Zakat(r.PathValue("id"))
+		if err != nil {
+			writeError(w, http.StatusInternalServerError, err.Error())
+			return
+		}
+		if !ok {
+			writeError(w, http.StatusNotFound, "the zakat transaction "+r.PathValue("id")+" does not exist")
+			return
+		}
+		writeJSON(w, http.StatusOK, zakat)
+	})
+	mux.HandleFunc("GET /summary", func(w http.ResponseWriter, r *http.Request) {
+		groupBy := r.URL.Query().Get("by")
+		if groupBy == "" {
+			groupBy = "organization"
+		}
+		if _, ok := summaryGroups[groupBy]; !ok {
+			writeError(w, http.StatusBadRequest, "by must be organization, type, status, unit, currency, restriction, month, year, hijri_month or hijri_year")
+			return
+		}
+		filter, err := filterOf(r)
+		if err != nil {
+			writeError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+		summary, err := store.Summary(groupBy, filter)
+		if err != nil {
+			writeError(w, http.StatusInternalServerError, err.Error())
+			return
+		}
+		writeJSON(w, http.StatusOK, summary)
+	})
+	mux.HandleFunc("GET /checkpoint", func(w http.ResponseWriter, r *http.Request) {
+		next, err := store.Checkpoint()
+		if err != nil {
+			writeError(w, http.StatusInternalServerError, err.Error())
+			return
+		}
+		writeJSON(w, http.StatusOK, map[string]uint64{"nextBlock": next})
+	})
+	return mux
+}
+
+// filterOf reads a filter from the query parameters, with the Hijri year or month
+// given as hijri=1447H or hijri=1447H09
+func filterOf(r *http.Request) (Filter, error) {
+	query := r.URL.Query()
+	filter := Filter{
+		Organization: query.Get("organization"),
+		Type:         query.Get("type"),
+		Status:       query.Get("status"),
+		From:         query.Get("from"),
+		To:           query.Get("to"),
+	}
+	if hijri := query.Get("hijri"); hijri != "" {
+		match := hijriPeriod.FindStringSubmatch(hijri)
+		if match == nil {
+			return Filter{}, fmt.Errorf("hijri must be a Hijri year or month, e.g. 1447H or 1447H09")
+		}
+		filter.HijriYear, _ = strconv.Atoi(match[1])
+		if match[2] != "" {
+			filter.HijriMonth, _ = strconv.Atoi(match[2])
+		}
+	}
+	return filter, nil
+}
+
+// hijriPeriod matches a Hijri year (1447H) or month (1447H09) period
+var hijriPeriod = regexp.MustCompile(`^(\d{4})H(0[1-9]|1[0-2])?$`)
+
+func writeJSON(w http.ResponseWriter, status int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(v)
+}
+
+func writeError(w http.ResponseWriter, status int, message string) {
+	writeJSON(w, status, map[string]string{"error": message})
+}
@@ -0,0 +1,51 @@
+// Package projector maintains a SQLite read model of the zakat transactions from
+// the channel's block events, for dashboards that need SQL-style queries instead of
+// world state scans.
+package projector
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"github.com/hyperledger/fabric-protos-go-apiv2/common"
+)
+
+// retryDelay is the wait before reconnecting after the block stream fails
+const retryDelay = 5 * time.Second
+
+// BlockSource streams the channel's blocks from a block number
+type BlockSource func(ctx context.Context, startBlock uint64) (<-chan *common.Block, error)
+
+// Run projects the chaincode's blocks into the store, starting from its checkpoint,
+// until ctx is done. The stream is reopened from the checkpoint whenever it fails.
+func Run(ctx context.Context, blocks BlockSource, chaincode string, store *Store) error {
+	for {
+		start, err := store.Checkpoint()
+		if err != nil {
+			return err
+		}
+
+		stream, err := blocks(ctx, start)
+		if err != nil {
+			log.Printf("failed to read blocks from %d: %v", start, err)
+		} else {
+			for block := range stream {
+				writes, err := ZakatWrites(block, chaincode)
+				if err != nil {
+					return err
+				}
+				if err := store.ApplyBlock(block.GetHeader().GetNumber(), writes); err != nil {
+					return err
+				}
+			}
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-time.After(retryDelay):
+			log.Print("block stream closed, reconnecting")
+		}
+	}
+}
@@ -0,0 +1,345 @@
+package projector
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"testing"
+
+	"github.com/hyperledger/fabric-protos-go-apiv2/common"
+	"github.com/hyperledger/fabric-protos-go-apiv2/ledger/rwset"
+	"github.com/hyperledger/fabric-protos-go-apiv2/ledger/rwset/kvrwset"
+	"github.com/hyperledger/fabric-protos-go-apiv2/peer"
+	"github.com/izzuddinafif/fabric-zakat/application/client"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/protobuf/proto"
+)
+
+func marshal(t *testing.T, m proto.Message) []byte {
+	data, err := proto.Marshal(m)
+	require.NoError(t, err)
+	return data
+}
+
+// envelope builds an endorser transaction writing to keys of a chaincode
+func envelope(t *testing.T, txID string, namespace string, writes ...*kvrwset.KVWrite) []byte {
+	results := marshal(t, &rwset.TxReadWriteSet{
+		DataModel: rwset.TxReadWriteSet_KV,
+		NsRwset: []*rwset.NsReadWriteSet{{
+			Namespace: namespace,
+			Rwset:     marshal(t, &kvrwset.KVRWSet{Writes: writes}),
+		}},
+	})
+	actionPayload := marshal(t, &peer.ChaincodeActionPayload{
+		Action: &peer.ChaincodeEndorsedAction{
+			ProposalResponsePayload: marshal(t, &peer.ProposalResponsePayload{
+				Extension: marshal(t, &peer.ChaincodeAction{Results: results}),
+			}),
+		},
+	})
+	payload := marshal(t, &common.Payload{
+		Header: &common.Header{ChannelHeader: marshal(t, &common.ChannelHeader{
+			Type: int32(common.HeaderType_ENDORSER_TRANSACTION),
+			TxId: txID,
+		})},
+		Data: marshal(t, &peer.Transaction{Actions: []*peer.TransactionAction{{Payload: actionPayload}}}),
+	})
+	return marshal(t, &common.Envelope{Payload: payload})
+}
+
+// block builds a block of transactions with their validation codes
+func block(number uint64, envelopes [][]byte, codes ...peer.TxValidationCode) *common.Block {
+	filter := make([]byte, len(codes))
+	for i, code := range codes {
+		filter[i] = byte(code)
+	}
+	metadata := make([][]byte, common.BlockMetadataIndex_TRANSACTIONS_FILTER+1)
+	metadata[common.BlockMetadataIndex_TRANSACTIONS_FILTER] = filter
+	return &common.Block{
+		Header:   &common.BlockHeader{Number: number},
+		Data:     &common.BlockData{Data: envelopes},
+		Metadata: &common.BlockMetadata{Metadata: metadata},
+	}
+}
+
+func zakatWrite(t *testing.T, zakat client.Zakat) *kvrwset.KVWrite {
+	value, err := json.Marshal(zakat)
+	require.NoError(t, err)
+	return &kvrwset.KVWrite{Key: zakat.ID, Value: value}
+}
+
+var (
+	ahmad = client.Zakat{ID: "ZKT-YDSF-MLG-202403-0001", Muzakki: "Ahmad", Amount: 45000, Type: "fitrah",
+		Status: "collected", Organization: "YDSF Malang", Timestamp: "2024-03-30T08:00:00Z"}
+	siti = client.Zakat{ID: "ZKT-YDSF-JTM-202403-0001", Muzakki: "Siti", Amount: 2500000, Type: "maal",
+		Status: "collected", Organization: "YDSF Jatim", Timestamp: "2024-03-12T09:00:00Z"}
+	rejected = client.Zakat{ID: "ZKT-YDSF-MLG-202403-0002", Muzakki: "Budi", Amount: 45000, Type: "fitrah",
+		Status: "collected", Organization: "YDSF Malang", Timestamp: "2024-03-30T09:00:00Z"}
+)
+
+func TestZakatWrites(t *testing.T) {
+	b := block(5, [][]byte{
+		envelope(t, "tx1", "zakat", zakatWrite(t, ahmad), &kvrwset.KVWrite{Key: "\x00pool\x00POOL-YDSF-MLG-FITRAH\x00", Value: []byte("{}")}),
+		envelope(t, "tx2", "zakat", zakatWrite(t, rejected)),
+		envelope(t, "tx3", "other", zakatWrite(t, siti)),
+	}, peer.TxValidationCode_VALID, peer.TxValidationCode_MVCC_READ_CONFLICT, peer.TxValidationCode_VALID)
+
+	writes, err := ZakatWrites(b, "zakat")
+	require.NoError(t, err)
+	require.Len(t, writes, 1)
+	require.Equal(t, "tx1", writes[0].TxID)
+	require.Equal(t, ahmad.ID, writes[0].Key)
+}
+
+func TestStore(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "zakat.db")
+	store, err := Open(path)
+	require.NoError(t, err)
+
+	next, err := store.Checkpoint()
+	require.NoError(t, err)
+	require.Equal(t, uint64(0), next)
+
+	// Blocks arrive in order; the distribution updates Ahmad's donation
+	blocks := make(chan *common.Block, 3)
+	blocks <- block(0, [][]byte{envelope(t, "tx1", "zakat", zakatWrite(t, ahmad))}, peer.TxValidationCode_VALID)
+	blocks <- block(1, [][]byte{envelope(t, "tx2", "zakat", zakatWrite(t, siti))}, peer.TxValidationCode_VALID)
+	distributed := ahmad
+	distributed.Status = "distributed"
+	distributed.Distribution = 45000
+	distributed.Distributions = []string{"DST-YDSF-MLG-202404-0001"}
+	blocks <- block(2, [][]byte{envelope(t, "tx3", "zakat", zakatWrite(t, distributed))}, peer.TxValidationCode_VALID)
+	close(blocks)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	var started []uint64
+	err = Run(ctx, func(ctx context.Context, start uint64) (<-chan *common.Block, error) {
+		started = append(started, start)
+		return blocks, nil
+	}, "zakat", store)
+	require.NoError(t, err)
+	require.Equal(t, []uint64{0}, started)
+
+	zakat, ok, err := store.Zakat(ahmad.ID)
+	require.NoError(t, err)
+	require.True(t, ok)
+	require.Equal(t, distributed, zakat)
+
+	// A replayed block is ignored
+	require.NoError(t, store.ApplyBlock(0, []Write{{TxID: "tx1", Key: ahmad.ID, Value: mustJSON(t, ahmad)}}))
+	zakat, _, err = store.Zakat(ahmad.ID)
+	require.NoError(t, err)
+	require.Equal(t, "distributed", zakat.Status)
+
+	// The checkpoint survives a restart
+	require.NoError(t, store.Close())
+	store, err = Open(path)
+	require.NoError(t, err)
+	defer store.Close()
+	next, err = store.Checkpoint()
+	require.NoError(t, err)
+	require.Equal(t, uint64(3), next)
+
+	zakats, err := store.ListZakat(Filter{Organization: "YDSF Malang"}, 0)
+	require.NoError(t, err)
+	require.Equal(t, []client.Zakat{distributed}, zakats)
+	zakats, err = store.ListZakat(Filter{From: "2024-03-01", To: "2024-03-20"}, 0)
+	require.NoError(t, err)
+	require.Equal(t, []client.Zakat{siti}, zakats)
+
+	summary, err := store.Summary("type", Filter{})
+	require.NoError(t, err)
+	require.Equal(t, []SummaryRow{
+		{Group: "fitrah", Donations: 1, Muzakki: 1, Jiwa: 1, Amount: 45000, Distributed: 45000},
+		{Group: "maal", Donations: 1, Muzakki: 1, Amount: 2500000},
+	}, summary)
+	_, err = store.Summary("muzakki; DROP TABLE zakat", Filter{})
+	require.Error(t, err)
+
+	// Read queries over HTTP
+	ts := httptest.NewServer(Handler(store))
+	defer ts.Close()
+	resp, err := http.Get(ts.URL + "/summary?by=month")
+	require.NoError(t, err)
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	var months []SummaryRow
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&months))
+	resp.Body.Close()
+	require.Equal(t, "2024-03", months[0].Group)
+	require.Equal(t, 2, months[0].Donations)
+
+	resp, err = http.Get(ts.URL + "/zakat/ZKT-YDSF-MLG-202403-0099")
+	require.NoError(t, err)
+	resp.Body.Close()
+	require.Equal(t, http.StatusNotFound, resp.StatusCode)
+}
+
+func TestHijri(t *testing.T) {
+	// A store created before records carried their Hijri date
+	path := filepath.Join(t.TempDir(), "zakat.db")
+	db, err := sql.Open("sqlite", path)
+	require.NoError(t, err)
+	_, err = db.Exec(`CREATE TABLE zakat (id TEXT PRIMARY KEY, muzakki TEXT NOT NULL, amount REAL NOT NULL, type TEXT NOT NULL,
+		status TEXT NOT NULL, organization TEXT NOT NULL, timestamp TEXT NOT NULL, mustahik TEXT NOT NULL, distributed REAL NOT NULL,
+		distributed_at TEXT NOT NULL, distributions TEXT NOT NULL, tx_id TEXT NOT NULL, block INTEGER NOT NULL);
+		INSERT INTO zakat VALUES ('ZKT-YDSF-MLG-202303-0001', 'Umar', 40000, 'fitrah', 'collected', 'YDSF Malang',
+			'2023-04-15T08:00:00Z', '', 0, '', 'null', 'tx0', 0)`)
+	require.NoError(t, err)
+	require.NoError(t, db.Close())
+
+	store, err := Open(path)
+	require.NoError(t, err)
+	defer store.Close()
+
+	ramadan := ahmad
+	ramadan.Hijri = &client.HijriDate{Year: 1445, Month: 9, Day: 20}
+	syawal := siti
+	syawal.ID = "ZKT-YDSF-JTM-202404-0001"
+	syawal.Timestamp = "2024-04-10T09:00:00Z"
+	syawal.Hijri = &client.HijriDate{Year: 1445, Month: 10, Day: 1}
+	require.NoError(t, store.ApplyBlock(1, []Write{
+		{TxID: "tx1", Key: ramadan.ID, Value: mustJSON(t, ramadan)},
+		{TxID: "tx2", Key: syawal.ID, Value: mustJSON(t, syawal)},
+	}))
+
+	zakats, err := store.ListZakat(Filter{HijriYear: 1445, HijriMonth: 9}, 0)
+	require.NoError(t, err)
+	require.Equal(t, []client.Zakat{ramadan}, zakats)
+
+	summary, err := store.Summary("hijri_month", Filter{})
+	require.NoError(t, err)
+	require.Equal(t, []SummaryRow{
+		{Group: "", Donations: 1, Muzakki: 1, Jiwa: 1, Amount: 40000},
+		{Group: "1445H09", Donations: 1, Muzakki: 1, Jiwa: 1, Amount: 45000},
+		{Group: "1445H10", Donations: 1, Muzakki: 1, Amount: 2500000},
+	}, summary)
+
+	ts := httptest.NewServer(Handler(store))
+	defer ts.Close()
+	resp, err := http.Get(ts.URL + "/summary?by=hijri_year&type=fitrah&hijri=1445H")
+	require.NoError(t, err)
+	var years []SummaryRow
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&years))
+	resp.Body.Close()
+	require.Equal(t, []SummaryRow{{Group: "1445H", Donations: 1, Muzakki: 1, Jiwa: 1, Amount: 45000}}, years)
+
+	resp, err = http.Get(ts.URL + "/zakat?hijri=1445-09")
+	require.NoError(t, err)
+	resp.Body.Close()
+	require.Equal(t, http.StatusBadRequest, resp.StatusCode)
+}
+
+func TestInKind(t *testing.T) {
+	store, err := Open(filepath.Join(t.TempDir(), "zakat.db"))
+	require.NoError(t, err)
+	defer store.Close()
+
+	rice := ahmad
+	rice.ID = "ZKT-YDSF-MLG-202403-0002"
+	rice.Amount, rice.Unit, rice.Value = 10, "kg_beras", 150000
+	rice.Distribution = 2.5
+	rice.Jiwa = 4
+	require.NoError(t, store.ApplyBlock(0, []Write{
+		{TxID: "tx1", Key: ahmad.ID, Value: mustJSON(t, ahmad)},
+		{TxID: "tx2", Key: rice.ID, Value: mustJSON(t, rice)},
+	}))
+
+	zakat, found, err := store.Zakat(rice.ID)
+	require.NoError(t, err)
+	require.True(t, found)
+	require.Equal(t, rice, zakat)
+
+	summary, err := store.Summary("unit", Filter{})
+	require.NoError(t, err)
+	require.Equal(t, []SummaryRow{
+		{Group: "IDR", Donations: 1, Muzakki: 1, Jiwa: 1, Amount: 45000},
+		{Group: "kg_beras", Donations: 1, Muzakki: 1, Jiwa: 4, Amount: 150000, Distributed: 37500},
+	}, summary)
+}
+
+func TestCurrency(t *testing.T) {
+	store, err := Open(filepath.Join(t.TempDir(), "zakat.db"))
+	require.NoError(t, err)
+	defer store.Close()
+
+	ringgit := siti
+	ringgit.ID = "ZKT-YDSF-JTM-202403-0002"
+	ringgit.Amount = 335055
+	ringgit.Conversion = &client.Conversion{Currency: "MYR", Amount: 100, Rate: 3350.55, Source: "BSI kurs beli"}
+	require.NoError(t, store.ApplyBlock(0, []Write{
+		{TxID: "tx1", Key: siti.ID, Value: mustJSON(t, siti)},
+		{TxID: "tx2", Key: ringgit.ID, Value: mustJSON(t, ringgit)},
+	}))
+
+	zakat, found, err := store.Zakat(ringgit.ID)
+	require.NoError(t, err)
+	require.True(t, found)
+	require.Equal(t, ringgit, zakat)
+
+	summary, err := store.Summary("currency", Filter{})
+	require.NoError(t, err)
+	require.Equal(t, []SummaryRow{
+		{Group: "IDR", Donations: 1, Muzakki: 1, Amount: 2500000},
+		{Group: "MYR", Donations: 1, Muzakki: 1, Amount: 335055},
+	}, summary)
+}
+
+func TestRestriction(t *testing.T) {
+	store, err := Open(filepath.Join(t.TempDir(), "zakat.db"))
+	require.NoError(t, err)
+	defer store.Close()
+
+	lumajang := siti
+	lumajang.ID = "ZKT-YDSF-JTM-202403-0002"
+	lumajang.Amount = 1000000
+	lumajang.Restriction = &client.Restriction{Kind: "region", Value: "Lumajang"}
+	lumajang.PledgeID = "PLG-YDSF-JTM-2024-0001"
+	lumajang.Muzakki, lumajang.DonorCommitment = "Hamba Allah", "9f2c1e0d4b7a6f5e3d2c1b0a9f8e7d6c5b4a3f2e1d0c9b8a7f6e5d4c3b2a1f0e"
+	require.NoError(t, store.ApplyBlock(0, []Write{
+		{TxID: "tx1", Key: siti.ID, Value: mustJSON(t, siti)},
+		{TxID: "tx2", Key: lumajang.ID, Value: mustJSON(t, lumajang)},
+	}))
+
+	zakat, found, err := store.Zakat(lumajang.ID)
+	require.NoError(t, err)
+	require.True(t, found)
+	require.Equal(t, lumajang, zakat)
+
+	summary, err := store.Summary("restriction", Filter{})
+	require.NoError(t, err)
+	require.Equal(t, []SummaryRow{
+		{Group: "", Donations: 1, Muzakki: 1, Amount: 2500000},
+		{Group: "region:Lumajang", Donations: 1, Muzakki: 1, Amount: 1000000},
+	}, summary)
+}
+
+func TestAnonymous(t *testing.T) {
+	store, err := Open(filepath.Join(t.TempDir(), "zakat.db"))
+	require.NoError(t, err)
+	defer store.Close()
+
+	first, second := siti, siti
+	first.ID, second.ID = "ZKT-YDSF-JTM-202403-0002", "ZKT-YDSF-JTM-202403-0003"
+	first.Muzakki, first.DonorCommitment = "Hamba Allah", "9f2c1e0d4b7a6f5e3d2c1b0a9f8e7d6c5b4a3f2e1d0c9b8a7f6e5d4c3b2a1f0e"
+	second.Muzakki, second.DonorCommitment = "Hamba Allah", "0e1f2a3b4c5d6e7f8a9b0c1d2e3f4a5b6c7d8e9f0a1b2c3d4e5f6a7b8c9d0e1f"
+	require.NoError(t, store.ApplyBlock(0, []Write{
+		{TxID: "tx1", Key: siti.ID, Value: mustJSON(t, siti)},
+		{TxID: "tx2", Key: first.ID, Value: mustJSON(t, first)},
+		{TxID: "tx3", Key: second.ID, Value: mustJSON(t, second)},
+	}))
+
+	// Each anonymous donation counts as a donor of its own, not one Hamba Allah
+	summary, err := store.Summary("organization", Filter{})
+	require.NoError(t, err)
+	require.Equal(t, []SummaryRow{{Group: "YDSF Jatim", Donations: 3, Muzakki: 3, Amount: 7500000}}, summary)
+}
+
+func mustJSON(t *testing.T, v interface{}) []byte {
+	data, err := json.Marshal(v)
+	require.NoError(t, err)
+	return data
+}
@@ -0,0 +1,391 @@
+package projector
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/izzuddinafif/fabric-zakat/application/client"
+	_ "modernc.org/sqlite"
+)
+
+// schema is the read model: the latest version of every zakat transaction, and the
+// next block to project
+const schema = `
+CREATE TABLE IF NOT EXISTS zakat (
+	id             TEXT PRIMARY KEY,
+	muzakki        TEXT NOT NULL,
+	amount         REAL NOT NULL,
+	unit           TEXT NOT NULL DEFAULT '',
+	value          REAL NOT NULL DEFAULT 0,
+	type           TEXT NOT NULL,
+	status         TEXT NOT NULL,
+	organization   TEXT NOT NULL,
+	timestamp      TEXT NOT NULL,
+	mustahik       TEXT NOT NULL,
+	distributed    REAL NOT NULL,
+	distributed_at TEXT NOT NULL,
+	distributions  TEXT NOT NULL,
+	hijri_year     INTEGER NOT NULL DEFAULT 0,
+	hijri_month    INTEGER NOT NULL DEFAULT 0,
+	hijri_day      INTEGER NOT NULL DEFAULT 0,
+	jiwa           INTEGER NOT NULL DEFAULT 0,
+	conversion     TEXT NOT NULL DEFAULT '',
+	restriction    TEXT NOT NULL DEFAULT '',
+	pledge_id      TEXT NOT NULL DEFAULT '',
+	donor_commitment TEXT NOT NULL DEFAULT '',
+	tx_id          TEXT NOT NULL,
+	block          INTEGER NOT NULL
+);
+CREATE INDEX IF NOT EXISTS zakat_organization ON zakat (organization, timestamp);
+CREATE INDEX IF NOT EXISTS zakat_type ON zakat (type, timestamp);
+CREATE TABLE IF NOT EXISTS checkpoint (
+	id         INTEGER PRIMARY KEY CHECK (id = 1),
+	next_block INTEGER NOT NULL
+);
+`
+
+// addedColumns are the columns added to stores created by earlier versions, with their
+// definitions: the Hijri date records were later stamped with, the unit and IDR
+// valuation of in-kind donations, the persons fitrah covers, the foreign currency a
+// donation was given in, the purpose it was restricted to, the pledge it was paid
+// towards and the commitment of an anonymous donor. Rows projected before then keep the
+// defaults, which read as IDR, no Hijri date, fitrah for one person, no restriction, no
+// pledge and a named donor, until re-projected.
+var addedColumns = []struct{ name, definition string }{
+	{"hijri_year", "INTEGER NOT NULL DEFAULT 0"},
+	{"hijri_month", "INTEGER NOT NULL DEFAULT 0"},
+	{"hijri_day", "INTEGER NOT NULL DEFAULT 0"},
+	{"unit", "TEXT NOT NULL DEFAULT ''"},
+	{"value", "REAL NOT NULL DEFAULT 0"},
+	{"jiwa", "INTEGER NOT NULL DEFAULT 0"},
+	{"conversion", "TEXT NOT NULL DEFAULT ''"},
+	{"restriction", "TEXT NOT NULL DEFAULT ''"},
+	{"pledge_id", "TEXT NOT NULL DEFAULT ''"},
+	{"donor_commitment", "TEXT NOT NULL DEFAULT ''"},
+}
+
+// zakatColumns are the columns a client.Zakat is read from, in scan order
+const zakatColumns = "id, muzakki, amount, unit, value, type, status, organization, timestamp, mustahik, distributed, distributed_at, distributions, hijri_year, hijri_month, hijri_day, jiwa, conversion, restriction, pledge_id, donor_commitment"
+
+// IDR values of the amount collected and distributed: the amounts of rupiah donations,
+// and the valuation of in-kind donations, in proportion for the part distributed
+const (
+	idrAmount      = "CASE unit WHEN '' THEN amount ELSE value END"
+	idrDistributed = "CASE unit WHEN '' THEN distributed ELSE value * distributed / amount END"
+)
+
+// distinctDonor is who a donation is counted for among distinct donors, as the
+// chaincode's reports count them: its muzakki, or for an anonymous donation, recorded
+// as Hamba Allah, its donor commitment
+const distinctDonor = "CASE donor_commitment WHEN '' THEN muzakki ELSE donor_commitment END"
+
+// coveredJiwa is the number of persons a donation covers, as the chaincode's reports
+// count them: fitrah without a count covers the muzakki alone, and zakat maal nobody
+const coveredJiwa = "CASE type WHEN 'fitrah' THEN MAX(jiwa, 1) ELSE 0 END"
+
+// Store is the SQLite read model
+type Store struct {
+	db *sql.DB
+}
+
+// Open opens or creates the read model at path
+func Open(path string) (*Store, error) {
+	db, err := sql.Open("sqlite", path)
+	if err != nil {
+		return nil, err
+	}
+	// One connection serializes the projector's writes with the queries
+	db.SetMaxOpenConns(1)
+	if _, err := db.Exec(schema); err != nil {
+		db.Close()
+		return nil, fmt.Errorf("failed to create schema: %w", err)
+	}
+	if err := migrate(db); err != nil {
+		db.Close()
+		return nil, fmt.Errorf("failed to migrate schema: %w", err)
+	}
+	if _, err := db.Exec("CREATE INDEX IF NOT EXISTS zakat_hijri ON zakat (hijri_year, hijri_month)"); err != nil {
+		db.Close()
+		return nil, fmt.Errorf("failed to create schema: %w", err)
+	}
+	return &Store{db: db}, nil
+}
+
+// migrate adds the columns missing from a store created by an earlier version
+func migrate(db *sql.DB) error {
+	rows, err := db.Query("SELECT name FROM pragma_table_info('zakat')")
+	if err != nil {
+		return err
+	}
+	existing := map[string]bool{}
+	for rows.Next() {
+		var name string
+		if err := rows.Scan(&name); err != nil {
+			rows.Close()
+			return err
+		}
+		existing[name] = true
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return err
+	}
+
+	for _, column := range addedColumns {
+		if existing[column.name] {
+			continue
+		}
+		if _, err := db.Exec("ALTER TABLE zakat ADD COLUMN " + column.name + " " + column.definition); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Close closes the database
+func (s *Store) Close() error {
+	return s.db.Close()
+}
+
+// Checkpoint returns the number of the next block to project, 0 for a new store
+func (s *Store) Checkpoint() (uint64, error) {
+	var next uint64
+	err := s.db.QueryRow("SELECT next_block FROM checkpoint WHERE id = 1").Scan(&next)
+	if errors.Is(err, sql.ErrNoRows) {
+		return 0, nil
+	}
+	return next, err
+}
+
+// ApplyBlock projects the zakat writes of a block and moves the checkpoint past it,
+// in one database transaction. A block before the checkpoint was already projected
+// and is ignored.
+func (s *Store) ApplyBlock(number uint64, writes []Write) error {
+	next, err := s.Checkpoint()
+	if err != nil {
+		return err
+	}
+	if number < next {
+		return nil
+	}
+
+	tx, err := s.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	for _, write := range writes {
+		if write.IsDelete {
+			if _, err := tx.Exec("DELETE FROM zakat WHERE id = ?", write.Key); err != nil {
+				return err
+			}
+			continue
+		}
+
+		var zakat client.Zakat
+		if err := json.Unmarshal(write.Value, &zakat); err != nil {
+			return fmt.Errorf("failed to unmarshal zakat %s in transaction %s: %w", write.Key, write.TxID, err)
+		}
+		distributions, err := json.Marshal(zakat.Distributions)
+		if err != nil {
+			return err
+		}
+		var hijri client.HijriDate
+		if zakat.Hijri != nil {
+			hijri = *zakat.Hijri
+		}
+		conversion := ""
+		if zakat.Conversion != nil {
+			conversionJSON, err := json.Marshal(zakat.Conversion)
+			if err != nil {
+				return err
+			}
+			conversion = string(conversionJSON)
+		}
+		restriction := ""
+		if zakat.Restriction != nil {
+			restriction = zakat.Restriction.String()
+		}
+		_, err = tx.Exec(`INSERT OR REPLACE INTO zakat (`+zakatColumns+`, tx_id, block)
+			VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+			write.Key, zakat.Muzakki, zakat.Amount, unit(zakat.Unit), zakat.Value, zakat.Type, zakat.Status, zakat.Organization, zakat.Timestamp,
+			zakat.Mustahik, zakat.Distribution, zakat.DistributedAt, string(distributions), hijri.Year, hijri.Month, hijri.Day, zakat.Jiwa, conversion, restriction, zakat.PledgeID, zakat.DonorCommitment,
+			write.TxID, number)
+		if err != nil {
+			return fmt.Errorf("failed to project zakat %s: %w", write.Key, err)
+		}
+	}
+
+	_, err = tx.Exec(`INSERT INTO checkpoint (id, next_block) VALUES (1, ?)
+		ON CONFLICT (id) DO UPDATE SET next_block = excluded.next_block`, number+1)
+	if err != nil {
+		return fmt.Errorf("failed to save checkpoint: %w", err)
+	}
+	return tx.Commit()
+}
+
+// unit returns the unit a donation is stored with, empty for rupiah
+func unit(unit string) string {
+	if client.IsIDR(unit) {
+		return ""
+	}
+	return unit
+}
+
+// Filter selects zakat transactions; empty fields match everything
+type Filter struct {
+	Organization string
+	Type         string
+	Status       string
+	From         string // Earliest timestamp, inclusive (ISO 8601 or a prefix such as 2024-03)
+	To           string // Latest timestamp, exclusive
+	HijriYear    int    // Hijri year, e.g. 1447; 0 matches every year
+	HijriMonth   int    // Hijri month, 1 to 12, e.g. 9 for Ramadan; 0 matches every month
+}
+
+// where returns the SQL condition and arguments of the filter
+func (f Filter) where() (string, []interface{}) {
+	conditions := []string{"1 = 1"}
+	var args []interface{}
+	add := func(condition string, value string) {
+		if value != "" {
+			conditions = append(conditions, condition)
+			args = append(args, value)
+		}
+	}
+	add("organization = ?", f.Organization)
+	add("type = ?", f.Type)
+	add("status = ?", f.Status)
+	add("timestamp >= ?", f.From)
+	add("timestamp < ?", f.To)
+	if f.HijriYear != 0 {
+		conditions = append(conditions, "hijri_year = ?")
+		args = append(args, f.HijriYear)
+	}
+	if f.HijriMonth != 0 {
+		conditions = append(conditions, "hijri_month = ?")
+		args = append(args, f.HijriMonth)
+	}
+	return strings.Join(conditions, " AND "), args
+}
+
+// Zakat returns a zakat transaction, or false if it is not in the read model
+func (s *Store) Zakat(id string) (client.Zakat, bool, error) {
+	zakats, err := s.queryZakat("SELECT "+zakatColumns+" FROM zakat WHERE id = ?", id)
+	if err != nil || len(zakats) == 0 {
+		return client.Zakat{}, false, err
+	}
+	return zakats[0], true, nil
+}
+
+// ListZakat returns the zakat transactions matching a filter, oldest first. A
+// positive limit caps the number returned.
+func (s *Store) ListZakat(filter Filter, limit int) ([]client.Zakat, error) {
+	where, args := filter.where()
+	query := "SELECT " + zakatColumns + " FROM zakat WHERE " + where + " ORDER BY timestamp, id"
+	if limit > 0 {
+		query += " LIMIT ?"
+		args = append(args, limit)
+	}
+	return s.queryZakat(query, args...)
+}
+
+func (s *Store) queryZakat(query string, args ...interface{}) ([]client.Zakat, error) {
+	rows, err := s.db.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	zakats := []client.Zakat{}
+	for rows.Next() {
+		var zakat client.Zakat
+		var distributions string
+		var hijri client.HijriDate
+		var conversion, restriction string
+		err := rows.Scan(&zakat.ID, &zakat.Muzakki, &zakat.Amount, &zakat.Unit, &zakat.Value, &zakat.Type, &zakat.Status, &zakat.Organization,
+			&zakat.Timestamp, &zakat.Mustahik, &zakat.Distribution, &zakat.DistributedAt, &distributions,
+			&hijri.Year, &hijri.Month, &hijri.Day, &zakat.Jiwa, &conversion, &restriction, &zakat.PledgeID, &zakat.DonorCommitment)
+		if err != nil {
+			return nil, err
+		}
+		if hijri.Year != 0 {
+			zakat.Hijri = &hijri
+		}
+		if err := json.Unmarshal([]byte(distributions), &zakat.Distributions); err != nil {
+			return nil, err
+		}
+		if conversion != "" {
+			if err := json.Unmarshal([]byte(conversion), &zakat.Conversion); err != nil {
+				return nil, err
+			}
+		}
+		if restriction != "" {
+			r, err := client.ParseRestriction(restriction)
+			if err != nil {
+				return nil, err
+			}
+			zakat.Restriction = &r
+		}
+		zakats = append(zakats, zakat)
+	}
+	return zakats, rows.Err()
+}
+
+// SummaryRow totals the zakat transactions of one group
+type SummaryRow struct {
+	Group       string  `json:"group"`
+	Donations   int     `json:"donations"`
+	Muzakki     int     `json:"muzakki"`     // Distinct donors
+	Jiwa        int     `json:"jiwa"`        // Persons covered by fitrah
+	Amount      float64 `json:"amount"`      // Collected, in IDR, with in-kind donations at their valuation
+	Distributed float64 `json:"distributed"` // Distributed so far, in IDR
+}
+
+// summaryGroups maps the groupings of Summary to their SQL expressions
+var summaryGroups = map[string]string{
+	"organization": "organization",
+	"type":         "type",
+	"status":       "status",
+	"unit":         "CASE unit WHEN '' THEN 'IDR' ELSE unit END",
+	"currency":     "CASE conversion WHEN '' THEN 'IDR' ELSE json_extract(conversion, '$.currency') END",
+	"month":        "substr(timestamp, 1, 7)",
+	"year":         "substr(timestamp, 1, 4)",
+	// Hijri periods as the chaincode's reports name them, e.g. 1447H09 and 1447H;
+	// transactions without a Hijri date are grouped under ""
+	"hijri_month": "CASE hijri_year WHEN 0 THEN '' ELSE printf('%04dH%02d', hijri_year, hijri_month) END",
+	"hijri_year":  "CASE hijri_year WHEN 0 THEN '' ELSE printf('%04dH', hijri_year) END",
+	// Restrictions as kind:value, e.g. region:Lumajang; unrestricted donations are
+	// grouped under ""
+	"restriction": "restriction",
+}
+
+// Summary totals the zakat transactions matching a filter by organization, type,
+// status, unit, currency, restriction, month, year, Hijri month or Hijri year
+func (s *Store) Summary(groupBy string, filter Filter) ([]SummaryRow, error) {
+	group, ok := summaryGroups[groupBy]
+	if !ok {
+		return nil, fmt.Errorf("unknown grouping %q, expected organization, type, status, unit, currency, restriction, month, year, hijri_month or hijri_year", groupBy)
+	}
+	where, args := filter.where()
+	rows, err := s.db.Query(`SELECT `+group+`, COUNT(*), COUNT(DISTINCT `+distinctDonor+`), SUM(`+coveredJiwa+`), SUM(`+idrAmount+`), SUM(`+idrDistributed+`)
+		FROM zakat WHERE `+where+` GROUP BY 1 ORDER BY 1`, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	summary := []SummaryRow{}
+	for rows.Next() {
+		var row SummaryRow
+		if err := rows.Scan(&row.Group, &row.Donations, &row.Muzakki, &row.Jiwa, &row.Amount, &row.Distributed); err != nil {
+			return nil, err
+		}
+		summary = append(summary, row)
+	}
+	return summary, rows.Err()
+}
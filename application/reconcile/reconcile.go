@@ -0,0 +1,223 @@
+// Package reconcile matches the credits of a bank statement or QRIS settlement
+// report with the donations recorded on the ledger, by their payment reference,
+// and reports the entries found on one side only.
+package reconcile
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io"
+	"math"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/izzuddinafif/fabric-zakat/application/client"
+	"github.com/izzuddinafif/fabric-zakat/application/output"
+	"github.com/izzuddinafif/fabric-zakat/application/spreadsheet"
+)
+
+// Statuses of a reconciled entry
+const (
+	StatusMatched        = "matched"         // Credit and donation agree
+	StatusAmountMismatch = "amount_mismatch" // Same reference, different amounts
+	StatusNotRecorded    = "not_recorded"    // Credit with no donation on the ledger
+	StatusNotReceived    = "not_received"    // Donation whose reference is not on the statement
+	StatusDuplicate      = "duplicate"       // Reference credited more than once
+	StatusInvalid        = "invalid"         // Statement row that could not be read
+	StatusUnreferenced   = "unreferenced"    // Donation recorded without a payment reference
+)
+
+// wib is the time zone bank statements are dated in
+var wib = time.FixedZone("WIB", 7*60*60)
+
+// Scope selects the ledger donations a statement is expected to cover
+type Scope struct {
+	Organization string // Donations of this organization, all if empty
+	From         string // First day, YYYY-MM-DD; the statement's first day if empty
+	To           string // Last day, YYYY-MM-DD; the statement's last day if empty
+	Unreferenced bool   // Also report donations recorded without a payment reference
+}
+
+// Entry is one line of a reconciliation report
+type Entry struct {
+	Status      string         `json:"status"`
+	Line        int            `json:"line,omitempty"`        // Statement line, 0 for ledger-only entries
+	Date        string         `json:"date"`                  // YYYY-MM-DD in WIB
+	Amount      float64        `json:"amount,omitempty"`      // Credited amount
+	Payment     client.Payment `json:"payment"`               // Payment reference
+	ZakatID     string         `json:"zakatId,omitempty"`     // Donation the payment is recorded for
+	ZakatAmount float64        `json:"zakatAmount,omitempty"` // Recorded amount
+	Detail      string         `json:"detail,omitempty"`      // Statement description, or why the row is invalid
+}
+
+// Report is the outcome of a reconciliation: the statement's entries in file
+// order, followed by the ledger donations that are not on the statement
+type Report struct {
+	From    string  `json:"from"`
+	To      string  `json:"to"`
+	Entries []Entry `json:"entries"`
+}
+
+// Unmatched counts the entries that need a look from staff
+func (r Report) Unmatched() int {
+	count := 0
+	for _, entry := range r.Entries {
+		if entry.Status != StatusMatched && entry.Status != StatusUnreferenced {
+			count++
+		}
+	}
+	return count
+}
+
+// Counts returns the number of entries of each status
+func (r Report) Counts() map[string]int {
+	counts := map[string]int{}
+	for _, entry := range r.Entries {
+		counts[entry.Status]++
+	}
+	return counts
+}
+
+// Reconcile matches statement credits with ledger donations on their normalized
+// channel, bank and reference. Donations that no credit matches are reported when
+// they were paid to a bank on the statement and fall in the scope.
+func Reconcile(credits []spreadsheet.StatementEntry, zakats []client.Zakat, scope Scope) (Report, error) {
+	byPayment := map[client.Payment]client.Zakat{}
+	for _, zakat := range zakats {
+		if zakat.Payment != nil {
+			byPayment[zakat.Payment.Normalize()] = zakat
+		}
+	}
+
+	report := Report{From: scope.From, To: scope.To}
+	accounts := map[[2]string]bool{}
+	seen := map[client.Payment]int{}
+	for _, credit := range credits {
+		entry := Entry{Line: credit.Line, Date: credit.Date, Amount: credit.Amount, Payment: credit.Payment, Detail: credit.Description}
+		if credit.Err != nil {
+			entry.Status = StatusInvalid
+			entry.Detail = credit.Err.Error()
+			report.Entries = append(report.Entries, entry)
+			continue
+		}
+		accounts[[2]string{credit.Payment.Channel, credit.Payment.Bank}] = true
+		if scope.From == "" && (report.From == "" || credit.Date < report.From) {
+			report.From = credit.Date
+		}
+		if scope.To == "" && credit.Date > report.To {
+			report.To = credit.Date
+		}
+
+		zakat, recorded := byPayment[credit.Payment]
+		if recorded {
+			entry.ZakatID = zakat.ID
+			entry.ZakatAmount = zakat.Amount
+		}
+		switch line, duplicate := seen[credit.Payment]; {
+		case duplicate:
+			entry.Status = StatusDuplicate
+			entry.Detail = fmt.Sprintf("also credited on line %d", line)
+		case !recorded:
+			entry.Status = StatusNotRecorded
+		case math.Abs(zakat.Amount-credit.Amount) >= 0.005:
+			entry.Status = StatusAmountMismatch
+		default:
+			entry.Status = StatusMatched
+		}
+		if _, ok := seen[credit.Payment]; !ok {
+			seen[credit.Payment] = credit.Line
+		}
+		report.Entries = append(report.Entries, entry)
+	}
+
+	// Donations the statement should have credited
+	var missing []Entry
+	for _, zakat := range zakats {
+		if scope.Organization != "" && zakat.Organization != scope.Organization {
+			continue
+		}
+		date, err := dateOf(zakat.Timestamp)
+		if err != nil {
+			return Report{}, fmt.Errorf("zakat %s: %w", zakat.ID, err)
+		}
+		if date < report.From || date > report.To {
+			continue
+		}
+		entry := Entry{Date: date, ZakatID: zakat.ID, ZakatAmount: zakat.Amount}
+		switch {
+		case !client.IsIDR(zakat.Unit):
+			// Donations in kind never pass through a bank account
+			continue
+		case zakat.Payment == nil:
+			if !scope.Unreferenced {
+				continue
+			}
+			entry.Status = StatusUnreferenced
+		default:
+			payment := zakat.Payment.Normalize()
+			if _, credited := seen[payment]; credited || !accounts[[2]string{payment.Channel, payment.Bank}] {
+				continue
+			}
+			entry.Status = StatusNotReceived
+			entry.Payment = payment
+		}
+		missing = append(missing, entry)
+	}
+	sort.SliceStable(missing, func(i, j int) bool {
+		if missing[i].Date != missing[j].Date {
+			return missing[i].Date < missing[j].Date
+		}
+		return missing[i].ZakatID < missing[j].ZakatID
+	})
+	report.Entries = append(report.Entries, missing...)
+	return report, nil
+}
+
+// dateOf returns the WIB date of a ledger timestamp
+func dateOf(timestamp string) (string, error) {
+	t, err := time.Parse(time.RFC3339, timestamp)
+	if err != nil {
+		return "", fmt.Errorf("invalid timestamp %q", timestamp)
+	}
+	return t.In(wib).Format("2006-01-02"), nil
+}
+
+var header = []string{"status", "line", "date", "amount", "channel", "bank", "reference", "zakatId", "zakatAmount", "detail"}
+
+// row returns the cells of an entry, leaving out the amounts and line it does not have
+func (e Entry) row(amount func(float64) string) []string {
+	line, statementAmount, zakatAmount := "", "", ""
+	if e.Line > 0 {
+		line = strconv.Itoa(e.Line)
+		statementAmount = amount(e.Amount)
+	}
+	if e.ZakatID != "" {
+		zakatAmount = amount(e.ZakatAmount)
+	}
+	return []string{e.Status, line, e.Date, statementAmount, e.Payment.Channel, e.Payment.Bank, e.Payment.Reference, e.ZakatID, zakatAmount, e.Detail}
+}
+
+// Table renders a report for the terminal
+func Table(report Report) output.Table {
+	table := output.Table{Header: []string{"STATUS", "LINE", "DATE", "AMOUNT", "CHANNEL", "BANK", "REFERENCE", "ZAKAT", "RECORDED", "DETAIL"}}
+	for _, entry := range report.Entries {
+		table.Rows = append(table.Rows, entry.row(output.Amount))
+	}
+	return table
+}
+
+// WriteCSV writes a report as CSV with a header row, for review in a spreadsheet
+func WriteCSV(w io.Writer, report Report) error {
+	writer := csv.NewWriter(w)
+	rows := [][]string{header}
+	for _, entry := range report.Entries {
+		rows = append(rows, entry.row(func(amount float64) string {
+			return strconv.FormatFloat(amount, 'f', 2, 64)
+		}))
+	}
+	if err := writer.WriteAll(rows); err != nil {
+		return fmt.Errorf("failed to write CSV: %w", err)
+	}
+	return nil
+}
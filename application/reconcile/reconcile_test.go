@@ -0,0 +1,82 @@
+package reconcile
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+
+	"github.com/izzuddinafif/fabric-zakat/application/client"
+	"github.com/izzuddinafif/fabric-zakat/application/spreadsheet"
+	"github.com/stretchr/testify/require"
+)
+
+func transfer(reference string) client.Payment {
+	return client.Payment{Channel: client.PaymentBankTransfer, Bank: "BSI", Reference: reference}
+}
+
+func paid(id string, amount float64, timestamp string, payment *client.Payment) client.Zakat {
+	return client.Zakat{ID: id, Muzakki: "Ahmad", Amount: amount, Type: "maal", Organization: "YDSF Malang", Timestamp: timestamp, Payment: payment}
+}
+
+func TestReconcile(t *testing.T) {
+	qris := client.Payment{Channel: client.PaymentQRIS, Bank: "BSI", Reference: "240330000001"}
+	zakats := []client.Zakat{
+		paid("ZKT-YDSF-MLG-202403-0001", 1250000, "2024-03-30T08:00:00Z", &client.Payment{Channel: "bank_transfer", Bank: "bsi", Reference: "FT001"}),
+		paid("ZKT-YDSF-MLG-202403-0002", 45000, "2024-03-30T09:00:00Z", &client.Payment{Channel: "bank_transfer", Bank: "BSI", Reference: "FT002"}),
+		// Evening of the 31st in UTC is the 1st of April in WIB, on the statement
+		paid("ZKT-YDSF-MLG-202403-0003", 90000, "2024-03-31T18:00:00Z", &client.Payment{Channel: "bank_transfer", Bank: "BSI", Reference: "FT003"}),
+		paid("ZKT-YDSF-MLG-202403-0004", 45000, "2024-03-31T02:00:00Z", nil),
+		// Rice never passes through the bank
+		{ID: "ZKT-YDSF-MLG-202403-0006", Muzakki: "Budi", Amount: 2.5, Unit: "kg_beras", Type: "fitrah", Organization: "YDSF Malang", Timestamp: "2024-03-31T03:00:00Z"},
+		// Another account, and outside the statement's period
+		paid("ZKT-YDSF-MLG-202403-0005", 45000, "2024-03-30T10:00:00Z", &qris),
+		paid("ZKT-YDSF-MLG-202402-0001", 45000, "2024-02-10T10:00:00Z", &client.Payment{Channel: "bank_transfer", Bank: "BSI", Reference: "FT000"}),
+	}
+	credits := []spreadsheet.StatementEntry{
+		{Line: 2, Date: "2024-03-30", Amount: 1250000, Payment: transfer("FT001"), Description: "TRF AHMAD"},
+		{Line: 3, Date: "2024-03-30", Amount: 1250000, Payment: transfer("FT001")},
+		{Line: 4, Date: "2024-03-31", Amount: 100000, Payment: transfer("FT004")},
+		{Line: 5, Date: "2024-04-01", Amount: 95000, Payment: transfer("FT003")},
+		{Line: 6, Err: errors.New(`invalid amount "abc"`)},
+	}
+
+	report, err := Reconcile(credits, zakats, Scope{Organization: "YDSF Malang", Unreferenced: true})
+	require.NoError(t, err)
+	require.Equal(t, "2024-03-30", report.From)
+	require.Equal(t, "2024-04-01", report.To)
+	require.Equal(t, []Entry{
+		{Status: StatusMatched, Line: 2, Date: "2024-03-30", Amount: 1250000, Payment: transfer("FT001"), ZakatID: "ZKT-YDSF-MLG-202403-0001", ZakatAmount: 1250000, Detail: "TRF AHMAD"},
+		{Status: StatusDuplicate, Line: 3, Date: "2024-03-30", Amount: 1250000, Payment: transfer("FT001"), ZakatID: "ZKT-YDSF-MLG-202403-0001", ZakatAmount: 1250000, Detail: "also credited on line 2"},
+		{Status: StatusNotRecorded, Line: 4, Date: "2024-03-31", Amount: 100000, Payment: transfer("FT004")},
+		{Status: StatusAmountMismatch, Line: 5, Date: "2024-04-01", Amount: 95000, Payment: transfer("FT003"), ZakatID: "ZKT-YDSF-MLG-202403-0003", ZakatAmount: 90000},
+		{Status: StatusInvalid, Line: 6, Detail: `invalid amount "abc"`},
+		{Status: StatusNotReceived, Date: "2024-03-30", Payment: transfer("FT002"), ZakatID: "ZKT-YDSF-MLG-202403-0002", ZakatAmount: 45000},
+		{Status: StatusUnreferenced, Date: "2024-03-31", ZakatID: "ZKT-YDSF-MLG-202403-0004", ZakatAmount: 45000},
+	}, report.Entries)
+	require.Equal(t, 5, report.Unmatched())
+	require.Equal(t, 1, report.Counts()[StatusMatched])
+
+	var buf bytes.Buffer
+	require.NoError(t, WriteCSV(&buf, report))
+	require.Contains(t, buf.String(), "status,line,date,amount,channel,bank,reference,zakatId,zakatAmount,detail\n"+
+		"matched,2,2024-03-30,1250000.00,bank_transfer,BSI,FT001,ZKT-YDSF-MLG-202403-0001,1250000.00,TRF AHMAD\n")
+	require.Contains(t, buf.String(), "not_received,,2024-03-30,,bank_transfer,BSI,FT002,ZKT-YDSF-MLG-202403-0002,45000.00,\n")
+}
+
+func TestReconcileScope(t *testing.T) {
+	zakats := []client.Zakat{paid("ZKT-YDSF-MLG-202403-0002", 45000, "2024-03-28T09:00:00Z", &client.Payment{Channel: "bank_transfer", Bank: "BSI", Reference: "FT002"})}
+	credits := []spreadsheet.StatementEntry{{Line: 2, Date: "2024-03-30", Amount: 1250000, Payment: transfer("FT001")}}
+
+	report, err := Reconcile(credits, zakats, Scope{})
+	require.NoError(t, err)
+	require.Len(t, report.Entries, 1)
+
+	report, err = Reconcile(credits, zakats, Scope{From: "2024-03-01", To: "2024-03-31"})
+	require.NoError(t, err)
+	require.Len(t, report.Entries, 2)
+	require.Equal(t, StatusNotReceived, report.Entries[1].Status)
+
+	report, err = Reconcile(credits, zakats, Scope{Organization: "YDSF Jatim", From: "2024-03-01", To: "2024-03-31"})
+	require.NoError(t, err)
+	require.Len(t, report.Entries, 1)
+}
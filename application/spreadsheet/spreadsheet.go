@@ -0,0 +1,504 @@
+// Package spreadsheet maps CSV files kept by branch staff to donations and
+// distributions, and writes ledger data back to CSV. Files saved by Excel are
+// accepted: a UTF-8 byte order mark is skipped and semicolon-separated files are
+// detected from the header.
+package spreadsheet
+
+import (
+	"bytes"
+	"encoding/csv"
+	"fmt"
+	"io"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/izzuddinafif/fabric-zakat/application/client"
+)
+
+// field is a value read from a column, found by its name or one of its aliases
+type field struct {
+	name     string
+	aliases  []string
+	optional bool
+}
+
+var zakatFields = []field{
+	{name: "ID", aliases: []string{"zakatid", "id"}},
+	{name: "muzakki", aliases: []string{"donor", "nama", "name"}},
+	{name: "amount", aliases: []string{"jumlah", "nominal"}},
+	{name: "unit", aliases: []string{"satuan"}, optional: true},
+	{name: "type", aliases: []string{"jenis", "zakattype"}},
+	{name: "organization", aliases: []string{"organisasi", "org"}},
+	{name: "timestamp", aliases: []string{"date", "tanggal", "waktu"}},
+	{name: "channel", aliases: []string{"metode", "saluran"}, optional: true},
+	{name: "bank", optional: true},
+	{name: "reference", aliases: []string{"referensi", "noreferensi", "ref"}, optional: true},
+	{name: "jiwa", aliases: []string{"jumlahjiwa"}, optional: true},
+	{name: "persons", aliases: []string{"tanggungan", "namajiwa"}, optional: true},
+	{name: "currency", aliases: []string{"matauang", "valuta"}, optional: true},
+	{name: "originalAmount", aliases: []string{"jumlahvaluta", "nominalvaluta"}, optional: true},
+	{name: "rate", aliases: []string{"kurs"}, optional: true},
+	{name: "rateSource", aliases: []string{"sumberkurs"}, optional: true},
+	{name: "restriction", aliases: []string{"peruntukan", "restrictedto"}, optional: true},
+	{name: "pledgeId", aliases: []string{"pledge", "ikrar"}, optional: true},
+	{name: "donorCommitment", aliases: []string{"commitment", "komitmen"}, optional: true},
+}
+
+var distributionFields = []field{
+	{name: "ID", aliases: []string{"distributionid", "id"}},
+	{name: "poolId", aliases: []string{"pool"}},
+	{name: "programId", aliases: []string{"program"}, optional: true},
+	{name: "mustahik", aliases: []string{"penerima", "recipient"}},
+	{name: "amount", aliases: []string{"jumlah", "nominal"}},
+	{name: "timestamp", aliases: []string{"date", "tanggal", "waktu"}},
+	{name: "region", aliases: []string{"wilayah", "daerah"}, optional: true},
+	{name: "asnaf", aliases: []string{"golongan"}, optional: true},
+}
+
+var statementFields = []field{
+	{name: "date", aliases: []string{"tanggal", "tgl", "transactiondate", "tanggaltransaksi"}},
+	{name: "amount", aliases: []string{"credit", "kredit", "jumlah", "nominal", "mutasi"}},
+	{name: "reference", aliases: []string{"referensi", "noreferensi", "ref", "rrn"}},
+	{name: "description", aliases: []string{"keterangan", "berita", "remark"}, optional: true},
+	{name: "channel", aliases: []string{"metode", "saluran"}, optional: true},
+	{name: "bank", optional: true},
+}
+
+// Mapping names the column that holds a field, overriding the default names and
+// aliases, e.g. {"muzakki": "Nama Donatur"}
+type Mapping map[string]string
+
+// ParseMapping parses a mapping of the form field=column,field=column
+func ParseMapping(s string) (Mapping, error) {
+	mapping := Mapping{}
+	if s == "" {
+		return mapping, nil
+	}
+	for _, pair := range strings.Split(s, ",") {
+		parts := strings.SplitN(pair, "=", 2)
+		if len(parts) != 2 || parts[0] == "" || parts[1] == "" {
+			return nil, fmt.Errorf("invalid column mapping %q, expected field=column", pair)
+		}
+		mapping[strings.TrimSpace(parts[0])] = strings.TrimSpace(parts[1])
+	}
+	return mapping, nil
+}
+
+// ZakatRow is a donation read from a CSV row
+type ZakatRow struct {
+	Line  int               // Line of the row in the file
+	Input client.ZakatInput // Donation, valid only if Err is nil
+	Err   error             // Why the row could not be read
+}
+
+// DistributionRow is a distribution read from a CSV row
+type DistributionRow struct {
+	Line  int
+	Input client.DistributionInput
+	Err   error
+}
+
+// ReadZakat reads donations from a CSV file with a header row
+func ReadZakat(r io.Reader, mapping Mapping) ([]ZakatRow, error) {
+	records, columns, err := read(r, zakatFields, mapping)
+	if err != nil {
+		return nil, err
+	}
+
+	rows := make([]ZakatRow, len(records))
+	for i, record := range records {
+		value := func(name string) string { return valueOf(record, columns, name) }
+		rows[i] = ZakatRow{
+			Line: i + 2,
+			Input: client.ZakatInput{
+				ID:              value("ID"),
+				Muzakki:         value("muzakki"),
+				Unit:            unitOf(value("unit")),
+				Type:            strings.ToLower(value("type")),
+				Organization:    value("organization"),
+				Timestamp:       value("timestamp"),
+				Persons:         personsOf(value("persons")),
+				PledgeID:        value("pledgeId"),
+				DonorCommitment: value("donorCommitment"),
+			},
+		}
+		if value("channel") != "" || value("bank") != "" || value("reference") != "" {
+			payment := client.Payment{Channel: value("channel"), Bank: value("bank"), Reference: value("reference")}.Normalize()
+			rows[i].Input.Payment = &payment
+		}
+		rows[i].Input.Amount, rows[i].Err = ParseAmount(value("amount"))
+		if jiwa := value("jiwa"); jiwa != "" && rows[i].Err == nil {
+			if rows[i].Input.Jiwa, rows[i].Err = strconv.Atoi(jiwa); rows[i].Err != nil {
+				rows[i].Err = fmt.Errorf("invalid jiwa %q", jiwa)
+			}
+		}
+		if currency := value("currency"); !client.IsIDR(strings.ToUpper(currency)) && rows[i].Err == nil {
+			rows[i].Input.Conversion, rows[i].Err = conversionOf(currency, value("originalAmount"), value("amount"), value("rate"), value("rateSource"))
+			if rows[i].Err == nil {
+				rows[i].Input.Amount = rows[i].Input.Conversion.IDRAmount()
+			}
+		}
+		if restriction := value("restriction"); restriction != "" && rows[i].Err == nil {
+			var r client.Restriction
+			if r, rows[i].Err = client.ParseRestriction(restriction); rows[i].Err == nil {
+				rows[i].Input.Restriction = &r
+			}
+		}
+	}
+	return rows, nil
+}
+
+// ReadDistributions reads distributions from a CSV file with a header row
+func ReadDistributions(r io.Reader, mapping Mapping) ([]DistributionRow, error) {
+	records, columns, err := read(r, distributionFields, mapping)
+	if err != nil {
+		return nil, err
+	}
+
+	rows := make([]DistributionRow, len(records))
+	for i, record := range records {
+		value := func(name string) string { return valueOf(record, columns, name) }
+		rows[i] = DistributionRow{
+			Line: i + 2,
+			Input: client.DistributionInput{
+				ID:        value("ID"),
+				PoolID:    value("poolId"),
+				ProgramID: value("programId"),
+				Mustahik:  value("mustahik"),
+				Timestamp: value("timestamp"),
+				Region:    value("region"),
+				Asnaf:     value("asnaf"),
+			},
+		}
+		rows[i].Input.Amount, rows[i].Err = ParseAmount(value("amount"))
+	}
+	return rows, nil
+}
+
+// StatementEntry is a credit read from a bank statement or QRIS settlement row
+type StatementEntry struct {
+	Line        int            // Line of the row in the file
+	Date        string         // Date of the credit as YYYY-MM-DD
+	Amount      float64        // Credited amount in IDR
+	Payment     client.Payment // Normalized payment reference
+	Description string         // Free text of the row, e.g. the transfer message
+	Err         error          // Why the row could not be read
+}
+
+// ReadStatement reads the credits of a bank statement or QRIS settlement report.
+// The channel and bank are taken from their columns when the file has them and
+// from defaults otherwise. Rows without a positive amount, such as debits in a
+// statement with separate debit and credit columns, are skipped.
+func ReadStatement(r io.Reader, mapping Mapping, defaults client.Payment) ([]StatementEntry, error) {
+	records, columns, err := read(r, statementFields, mapping)
+	if err != nil {
+		return nil, err
+	}
+
+	var entries []StatementEntry
+	for i, record := range records {
+		value := func(name string) string { return valueOf(record, columns, name) }
+		amount := value("amount")
+		if upper := strings.ToUpper(amount); strings.HasSuffix(upper, "DB") || strings.HasPrefix(amount, "-") {
+			continue
+		}
+		if upper := strings.ToUpper(amount); strings.HasSuffix(upper, "CR") {
+			amount = strings.TrimSpace(amount[:len(amount)-2])
+		}
+		if amount == "" {
+			continue
+		}
+
+		entry := StatementEntry{
+			Line:        i + 2,
+			Payment:     client.Payment{Channel: defaults.Channel, Bank: defaults.Bank, Reference: value("reference")},
+			Description: value("description"),
+		}
+		if channel := value("channel"); channel != "" {
+			entry.Payment.Channel = channel
+		}
+		if bank := value("bank"); bank != "" {
+			entry.Payment.Bank = bank
+		}
+		entry.Payment = entry.Payment.Normalize()
+
+		entry.Amount, entry.Err = ParseAmount(amount)
+		if entry.Err == nil && entry.Amount <= 0 {
+			continue
+		}
+		if entry.Err == nil {
+			entry.Date, entry.Err = ParseDate(value("date"))
+		}
+		if entry.Err == nil && entry.Payment.Reference == "" {
+			entry.Err = fmt.Errorf("the credit has no reference")
+		}
+		entries = append(entries, entry)
+	}
+	return entries, nil
+}
+
+// dateLayouts are the date formats found in Indonesian bank statements, day first
+var dateLayouts = []string{
+	"2006-01-02",
+	"02/01/2006",
+	"2/1/2006",
+	"02-01-2006",
+	"02/01/06",
+	"02 Jan 2006",
+	"2006-01-02 15:04:05",
+	"02/01/2006 15:04:05",
+	"02/01/2006 15:04",
+	time.RFC3339,
+}
+
+// ParseDate parses a statement date and returns it as YYYY-MM-DD
+func ParseDate(s string) (string, error) {
+	for _, layout := range dateLayouts {
+		if t, err := time.Parse(layout, strings.TrimSpace(s)); err == nil {
+			return t.Format("2006-01-02"), nil
+		}
+	}
+	return "", fmt.Errorf("invalid date %q", s)
+}
+
+// read returns the data rows of a CSV file and the column index of each field found
+func read(r io.Reader, fields []field, mapping Mapping) ([][]string, map[string]int, error) {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return nil, nil, err
+	}
+	data = bytes.TrimPrefix(data, []byte("\xef\xbb\xbf"))
+
+	reader := csv.NewReader(bytes.NewReader(data))
+	firstLine, _, _ := bytes.Cut(data, []byte("\n"))
+	if bytes.Count(firstLine, []byte(";")) > bytes.Count(firstLine, []byte(",")) {
+		reader.Comma = ';'
+	}
+	reader.FieldsPerRecord = -1
+	reader.TrimLeadingSpace = true
+
+	records, err := reader.ReadAll()
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to read CSV: %w", err)
+	}
+	if len(records) == 0 {
+		return nil, nil, fmt.Errorf("the file is empty")
+	}
+
+	columns, err := mapColumns(records[0], fields, mapping)
+	if err != nil {
+		return nil, nil, err
+	}
+	return records[1:], columns, nil
+}
+
+// mapColumns finds the column of every field in the header row
+func mapColumns(header []string, fields []field, mapping Mapping) (map[string]int, error) {
+	index := map[string]int{}
+	for i, column := range header {
+		index[normalize(column)] = i
+	}
+
+	known := map[string]bool{}
+	columns := map[string]int{}
+	for _, f := range fields {
+		known[f.name] = true
+		names := append([]string{f.name}, f.aliases...)
+		if column, ok := mapping[f.name]; ok {
+			names = []string{column}
+		}
+		found := false
+		for _, name := range names {
+			if i, ok := index[normalize(name)]; ok {
+				columns[f.name] = i
+				found = true
+				break
+			}
+		}
+		if !found && !f.optional {
+			return nil, fmt.Errorf("no column for %s, expected one of %v or a mapping %s=<column>", f.name, names, f.name)
+		}
+	}
+	for name := range mapping {
+		if !known[name] {
+			return nil, fmt.Errorf("unknown field %q in column mapping", name)
+		}
+	}
+	return columns, nil
+}
+
+// normalize makes column names comparable regardless of case, spaces and underscores
+func normalize(column string) string {
+	return strings.NewReplacer(" ", "", "_", "", "-", "").Replace(strings.ToLower(strings.TrimSpace(column)))
+}
+
+// valueOf returns the trimmed value of a field in a record, empty if the column is absent
+func valueOf(record []string, columns map[string]int, name string) string {
+	i, ok := columns[name]
+	if !ok || i >= len(record) {
+		return ""
+	}
+	return strings.TrimSpace(record[i])
+}
+
+var (
+	indonesianAmount = regexp.MustCompile(`^\d{1,3}(\.\d{3})+(,\d+)?$`)
+	englishAmount    = regexp.MustCompile(`^\d{1,3}(,\d{3})+(\.\d+)?$`)
+	decimalComma     = regexp.MustCompile(`^\d+,\d{1,2}$`)
+)
+
+// unitOf returns the unit of a row, empty for rupiah so that rupiah rows read as before
+func unitOf(s string) string {
+	if unit := client.NormalizeUnit(s); !client.IsIDR(unit) {
+		return unit
+	}
+	return ""
+}
+
+// personsOf splits the names of the persons a fitrah donation covers, separated by
+// semicolons or commas
+func personsOf(s string) []string {
+	var persons []string
+	for _, person := range strings.FieldsFunc(s, func(r rune) bool { return r == ';' || r == ',' }) {
+		if person = strings.TrimSpace(person); person != "" {
+			persons = append(persons, person)
+		}
+	}
+	return persons
+}
+
+// conversionOf reads the conversion of a row in a foreign currency. The amount in the
+// currency is the originalAmount column of an export, or else the amount column, and
+// the rate may be written like an amount, e.g. 3.350,55.
+func conversionOf(currency string, originalAmount string, amount string, rate string, source string) (*client.Conversion, error) {
+	if originalAmount == "" {
+		originalAmount = amount
+	}
+	value, err := ParseAmount(originalAmount)
+	if err != nil {
+		return nil, err
+	}
+	perUnit, err := ParseAmount(rate)
+	if err != nil {
+		return nil, fmt.Errorf("invalid rate %q", rate)
+	}
+	conversion := client.Conversion{Currency: currency, Amount: value, Rate: perUnit, Source: source}.Normalize()
+	return &conversion, nil
+}
+
+// ParseAmount parses an IDR amount or in-kind quantity as written in spreadsheets:
+// plain numbers, an optional "Rp" prefix, Indonesian (1.000.000,50) or English
+// (1,000,000.50) thousands separators, and a decimal comma with one or two digits
+// (2,5 kg)
+func ParseAmount(s string) (float64, error) {
+	amount := strings.TrimSpace(s)
+	for _, prefix := range []string{"Rp.", "Rp", "IDR"} {
+		amount = strings.TrimSpace(strings.TrimPrefix(amount, prefix))
+	}
+	amount = strings.ReplaceAll(amount, " ", "")
+
+	switch {
+	case indonesianAmount.MatchString(amount):
+		amount = strings.ReplaceAll(strings.ReplaceAll(amount, ".", ""), ",", ".")
+	case englishAmount.MatchString(amount):
+		amount = strings.ReplaceAll(amount, ",", "")
+	case decimalComma.MatchString(amount):
+		amount = strings.ReplaceAll(amount, ",", ".")
+	}
+
+	value, err := strconv.ParseFloat(amount, 64)
+	if err != nil {
+		return 0, fmt.Errorf("invalid amount %q", s)
+	}
+	return value, nil
+}
+
+// formatAmount formats an amount for export
+func formatAmount(amount float64) string {
+	return strconv.FormatFloat(amount, 'f', 2, 64)
+}
+
+// WriteZakat writes donations as CSV with a header row of their field names, which
+// ReadZakat accepts back
+func WriteZakat(w io.Writer, zakats []client.Zakat) error {
+	writer := csv.NewWriter(w)
+	rows := [][]string{{"ID", "muzakki", "amount", "unit", "value", "type", "status", "organization", "timestamp", "distribution", "distributedAt", "distributions", "channel", "bank", "reference", "jiwa", "persons", "currency", "originalAmount", "rate", "rateSource", "restriction", "pledgeId", "donorCommitment"}}
+	for _, zakat := range zakats {
+		var payment client.Payment
+		if zakat.Payment != nil {
+			payment = *zakat.Payment
+		}
+		conversion := make([]string, 4)
+		if c := zakat.Conversion; c != nil {
+			conversion = []string{c.Currency, formatAmount(c.Amount), strconv.FormatFloat(c.Rate, 'f', -1, 64), c.Source}
+		}
+		rows = append(rows, append([]string{
+			zakat.ID,
+			zakat.Muzakki,
+			formatAmount(zakat.Amount),
+			zakat.Unit,
+			formatAmount(zakat.IDRValue()),
+			zakat.Type,
+			zakat.Status,
+			zakat.Organization,
+			zakat.Timestamp,
+			formatAmount(zakat.Distribution),
+			zakat.DistributedAt,
+			strings.Join(zakat.Distributions, ";"),
+			payment.Channel,
+			payment.Bank,
+			payment.Reference,
+			jiwaOf(zakat.Jiwa),
+			strings.Join(zakat.Persons, ";"),
+		}, append(conversion, restrictionOf(zakat.Restriction), zakat.PledgeID, zakat.DonorCommitment)...))
+	}
+	if err := writer.WriteAll(rows); err != nil {
+		return fmt.Errorf("failed to write CSV: %w", err)
+	}
+	return nil
+}
+
+// jiwaOf formats the number of persons a donation covers for export, empty if not given
+func jiwaOf(jiwa int) string {
+	if jiwa == 0 {
+		return ""
+	}
+	return strconv.Itoa(jiwa)
+}
+
+// restrictionOf formats a donation's restriction for export, empty if it has none
+func restrictionOf(restriction *client.Restriction) string {
+	if restriction == nil {
+		return ""
+	}
+	return restriction.String()
+}
+
+// WriteDistributions writes distributions as CSV with a header row of their field
+// names, which ReadDistributions accepts back
+func WriteDistributions(w io.Writer, distributions []client.Distribution) error {
+	writer := csv.NewWriter(w)
+	rows := [][]string{{"ID", "poolId", "programId", "asnaf", "organization", "type", "mustahik", "amount", "unit", "value", "timestamp", "region"}}
+	for _, d := range distributions {
+		rows = append(rows, []string{
+			d.ID,
+			d.PoolID,
+			d.ProgramID,
+			d.Asnaf,
+			d.Organization,
+			d.Type,
+			d.Mustahik,
+			formatAmount(d.Amount),
+			d.Unit,
+			formatAmount(d.IDRValue()),
+			d.Timestamp,
+			d.Region,
+		})
+	}
+	if err := writer.WriteAll(rows); err != nil {
+		return fmt.Errorf("failed to write CSV: %w", err)
+	}
+	return nil
+}
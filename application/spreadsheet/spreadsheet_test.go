@@ -0,0 +1,221 @@
+package spreadsheet
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/izzuddinafif/fabric-zakat/application/client"
+	"github.com/stretchr/testify/require"
+)
+
+func TestReadZakat(t *testing.T) {
+	t.Run("Field names", func(t *testing.T) {
+		rows, err := ReadZakat(strings.NewReader(`ID,muzakki,amount,type,organization,timestamp
+ZKT-YDSF-MLG-202403-0001,Ahmad,45000,fitrah,YDSF Malang,2024-03-30T08:00:00Z
+`), nil)
+		require.NoError(t, err)
+		require.Equal(t, []ZakatRow{{
+			Line:  2,
+			Input: client.ZakatInput{ID: "ZKT-YDSF-MLG-202403-0001", Muzakki: "Ahmad", Amount: 45000, Type: "fitrah", Organization: "YDSF Malang", Timestamp: "2024-03-30T08:00:00Z"},
+		}}, rows)
+	})
+
+	t.Run("Excel export with aliases and mapping", func(t *testing.T) {
+		rows, err := ReadZakat(strings.NewReader("\xef\xbb\xbfNo;Zakat ID;Nama Donatur;Jumlah;Jenis;Organisasi;Tanggal\n"+
+			"1;ZKT-YDSF-MLG-202403-0001;Ahmad;Rp 1.250.000;Maal;YDSF Malang;2024-03-30T08:00:00Z\n"+
+			"2;ZKT-YDSF-MLG-202403-0002;Budi;abc;fitrah;YDSF Malang;2024-03-30T08:05:00Z\n"), Mapping{"muzakki": "Nama Donatur"})
+		require.NoError(t, err)
+		require.Len(t, rows, 2)
+		require.NoError(t, rows[0].Err)
+		require.Equal(t, client.ZakatInput{ID: "ZKT-YDSF-MLG-202403-0001", Muzakki: "Ahmad", Amount: 1250000, Type: "maal", Organization: "YDSF Malang", Timestamp: "2024-03-30T08:00:00Z"}, rows[0].Input)
+		require.Equal(t, 3, rows[1].Line)
+		require.EqualError(t, rows[1].Err, `invalid amount "abc"`)
+	})
+
+	t.Run("In kind", func(t *testing.T) {
+		rows, err := ReadZakat(strings.NewReader("Zakat ID;Nama;Jumlah;Satuan;Jenis;Organisasi;Tanggal\n"+
+			"ZKT-YDSF-MLG-202403-0001;Ahmad;2,5;KG_BERAS;fitrah;YDSF Malang;2024-03-30T08:00:00Z\n"+
+			"ZKT-YDSF-MLG-202403-0002;Budi;45.000;IDR;fitrah;YDSF Malang;2024-03-30T08:05:00Z\n"), nil)
+		require.NoError(t, err)
+		require.NoError(t, rows[0].Err)
+		require.Equal(t, client.ZakatInput{ID: "ZKT-YDSF-MLG-202403-0001", Muzakki: "Ahmad", Amount: 2.5, Unit: "kg_beras", Type: "fitrah", Organization: "YDSF Malang", Timestamp: "2024-03-30T08:00:00Z"}, rows[0].Input)
+		require.Equal(t, "", rows[1].Input.Unit)
+		require.Equal(t, float64(45000), rows[1].Input.Amount)
+	})
+
+	t.Run("Household fitrah", func(t *testing.T) {
+		rows, err := ReadZakat(strings.NewReader("Zakat ID;Nama;Jumlah;Jenis;Organisasi;Tanggal;Jumlah Jiwa;Tanggungan\n"+
+			"ZKT-YDSF-MLG-202403-0001;Ahmad;Rp 135.000;fitrah;YDSF Malang;2024-03-30T08:00:00Z;3;Ahmad, Fatimah, Umar\n"+
+			"ZKT-YDSF-MLG-202403-0002;Budi;90.000;fitrah;YDSF Malang;2024-03-30T08:05:00Z;dua;\n"), nil)
+		require.NoError(t, err)
+		require.NoError(t, rows[0].Err)
+		require.Equal(t, []string{"Ahmad", "Fatimah", "Umar"}, rows[0].Input.Persons)
+		require.Equal(t, 3, rows[0].Input.Jiwa)
+		require.EqualError(t, rows[1].Err, `invalid jiwa "dua"`)
+	})
+
+	t.Run("Foreign currency", func(t *testing.T) {
+		rows, err := ReadZakat(strings.NewReader("Zakat ID;Nama;Jumlah;Mata Uang;Kurs;Sumber Kurs;Jenis;Organisasi;Tanggal\n"+
+			"ZKT-YDSF-MLG-202403-0001;Ahmad;100;myr;3.350,55;BSI kurs beli;maal;YDSF Malang;2024-03-30T08:00:00Z\n"+
+			"ZKT-YDSF-MLG-202403-0002;Budi;45.000;IDR;;;fitrah;YDSF Malang;2024-03-30T08:05:00Z\n"+
+			"ZKT-YDSF-MLG-202403-0003;Citra;100;SAR;;JISDOR;maal;YDSF Malang;2024-03-30T08:10:00Z\n"), nil)
+		require.NoError(t, err)
+		require.NoError(t, rows[0].Err)
+		require.Equal(t, float64(335055), rows[0].Input.Amount)
+		require.Equal(t, &client.Conversion{Currency: "MYR", Amount: 100, Rate: 3350.55, Source: "BSI kurs beli"}, rows[0].Input.Conversion)
+		require.Nil(t, rows[1].Input.Conversion)
+		require.EqualError(t, rows[2].Err, `invalid rate ""`)
+	})
+
+	t.Run("Restriction", func(t *testing.T) {
+		rows, err := ReadZakat(strings.NewReader("ID,muzakki,amount,type,organization,timestamp,peruntukan\n"+
+			"ZKT-YDSF-MLG-202403-0001,Ahmad,1000000,maal,YDSF Malang,2024-03-30T08:00:00Z,Region: Lumajang\n"+
+			"ZKT-YDSF-MLG-202403-0002,Budi,45000,maal,YDSF Malang,2024-03-30T08:05:00Z,\n"+
+			"ZKT-YDSF-MLG-202403-0003,Citra,45000,maal,YDSF Malang,2024-03-30T08:10:00Z,Lumajang\n"), nil)
+		require.NoError(t, err)
+		require.Equal(t, &client.Restriction{Kind: "region", Value: "Lumajang"}, rows[0].Input.Restriction)
+		require.Nil(t, rows[1].Input.Restriction)
+		require.Error(t, rows[2].Err)
+		require.Contains(t, rows[2].Err.Error(), "Must be kind:value")
+	})
+
+	t.Run("Missing column", func(t *testing.T) {
+		_, err := ReadZakat(strings.NewReader("ID,amount\n"), nil)
+		require.Error(t, err)
+		require.Contains(t, err.Error(), "no column for muzakki")
+	})
+
+	t.Run("Unknown mapped field", func(t *testing.T) {
+		_, err := ReadZakat(strings.NewReader("ID,muzakki,amount,type,organization,timestamp\n"), Mapping{"donor": "Nama"})
+		require.Error(t, err)
+		require.Contains(t, err.Error(), `unknown field "donor"`)
+	})
+}
+
+func TestReadDistributions(t *testing.T) {
+	rows, err := ReadDistributions(strings.NewReader(`ID,pool,penerima,jumlah,tanggal,wilayah,golongan
+DST-YDSF-MLG-202404-0001,POOL-YDSF-MLG-FITRAH,Siti,45000,2024-04-05T08:00:00Z,Lumajang,miskin
+`), nil)
+	require.NoError(t, err)
+	require.Equal(t, client.DistributionInput{ID: "DST-YDSF-MLG-202404-0001", PoolID: "POOL-YDSF-MLG-FITRAH", Mustahik: "Siti", Amount: 45000, Timestamp: "2024-04-05T08:00:00Z", Region: "Lumajang", Asnaf: "miskin"}, rows[0].Input)
+}
+
+func TestParseAmount(t *testing.T) {
+	for input, expected := range map[string]float64{
+		"45000":           45000,
+		"45000.50":        45000.5,
+		"Rp 1.000.000":    1000000,
+		"Rp. 1.000.000,5": 1000000.5,
+		"1,000,000.25":    1000000.25,
+		"IDR 2.500":       2500,
+		"2,5":             2.5,
+		"3,50":            3.5,
+	} {
+		amount, err := ParseAmount(input)
+		require.NoError(t, err, input)
+		require.Equal(t, expected, amount, input)
+	}
+
+	_, err := ParseAmount("satu juta")
+	require.Error(t, err)
+}
+
+func TestWriteZakat(t *testing.T) {
+	zakats := []client.Zakat{{
+		ID:            "ZKT-YDSF-MLG-202403-0001",
+		Muzakki:       "Ahmad",
+		Amount:        45000,
+		Type:          "fitrah",
+		Status:        "distributed",
+		Organization:  "YDSF Malang",
+		Timestamp:     "2024-03-30T08:00:00Z",
+		Distribution:  45000,
+		DistributedAt: "2024-04-05T08:00:00Z",
+		Distributions: []string{"DST-YDSF-MLG-202404-0001", "DST-YDSF-MLG-202404-0002"},
+		Payment:       &client.Payment{Channel: "qris", Bank: "BSI", Reference: "240330123456"},
+		Persons:       []string{"Ahmad"},
+		PledgeID:      "PLG-YDSF-MLG-2024-0001",
+	}, {
+		ID:           "ZKT-YDSF-MLG-202403-0002",
+		Muzakki:      "Budi",
+		Amount:       335055,
+		Type:         "maal",
+		Status:       "collected",
+		Organization: "YDSF Malang",
+		Timestamp:    "2024-03-30T09:00:00Z",
+		Conversion:   &client.Conversion{Currency: "MYR", Amount: 100, Rate: 3350.55, Source: "BSI kurs beli"},
+		Restriction:  &client.Restriction{Kind: "region", Value: "Lumajang"},
+	}, {
+		ID:              "ZKT-YDSF-MLG-202403-0003",
+		Muzakki:         "Hamba Allah",
+		Amount:          2500000,
+		Type:            "maal",
+		Status:          "collected",
+		Organization:    "YDSF Malang",
+		Timestamp:       "2024-03-30T10:00:00Z",
+		DonorCommitment: "9f2c1e0d4b7a6f5e3d2c1b0a9f8e7d6c5b4a3f2e1d0c9b8a7f6e5d4c3b2a1f0e",
+	}}
+
+	var buf bytes.Buffer
+	require.NoError(t, WriteZakat(&buf, zakats))
+	require.Equal(t, `ID,muzakki,amount,unit,value,type,status,organization,timestamp,distribution,distributedAt,distributions,channel,bank,reference,jiwa,persons,currency,originalAmount,rate,rateSource,restriction,pledgeId,donorCommitment
+ZKT-YDSF-MLG-202403-0001,Ahmad,45000.00,,45000.00,fitrah,distributed,YDSF Malang,2024-03-30T08:00:00Z,45000.00,2024-04-05T08:00:00Z,DST-YDSF-MLG-202404-0001;DST-YDSF-MLG-202404-0002,qris,BSI,240330123456,,Ahmad,,,,,,PLG-YDSF-MLG-2024-0001,
+ZKT-YDSF-MLG-202403-0002,Budi,335055.00,,335055.00,maal,collected,YDSF Malang,2024-03-30T09:00:00Z,0.00,,,,,,,,MYR,100.00,3350.55,BSI kurs beli,region:Lumajang,,
+ZKT-YDSF-MLG-202403-0003,Hamba Allah,2500000.00,,2500000.00,maal,collected,YDSF Malang,2024-03-30T10:00:00Z,0.00,,,,,,,,,,,,,,9f2c1e0d4b7a6f5e3d2c1b0a9f8e7d6c5b4a3f2e1d0c9b8a7f6e5d4c3b2a1f0e
+`, buf.String())
+
+	// An export can be imported again
+	rows, err := ReadZakat(&buf, nil)
+	require.NoError(t, err)
+	require.Equal(t, client.ZakatInput{
+		ID: "ZKT-YDSF-MLG-202403-0001", Muzakki: "Ahmad", Amount: 45000, Type: "fitrah", Organization: "YDSF Malang", Timestamp: "2024-03-30T08:00:00Z",
+		Payment:  &client.Payment{Channel: "qris", Bank: "BSI", Reference: "240330123456"},
+		Persons:  []string{"Ahmad"},
+		PledgeID: "PLG-YDSF-MLG-2024-0001",
+	}, rows[0].Input)
+	require.Equal(t, float64(335055), rows[1].Input.Amount)
+	require.Equal(t, zakats[1].Conversion, rows[1].Input.Conversion)
+	require.Equal(t, zakats[1].Restriction, rows[1].Input.Restriction)
+	require.Equal(t, zakats[2].DonorCommitment, rows[2].Input.DonorCommitment)
+}
+
+func TestReadStatement(t *testing.T) {
+	entries, err := ReadStatement(strings.NewReader(`Tanggal;Keterangan;No Referensi;Debet;Kredit
+30/03/2024;TRF ZAKAT AHMAD;FT24090ABC123;;1.250.000,00
+30/03/2024;BIAYA ADM;FT24090ABC124;10.000,00;
+30/03/2024;TRF QRIS;FT24090ABC125;;25.000,00 CR
+31/03/2024;TRF BUDI;;;45.000
+01/04/2024;TRF CITRA;FT24092XYZ001;;abc
+`), Mapping{"amount": "Kredit"}, client.Payment{Channel: "bank_transfer", Bank: "bsi"})
+	require.NoError(t, err)
+	require.Len(t, entries, 4)
+	require.Equal(t, StatementEntry{
+		Line:        2,
+		Date:        "2024-03-30",
+		Amount:      1250000,
+		Payment:     client.Payment{Channel: "bank_transfer", Bank: "BSI", Reference: "FT24090ABC123"},
+		Description: "TRF ZAKAT AHMAD",
+	}, entries[0])
+	require.Equal(t, 25000.0, entries[1].Amount)
+	require.Equal(t, 5, entries[2].Line)
+	require.EqualError(t, entries[2].Err, "the credit has no reference")
+	require.EqualError(t, entries[3].Err, `invalid amount "abc"`)
+}
+
+func TestParseDate(t *testing.T) {
+	for input, expected := range map[string]string{
+		"2024-03-30":          "2024-03-30",
+		"30/03/2024":          "2024-03-30",
+		"30-03-2024":          "2024-03-30",
+		"30 Mar 2024":         "2024-03-30",
+		"30/03/2024 14:05:09": "2024-03-30",
+	} {
+		date, err := ParseDate(input)
+		require.NoError(t, err, input)
+		require.Equal(t, expected, date, input)
+	}
+
+	_, err := ParseDate("March 30")
+	require.Error(t, err)
+}
@@ -0,0 +1,424 @@
+// Package validate checks donations and distributions off-chain with the rules the
+// zakat chaincode applies, so that bad rows are caught before they are submitted.
+// Organization rules are checked against the registry read from the ledger.
+package validate
+
+import (
+	"fmt"
+	"math"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/izzuddinafif/fabric-zakat/application/client"
+)
+
+// maxReferenceLength, maxRateSourceLength and maxRegionLength are the longest payment
+// reference, rate source and region the chaincode accepts
+const (
+	maxReferenceLength  = 64
+	maxRateSourceLength = 64
+	maxRegionLength     = 64
+)
+
+// asnafCategories are the eight categories of mustahik
+var asnafCategories = []string{"fakir", "miskin", "amil", "mualaf", "riqab", "gharimin", "fisabilillah", "ibnusabil"}
+
+var (
+	zakatIDPattern        = regexp.MustCompile(`^ZKT-YDSF-([A-Z]{3})-\d{6}-\d{4}$`)
+	distributionIDPattern = regexp.MustCompile(`^DST-YDSF-([A-Z]{3})-\d{6}-\d{4}$`)
+	poolIDPattern         = regexp.MustCompile(`^POOL-YDSF-([A-Z]{3})-(FITRAH|MAAL)(-[A-Z_]+)?$`)
+	pledgeIDPattern       = regexp.MustCompile(`^PLG-YDSF-([A-Z]{3})-\d{4}-\d{4}$`)
+	currencyPattern       = regexp.MustCompile(`^[A-Z]{3}$`)
+	commitmentPattern     = regexp.MustCompile(`^[0-9a-f]{64}$`)
+)
+
+// Registry indexes the registered organizations by name and code
+type Registry struct {
+	byName map[string]client.Organization
+	byCode map[string]client.Organization
+}
+
+// NewRegistry indexes the organizations returned by GetAllOrganizations
+func NewRegistry(organizations []client.Organization) *Registry {
+	registry := &Registry{byName: map[string]client.Organization{}, byCode: map[string]client.Organization{}}
+	for _, organization := range organizations {
+		registry.byName[organization.Name] = organization
+		registry.byCode[organization.Code] = organization
+	}
+	return registry
+}
+
+// ZakatID checks if the provided ID follows the required format and carries the code
+// of the given organization
+func (r *Registry) ZakatID(id string, organization string) error {
+	matches := zakatIDPattern.FindStringSubmatch(id)
+	if matches == nil {
+		return fmt.Errorf("invalid zakat ID format. Expected format: ZKT-YDSF-{ORG}-YYYYMM-NNNN (e.g., ZKT-YDSF-MLG-202311-0001)")
+	}
+	registered, ok := r.byCode[matches[1]]
+	if !ok {
+		return fmt.Errorf("invalid zakat ID %s: no organization is registered with code %s", id, matches[1])
+	}
+	if registered.Name != organization {
+		return fmt.Errorf("zakat ID %s does not belong to organization %s", id, organization)
+	}
+	return nil
+}
+
+// Organization checks that the organization is registered and active
+func (r *Registry) Organization(name string) error {
+	organization, ok := r.byName[name]
+	if !ok {
+		return fmt.Errorf("invalid organization. %s is not registered", name)
+	}
+	if organization.Status != "active" {
+		return fmt.Errorf("invalid organization. %s is %s", name, organization.Status)
+	}
+	return nil
+}
+
+// Code returns the ID code of an active organization
+func (r *Registry) Code(name string) (string, error) {
+	if err := r.Organization(name); err != nil {
+		return "", err
+	}
+	return r.byName[name].Code, nil
+}
+
+// DistributionID checks if the provided ID follows the required format and belongs to
+// the organization of the pool it is drawn from
+func (r *Registry) DistributionID(id string, poolID string) error {
+	matches := distributionIDPattern.FindStringSubmatch(id)
+	if matches == nil {
+		return fmt.Errorf("invalid distribution ID format. Expected format: DST-YDSF-{ORG}-YYYYMM-NNNN (e.g., DST-YDSF-MLG-202311-0001)")
+	}
+	pool := poolIDPattern.FindStringSubmatch(poolID)
+	if pool == nil {
+		return fmt.Errorf("invalid pool ID format. Expected format: POOL-YDSF-{ORG}-{FITRAH|MAAL}[-{UNIT}] (e.g., POOL-YDSF-MLG-MAAL or POOL-YDSF-MLG-FITRAH-KG_BERAS)")
+	}
+	organization, ok := r.byCode[pool[1]]
+	if !ok {
+		return fmt.Errorf("invalid pool ID %s: no organization is registered with code %s", poolID, pool[1])
+	}
+	if matches[1] != organization.Code {
+		return fmt.Errorf("distribution ID %s does not belong to organization %s", id, organization.Name)
+	}
+	return nil
+}
+
+// PledgeID checks if the provided ID follows the required format and belongs to the
+// organization
+func (r *Registry) PledgeID(id string, organization string) error {
+	matches := pledgeIDPattern.FindStringSubmatch(id)
+	if matches == nil {
+		return fmt.Errorf("invalid pledge ID format. Expected format: PLG-YDSF-{ORG}-YYYY-NNNN (e.g., PLG-YDSF-MLG-2026-0001)")
+	}
+	if r.byName[organization].Code != matches[1] {
+		return fmt.Errorf("pledge ID %s does not belong to organization %s", id, organization)
+	}
+	return nil
+}
+
+// Timestamp checks if the provided timestamp is in ISO 8601 format
+func Timestamp(timestamp string) error {
+	if _, err := time.Parse(time.RFC3339, timestamp); err != nil {
+		return fmt.Errorf("invalid timestamp format. Expected ISO 8601 format (e.g., 2023-11-28T12:00:00Z)")
+	}
+	return nil
+}
+
+// ZakatType checks if the provided type is valid
+func ZakatType(zakatType string) error {
+	if zakatType != "fitrah" && zakatType != "maal" {
+		return fmt.Errorf("invalid zakat type. Must be either 'fitrah' or 'maal'")
+	}
+	return nil
+}
+
+// Amount checks if the provided amount is valid
+func Amount(amount float64) error {
+	if amount <= 0 {
+		return fmt.Errorf("invalid amount. Must be greater than 0")
+	}
+	return nil
+}
+
+// Unit checks if the provided unit is rupiah or a known in-kind unit, and that the
+// quantity suits it: livestock is given in whole animals
+func Unit(unit string, quantity float64) error {
+	unit = client.NormalizeUnit(unit)
+	if client.IsIDR(unit) {
+		return nil
+	}
+	known := false
+	for _, inKind := range client.InKindUnits {
+		known = known || unit == inKind
+	}
+	if !known {
+		return fmt.Errorf("invalid unit. Must be 'IDR' or one of '%s'", strings.Join(client.InKindUnits, "', '"))
+	}
+	if strings.HasPrefix(unit, "ekor_") && quantity != math.Trunc(quantity) {
+		return fmt.Errorf("invalid quantity %v. Livestock is given in whole animals", quantity)
+	}
+	return nil
+}
+
+// Persons checks the persons a fitrah donation covers, given by name, by count or
+// both, and that a donation in rice is the per-person measure times their number.
+// The rate in IDR is on the ledger, so AddZakatFitrah checks IDR amounts.
+func Persons(input client.ZakatInput) error {
+	if input.Type != "fitrah" {
+		return fmt.Errorf("only zakat fitrah covers persons")
+	}
+	for _, person := range input.Persons {
+		if strings.TrimSpace(person) == "" {
+			return fmt.Errorf("invalid persons. Names must not be empty")
+		}
+	}
+	jiwa := input.Jiwa
+	switch {
+	case jiwa < 0:
+		return fmt.Errorf("invalid jiwa %d. Must be greater than 0", jiwa)
+	case len(input.Persons) > 0 && jiwa > 0 && jiwa != len(input.Persons):
+		return fmt.Errorf("jiwa %d does not match the %d persons named", jiwa, len(input.Persons))
+	case len(input.Persons) > 0:
+		jiwa = len(input.Persons)
+	case jiwa == 0:
+		return fmt.Errorf("a fitrah donation must cover at least one person")
+	}
+	unit := client.NormalizeUnit(input.Unit)
+	if client.IsIDR(unit) {
+		return nil
+	}
+	perPerson, ok := client.FitrahInKindPerPerson[unit]
+	if !ok {
+		return fmt.Errorf("fitrah in %s has no per-person rate", unit)
+	}
+	if due := perPerson * float64(jiwa); math.Abs(input.Amount-due) > 0.005 {
+		return fmt.Errorf("fitrah amount %v does not match %d jiwa at %v per person (%v)", input.Amount, jiwa, perPerson, due)
+	}
+	return nil
+}
+
+// Conversion checks the conversion of a donation given in a foreign currency like
+// AddZakatInCurrency, and that the amount, if given, is the IDR amount it converts to
+func Conversion(input client.ZakatInput) error {
+	if unit := client.NormalizeUnit(input.Unit); !client.IsIDR(unit) {
+		return fmt.Errorf("a donation in %s cannot be given in a foreign currency", unit)
+	}
+	conversion := input.Conversion.Normalize()
+	if !currencyPattern.MatchString(conversion.Currency) || conversion.Currency == client.UnitIDR {
+		return fmt.Errorf("invalid currency %q. Must be an ISO 4217 code other than IDR, e.g. MYR", conversion.Currency)
+	}
+	if conversion.Amount <= 0 {
+		return fmt.Errorf("invalid amount in %s. Must be greater than 0", conversion.Currency)
+	}
+	if conversion.Rate <= 0 {
+		return fmt.Errorf("invalid rate. Must be greater than 0 IDR per %s", conversion.Currency)
+	}
+	if conversion.Source == "" || len(conversion.Source) > maxRateSourceLength {
+		return fmt.Errorf("invalid rate source. Must be 1 to %d characters", maxRateSourceLength)
+	}
+	if idr := conversion.IDRAmount(); input.Amount != 0 && math.Abs(input.Amount-idr) > 0.005 {
+		return fmt.Errorf("amount %v does not match %s %v at %v (%v)", input.Amount, conversion.Currency, conversion.Amount, conversion.Rate, idr)
+	}
+	return nil
+}
+
+// Restriction checks the purpose a donation is restricted to like AddZakatRestricted.
+// Whether a program exists and belongs to the organization is only known on the ledger.
+func Restriction(restriction client.Restriction) error {
+	restriction = restriction.Normalize()
+	switch restriction.Kind {
+	case client.RestrictionProgram:
+		if restriction.Value == "" {
+			return fmt.Errorf("invalid restriction. The program must not be empty")
+		}
+	case client.RestrictionAsnaf:
+		if restriction.Value == "amil" {
+			return fmt.Errorf("a donation cannot be restricted to the amil")
+		}
+		return Asnaf(restriction.Value)
+	case client.RestrictionRegion:
+		if restriction.Value == "" {
+			return fmt.Errorf("invalid restriction. The region must not be empty")
+		}
+		return Region(restriction.Value)
+	default:
+		return fmt.Errorf("invalid restriction kind. Must be 'program', 'asnaf' or 'region'")
+	}
+	return nil
+}
+
+// Asnaf checks if the provided asnaf is one of the eight categories
+func Asnaf(asnaf string) error {
+	for _, category := range asnafCategories {
+		if asnaf == category {
+			return nil
+		}
+	}
+	return fmt.Errorf("invalid asnaf. Must be one of %v", asnafCategories)
+}
+
+// Region checks the region of a restriction or distribution, which may be empty
+func Region(region string) error {
+	if len(region) > maxRegionLength {
+		return fmt.Errorf("invalid region. Must be at most %d characters", maxRegionLength)
+	}
+	return nil
+}
+
+// Anonymous checks an anonymous donation like the chaincode does: the commitment is a
+// SHA-256 hash in hex, and the donation names neither the muzakki nor the persons it
+// covers and is not paid towards a pledge
+func Anonymous(input client.ZakatInput) error {
+	if !commitmentPattern.MatchString(strings.ToLower(strings.TrimSpace(input.DonorCommitment))) {
+		return fmt.Errorf("invalid donor commitment. Expected a SHA-256 hash of 64 hex digits")
+	}
+	if input.Muzakki != "" && input.Muzakki != client.AnonymousMuzakki {
+		return fmt.Errorf("an anonymous donation is recorded as %s and cannot name the muzakki", client.AnonymousMuzakki)
+	}
+	if len(input.Persons) > 0 {
+		return fmt.Errorf("an anonymous donation cannot name the persons it covers; give their number as jiwa")
+	}
+	if input.PledgeID != "" {
+		return fmt.Errorf("an anonymous donation cannot be paid towards a pledge")
+	}
+	return nil
+}
+
+// Payment checks if the provided payment reference is complete, once normalized
+func Payment(payment client.Payment) error {
+	payment = payment.Normalize()
+	if payment.Channel != client.PaymentBankTransfer && payment.Channel != client.PaymentQRIS && payment.Channel != client.PaymentGateway {
+		return fmt.Errorf("invalid payment channel. Must be 'bank_transfer', 'qris' or 'gateway'")
+	}
+	if payment.Bank == "" {
+		return fmt.Errorf("invalid payment. The bank must not be empty")
+	}
+	if payment.Reference == "" || len(payment.Reference) > maxReferenceLength {
+		return fmt.Errorf("invalid payment reference. Must be 1 to %d characters", maxReferenceLength)
+	}
+	return nil
+}
+
+// Zakat checks a donation like AddZakat and AddZakatWithPayment do
+func (r *Registry) Zakat(input client.ZakatInput) error {
+	if err := r.ZakatID(input.ID, input.Organization); err != nil {
+		return err
+	}
+	amount := input.Amount
+	if input.Conversion != nil {
+		if err := Conversion(input); err != nil {
+			return err
+		}
+		amount = input.Conversion.Normalize().IDRAmount()
+	}
+	if err := Amount(amount); err != nil {
+		return err
+	}
+	if err := Unit(input.Unit, amount); err != nil {
+		return err
+	}
+	// A donation towards a pledge may leave its type to the pledge
+	if input.PledgeID == "" || input.Type != "" {
+		if err := ZakatType(input.Type); err != nil {
+			return err
+		}
+	}
+	if err := r.Organization(input.Organization); err != nil {
+		return err
+	}
+	if err := Timestamp(input.Timestamp); err != nil {
+		return err
+	}
+	if input.PledgeID != "" {
+		if err := r.PledgeID(input.PledgeID, input.Organization); err != nil {
+			return err
+		}
+		if unit := client.NormalizeUnit(input.Unit); !client.IsIDR(unit) {
+			return fmt.Errorf("a donation in %s cannot be paid towards a pledge", unit)
+		}
+	}
+	if len(input.Persons) > 0 || input.Jiwa != 0 {
+		if err := Persons(input); err != nil {
+			return err
+		}
+	}
+	if input.Restriction != nil {
+		if err := Restriction(*input.Restriction); err != nil {
+			return err
+		}
+	}
+	if input.DonorCommitment != "" {
+		if err := Anonymous(input); err != nil {
+			return err
+		}
+	}
+	if input.Payment != nil {
+		if !client.IsIDR(client.NormalizeUnit(input.Unit)) {
+			return fmt.Errorf("a donation in %s cannot carry a payment", client.NormalizeUnit(input.Unit))
+		}
+		return Payment(*input.Payment)
+	}
+	return nil
+}
+
+// Pledge checks a pledge like CreatePledge does
+func (r *Registry) Pledge(input client.PledgeInput) error {
+	if err := r.Organization(input.Organization); err != nil {
+		return err
+	}
+	if err := r.PledgeID(input.ID, input.Organization); err != nil {
+		return err
+	}
+	if strings.TrimSpace(input.Muzakki) == "" {
+		return fmt.Errorf("muzakki must not be empty")
+	}
+	if err := ZakatType(input.Type); err != nil {
+		return err
+	}
+	if err := Amount(input.Amount); err != nil {
+		return err
+	}
+	switch strings.ToLower(strings.TrimSpace(input.Frequency)) {
+	case client.FrequencyMonthly, client.FrequencyQuarterly, client.FrequencyYearly:
+	default:
+		return fmt.Errorf("invalid frequency. Must be 'monthly', 'quarterly' or 'yearly'")
+	}
+	if err := Timestamp(input.StartDate); err != nil {
+		return err
+	}
+	if err := Timestamp(input.EndDate); err != nil {
+		return err
+	}
+	start, _ := time.Parse(time.RFC3339, input.StartDate)
+	end, _ := time.Parse(time.RFC3339, input.EndDate)
+	if end.Before(start) {
+		return fmt.Errorf("pledge end date must not be before its start date")
+	}
+	return nil
+}
+
+// Distribution checks a distribution like DistributeZakat does before reading the ledger
+func (r *Registry) Distribution(input client.DistributionInput) error {
+	if err := r.DistributionID(input.ID, input.PoolID); err != nil {
+		return err
+	}
+	if err := Amount(input.Amount); err != nil {
+		return err
+	}
+	if err := Region(strings.TrimSpace(input.Region)); err != nil {
+		return err
+	}
+	if asnaf := strings.ToLower(strings.TrimSpace(input.Asnaf)); asnaf != "" {
+		if asnaf == "amil" {
+			return fmt.Errorf("the amil's share is allocated with AllocateAmilShare, not distributed to a mustahik")
+		}
+		if err := Asnaf(asnaf); err != nil {
+			return err
+		}
+	}
+	return Timestamp(input.Timestamp)
+}
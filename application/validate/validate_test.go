@@ -0,0 +1,185 @@
+package validate
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/izzuddinafif/fabric-zakat/application/client"
+	"github.com/stretchr/testify/require"
+)
+
+var registry = NewRegistry([]client.Organization{
+	{Name: "YDSF Malang", MSPID: "YDSFMalangMSP", Code: "MLG", Status: "active"},
+	{Name: "YDSF Jatim", MSPID: "YDSFJatimMSP", Code: "JTM", Status: "inactive"},
+})
+
+func TestZakat(t *testing.T) {
+	valid := client.ZakatInput{ID: "ZKT-YDSF-MLG-202403-0001", Muzakki: "Ahmad", Amount: 45000, Type: "fitrah", Organization: "YDSF Malang", Timestamp: "2024-03-30T08:00:00Z"}
+	require.NoError(t, registry.Zakat(valid))
+
+	paid := valid
+	paid.Payment = &client.Payment{Channel: " Bank_Transfer", Bank: "bsi", Reference: "FT24090ABC123"}
+	require.NoError(t, registry.Zakat(paid))
+
+	rice := valid
+	rice.Amount, rice.Unit = 2.5, "kg_beras"
+	require.NoError(t, registry.Zakat(rice))
+
+	household := valid
+	household.Amount, household.Persons = 135000, []string{"Ahmad", "Fatimah", "Umar"}
+	require.NoError(t, registry.Zakat(household))
+
+	foreign := valid
+	foreign.Type, foreign.Amount = "maal", 0
+	foreign.Conversion = &client.Conversion{Currency: "sar", Amount: 250, Rate: 4215.3, Source: "JISDOR"}
+	require.NoError(t, registry.Zakat(foreign))
+	foreign.Amount = 1053825
+	require.NoError(t, registry.Zakat(foreign))
+
+	restricted := valid
+	restricted.Restriction = &client.Restriction{Kind: "Asnaf", Value: " Fakir"}
+	require.NoError(t, registry.Zakat(restricted))
+
+	pledged := valid
+	pledged.Muzakki, pledged.Type, pledged.PledgeID = "", "", "PLG-YDSF-MLG-2026-0001"
+	require.NoError(t, registry.Zakat(pledged))
+
+	anonymous := valid
+	anonymous.Muzakki, anonymous.DonorCommitment = "", client.DonorCommitment(valid.ID, "b7e1f0c2a9d84e6f")
+	require.NoError(t, registry.Zakat(anonymous))
+
+	householdRice := rice
+	householdRice.Amount, householdRice.Unit, householdRice.Jiwa = 10.5, "liter_beras", 3
+	require.NoError(t, registry.Zakat(householdRice))
+
+	for name, test := range map[string]struct {
+		change func(*client.ZakatInput)
+		err    string
+	}{
+		"ID format":         {func(z *client.ZakatInput) { z.ID = "ZKT-MLG-202403-0001" }, "invalid zakat ID format"},
+		"Unregistered code": {func(z *client.ZakatInput) { z.ID = "ZKT-YDSF-SBY-202403-0001" }, "no organization is registered with code SBY"},
+		"Amount":            {func(z *client.ZakatInput) { z.Amount = -1 }, "invalid amount"},
+		"Type":              {func(z *client.ZakatInput) { z.Type = "infaq" }, "invalid zakat type"},
+		"Other org code":    {func(z *client.ZakatInput) { z.Organization = "YDSF Surabaya" }, "does not belong to organization YDSF Surabaya"},
+		"Inactive":          {func(z *client.ZakatInput) { z.ID, z.Organization = "ZKT-YDSF-JTM-202403-0001", "YDSF Jatim" }, "YDSF Jatim is inactive"},
+		"Timestamp":         {func(z *client.ZakatInput) { z.Timestamp = "30/03/2024" }, "invalid timestamp format"},
+		"Payment channel": {func(z *client.ZakatInput) {
+			z.Payment = &client.Payment{Channel: "cash", Bank: "BSI", Reference: "FT1"}
+		}, "invalid payment channel"},
+		"Unit":      {func(z *client.ZakatInput) { z.Unit = "kg_jagung" }, "invalid unit"},
+		"Livestock": {func(z *client.ZakatInput) { z.Amount, z.Unit = 1.5, "ekor_sapi" }, "whole animals"},
+		"Payment in kind": {func(z *client.ZakatInput) {
+			z.Unit, z.Payment = "kg_beras", &client.Payment{Channel: "qris", Bank: "BSI", Reference: "FT1"}
+		}, "cannot carry a payment"},
+		"Persons in maal": {func(z *client.ZakatInput) { z.Type, z.Jiwa = "maal", 2 }, "only zakat fitrah covers persons"},
+		"Empty person":    {func(z *client.ZakatInput) { z.Persons = []string{"Ahmad", " "} }, "Names must not be empty"},
+		"Negative jiwa":   {func(z *client.ZakatInput) { z.Jiwa = -1 }, "invalid jiwa"},
+		"Jiwa mismatch":   {func(z *client.ZakatInput) { z.Persons, z.Jiwa = []string{"Ahmad"}, 2 }, "does not match the 1 persons named"},
+		"Rice per person": {func(z *client.ZakatInput) { z.Amount, z.Unit, z.Jiwa = 5, "kg_beras", 3 }, "does not match 3 jiwa"},
+		"Fitrah in gabah": {func(z *client.ZakatInput) { z.Amount, z.Unit, z.Jiwa = 5, "kg_gabah", 2 }, "no per-person rate"},
+		"Currency": {func(z *client.ZakatInput) {
+			z.Conversion = &client.Conversion{Currency: "IDR", Amount: 45000, Rate: 1, Source: "JISDOR"}
+		}, "invalid currency"},
+		"Rate source": {func(z *client.ZakatInput) {
+			z.Conversion = &client.Conversion{Currency: "MYR", Amount: 10, Rate: 3350, Source: ""}
+		}, "invalid rate source"},
+		"Converted amount": {func(z *client.ZakatInput) {
+			z.Conversion = &client.Conversion{Currency: "MYR", Amount: 10, Rate: 3350, Source: "BSI"}
+		}, "does not match MYR 10 at 3350 (33500)"},
+		"Currency in kind": {func(z *client.ZakatInput) {
+			z.Amount, z.Unit = 0, "gram_emas"
+			z.Conversion = &client.Conversion{Currency: "SAR", Amount: 10, Rate: 4200, Source: "JISDOR"}
+		}, "cannot be given in a foreign currency"},
+		"Restriction kind": {func(z *client.ZakatInput) {
+			z.Restriction = &client.Restriction{Kind: "mosque", Value: "Masjid Jami"}
+		}, "invalid restriction kind"},
+		"Restricted to the amil": {func(z *client.ZakatInput) {
+			z.Restriction = &client.Restriction{Kind: "asnaf", Value: "amil"}
+		}, "cannot be restricted to the amil"},
+		"Restricted region": {func(z *client.ZakatInput) {
+			z.Restriction = &client.Restriction{Kind: "region", Value: strings.Repeat("x", 65)}
+		}, "invalid region"},
+		"Pledge ID": {func(z *client.ZakatInput) { z.PledgeID = "PLG-YDSF-JTM-2026-0001" }, "does not belong to organization YDSF Malang"},
+		"Pledge in kind": {func(z *client.ZakatInput) {
+			z.Amount, z.Unit, z.PledgeID = 10, "kg_beras", "PLG-YDSF-MLG-2026-0001"
+		}, "cannot be paid towards a pledge"},
+		"Donor commitment":  {func(z *client.ZakatInput) { z.Muzakki, z.DonorCommitment = "", "b7e1f0c2a9d84e6f" }, "invalid donor commitment"},
+		"Anonymous muzakki": {func(z *client.ZakatInput) { z.DonorCommitment = strings.Repeat("ab", 32) }, "cannot name the muzakki"},
+		"Payment reference": {func(z *client.ZakatInput) { z.Payment = &client.Payment{Channel: "qris", Bank: "BSI", Reference: " "} }, "invalid payment reference"},
+	} {
+		t.Run(name, func(t *testing.T) {
+			input := valid
+			test.change(&input)
+			err := registry.Zakat(input)
+			require.Error(t, err)
+			require.Contains(t, err.Error(), test.err)
+		})
+	}
+}
+
+func TestDistribution(t *testing.T) {
+	valid := client.DistributionInput{ID: "DST-YDSF-MLG-202404-0001", PoolID: "POOL-YDSF-MLG-FITRAH", Mustahik: "Siti", Amount: 45000, Timestamp: "2024-04-05T08:00:00Z"}
+	require.NoError(t, registry.Distribution(valid))
+
+	rice := valid
+	rice.PoolID = "POOL-YDSF-MLG-FITRAH-KG_BERAS"
+	require.NoError(t, registry.Distribution(rice))
+
+	other := valid
+	other.PoolID = "POOL-YDSF-JTM-FITRAH"
+	err := registry.Distribution(other)
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "does not belong to organization YDSF Jatim")
+
+	region := valid
+	region.Region = "Lumajang"
+	require.NoError(t, registry.Distribution(region))
+	region.Region = strings.Repeat("x", 65)
+	err = registry.Distribution(region)
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "invalid region")
+
+	asnaf := valid
+	asnaf.Asnaf = " Miskin"
+	require.NoError(t, registry.Distribution(asnaf))
+	asnaf.Asnaf = "orphans"
+	err = registry.Distribution(asnaf)
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "invalid asnaf")
+	asnaf.Asnaf = "amil"
+	err = registry.Distribution(asnaf)
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "AllocateAmilShare")
+
+	badPool := valid
+	badPool.PoolID = "POOL-MLG"
+	err = registry.Distribution(badPool)
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "invalid pool ID format")
+}
+
+func TestPledge(t *testing.T) {
+	valid := client.PledgeInput{
+		ID: "PLG-YDSF-MLG-2026-0001", Muzakki: "Ahmad", Organization: "YDSF Malang", Type: "maal", Amount: 500000,
+		Frequency: "Monthly", StartDate: "2026-01-25T00:00:00+07:00", EndDate: "2026-12-25T00:00:00+07:00",
+	}
+	require.NoError(t, registry.Pledge(valid))
+
+	for name, test := range map[string]struct {
+		change func(*client.PledgeInput)
+		err    string
+	}{
+		"ID format": {func(p *client.PledgeInput) { p.ID = "PLG-MLG-2026-0001" }, "invalid pledge ID format"},
+		"Muzakki":   {func(p *client.PledgeInput) { p.Muzakki = " " }, "muzakki must not be empty"},
+		"Frequency": {func(p *client.PledgeInput) { p.Frequency = "weekly" }, "invalid frequency"},
+		"Dates":     {func(p *client.PledgeInput) { p.EndDate = "2025-12-25T00:00:00+07:00" }, "must not be before its start date"},
+	} {
+		t.Run(name, func(t *testing.T) {
+			input := valid
+			test.change(&input)
+			err := registry.Pledge(input)
+			require.Error(t, err)
+			require.Contains(t, err.Error(), test.err)
+		})
+	}
+}
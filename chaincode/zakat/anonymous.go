@@ -0,0 +1,92 @@
+package main
+
+import (
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/hyperledger/fabric-contract-api-go/contractapi"
+)
+
+// anonymousMuzakki is the name anonymous donations are recorded under
+const anonymousMuzakki = "Hamba Allah"
+
+// donorCommitment returns the commitment to a donor secret for a donation: the SHA-256
+// hash of the zakat ID, a colon and the secret, in hex. Binding it to the ID keeps the
+// donations of a donor who reuses a secret from being linked.
+func donorCommitment(zakatID string, secret string) string {
+	sum := sha256.Sum256([]byte(zakatID + ":" + secret))
+	return hex.EncodeToString(sum[:])
+}
+
+// validateDonorCommitment checks that a commitment is a SHA-256 hash in hex
+func validateDonorCommitment(commitment string) error {
+	if !regexp.MustCompile(`^[0-9a-f]{64}$`).MatchString(commitment) {
+		return fmt.Errorf("invalid donor commitment. Expected a SHA-256 hash of 64 hex digits")
+	}
+	return nil
+}
+
+// checkAnonymous checks an anonymous donation, which is recorded as Hamba Allah with
+// the donor's commitment and names no one
+func checkAnonymous(input *ZakatInput) error {
+	input.DonorCommitment = strings.ToLower(strings.TrimSpace(input.DonorCommitment))
+	if err := validateDonorCommitment(input.DonorCommitment); err != nil {
+		return err
+	}
+	if input.Muzakki != "" && input.Muzakki != anonymousMuzakki {
+		return fmt.Errorf("an anonymous donation is recorded as %s and cannot name the muzakki", anonymousMuzakki)
+	}
+	if len(input.Persons) > 0 {
+		return fmt.Errorf("an anonymous donation cannot name the persons it covers; give their number as jiwa")
+	}
+	if input.PledgeID != "" {
+		return fmt.Errorf("an anonymous donation cannot be paid towards a pledge")
+	}
+	input.Muzakki = anonymousMuzakki
+	return nil
+}
+
+// donor returns who a donation is counted for among distinct donors: its muzakki, or
+// for an anonymous donation its commitment, so that anonymous donors recorded under
+// the same name are each counted. A commitment is bound to its donation, so each
+// anonymous donation counts as a donor of its own.
+func (z Zakat) donor() string {
+	if z.DonorCommitment != "" {
+		return z.DonorCommitment
+	}
+	return z.Muzakki
+}
+
+// AddZakatAnonymous adds a donation like AddZakat for a donor who wants to be recorded
+// as Hamba Allah. The record carries the donor's commitment, the hash of the zakat ID
+// and a secret only the donor holds, instead of their name, e.g.
+// AddZakatAnonymous("ZKT-YDSF-MLG-202603-0001", "9f86d0...", 2500000, "maal",
+// "YDSF Malang", "2026-03-10T09:00:00+07:00").
+func (s *SmartContract) AddZakatAnonymous(ctx contractapi.TransactionContextInterface, id string, commitment string, amount float64, zakatType string, organization string, timestamp string) error {
+	return s.addZakat(ctx, ZakatInput{
+		ID:              id,
+		Amount:          amount,
+		Type:            zakatType,
+		Organization:    organization,
+		Timestamp:       timestamp,
+		DonorCommitment: commitment,
+	})
+}
+
+// VerifyDonor returns true when the secret is the one an anonymous donation was
+// committed to, proving the donation is the donor's. It is meant to be evaluated, not
+// submitted, so the secret is not written to the ledger.
+func (s *SmartContract) VerifyDonor(ctx contractapi.TransactionContextInterface, id string, secret string) (bool, error) {
+	zakat, err := s.QueryZakat(ctx, id)
+	if err != nil {
+		return false, err
+	}
+	if zakat.DonorCommitment == "" {
+		return false, fmt.Errorf("the zakat %s is not anonymous", id)
+	}
+	return subtle.ConstantTimeCompare([]byte(donorCommitment(id, secret)), []byte(zakat.DonorCommitment)) == 1, nil
+}
@@ -0,0 +1,100 @@
+package main
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/hyperledger/fabric-contract-api-go/contractapi"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+func TestAnonymousDonation(t *testing.T) {
+	chaincodeStub := new(MockStub)
+	transactionContext := new(contractapi.TransactionContext)
+	transactionContext.SetStub(chaincodeStub)
+	transactionContext.SetClientIdentity(&MockClientIdentity{MSPID: "YDSFMalangMSP", OUs: []string{"admin"}})
+	newWorldState(chaincodeStub)
+	now := time.Date(2026, 3, 10, 3, 0, 0, 0, time.UTC)
+	chaincodeStub.On("GetTxTimestamp").Return(timestamppb.New(now), nil).Maybe()
+
+	smartContract := new(SmartContract)
+	secret := "b7e1f0c2a9d84e6f"
+	commitment := donorCommitment("ZKT-YDSF-MLG-202603-0001", secret)
+	require.NoError(t, smartContract.AddZakatAnonymous(transactionContext, "ZKT-YDSF-MLG-202603-0001", " "+strings.ToUpper(commitment), 2500000, "maal", "YDSF Malang", "2026-03-10T09:00:00+07:00"))
+	require.NoError(t, smartContract.AddZakat(transactionContext, "ZKT-YDSF-MLG-202603-0002", "Ahmad", 2500000, "maal", "YDSF Malang", "2026-03-10T09:00:00+07:00"))
+	require.NoError(t, smartContract.AddZakatAnonymous(transactionContext, "ZKT-YDSF-MLG-202603-0004", donorCommitment("ZKT-YDSF-MLG-202603-0004", "another donor"), 1000000, "maal", "YDSF Malang", "2026-03-10T10:00:00+07:00"))
+
+	zakat, err := smartContract.QueryZakat(transactionContext, "ZKT-YDSF-MLG-202603-0001")
+	require.NoError(t, err)
+	require.Equal(t, anonymousMuzakki, zakat.Muzakki)
+	require.Equal(t, commitment, zakat.DonorCommitment)
+
+	// Anonymous donors are not followed in the donor registry
+	_, err = smartContract.QueryDonor(transactionContext, "YDSF Malang", anonymousMuzakki)
+	require.Error(t, err)
+
+	t.Run("Donors", func(t *testing.T) {
+		// Every anonymous donation counts as a donor of its own, not one Hamba Allah
+		report, err := smartContract.GetReport(transactionContext, "YDSF Malang", "202603")
+		require.NoError(t, err)
+		require.Equal(t, 3, report.Total.Donations)
+		require.Equal(t, 3, report.Total.Donors)
+
+		baznas, err := smartContract.GetBaznasReport(transactionContext, "YDSF Malang", "202603")
+		require.NoError(t, err)
+		require.Equal(t, 3, baznas.Muzakki)
+	})
+
+	t.Run("Verify", func(t *testing.T) {
+		verified, err := smartContract.VerifyDonor(transactionContext, "ZKT-YDSF-MLG-202603-0001", secret)
+		require.NoError(t, err)
+		require.True(t, verified)
+
+		verified, err = smartContract.VerifyDonor(transactionContext, "ZKT-YDSF-MLG-202603-0001", "a guess")
+		require.NoError(t, err)
+		require.False(t, verified)
+
+		_, err = smartContract.VerifyDonor(transactionContext, "ZKT-YDSF-MLG-202603-0002", secret)
+		require.Error(t, err)
+		require.Contains(t, err.Error(), "is not anonymous")
+	})
+
+	t.Run("Batch", func(t *testing.T) {
+		entries, err := json.Marshal([]ZakatInput{{
+			ID: "ZKT-YDSF-MLG-202603-0003", Muzakki: anonymousMuzakki, Amount: 90000, Type: "fitrah", Organization: "YDSF Malang",
+			Timestamp: "2026-03-10T09:00:00+07:00", Jiwa: 2, DonorCommitment: donorCommitment("ZKT-YDSF-MLG-202603-0003", secret),
+		}})
+		require.NoError(t, err)
+		require.NoError(t, smartContract.SetFitrahRate(transactionContext, "YDSF Malang", 1447, 45000))
+		_, err = smartContract.AddZakatBatch(transactionContext, string(entries), "atomic")
+		require.NoError(t, err)
+
+		verified, err := smartContract.VerifyDonor(transactionContext, "ZKT-YDSF-MLG-202603-0003", secret)
+		require.NoError(t, err)
+		require.True(t, verified)
+	})
+
+	for name, test := range map[string]struct {
+		input ZakatInput
+		err   string
+	}{
+		"Commitment": {input: ZakatInput{DonorCommitment: "not-a-hash"}, err: "invalid donor commitment"},
+		"Named":      {input: ZakatInput{Muzakki: "Ahmad", DonorCommitment: commitment}, err: "cannot name the muzakki"},
+		"Persons":    {input: ZakatInput{Type: "fitrah", Persons: []string{"Ahmad", "Fatimah"}, DonorCommitment: commitment}, err: "cannot name the persons"},
+		"Pledge":     {input: ZakatInput{PledgeID: "PLG-YDSF-MLG-2026-0001", DonorCommitment: commitment}, err: "cannot be paid towards a pledge"},
+	} {
+		t.Run(name, func(t *testing.T) {
+			input := test.input
+			input.ID, input.Amount, input.Organization, input.Timestamp = "ZKT-YDSF-MLG-202603-0009", 100000, "YDSF Malang", "2026-03-10T09:00:00+07:00"
+			if input.Type == "" {
+				input.Type = "maal"
+			}
+			err := smartContract.addZakat(transactionContext, input)
+			require.Error(t, err)
+			require.Contains(t, err.Error(), test.err)
+		})
+	}
+}
@@ -0,0 +1,186 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/hyperledger/fabric-contract-api-go/contractapi"
+)
+
+// Batch modes. In atomic mode one invalid entry fails the whole transaction; in
+// partial mode invalid entries are skipped and the valid ones are recorded.
+const (
+	batchAtomic  = "atomic"
+	batchPartial = "partial"
+
+	// maxBatchSize bounds the number of entries in one batch transaction
+	maxBatchSize = 500
+)
+
+// Batch result statuses
+const (
+	batchAdded  = "added"
+	batchFailed = "failed"
+)
+
+// BatchResult is the outcome of one entry of a batch
+type BatchResult struct {
+	Index  int    `json:"index"`           // Position of the entry in the batch
+	ID     string `json:"ID"`              // Zakat ID of the entry
+	Status string `json:"status"`          // "added" or "failed"
+	Error  string `json:"error,omitempty"` // Why the entry failed
+}
+
+// validateBatchMode checks if the provided batch mode is valid
+func validateBatchMode(mode string) error {
+	if mode != batchAtomic && mode != batchPartial {
+		return fmt.Errorf("invalid batch mode. Must be either 'atomic' or 'partial'")
+	}
+	return nil
+}
+
+// AddZakatBatch records a JSON array of donations in one transaction, validating each
+// entry like AddZakat. Entries are applied in order, so later entries see the pools and
+// reports updated by earlier ones and a repeated ID fails as a duplicate. In atomic mode
+// any failure fails the transaction and nothing is recorded; in partial mode the failed
+// entries are reported and the others recorded.
+func (s *SmartContract) AddZakatBatch(ctx contractapi.TransactionContextInterface, entriesJSON string, mode string) ([]BatchResult, error) {
+	if err := validateBatchMode(mode); err != nil {
+		return nil, err
+	}
+
+	var entries []ZakatInput
+	if err := json.Unmarshal([]byte(entriesJSON), &entries); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal batch entries: %v", err)
+	}
+	if len(entries) == 0 {
+		return nil, fmt.Errorf("batch must contain at least one entry")
+	}
+	if len(entries) > maxBatchSize {
+		return nil, fmt.Errorf("batch of %d entries exceeds the maximum of %d", len(entries), maxBatchSize)
+	}
+
+	batch := newTxCache(ctx.GetStub())
+	results := make([]BatchResult, len(entries))
+	var failures []string
+	for i, entry := range entries {
+		results[i] = BatchResult{Index: i, ID: entry.ID}
+
+		// Each entry writes to its own cache, merged into the batch only if it succeeds
+		item := newTxCache(batch)
+		if err := s.addZakat(item.context(ctx), entry); err != nil {
+			results[i].Status = batchFailed
+			results[i].Error = err.Error()
+			failures = append(failures, fmt.Sprintf("entry %d (%s): %v", i, entry.ID, err))
+			continue
+		}
+		if err := item.flush(); err != nil {
+			return nil, err
+		}
+		results[i].Status = batchAdded
+	}
+
+	if mode == batchAtomic && len(failures) > 0 {
+		return nil, fmt.Errorf("batch rejected, %d of %d entries failed: %s", len(failures), len(entries), strings.Join(failures, "; "))
+	}
+	if err := batch.flush(); err != nil {
+		return nil, err
+	}
+	return results, nil
+}
+
+// DistributionEntry is one mustahik of a bulk distribution
+type DistributionEntry struct {
+	ID       string  `json:"ID"`               // Format: DST-YDSF-{ORG}-{YYYY}{MM}-{COUNTER}
+	Mustahik string  `json:"mustahik"`         // Recipient's name
+	Amount   float64 `json:"amount"`           // Amount in IDR, or quantity in the pool's unit
+	Asnaf    string  `json:"asnaf,omitempty"`  // Asnaf of the mustahik, taken from the program if empty
+	Region   string  `json:"region,omitempty"` // Region the mustahik is in, if given
+}
+
+// DistributeZakatBatch disburses from one fund pool to every mustahik of a JSON array
+// of entries in one transaction, e.g. the morning's rice fitrah to several hundred
+// families. Each entry is a distribution like DistributeZakat under the same program
+// and timestamp. The entries are validated together: their total must not exceed the
+// pool balance, and any failing entry fails the transaction so nothing is recorded.
+// The transaction carries one ZakatDistributed event for all the donations the batch
+// completed, each with the ID and asnaf of the entry that completed it.
+func (s *SmartContract) DistributeZakatBatch(ctx contractapi.TransactionContextInterface, poolID string, programID string, entriesJSON string, timestamp string) error {
+	var entries []DistributionEntry
+	if err := json.Unmarshal([]byte(entriesJSON), &entries); err != nil {
+		return fmt.Errorf("failed to unmarshal batch entries: %v", err)
+	}
+	if len(entries) == 0 {
+		return fmt.Errorf("batch must contain at least one entry")
+	}
+	if len(entries) > maxBatchSize {
+		return fmt.Errorf("batch of %d entries exceeds the maximum of %d", len(entries), maxBatchSize)
+	}
+
+	pool, err := s.QueryPool(ctx, poolID)
+	if err != nil {
+		return err
+	}
+	var total float64
+	for _, entry := range entries {
+		total += entry.Amount
+	}
+	if total > pool.Balance && !amountsEqual(total, pool.Balance) {
+		return fmt.Errorf("batch total %.2f exceeds the balance %.2f of pool %s", total, pool.Balance, poolID)
+	}
+
+	batch := newTxCache(ctx.GetStub())
+	var event *ZakatDistributedEvent
+	var failures []string
+	for i, entry := range entries {
+		// Each entry writes to its own cache, so a failing entry leaves no partial writes
+		// behind for the entries after it, whose errors are reported too
+		item := newTxCache(batch)
+		completed, err := s.distributeEntry(item.context(ctx), poolID, programID, entry, timestamp)
+		if err != nil {
+			failures = append(failures, fmt.Sprintf("entry %d (%s): %v", i, entry.ID, err))
+			continue
+		}
+		if err := item.flush(); err != nil {
+			return err
+		}
+		if len(completed.Zakats) == 0 {
+			continue
+		}
+		if event == nil {
+			event = &completed
+			continue
+		}
+		event.Zakats = append(event.Zakats, completed.Zakats...)
+	}
+
+	if len(failures) > 0 {
+		return fmt.Errorf("batch rejected, %d of %d entries failed: %s", len(failures), len(entries), strings.Join(failures, "; "))
+	}
+	if err := batch.flush(); err != nil {
+		return err
+	}
+	if event == nil {
+		return nil
+	}
+	return emitZakatDistributed(ctx, *event)
+}
+
+// distributeEntry records one entry of a bulk distribution and returns its event
+func (s *SmartContract) distributeEntry(ctx contractapi.TransactionContextInterface, poolID string, programID string, entry DistributionEntry, timestamp string) (ZakatDistributedEvent, error) {
+	mustahik := strings.TrimSpace(entry.Mustahik)
+	if mustahik == "" {
+		return ZakatDistributedEvent{}, fmt.Errorf("mustahik must not be empty")
+	}
+	asnaf := strings.ToLower(strings.TrimSpace(entry.Asnaf))
+	if asnaf == amilAsnaf {
+		return ZakatDistributedEvent{}, fmt.Errorf("the amil's share is allocated with AllocateAmilShare, not distributed to a mustahik")
+	}
+	if asnaf != "" {
+		if err := validateAsnaf(asnaf); err != nil {
+			return ZakatDistributedEvent{}, err
+		}
+	}
+	return s.disburse(ctx, entry.ID, poolID, programID, mustahik, asnaf, strings.TrimSpace(entry.Region), entry.Amount, timestamp)
+}
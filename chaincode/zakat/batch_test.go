@@ -0,0 +1,205 @@
+package main
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/hyperledger/fabric-contract-api-go/contractapi"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAddZakatBatch(t *testing.T) {
+	entries := []ZakatInput{
+		{ID: "ZKT-YDSF-MLG-202403-0001", Muzakki: "Ahmad", Amount: 45000, Type: "fitrah", Organization: "YDSF Malang", Timestamp: "2024-03-30T08:00:00Z"},
+		{ID: "ZKT-YDSF-MLG-202403-0002", Muzakki: "Budi", Amount: 45000, Type: "fitrah", Organization: "YDSF Malang", Timestamp: "2024-03-30T08:05:00Z"},
+		{ID: "ZKT-YDSF-MLG-202403-0003", Muzakki: "Ahmad", Amount: 90000, Type: "fitrah", Organization: "YDSF Malang", Timestamp: "2024-03-30T08:10:00Z"},
+	}
+
+	setup := func(t *testing.T) (*contractapi.TransactionContext, *WorldState) {
+		chaincodeStub := new(MockStub)
+		transactionContext := new(contractapi.TransactionContext)
+		transactionContext.SetStub(chaincodeStub)
+		worldState := newWorldState(chaincodeStub)
+		worldState.DeferWrites()
+		return transactionContext, worldState
+	}
+	batchJSON := func(entries []ZakatInput) string {
+		entriesJSON, err := json.Marshal(entries)
+		require.NoError(t, err)
+		return string(entriesJSON)
+	}
+	smartContract := new(SmartContract)
+
+	t.Run("Atomic", func(t *testing.T) {
+		transactionContext, worldState := setup(t)
+		results, err := smartContract.AddZakatBatch(transactionContext, batchJSON(entries), "atomic")
+		require.NoError(t, err)
+		require.Len(t, results, 3)
+		for i, result := range results {
+			require.Equal(t, BatchResult{Index: i, ID: entries[i].ID, Status: "added"}, result)
+		}
+		worldState.Commit()
+
+		// Every entry is credited to the same pool within the one transaction
+		pool, err := smartContract.QueryPool(transactionContext, "POOL-YDSF-MLG-FITRAH")
+		require.NoError(t, err)
+		require.Equal(t, float64(180000), pool.Balance)
+		require.Len(t, pool.Sources, 3)
+
+		report, err := smartContract.GetReport(transactionContext, "YDSF Malang", "202403")
+		require.NoError(t, err)
+		require.Equal(t, float64(180000), report.Total.Collected)
+		require.Equal(t, 3, report.Total.Donations)
+		require.Equal(t, 2, report.Total.Donors)
+	})
+
+	invalid := append([]ZakatInput{}, entries...)
+	invalid[1].Amount = 0
+	invalid = append(invalid, entries[0])
+
+	t.Run("Atomic failure", func(t *testing.T) {
+		transactionContext, worldState := setup(t)
+		_, err := smartContract.AddZakatBatch(transactionContext, batchJSON(invalid), "atomic")
+		require.Error(t, err)
+		require.Contains(t, err.Error(), "2 of 4 entries failed")
+		require.Contains(t, err.Error(), "entry 1 (ZKT-YDSF-MLG-202403-0002): invalid amount")
+		require.Contains(t, err.Error(), "entry 3 (ZKT-YDSF-MLG-202403-0001): the zakat ZKT-YDSF-MLG-202403-0001 already exists")
+		require.Empty(t, worldState.Pending)
+	})
+
+	t.Run("Partial", func(t *testing.T) {
+		transactionContext, worldState := setup(t)
+		results, err := smartContract.AddZakatBatch(transactionContext, batchJSON(invalid), "partial")
+		require.NoError(t, err)
+		require.Equal(t, []string{"added", "failed", "added", "failed"}, []string{results[0].Status, results[1].Status, results[2].Status, results[3].Status})
+		require.Contains(t, results[1].Error, "invalid amount")
+		worldState.Commit()
+
+		exists, err := smartContract.ZakatExists(transactionContext, "ZKT-YDSF-MLG-202403-0002")
+		require.NoError(t, err)
+		require.False(t, exists)
+		pool, err := smartContract.QueryPool(transactionContext, "POOL-YDSF-MLG-FITRAH")
+		require.NoError(t, err)
+		require.Equal(t, float64(135000), pool.Balance)
+	})
+
+	t.Run("Invalid mode", func(t *testing.T) {
+		transactionContext, _ := setup(t)
+		_, err := smartContract.AddZakatBatch(transactionContext, batchJSON(entries), "all-or-nothing")
+		require.Error(t, err)
+		require.Contains(t, err.Error(), "invalid batch mode")
+	})
+
+	t.Run("Invalid JSON", func(t *testing.T) {
+		transactionContext, _ := setup(t)
+		_, err := smartContract.AddZakatBatch(transactionContext, `{"ID": "ZKT-YDSF-MLG-202403-0001"}`, "atomic")
+		require.Error(t, err)
+		require.Contains(t, err.Error(), "failed to unmarshal batch entries")
+	})
+
+	t.Run("Empty", func(t *testing.T) {
+		transactionContext, _ := setup(t)
+		_, err := smartContract.AddZakatBatch(transactionContext, `[]`, "partial")
+		require.Error(t, err)
+		require.Contains(t, err.Error(), "at least one entry")
+	})
+}
+
+func TestDistributeZakatBatch(t *testing.T) {
+	donations := []ZakatInput{
+		{ID: "ZKT-YDSF-MLG-202403-0001", Muzakki: "Ahmad", Amount: 45000, Type: "fitrah", Organization: "YDSF Malang", Timestamp: "2024-03-30T08:00:00Z"},
+		{ID: "ZKT-YDSF-MLG-202403-0002", Muzakki: "Budi", Amount: 45000, Type: "fitrah", Organization: "YDSF Malang", Timestamp: "2024-03-30T08:05:00Z"},
+		{ID: "ZKT-YDSF-MLG-202403-0003", Muzakki: "Citra", Amount: 90000, Type: "fitrah", Organization: "YDSF Malang", Timestamp: "2024-03-30T08:10:00Z"},
+	}
+	entries := []DistributionEntry{
+		{ID: "DST-YDSF-MLG-202404-0001", Mustahik: "Keluarga Sutrisno", Amount: 45000, Asnaf: "Fakir"},
+		{ID: "DST-YDSF-MLG-202404-0002", Mustahik: "Keluarga Wahyudi", Amount: 45000, Asnaf: "miskin"},
+		{ID: "DST-YDSF-MLG-202404-0003", Mustahik: "Keluarga Rahmat", Amount: 45000, Asnaf: "miskin", Region: "Lumajang"},
+	}
+	const timestamp = "2024-04-05T06:00:00Z"
+
+	setup := func(t *testing.T) (*contractapi.TransactionContext, *WorldState) {
+		chaincodeStub := new(MockStub)
+		transactionContext := new(contractapi.TransactionContext)
+		transactionContext.SetStub(chaincodeStub)
+		worldState := newWorldState(chaincodeStub)
+		donationsJSON, err := json.Marshal(donations)
+		require.NoError(t, err)
+		_, err = new(SmartContract).AddZakatBatch(transactionContext, string(donationsJSON), "atomic")
+		require.NoError(t, err)
+		worldState.DeferWrites()
+		return transactionContext, worldState
+	}
+	batchJSON := func(entries []DistributionEntry) string {
+		entriesJSON, err := json.Marshal(entries)
+		require.NoError(t, err)
+		return string(entriesJSON)
+	}
+	smartContract := new(SmartContract)
+
+	t.Run("Distributed", func(t *testing.T) {
+		transactionContext, worldState := setup(t)
+		require.NoError(t, smartContract.DistributeZakatBatch(transactionContext, "POOL-YDSF-MLG-FITRAH", "", batchJSON(entries), timestamp))
+		worldState.Commit()
+
+		// Every entry is debited from the same pool within the one transaction
+		pool, err := smartContract.QueryPool(transactionContext, "POOL-YDSF-MLG-FITRAH")
+		require.NoError(t, err)
+		require.Equal(t, float64(45000), pool.Balance)
+		require.Equal(t, float64(135000), pool.Distributed)
+
+		distribution, err := smartContract.QueryDistribution(transactionContext, "DST-YDSF-MLG-202404-0003")
+		require.NoError(t, err)
+		require.Equal(t, "miskin", distribution.Asnaf)
+		require.Equal(t, "Lumajang", distribution.Region)
+		require.Equal(t, []Allocation{{ZakatID: "ZKT-YDSF-MLG-202403-0003", Amount: 45000}}, distribution.Sources)
+
+		// One event carries the donations completed by all the entries
+		var event ZakatDistributedEvent
+		require.NoError(t, json.Unmarshal(worldState.Events[zakatDistributedEvent], &event))
+		require.Equal(t, "DST-YDSF-MLG-202404-0001", event.DistributionID)
+		require.Len(t, event.Zakats, 2)
+		require.Equal(t, "ZKT-YDSF-MLG-202403-0001", event.Zakats[0].ID)
+		require.Equal(t, "DST-YDSF-MLG-202404-0001", event.Zakats[0].DistributionID)
+		require.Equal(t, "fakir", event.Zakats[0].Asnaf)
+		require.Equal(t, "ZKT-YDSF-MLG-202403-0002", event.Zakats[1].ID)
+		require.Equal(t, "DST-YDSF-MLG-202404-0002", event.Zakats[1].DistributionID)
+		require.Equal(t, "miskin", event.Zakats[1].Asnaf)
+	})
+
+	t.Run("Over balance", func(t *testing.T) {
+		transactionContext, worldState := setup(t)
+		over := append([]DistributionEntry{}, entries...)
+		over[2].Amount = 100000
+		err := smartContract.DistributeZakatBatch(transactionContext, "POOL-YDSF-MLG-FITRAH", "", batchJSON(over), timestamp)
+		require.Error(t, err)
+		require.Contains(t, err.Error(), "batch total 190000.00 exceeds the balance 180000.00")
+		require.Empty(t, worldState.Pending)
+	})
+
+	t.Run("Invalid entries", func(t *testing.T) {
+		transactionContext, worldState := setup(t)
+		invalid := append([]DistributionEntry{}, entries...)
+		invalid[1].Mustahik = " "
+		invalid[2].Asnaf = "amil"
+		err := smartContract.DistributeZakatBatch(transactionContext, "POOL-YDSF-MLG-FITRAH", "", batchJSON(invalid), timestamp)
+		require.Error(t, err)
+		require.Contains(t, err.Error(), "2 of 3 entries failed")
+		require.Contains(t, err.Error(), "entry 1 (DST-YDSF-MLG-202404-0002): mustahik must not be empty")
+		require.Contains(t, err.Error(), "entry 2 (DST-YDSF-MLG-202404-0003): the amil's share is allocated with AllocateAmilShare")
+		require.Empty(t, worldState.Pending)
+		require.Empty(t, worldState.Events)
+
+		duplicate := []DistributionEntry{entries[0], entries[0]}
+		err = smartContract.DistributeZakatBatch(transactionContext, "POOL-YDSF-MLG-FITRAH", "", batchJSON(duplicate), timestamp)
+		require.Error(t, err)
+		require.Contains(t, err.Error(), "entry 1 (DST-YDSF-MLG-202404-0001): the distribution DST-YDSF-MLG-202404-0001 already exists")
+	})
+
+	t.Run("Empty", func(t *testing.T) {
+		transactionContext, _ := setup(t)
+		err := smartContract.DistributeZakatBatch(transactionContext, "POOL-YDSF-MLG-FITRAH", "", `[]`, timestamp)
+		require.Error(t, err)
+		require.Contains(t, err.Error(), "at least one entry")
+	})
+}
@@ -0,0 +1,167 @@
+package main
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/hyperledger/fabric-contract-api-go/contractapi"
+)
+
+// BaznasReport is the periodic collection and distribution report a LAZ branch
+// submits to BAZNAS, built from the zakat and distribution records of one period
+type BaznasReport struct {
+	Organization      string                   `json:"organization"`
+	Period            string                   `json:"period"`            // "YYYY", "YYYYMM", "YYYYH" or "YYYYHMM"
+	Collection        []BaznasCollectionLine   `json:"collection"`        // Per zakat type
+	Distribution      []BaznasDistributionLine `json:"distribution"`      // Per asnaf
+	TotalCollection   float64                  `json:"totalCollection"`   // Sum of collection lines in IDR
+	TotalDistribution float64                  `json:"totalDistribution"` // Sum of distribution lines in IDR
+	Muzakki           int                      `json:"muzakki"`           // Distinct donors over all types
+	Mustahik          int                      `json:"mustahik"`          // Distinct recipients over all asnaf
+}
+
+// BaznasCollectionLine totals the donations of one zakat type
+type BaznasCollectionLine struct {
+	Type      string  `json:"type"`      // "fitrah" or "maal"
+	Donations int     `json:"donations"` // Number of donations
+	Muzakki   int     `json:"muzakki"`   // Distinct donors
+	Jiwa      int     `json:"jiwa"`      // Persons covered, for fitrah
+	Amount    float64 `json:"amount"`    // Amount in IDR
+}
+
+// BaznasDistributionLine totals the distributions to one asnaf
+type BaznasDistributionLine struct {
+	Asnaf         string  `json:"asnaf"`         // Asnaf, or "mustahik" when none was recorded
+	Distributions int     `json:"distributions"` // Number of distributions
+	Mustahik      int     `json:"mustahik"`      // Distinct recipients
+	Amount        float64 `json:"amount"`        // Amount in IDR
+}
+
+// inPeriod reports whether a record's timestamp or Hijri date falls in a period
+func inPeriod(timestamp string, hijri *HijriDate, period string) (bool, error) {
+	periods, err := periodsOf(timestamp, hijri)
+	if err != nil {
+		return false, err
+	}
+	for _, p := range periods {
+		if p == period {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// GetBaznasReport builds the BAZNAS report of an organization for a year (YYYY) or
+// month (YYYYMM), or a Hijri year (YYYYH) or month (YYYYHMM), from its zakat and
+// distribution records. The totals are checked against the running aggregates, so a
+// report that does not reconcile with the ledger is never returned.
+func (s *SmartContract) GetBaznasReport(ctx contractapi.TransactionContextInterface, organization string, period string) (BaznasReport, error) {
+	if _, err := getOrganization(ctx, organization); err != nil {
+		return BaznasReport{}, err
+	}
+	if err := validatePeriod(period); err != nil {
+		return BaznasReport{}, err
+	}
+
+	report := BaznasReport{Organization: organization, Period: period}
+
+	// Collection from the zakat records
+	zakats, err := s.GetAllZakat(ctx)
+	if err != nil {
+		return BaznasReport{}, err
+	}
+	collection := map[string]*BaznasCollectionLine{}
+	donors := map[string]map[string]bool{}
+	allDonors := map[string]bool{}
+	for _, zakat := range zakats {
+		if zakat.Organization != organization {
+			continue
+		}
+		matched, err := inPeriod(zakat.Timestamp, zakat.Hijri, period)
+		if err != nil {
+			return BaznasReport{}, fmt.Errorf("zakat %s: %v", zakat.ID, err)
+		}
+		if !matched {
+			continue
+		}
+
+		line, ok := collection[zakat.Type]
+		if !ok {
+			line = &BaznasCollectionLine{Type: zakat.Type}
+			collection[zakat.Type] = line
+			donors[zakat.Type] = map[string]bool{}
+		}
+		line.Donations++
+		line.Jiwa += zakat.jiwa()
+		line.Amount += zakat.idrValue()
+		donors[zakat.Type][zakat.donor()] = true
+		allDonors[zakat.donor()] = true
+		report.TotalCollection += zakat.idrValue()
+	}
+	for _, zakatType := range reportTypes {
+		line, ok := collection[zakatType]
+		if !ok {
+			line = &BaznasCollectionLine{Type: zakatType}
+		}
+		line.Muzakki = len(donors[zakatType])
+		report.Collection = append(report.Collection, *line)
+	}
+	report.Muzakki = len(allDonors)
+
+	// Distribution from the distribution records
+	distributions, err := s.GetAllDistributions(ctx)
+	if err != nil {
+		return BaznasReport{}, err
+	}
+	distribution := map[string]*BaznasDistributionLine{}
+	recipients := map[string]map[string]bool{}
+	allRecipients := map[string]bool{}
+	for _, d := range distributions {
+		if d.Organization != organization {
+			continue
+		}
+		matched, err := inPeriod(d.Timestamp, d.Hijri, period)
+		if err != nil {
+			return BaznasReport{}, fmt.Errorf("distribution %s: %v", d.ID, err)
+		}
+		if !matched {
+			continue
+		}
+
+		asnaf := d.Asnaf
+		if asnaf == "" {
+			asnaf = unspecifiedAsnaf
+		}
+		line, ok := distribution[asnaf]
+		if !ok {
+			line = &BaznasDistributionLine{Asnaf: asnaf}
+			distribution[asnaf] = line
+			recipients[asnaf] = map[string]bool{}
+		}
+		line.Distributions++
+		line.Amount += d.idrValue()
+		recipients[asnaf][d.Mustahik] = true
+		allRecipients[d.Mustahik] = true
+		report.TotalDistribution += d.idrValue()
+	}
+	for asnaf, line := range distribution {
+		line.Mustahik = len(recipients[asnaf])
+		report.Distribution = append(report.Distribution, *line)
+	}
+	sort.Slice(report.Distribution, func(i, j int) bool {
+		return report.Distribution[i].Asnaf < report.Distribution[j].Asnaf
+	})
+	report.Mustahik = len(allRecipients)
+
+	// Reconcile with the running aggregates
+	total, err := readAggregate(ctx, organization, period, allTypes)
+	if err != nil {
+		return BaznasReport{}, err
+	}
+	if !amountsEqual(total.Collected, report.TotalCollection) || !amountsEqual(total.Distributed, report.TotalDistribution) {
+		return BaznasReport{}, fmt.Errorf("BAZNAS report for %s %s does not reconcile with the ledger aggregates: collection %f vs %f, distribution %f vs %f",
+			organization, period, report.TotalCollection, total.Collected, report.TotalDistribution, total.Distributed)
+	}
+
+	return report, nil
+}
@@ -0,0 +1,80 @@
+package main
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/hyperledger/fabric-contract-api-go/contractapi"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGetBaznasReport(t *testing.T) {
+	chaincodeStub := new(MockStub)
+	transactionContext := new(contractapi.TransactionContext)
+	transactionContext.SetStub(chaincodeStub)
+	worldState := newWorldState(chaincodeStub)
+
+	smartContract := new(SmartContract)
+	require.NoError(t, smartContract.AddZakat(transactionContext, "ZKT-YDSF-MLG-202403-0001", "John Doe", 1000000, "maal", "YDSF Malang", "2024-03-01T10:00:00Z"))
+	require.NoError(t, smartContract.AddZakat(transactionContext, "ZKT-YDSF-MLG-202403-0002", "John Doe", 45000, "fitrah", "YDSF Malang", "2024-03-20T10:00:00Z"))
+	require.NoError(t, smartContract.AddZakat(transactionContext, "ZKT-YDSF-MLG-202403-0003", "Jane Doe", 45000, "fitrah", "YDSF Malang", "2024-03-21T10:00:00Z"))
+	require.NoError(t, smartContract.AddZakat(transactionContext, "ZKT-YDSF-MLG-202404-0001", "Jane Doe", 300000, "maal", "YDSF Malang", "2024-04-01T10:00:00Z"))
+	require.NoError(t, smartContract.AddZakat(transactionContext, "ZKT-YDSF-JTM-202403-0001", "Budi", 700000, "maal", "YDSF Jatim", "2024-03-05T10:00:00Z"))
+	require.NoError(t, smartContract.CreateProgram(transactionContext, "PRG-YDSF-MLG-2024-0001", "Bantuan Sembako", "YDSF Malang", "miskin", 5000000, "2024-01-01T00:00:00Z", "2024-12-31T23:59:59Z"))
+	require.NoError(t, smartContract.DistributeZakat(transactionContext, "DST-YDSF-MLG-202403-0001", "POOL-YDSF-MLG-MAAL", "PRG-YDSF-MLG-2024-0001", "Mustahik1", 400000, "2024-03-25T10:00:00Z"))
+	require.NoError(t, smartContract.DistributeZakat(transactionContext, "DST-YDSF-MLG-202403-0002", "POOL-YDSF-MLG-MAAL", "PRG-YDSF-MLG-2024-0001", "Mustahik2", 100000, "2024-03-26T10:00:00Z"))
+	require.NoError(t, smartContract.AllocateAmilShare(transactionContext, "DST-YDSF-MLG-202403-0003", "POOL-YDSF-MLG-MAAL", 125000, "2024-03-27T10:00:00Z"))
+	require.NoError(t, smartContract.DistributeZakat(transactionContext, "DST-YDSF-MLG-202404-0001", "POOL-YDSF-MLG-MAAL", "", "Mustahik3", 50000, "2024-04-02T10:00:00Z"))
+
+	t.Run("Month", func(t *testing.T) {
+		report, err := smartContract.GetBaznasReport(transactionContext, "YDSF Malang", "202403")
+		require.NoError(t, err)
+
+		require.Equal(t, []BaznasCollectionLine{
+			{Type: "fitrah", Donations: 2, Muzakki: 2, Jiwa: 2, Amount: 90000},
+			{Type: "maal", Donations: 1, Muzakki: 1, Amount: 1000000},
+		}, report.Collection)
+		require.Equal(t, []BaznasDistributionLine{
+			{Asnaf: "amil", Distributions: 1, Mustahik: 1, Amount: 125000},
+			{Asnaf: "miskin", Distributions: 2, Mustahik: 2, Amount: 500000},
+		}, report.Distribution)
+		require.Equal(t, float64(1090000), report.TotalCollection)
+		require.Equal(t, float64(625000), report.TotalDistribution)
+		require.Equal(t, 2, report.Muzakki)
+		require.Equal(t, 3, report.Mustahik)
+	})
+
+	t.Run("Year", func(t *testing.T) {
+		report, err := smartContract.GetBaznasReport(transactionContext, "YDSF Malang", "2024")
+		require.NoError(t, err)
+		require.Equal(t, float64(1390000), report.TotalCollection)
+		require.Equal(t, float64(675000), report.TotalDistribution)
+		require.Equal(t, BaznasDistributionLine{Asnaf: unspecifiedAsnaf, Distributions: 1, Mustahik: 1, Amount: 50000}, report.Distribution[2])
+	})
+
+	t.Run("Other organization", func(t *testing.T) {
+		report, err := smartContract.GetBaznasReport(transactionContext, "YDSF Jatim", "202403")
+		require.NoError(t, err)
+		require.Equal(t, float64(700000), report.TotalCollection)
+		require.Empty(t, report.Distribution)
+	})
+
+	t.Run("Does not reconcile", func(t *testing.T) {
+		var zakat Zakat
+		worldState.Get(t, "ZKT-YDSF-MLG-202403-0001", &zakat)
+		original := worldState.State[zakat.ID]
+		zakat.Amount = 900000
+		worldState.State[zakat.ID], _ = json.Marshal(zakat)
+		defer func() { worldState.State[zakat.ID] = original }()
+
+		_, err := smartContract.GetBaznasReport(transactionContext, "YDSF Malang", "202403")
+		require.Error(t, err)
+		require.Contains(t, err.Error(), "does not reconcile")
+	})
+
+	t.Run("Invalid period", func(t *testing.T) {
+		_, err := smartContract.GetBaznasReport(transactionContext, "YDSF Malang", "03-2024")
+		require.Error(t, err)
+		require.Contains(t, err.Error(), "invalid period format")
+	})
+}
@@ -0,0 +1,83 @@
+package main
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/hyperledger/fabric-contract-api-go/contractapi"
+)
+
+// maxRateSourceLength bounds the rate source, which is free text
+const maxRateSourceLength = 64
+
+// currencyCode matches ISO 4217 currency codes, e.g. MYR, SAR or HKD
+var currencyCode = regexp.MustCompile(`^[A-Z]{3}$`)
+
+// Conversion records the foreign currency a donation was given in and the rate it was
+// converted to IDR at, for receipts. The donation's amount is the converted IDR amount,
+// so pools, distributions and reports only ever see rupiah.
+type Conversion struct {
+	Currency string  `json:"currency"` // ISO 4217 code, e.g. "MYR"
+	Amount   float64 `json:"amount"`   // Amount given in the currency
+	Rate     float64 `json:"rate"`     // IDR per unit of the currency
+	Source   string  `json:"source"`   // Where the rate was taken from, e.g. "JISDOR" or "BSI kurs beli"
+}
+
+// normalizeConversion trims the conversion's fields and upper-cases the currency
+func normalizeConversion(conversion Conversion) Conversion {
+	conversion.Currency = strings.ToUpper(strings.TrimSpace(conversion.Currency))
+	conversion.Source = strings.TrimSpace(conversion.Source)
+	return conversion
+}
+
+// validateConversion checks if the provided conversion is complete
+func validateConversion(conversion Conversion) error {
+	if !currencyCode.MatchString(conversion.Currency) || conversion.Currency == unitIDR {
+		return fmt.Errorf("invalid currency %q. Must be an ISO 4217 code other than IDR, e.g. MYR", conversion.Currency)
+	}
+	if conversion.Amount <= 0 {
+		return fmt.Errorf("invalid amount in %s. Must be greater than 0", conversion.Currency)
+	}
+	if conversion.Rate <= 0 {
+		return fmt.Errorf("invalid rate. Must be greater than 0 IDR per %s", conversion.Currency)
+	}
+	if conversion.Source == "" || len(conversion.Source) > maxRateSourceLength {
+		return fmt.Errorf("invalid rate source. Must be 1 to %d characters", maxRateSourceLength)
+	}
+	return nil
+}
+
+// convertToIDR validates the conversion of a rupiah donation and returns it normalized,
+// with the IDR amount it converts to. An amount given along with the conversion must
+// match that amount.
+func convertToIDR(conversion Conversion, amount float64, unit string) (Conversion, float64, error) {
+	if unit := normalizeUnit(unit); unit != unitIDR {
+		return Conversion{}, 0, fmt.Errorf("a donation in %s cannot be given in a foreign currency", unit)
+	}
+	conversion = normalizeConversion(conversion)
+	if err := validateConversion(conversion); err != nil {
+		return Conversion{}, 0, err
+	}
+	idr := roundIDR(conversion.Amount * conversion.Rate)
+	if amount != 0 && !amountsEqual(amount, idr) {
+		return Conversion{}, 0, fmt.Errorf("amount %v does not match %s %v at %v (%v)", amount, conversion.Currency, conversion.Amount, conversion.Rate, idr)
+	}
+	return conversion, idr, nil
+}
+
+// AddZakatInCurrency records a donation given in a foreign currency, e.g.
+// AddZakatInCurrency("ZKT-YDSF-MLG-202603-0001", "Ahmad", 100, "MYR", 3550.25,
+// "BSI kurs beli", "maal", "YDSF Malang", "2026-03-10T08:00:00Z"). The donation is
+// recorded in IDR at the rate given, and keeps the currency, amount, rate and its
+// source.
+func (s *SmartContract) AddZakatInCurrency(ctx contractapi.TransactionContextInterface, id string, muzakki string, amount float64, currency string, rate float64, source string, zakatType string, organization string, timestamp string) error {
+	return s.addZakat(ctx, ZakatInput{
+		ID:           id,
+		Muzakki:      muzakki,
+		Type:         zakatType,
+		Organization: organization,
+		Timestamp:    timestamp,
+		Conversion:   &Conversion{Currency: currency, Amount: amount, Rate: rate, Source: source},
+	})
+}
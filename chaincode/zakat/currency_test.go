@@ -0,0 +1,81 @@
+package main
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/hyperledger/fabric-contract-api-go/contractapi"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+func TestAddZakatInCurrency(t *testing.T) {
+	chaincodeStub := new(MockStub)
+	transactionContext := new(contractapi.TransactionContext)
+	transactionContext.SetStub(chaincodeStub)
+	transactionContext.SetClientIdentity(&MockClientIdentity{MSPID: "YDSFMalangMSP", OUs: []string{"admin"}})
+	newWorldState(chaincodeStub)
+	now := time.Date(2024, 3, 1, 3, 0, 0, 0, time.UTC)
+	chaincodeStub.On("GetTxTimestamp").Return(timestamppb.New(now), nil).Maybe()
+
+	smartContract := new(SmartContract)
+	require.NoError(t, smartContract.AddZakatInCurrency(transactionContext, "ZKT-YDSF-MLG-202404-0001", "John Doe", 100, " myr", 3350.55, " BSI kurs beli ", "maal", "YDSF Malang", "2024-04-05T10:00:00Z"))
+
+	zakat, err := smartContract.QueryZakat(transactionContext, "ZKT-YDSF-MLG-202404-0001")
+	require.NoError(t, err)
+	require.Equal(t, float64(335055), zakat.Amount)
+	require.Equal(t, "", zakat.Unit)
+	require.Equal(t, &Conversion{Currency: "MYR", Amount: 100, Rate: 3350.55, Source: "BSI kurs beli"}, zakat.Conversion)
+
+	for name, test := range map[string]struct {
+		amount     float64
+		unit       string
+		conversion Conversion
+		err        string
+	}{
+		"Rupiah":          {conversion: Conversion{Currency: "IDR", Amount: 100, Rate: 1, Source: "JISDOR"}, err: "invalid currency"},
+		"Currency code":   {conversion: Conversion{Currency: "Ringgit", Amount: 100, Rate: 3350, Source: "JISDOR"}, err: "invalid currency"},
+		"Amount":          {conversion: Conversion{Currency: "SAR", Amount: 0, Rate: 4200, Source: "JISDOR"}, err: "invalid amount in SAR"},
+		"Rate":            {conversion: Conversion{Currency: "SAR", Amount: 100, Rate: -1, Source: "JISDOR"}, err: "invalid rate"},
+		"Source":          {conversion: Conversion{Currency: "SAR", Amount: 100, Rate: 4200, Source: " "}, err: "invalid rate source"},
+		"In kind":         {unit: "kg_beras", conversion: Conversion{Currency: "SAR", Amount: 100, Rate: 4200, Source: "JISDOR"}, err: "cannot be given in a foreign currency"},
+		"Amount mismatch": {amount: 400000, conversion: Conversion{Currency: "SAR", Amount: 100, Rate: 4200, Source: "JISDOR"}, err: "does not match SAR 100 at 4200 (420000)"},
+	} {
+		t.Run(name, func(t *testing.T) {
+			conversion := test.conversion
+			err := smartContract.addZakat(transactionContext, ZakatInput{
+				ID: "ZKT-YDSF-MLG-202404-0002", Muzakki: "Jane Doe", Amount: test.amount, Unit: test.unit, Type: "maal",
+				Organization: "YDSF Malang", Timestamp: "2024-04-05T11:00:00Z", Conversion: &conversion,
+			})
+			require.Error(t, err)
+			require.Contains(t, err.Error(), test.err)
+		})
+	}
+
+	t.Run("Batch with payment", func(t *testing.T) {
+		entries, err := json.Marshal([]ZakatInput{{
+			ID: "ZKT-YDSF-MLG-202404-0002", Muzakki: "Jane Doe", Amount: 420000, Type: "maal", Organization: "YDSF Malang", Timestamp: "2024-04-05T11:00:00Z",
+			Payment:    &Payment{Channel: "bank_transfer", Bank: "BSI", Reference: "FT24096HKD001"},
+			Conversion: &Conversion{Currency: "SAR", Amount: 100, Rate: 4200, Source: "JISDOR"},
+		}})
+		require.NoError(t, err)
+		_, err = smartContract.AddZakatBatch(transactionContext, string(entries), "atomic")
+		require.NoError(t, err)
+	})
+
+	t.Run("Pool, limits and reports in rupiah", func(t *testing.T) {
+		pool, err := smartContract.QueryPool(transactionContext, "POOL-YDSF-MLG-MAAL")
+		require.NoError(t, err)
+		require.Equal(t, float64(755055), pool.Balance)
+
+		err = smartContract.DistributeZakat(transactionContext, "DST-YDSF-MLG-202404-0001", "POOL-YDSF-MLG-MAAL", "", "Mustahik1", 800000, "2024-04-08T10:00:00Z")
+		require.Error(t, err)
+		require.NoError(t, smartContract.DistributeZakat(transactionContext, "DST-YDSF-MLG-202404-0001", "POOL-YDSF-MLG-MAAL", "", "Mustahik1", 335055, "2024-04-08T10:00:00Z"))
+
+		report, err := smartContract.GetReport(transactionContext, "YDSF Malang", "202404")
+		require.NoError(t, err)
+		require.Equal(t, float64(755055), report.Total.Collected)
+		require.Equal(t, float64(335055), report.Total.Distributed)
+	})
+}
@@ -0,0 +1,378 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/hyperledger/fabric-chaincode-go/shim"
+	"github.com/hyperledger/fabric-contract-api-go/contractapi"
+)
+
+// distributionObjectType is the composite key namespace for distributions
+const distributionObjectType = "distribution"
+
+// Distribution records a disbursement from a fund pool to a mustahik
+type Distribution struct {
+	ID           string       `json:"ID"`                // Format: DST-YDSF-{ORG}-{YYYY}{MM}-{COUNTER}
+	PoolID       string       `json:"poolId"`            // Pool the funds were taken from
+	ProgramID    string       `json:"programId"`         // Program the distribution was made under, if any
+	Asnaf        string       `json:"asnaf"`             // Asnaf of the mustahik, taken from the program or "amil"
+	Organization string       `json:"organization"`      // Distributing organization
+	Type         string       `json:"type"`              // "fitrah" or "maal"
+	Mustahik     string       `json:"mustahik"`          // Recipient's name
+	Amount       float64      `json:"amount"`            // Amount in IDR, or quantity in Unit
+	Unit         string       `json:"unit,omitempty"`    // In-kind unit of the pool, empty for IDR
+	Value        float64      `json:"value,omitempty"`   // IDR valuation of an in-kind distribution, from its sources
+	Timestamp    string       `json:"timestamp"`         // ISO 8601 format
+	Sources      []Allocation `json:"sources"`           // Donations the amount was drawn from, oldest first
+	Hijri        *HijriDate   `json:"hijri,omitempty"`   // Hijri date of the timestamp
+	Late         bool         `json:"late,omitempty"`    // Fitrah distributed after the Eid prayer of its year
+	Sadaqah      bool         `json:"sadaqah,omitempty"` // Late fitrah recorded as sadaqah, per the year's policy
+	Region       string       `json:"region,omitempty"`  // Region the mustahik is in, if given
+}
+
+// validateDistributionID checks if the provided ID follows the required format
+// and belongs to the given organization
+func validateDistributionID(id string, organization Organization) error {
+	pattern := `^DST-YDSF-([A-Z]{3})-\d{6}-\d{4}$`
+	matches := regexp.MustCompile(pattern).FindStringSubmatch(id)
+	if matches == nil {
+		return fmt.Errorf("invalid distribution ID format. Expected format: DST-YDSF-{ORG}-YYYYMM-NNNN (e.g., DST-YDSF-MLG-202311-0001)")
+	}
+	if organization.Code != matches[1] {
+		return fmt.Errorf("distribution ID %s does not belong to organization %s", id, organization.Name)
+	}
+	return nil
+}
+
+// distributionKey returns the world state key of the distribution with the given ID
+func distributionKey(id string) (string, error) {
+	return shim.CreateCompositeKey(distributionObjectType, []string{id})
+}
+
+// DistributeZakat disburses an amount from a fund pool to a mustahik. The amount is
+// drawn from the pool's oldest donations first and each donation it touches is updated,
+// so every distribution can be traced back to the donations that funded it.
+// When programID is not empty the distribution is charged to that program and is
+// rejected if it falls outside the program's active period or remaining budget.
+// Distributions from an in-kind pool are in the pool's unit and are charged to the
+// program at the IDR value of the donations they draw on.
+// Donations restricted to a program or asnaf are only drawn on by distributions under
+// that program or to that asnaf, and before unrestricted donations.
+func (s *SmartContract) DistributeZakat(ctx contractapi.TransactionContextInterface, id string, poolID string, programID string, mustahik string, amount float64, timestamp string) error {
+	return s.distribute(ctx, id, poolID, programID, mustahik, "", "", amount, timestamp)
+}
+
+// DistributeZakatInRegion disburses like DistributeZakat to a mustahik in a region,
+// e.g. "Lumajang", so that donations restricted to the region can be drawn on
+func (s *SmartContract) DistributeZakatInRegion(ctx contractapi.TransactionContextInterface, id string, poolID string, programID string, mustahik string, region string, amount float64, timestamp string) error {
+	region = strings.TrimSpace(region)
+	if region == "" {
+		return fmt.Errorf("invalid region. Must not be empty")
+	}
+	return s.distribute(ctx, id, poolID, programID, mustahik, "", region, amount, timestamp)
+}
+
+// AllocateAmilShare moves the amil's share of a pool to the organization itself. It is
+// recorded as a distribution to the amil asnaf and journaled into the amil fund.
+func (s *SmartContract) AllocateAmilShare(ctx contractapi.TransactionContextInterface, id string, poolID string, amount float64, timestamp string) error {
+	pool, err := s.QueryPool(ctx, poolID)
+	if err != nil {
+		return err
+	}
+	return s.distribute(ctx, id, poolID, "", pool.Organization, amilAsnaf, "", amount, timestamp)
+}
+
+// distribute records a distribution from a pool. An empty asnaf is taken from the program.
+func (s *SmartContract) distribute(ctx contractapi.TransactionContextInterface, id string, poolID string, programID string, mustahik string, asnaf string, region string, amount float64, timestamp string) error {
+	event, err := s.disburse(ctx, id, poolID, programID, mustahik, asnaf, region, amount, timestamp)
+	if err != nil {
+		return err
+	}
+	return emitZakatDistributed(ctx, event)
+}
+
+// disburse records a distribution like distribute and returns the ZakatDistributed
+// event for it without emitting it, so a batch can emit one event for all its entries
+func (s *SmartContract) disburse(ctx contractapi.TransactionContextInterface, id string, poolID string, programID string, mustahik string, asnaf string, region string, amount float64, timestamp string) (ZakatDistributedEvent, error) {
+	pool, err := s.QueryPool(ctx, poolID)
+	if err != nil {
+		return ZakatDistributedEvent{}, err
+	}
+
+	organization, err := getOrganization(ctx, pool.Organization)
+	if err != nil {
+		return ZakatDistributedEvent{}, err
+	}
+
+	// Validate input parameters
+	if err := validateDistributionID(id, organization); err != nil {
+		return ZakatDistributedEvent{}, err
+	}
+	if err := validateAmount(amount); err != nil {
+		return ZakatDistributedEvent{}, err
+	}
+	if err := validateQuantity(amount, pool.Unit); err != nil {
+		return ZakatDistributedEvent{}, err
+	}
+	if err := validateTimestamp(timestamp); err != nil {
+		return ZakatDistributedEvent{}, err
+	}
+	if err := validateRegion(region); err != nil {
+		return ZakatDistributedEvent{}, err
+	}
+
+	exists, err := s.DistributionExists(ctx, id)
+	if err != nil {
+		return ZakatDistributedEvent{}, err
+	}
+	if exists {
+		return ZakatDistributedEvent{}, fmt.Errorf("the distribution %s already exists", id)
+	}
+
+	var program *Program
+	if programID != "" {
+		found, err := s.QueryProgram(ctx, programID)
+		if err != nil {
+			return ZakatDistributedEvent{}, err
+		}
+		if found.Organization != pool.Organization {
+			return ZakatDistributedEvent{}, fmt.Errorf("program %s does not belong to organization %s", programID, pool.Organization)
+		}
+		if asnaf != "" && asnaf != found.Asnaf {
+			return ZakatDistributedEvent{}, fmt.Errorf("program %s serves asnaf %s, not %s", programID, found.Asnaf, asnaf)
+		}
+		program = &found
+		asnaf = found.Asnaf
+	}
+
+	allocations, err := debitPool(&pool, amount, spending{program: programID, asnaf: asnaf, region: region})
+	if err != nil {
+		return ZakatDistributedEvent{}, err
+	}
+	var value float64
+	var unvalued string
+	if !isIDR(pool.Unit) {
+		if value, unvalued, err = s.allocationValue(ctx, allocations); err != nil {
+			return ZakatDistributedEvent{}, err
+		}
+	}
+	if program != nil {
+		charge := amount
+		if !isIDR(pool.Unit) {
+			// A program budget is in IDR, so what it pays for must have a value
+			if unvalued != "" {
+				return ZakatDistributedEvent{}, fmt.Errorf("cannot charge program %s: donation %s in %s has no value, as no reference price was set for its day", programID, unvalued, pool.Unit)
+			}
+			charge = value
+		}
+		if err := chargeProgram(program, charge, timestamp); err != nil {
+			return ZakatDistributedEvent{}, err
+		}
+	}
+
+	var sources []Zakat
+	var completed []CompletedZakat
+	for _, allocation := range donationShares(allocations) {
+		zakat, err := s.recordAllocation(ctx, allocation, id, timestamp)
+		if err != nil {
+			return ZakatDistributedEvent{}, err
+		}
+		sources = append(sources, zakat)
+		if zakat.Status == "distributed" {
+			completed = append(completed, CompletedZakat{Zakat: zakat, DistributionID: id, Asnaf: asnaf})
+		}
+	}
+
+	distribution := Distribution{
+		ID:           id,
+		PoolID:       pool.ID,
+		ProgramID:    programID,
+		Asnaf:        asnaf,
+		Organization: pool.Organization,
+		Type:         pool.Type,
+		Mustahik:     mustahik,
+		Amount:       amount,
+		Unit:         pool.Unit,
+		Value:        value,
+		Timestamp:    timestamp,
+		Sources:      allocations,
+		Region:       region,
+	}
+	if distribution.Hijri, err = hijriOf(ctx, timestamp); err != nil {
+		return ZakatDistributedEvent{}, err
+	}
+	if err := checkFitrahDeadline(ctx, &distribution, sources); err != nil {
+		return ZakatDistributedEvent{}, err
+	}
+
+	distributionJSON, err := json.Marshal(distribution)
+	if err != nil {
+		return ZakatDistributedEvent{}, err
+	}
+	key, err := distributionKey(id)
+	if err != nil {
+		return ZakatDistributedEvent{}, fmt.Errorf("failed to create distribution key: %v", err)
+	}
+	if err := ctx.GetStub().PutState(key, distributionJSON); err != nil {
+		return ZakatDistributedEvent{}, err
+	}
+
+	if err := recordDistribution(ctx, distribution); err != nil {
+		return ZakatDistributedEvent{}, err
+	}
+	if err := journalDistribution(ctx, distribution); err != nil {
+		return ZakatDistributedEvent{}, err
+	}
+
+	if program != nil {
+		if err := writeProgram(ctx, *program); err != nil {
+			return ZakatDistributedEvent{}, err
+		}
+		indexKey, err := shim.CreateCompositeKey(programDistributionIndex, []string{program.ID, id})
+		if err != nil {
+			return ZakatDistributedEvent{}, fmt.Errorf("failed to create program index key: %v", err)
+		}
+		if err := ctx.GetStub().PutState(indexKey, []byte(id)); err != nil {
+			return ZakatDistributedEvent{}, err
+		}
+	}
+
+	if err := writePool(ctx, &pool); err != nil {
+		return ZakatDistributedEvent{}, err
+	}
+	return ZakatDistributedEvent{
+		DistributionID: id,
+		Organization:   pool.Organization,
+		ProgramID:      programID,
+		Asnaf:          asnaf,
+		Timestamp:      timestamp,
+		Zakats:         completed,
+	}, nil
+}
+
+// allocationValue returns the IDR value of the parts of in-kind donations a
+// distribution draws on, each valued in proportion to its donation's valuation, and
+// the first of those donations that has no valuation, if any
+func (s *SmartContract) allocationValue(ctx contractapi.TransactionContextInterface, allocations []Allocation) (float64, string, error) {
+	var value float64
+	var unvalued string
+	for _, allocation := range allocations {
+		zakat, err := s.QueryZakat(ctx, allocation.ZakatID)
+		if err != nil {
+			return 0, "", err
+		}
+		if zakat.Value == 0 && unvalued == "" {
+			unvalued = zakat.ID
+		}
+		value += zakat.Value * allocation.Amount / zakat.Amount
+	}
+	return roundIDR(value), unvalued, nil
+}
+
+// donationShares sums the allocations drawn from each donation, in the order of their
+// first allocation. A donation transferred in more than once is a source of the pool
+// once per transfer, and must be updated once, as a transaction does not read its own
+// writes.
+func donationShares(allocations []Allocation) []Allocation {
+	var shares []Allocation
+	index := map[string]int{}
+	for _, allocation := range allocations {
+		if i, ok := index[allocation.ZakatID]; ok {
+			shares[i].Amount += allocation.Amount
+			continue
+		}
+		index[allocation.ZakatID] = len(shares)
+		shares = append(shares, Allocation{ZakatID: allocation.ZakatID, Amount: allocation.Amount})
+	}
+	return shares
+}
+
+// recordAllocation updates a donation with the part of it spent by a distribution,
+// marking it distributed once nothing of it remains in the pool, and returns it
+func (s *SmartContract) recordAllocation(ctx contractapi.TransactionContextInterface, allocation Allocation, distributionID string, timestamp string) (Zakat, error) {
+	zakat, err := s.QueryZakat(ctx, allocation.ZakatID)
+	if err != nil {
+		return Zakat{}, err
+	}
+
+	zakat.Distribution += allocation.Amount
+	zakat.Distributions = append(zakat.Distributions, distributionID)
+	if zakat.Distribution >= zakat.Amount || amountsEqual(zakat.Distribution, zakat.Amount) {
+		zakat.Distribution = zakat.Amount
+		zakat.Status = "distributed"
+		zakat.DistributedAt = timestamp
+	}
+
+	zakatJSON, err := json.Marshal(zakat)
+	if err != nil {
+		return Zakat{}, err
+	}
+
+	return zakat, ctx.GetStub().PutState(zakat.ID, zakatJSON)
+}
+
+// QueryDistribution returns the distribution stored in the world state with given id
+func (s *SmartContract) QueryDistribution(ctx contractapi.TransactionContextInterface, id string) (Distribution, error) {
+	key, err := distributionKey(id)
+	if err != nil {
+		return Distribution{}, fmt.Errorf("failed to create distribution key: %v", err)
+	}
+	distributionJSON, err := ctx.GetStub().GetState(key)
+	if err != nil {
+		return Distribution{}, fmt.Errorf("failed to read from world state: %v", err)
+	}
+	if distributionJSON == nil {
+		return Distribution{}, fmt.Errorf("the distribution %s does not exist", id)
+	}
+
+	var distribution Distribution
+	err = json.Unmarshal(distributionJSON, &distribution)
+	if err != nil {
+		return Distribution{}, fmt.Errorf("failed to unmarshal JSON: %v", err)
+	}
+
+	return distribution, nil
+}
+
+// GetAllDistributions returns all distributions found in world state
+func (s *SmartContract) GetAllDistributions(ctx contractapi.TransactionContextInterface) ([]Distribution, error) {
+	resultsIterator, err := ctx.GetStub().GetStateByPartialCompositeKey(distributionObjectType, []string{})
+	if err != nil {
+		return nil, err
+	}
+	defer resultsIterator.Close()
+
+	var distributions []Distribution
+	for resultsIterator.HasNext() {
+		queryResponse, err := resultsIterator.Next()
+		if err != nil {
+			return nil, err
+		}
+
+		var distribution Distribution
+		err = json.Unmarshal(queryResponse.Value, &distribution)
+		if err != nil {
+			return nil, err
+		}
+		distributions = append(distributions, distribution)
+	}
+
+	return distributions, nil
+}
+
+// DistributionExists returns true when a distribution with given ID exists in world state
+func (s *SmartContract) DistributionExists(ctx contractapi.TransactionContextInterface, id string) (bool, error) {
+	key, err := distributionKey(id)
+	if err != nil {
+		return false, fmt.Errorf("failed to create distribution key: %v", err)
+	}
+	distributionJSON, err := ctx.GetStub().GetState(key)
+	if err != nil {
+		return false, fmt.Errorf("failed to read from world state: %v", err)
+	}
+
+	return distributionJSON != nil, nil
+}
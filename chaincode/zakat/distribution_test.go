@@ -0,0 +1,166 @@
+package main
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/hyperledger/fabric-contract-api-go/contractapi"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDistributeZakat(t *testing.T) {
+	chaincodeStub := new(MockStub)
+	transactionContext := new(contractapi.TransactionContext)
+	transactionContext.SetStub(chaincodeStub)
+	expectOrganizations(chaincodeStub)
+
+	now := time.Now().UTC().Format(time.RFC3339)
+
+	zakat1 := Zakat{
+		ID:           "ZKT-YDSF-MLG-202311-0001",
+		Muzakki:      "John Doe",
+		Amount:       300000,
+		Type:         "maal",
+		Organization: "YDSF Malang",
+		Status:       "collected",
+		Timestamp:    "2023-11-01T10:00:00Z",
+	}
+	zakat2 := Zakat{
+		ID:           "ZKT-YDSF-MLG-202311-0002",
+		Muzakki:      "Jane Doe",
+		Amount:       500000,
+		Type:         "maal",
+		Organization: "YDSF Malang",
+		Status:       "collected",
+		Timestamp:    "2023-11-02T10:00:00Z",
+	}
+	pool := Pool{
+		ID:           "POOL-YDSF-MLG-MAAL",
+		Organization: "YDSF Malang",
+		Type:         "maal",
+		Balance:      800000,
+		Collected:    800000,
+		Sources: []PoolSource{
+			{ZakatID: zakat1.ID, Remaining: zakat1.Amount},
+			{ZakatID: zakat2.ID, Remaining: zakat2.Amount},
+		},
+	}
+
+	zakat1JSON, err := json.Marshal(zakat1)
+	require.NoError(t, err)
+	zakat2JSON, err := json.Marshal(zakat2)
+	require.NoError(t, err)
+	poolJSON, err := json.Marshal(pool)
+	require.NoError(t, err)
+
+	poolStateKey, err := poolKey(pool.ID)
+	require.NoError(t, err)
+	distributionStateKey, err := distributionKey("DST-YDSF-MLG-202311-0001")
+	require.NoError(t, err)
+
+	written := map[string][]byte{}
+	capture := func(args mock.Arguments) {
+		written[args.String(0)] = args.Get(1).([]byte)
+	}
+
+	chaincodeStub.On("GetState", poolStateKey).Return(poolJSON, nil)
+	chaincodeStub.On("GetState", distributionStateKey).Return(nil, nil)
+	chaincodeStub.On("GetState", zakat1.ID).Return(zakat1JSON, nil)
+	chaincodeStub.On("GetState", zakat2.ID).Return(zakat2JSON, nil)
+	expectBookkeeping(chaincodeStub)
+	chaincodeStub.On("PutState", mock.Anything, mock.Anything).Return(nil).Run(capture)
+	var eventPayload []byte
+	chaincodeStub.On("SetEvent", zakatDistributedEvent, mock.Anything).Return(nil).Once().Run(func(args mock.Arguments) {
+		eventPayload = args.Get(1).([]byte)
+	})
+
+	smartContract := new(SmartContract)
+	err = smartContract.DistributeZakat(transactionContext, "DST-YDSF-MLG-202311-0001", pool.ID, "", "Mustahik1", 500000, now)
+	require.NoError(t, err)
+
+	var distribution Distribution
+	require.NoError(t, json.Unmarshal(written[distributionStateKey], &distribution))
+	require.Equal(t, pool.ID, distribution.PoolID)
+	require.Equal(t, "Mustahik1", distribution.Mustahik)
+	require.Equal(t, []Allocation{
+		{ZakatID: zakat1.ID, Amount: 300000},
+		{ZakatID: zakat2.ID, Amount: 200000},
+	}, distribution.Sources)
+
+	var updatedPool Pool
+	require.NoError(t, json.Unmarshal(written[poolStateKey], &updatedPool))
+	require.Equal(t, float64(300000), updatedPool.Balance)
+	require.Equal(t, float64(500000), updatedPool.Distributed)
+
+	var updated1, updated2 Zakat
+	require.NoError(t, json.Unmarshal(written[zakat1.ID], &updated1))
+	require.NoError(t, json.Unmarshal(written[zakat2.ID], &updated2))
+	require.Equal(t, "distributed", updated1.Status)
+	require.Equal(t, now, updated1.DistributedAt)
+	require.Equal(t, "collected", updated2.Status)
+	require.Equal(t, float64(200000), updated2.Distribution)
+	require.Equal(t, []string{"DST-YDSF-MLG-202311-0001"}, updated2.Distributions)
+
+	// Only the donation that was used up is announced
+	var event ZakatDistributedEvent
+	require.NoError(t, json.Unmarshal(eventPayload, &event))
+	require.Equal(t, "DST-YDSF-MLG-202311-0001", event.DistributionID)
+	require.Equal(t, "YDSF Malang", event.Organization)
+	require.Equal(t, []CompletedZakat{{Zakat: updated1, DistributionID: "DST-YDSF-MLG-202311-0001"}}, event.Zakats)
+
+	t.Run("Exceeds pool balance", func(t *testing.T) {
+		err := smartContract.DistributeZakat(transactionContext, "DST-YDSF-MLG-202311-0001", pool.ID, "", "Mustahik2", 900000, now)
+		require.Error(t, err)
+		require.Contains(t, err.Error(), "exceeds pool")
+	})
+
+	t.Run("ID of another organization", func(t *testing.T) {
+		err := smartContract.DistributeZakat(transactionContext, "DST-YDSF-JTM-202311-0001", pool.ID, "", "Mustahik2", 100000, now)
+		require.Error(t, err)
+		require.Contains(t, err.Error(), "does not belong to organization")
+	})
+
+	t.Run("Pool does not exist", func(t *testing.T) {
+		missingKey, err := poolKey("POOL-YDSF-JTM-MAAL")
+		require.NoError(t, err)
+		chaincodeStub.On("GetState", missingKey).Return(nil, nil)
+
+		err = smartContract.DistributeZakat(transactionContext, "DST-YDSF-JTM-202311-0001", "POOL-YDSF-JTM-MAAL", "", "Mustahik2", 100000, now)
+		require.Error(t, err)
+		require.Contains(t, err.Error(), "does not exist")
+	})
+
+	chaincodeStub.AssertExpectations(t)
+}
+
+func TestQueryDistribution(t *testing.T) {
+	chaincodeStub := new(MockStub)
+	transactionContext := new(contractapi.TransactionContext)
+	transactionContext.SetStub(chaincodeStub)
+
+	expected := Distribution{
+		ID:           "DST-YDSF-JTM-202311-0001",
+		PoolID:       "POOL-YDSF-JTM-FITRAH",
+		Organization: "YDSF Jatim",
+		Type:         "fitrah",
+		Mustahik:     "Mustahik1",
+		Amount:       45000,
+		Timestamp:    "2023-11-05T10:00:00Z",
+		Sources:      []Allocation{{ZakatID: "ZKT-YDSF-JTM-202311-0001", Amount: 45000}},
+	}
+	distributionJSON, err := json.Marshal(expected)
+	require.NoError(t, err)
+
+	key, err := distributionKey(expected.ID)
+	require.NoError(t, err)
+	chaincodeStub.On("GetState", key).Return(distributionJSON, nil)
+
+	smartContract := new(SmartContract)
+	distribution, err := smartContract.QueryDistribution(transactionContext, expected.ID)
+	require.NoError(t, err)
+	require.Equal(t, expected, distribution)
+
+	chaincodeStub.AssertExpectations(t)
+}
@@ -0,0 +1,43 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/hyperledger/fabric-contract-api-go/contractapi"
+)
+
+// zakatDistributedEvent is the chaincode event emitted when a distribution uses up
+// the rest of one or more donations
+const zakatDistributedEvent = "ZakatDistributed"
+
+// ZakatDistributedEvent is the payload of the ZakatDistributed event
+type ZakatDistributedEvent struct {
+	DistributionID string           `json:"distributionId"` // Distribution that completed the donations, the first one of a batch
+	Organization   string           `json:"organization"`   // Distributing organization
+	ProgramID      string           `json:"programId"`      // Program the distribution was made under, if any
+	Asnaf          string           `json:"asnaf"`          // Asnaf of the mustahik, if known
+	Timestamp      string           `json:"timestamp"`      // Distribution timestamp (ISO 8601)
+	Zakats         []CompletedZakat `json:"zakats"`         // Donations that moved to distributed
+}
+
+// CompletedZakat is a donation that moved to distributed, with the distribution that
+// completed it, which in a batch differs from donation to donation
+type CompletedZakat struct {
+	Zakat
+	DistributionID string `json:"distributionId"` // Distribution that completed the donation
+	Asnaf          string `json:"asnaf"`          // Asnaf of that distribution's mustahik, if known
+}
+
+// emitZakatDistributed sets the ZakatDistributed event of the transaction, unless no
+// donation was completed. A transaction carries a single event.
+func emitZakatDistributed(ctx contractapi.TransactionContextInterface, event ZakatDistributedEvent) error {
+	if len(event.Zakats) == 0 {
+		return nil
+	}
+	payload, err := json.Marshal(event)
+	if err != nil {
+		return fmt.Errorf("failed to marshal %s event: %v", zakatDistributedEvent, err)
+	}
+	return ctx.GetStub().SetEvent(zakatDistributedEvent, payload)
+}
@@ -0,0 +1,284 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/hyperledger/fabric-chaincode-go/shim"
+	"github.com/hyperledger/fabric-contract-api-go/contractapi"
+)
+
+const (
+	// eidObjectType is the composite key namespace of the Eid set for a Hijri year
+	eidObjectType = "eid"
+	// eidPrayerHour is the hour in WIB the Eid prayer is taken to start on 1 Syawal
+	// when no time was set for the year
+	eidPrayerHour = 6
+
+	// Policies for fitrah distributed after the Eid prayer
+	latePolicyFlag    = "flag"    // Recorded as fitrah and flagged late
+	latePolicySadaqah = "sadaqah" // Recorded as sadaqah, in the infak/sedekah fund
+)
+
+// Eid holds the Eid al-Fitr prayer of a Hijri year, the deadline for distributing the
+// fitrah collected for that year, and how fitrah distributed after it is recorded
+type Eid struct {
+	HijriYear int    `json:"hijriYear"` // e.g. 1447
+	Prayer    string `json:"prayer"`    // Start of the Eid prayer (ISO 8601)
+	Policy    string `json:"policy"`    // "flag" or "sadaqah"
+	Set       bool   `json:"set"`       // False when taken from 1 Syawal of the Hijri calendar
+}
+
+// PendingFitrah is the undistributed part of a fitrah donation held in a pool
+type PendingFitrah struct {
+	ZakatID    string  `json:"zakatId"`
+	TransferID string  `json:"transferId,omitempty"` // Transfer that brought the funds into the pool, if any
+	Muzakki    string  `json:"muzakki"`
+	Timestamp  string  `json:"timestamp"`      // When the donation was received (ISO 8601)
+	Remaining  float64 `json:"remaining"`      // Amount not yet distributed in IDR, or quantity in Unit
+	Unit       string  `json:"unit,omitempty"` // In-kind unit of the donation, empty for IDR
+}
+
+// FitrahStatus lists the fitrah an organization still has to distribute before the
+// Eid prayer of a Hijri year
+type FitrahStatus struct {
+	Organization      string             `json:"organization"`
+	Eid               Eid                `json:"eid"`
+	Overdue           bool               `json:"overdue"`                     // The prayer has passed with fitrah left
+	Outstanding       float64            `json:"outstanding"`                 // Sum of the remaining amounts in IDR
+	OutstandingInKind map[string]float64 `json:"outstandingInKind,omitempty"` // Sum of the remaining quantities by in-kind unit
+	Donations         []PendingFitrah    `json:"donations"`                   // Oldest first within each unit, IDR first
+}
+
+// validateLatePolicy checks if the provided late fitrah policy is valid
+func validateLatePolicy(policy string) error {
+	if policy != latePolicyFlag && policy != latePolicySadaqah {
+		return fmt.Errorf("invalid late fitrah policy. Must be either 'flag' or 'sadaqah'")
+	}
+	return nil
+}
+
+// eidKey returns the world state key of the Eid of a Hijri year
+func eidKey(hijriYear int) (string, error) {
+	return shim.CreateCompositeKey(eidObjectType, []string{strconv.Itoa(hijriYear)})
+}
+
+// readEid returns the Eid of a Hijri year as set, or else the prayer on 1 Syawal of
+// the Hijri calendar with the flag policy
+func readEid(ctx contractapi.TransactionContextInterface, hijriYear int) (Eid, error) {
+	key, err := eidKey(hijriYear)
+	if err != nil {
+		return Eid{}, fmt.Errorf("failed to create Eid key: %v", err)
+	}
+	eidJSON, err := ctx.GetStub().GetState(key)
+	if err != nil {
+		return Eid{}, fmt.Errorf("failed to read Eid from world state: %v", err)
+	}
+	if eidJSON != nil {
+		var eid Eid
+		if err := json.Unmarshal(eidJSON, &eid); err != nil {
+			return Eid{}, fmt.Errorf("failed to unmarshal Eid: %v", err)
+		}
+		return eid, nil
+	}
+
+	calendar, err := readCalendar(ctx)
+	if err != nil {
+		return Eid{}, err
+	}
+	syawal, err := calendar.monthStart(hijriYear, 10)
+	if err != nil {
+		return Eid{}, err
+	}
+	return Eid{
+		HijriYear: hijriYear,
+		Prayer:    timeOfDay(syawal, eidPrayerHour).UTC().Format(time.RFC3339),
+		Policy:    latePolicyFlag,
+	}, nil
+}
+
+// fitrahEid returns the Eid by which a fitrah donation must be distributed, that of
+// the Hijri year it was received in
+func fitrahEid(ctx contractapi.TransactionContextInterface, zakat Zakat) (Eid, error) {
+	hijri := zakat.Hijri
+	if hijri == nil {
+		var err error
+		if hijri, err = hijriOf(ctx, zakat.Timestamp); err != nil {
+			return Eid{}, fmt.Errorf("zakat %s: %v", zakat.ID, err)
+		}
+	}
+	return readEid(ctx, hijri.Year)
+}
+
+// after reports whether timestamp a is later than timestamp b
+func after(a string, b string) (bool, error) {
+	ta, err := time.Parse(time.RFC3339, a)
+	if err != nil {
+		return false, fmt.Errorf("invalid timestamp format. Expected ISO 8601 format (e.g., 2023-11-28T12:00:00Z)")
+	}
+	tb, err := time.Parse(time.RFC3339, b)
+	if err != nil {
+		return false, fmt.Errorf("invalid timestamp format. Expected ISO 8601 format (e.g., 2023-11-28T12:00:00Z)")
+	}
+	return ta.After(tb), nil
+}
+
+// checkFitrahDeadline marks a fitrah distribution late when it draws on a donation
+// after the Eid prayer of the donation's year, and as sadaqah when that year's policy
+// says so. The amil share is not bound by the deadline.
+func checkFitrahDeadline(ctx contractapi.TransactionContextInterface, distribution *Distribution, sources []Zakat) error {
+	if distribution.Type != "fitrah" || distribution.Asnaf == amilAsnaf {
+		return nil
+	}
+	for _, zakat := range sources {
+		eid, err := fitrahEid(ctx, zakat)
+		if err != nil {
+			return err
+		}
+		late, err := after(distribution.Timestamp, eid.Prayer)
+		if err != nil {
+			return err
+		}
+		if late {
+			distribution.Late = true
+			distribution.Sadaqah = eid.Policy == latePolicySadaqah
+			return nil
+		}
+	}
+	return nil
+}
+
+// GetEid returns the Eid prayer that closes the fitrah of a Hijri year and the policy
+// for fitrah distributed after it
+func (s *SmartContract) GetEid(ctx contractapi.TransactionContextInterface, hijriYear int) (Eid, error) {
+	if hijriYear < 1 {
+		return Eid{}, fmt.Errorf("invalid Hijri year %d", hijriYear)
+	}
+	return readEid(ctx, hijriYear)
+}
+
+// SetEid sets the Eid prayer of a Hijri year, e.g. SetEid(1447, "2026-03-20T06:30:00+07:00",
+// "sadaqah"), and the policy for fitrah distributed after it. The prayer must fall on
+// or near 1 Syawal and cannot be changed once it has passed, so distributions already
+// recorded keep their standing. Like the Hijri calendar, it is set by an admin with
+// the endorsement of a majority of the active organizations.
+func (s *SmartContract) SetEid(ctx contractapi.TransactionContextInterface, hijriYear int, prayer string, policy string) error {
+	registry, err := readRegistry(ctx)
+	if err != nil {
+		return err
+	}
+	if err := requireAdmin(ctx, registry); err != nil {
+		return err
+	}
+	if hijriYear < 1 {
+		return fmt.Errorf("invalid Hijri year %d", hijriYear)
+	}
+	t, err := time.Parse(time.RFC3339, prayer)
+	if err != nil {
+		return fmt.Errorf("invalid prayer time format. Expected ISO 8601 format (e.g., 2026-03-20T06:30:00+07:00)")
+	}
+	if err := validateLatePolicy(policy); err != nil {
+		return err
+	}
+	if shift := dayNumber(t) - tabularMonthStart(hijriYear, 10); shift < -maxIsbatShift || shift > maxIsbatShift {
+		return fmt.Errorf("the Eid prayer of %d cannot be %d days from 1 Syawal of the calendar", hijriYear, shift)
+	}
+
+	current, err := readEid(ctx, hijriYear)
+	if err != nil {
+		return err
+	}
+	txTimestamp, err := ctx.GetStub().GetTxTimestamp()
+	if err != nil {
+		return fmt.Errorf("failed to read transaction timestamp: %v", err)
+	}
+	for _, deadline := range []string{current.Prayer, t.UTC().Format(time.RFC3339)} {
+		passed, err := after(txTimestamp.AsTime().Format(time.RFC3339), deadline)
+		if err != nil {
+			return err
+		}
+		if passed {
+			return fmt.Errorf("the fitrah deadline of %d has already passed", hijriYear)
+		}
+	}
+
+	key, err := eidKey(hijriYear)
+	if err != nil {
+		return fmt.Errorf("failed to create Eid key: %v", err)
+	}
+	eid := Eid{HijriYear: hijriYear, Prayer: t.UTC().Format(time.RFC3339), Policy: policy, Set: true}
+	return putEndorsedByMajority(ctx, registry, key, "Eid", eid)
+}
+
+// GetPendingFitrah lists the fitrah donations of a Hijri year an organization has
+// not fully distributed, with the Eid prayer they must be distributed by, so that
+// amil can act as the deadline approaches. Donations transferred in from other
+// organizations and fitrah given in kind are included.
+func (s *SmartContract) GetPendingFitrah(ctx contractapi.TransactionContextInterface, organization string, hijriYear int) (FitrahStatus, error) {
+	org, err := getOrganization(ctx, organization)
+	if err != nil {
+		return FitrahStatus{}, err
+	}
+	eid, err := s.GetEid(ctx, hijriYear)
+	if err != nil {
+		return FitrahStatus{}, err
+	}
+	status := FitrahStatus{Organization: organization, Eid: eid, Donations: []PendingFitrah{}}
+
+	for _, unit := range append([]string{unitIDR}, inKindUnits...) {
+		if err := s.pendingFitrah(ctx, &status, poolID(org, "fitrah", unit), hijriYear); err != nil {
+			return FitrahStatus{}, err
+		}
+	}
+
+	if len(status.Donations) > 0 {
+		txTimestamp, err := ctx.GetStub().GetTxTimestamp()
+		if err != nil {
+			return FitrahStatus{}, fmt.Errorf("failed to read transaction timestamp: %v", err)
+		}
+		if status.Overdue, err = after(txTimestamp.AsTime().Format(time.RFC3339), eid.Prayer); err != nil {
+			return FitrahStatus{}, err
+		}
+	}
+	return status, nil
+}
+
+// pendingFitrah adds the donations of a Hijri year left in a fitrah pool to the status
+func (s *SmartContract) pendingFitrah(ctx contractapi.TransactionContextInterface, status *FitrahStatus, id string, hijriYear int) error {
+	pool, err := readPool(ctx, id)
+	if err != nil || pool == nil {
+		return err
+	}
+	for _, source := range pool.Sources {
+		zakat, err := s.QueryZakat(ctx, source.ZakatID)
+		if err != nil {
+			return err
+		}
+		due, err := fitrahEid(ctx, zakat)
+		if err != nil {
+			return err
+		}
+		if due.HijriYear != hijriYear {
+			continue
+		}
+		status.Donations = append(status.Donations, PendingFitrah{
+			ZakatID:    zakat.ID,
+			TransferID: source.TransferID,
+			Muzakki:    zakat.Muzakki,
+			Timestamp:  zakat.Timestamp,
+			Remaining:  source.Remaining,
+			Unit:       pool.Unit,
+		})
+		if isIDR(pool.Unit) {
+			status.Outstanding += source.Remaining
+			continue
+		}
+		if status.OutstandingInKind == nil {
+			status.OutstandingInKind = map[string]float64{}
+		}
+		status.OutstandingInKind[pool.Unit] += source.Remaining
+	}
+	return nil
+}
@@ -0,0 +1,126 @@
+package main
+
+import (
+	"testing"
+	"time"
+
+	"github.com/hyperledger/fabric-contract-api-go/contractapi"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+func TestFitrahDeadline(t *testing.T) {
+	chaincodeStub := new(MockStub)
+	transactionContext := new(contractapi.TransactionContext)
+	transactionContext.SetStub(chaincodeStub)
+	newWorldState(chaincodeStub)
+	now := time.Date(2024, 4, 8, 3, 0, 0, 0, time.UTC)
+	chaincodeStub.On("GetTxTimestamp").Return(timestamppb.New(now), nil).Maybe()
+
+	smartContract := new(SmartContract)
+	require.NoError(t, smartContract.AddZakat(transactionContext, "ZKT-YDSF-MLG-202404-0001", "John Doe", 90000, "fitrah", "YDSF Malang", "2024-04-05T10:00:00Z"))
+	require.NoError(t, smartContract.AddZakat(transactionContext, "ZKT-YDSF-MLG-202404-0002", "Jane Doe", 45000, "fitrah", "YDSF Malang", "2024-04-06T10:00:00Z"))
+
+	// Without a prayer time set, the deadline is 06:00 WIB on 1 Syawal
+	eid, err := smartContract.GetEid(transactionContext, 1445)
+	require.NoError(t, err)
+	require.Equal(t, Eid{HijriYear: 1445, Prayer: "2024-04-09T23:00:00Z", Policy: latePolicyFlag}, eid)
+
+	status, err := smartContract.GetPendingFitrah(transactionContext, "YDSF Malang", 1445)
+	require.NoError(t, err)
+	require.Equal(t, float64(135000), status.Outstanding)
+	require.Len(t, status.Donations, 2)
+	require.False(t, status.Overdue)
+
+	status, err = smartContract.GetPendingFitrah(transactionContext, "YDSF Malang", 1446)
+	require.NoError(t, err)
+	require.Empty(t, status.Donations)
+
+	t.Run("Before the prayer", func(t *testing.T) {
+		require.NoError(t, smartContract.DistributeZakat(transactionContext, "DST-YDSF-MLG-202404-0001", "POOL-YDSF-MLG-FITRAH", "", "Mustahik1", 60000, "2024-04-09T22:00:00Z"))
+		distribution, err := smartContract.QueryDistribution(transactionContext, "DST-YDSF-MLG-202404-0001")
+		require.NoError(t, err)
+		require.False(t, distribution.Late)
+	})
+
+	t.Run("After the prayer is flagged", func(t *testing.T) {
+		require.NoError(t, smartContract.DistributeZakat(transactionContext, "DST-YDSF-MLG-202404-0002", "POOL-YDSF-MLG-FITRAH", "", "Mustahik2", 50000, "2024-04-10T03:00:00Z"))
+		distribution, err := smartContract.QueryDistribution(transactionContext, "DST-YDSF-MLG-202404-0002")
+		require.NoError(t, err)
+		require.True(t, distribution.Late)
+		require.False(t, distribution.Sadaqah)
+	})
+
+	t.Run("Amil share is not bound by the deadline", func(t *testing.T) {
+		require.NoError(t, smartContract.AllocateAmilShare(transactionContext, "DST-YDSF-MLG-202404-0003", "POOL-YDSF-MLG-FITRAH", 5000, "2024-04-10T04:00:00Z"))
+		distribution, err := smartContract.QueryDistribution(transactionContext, "DST-YDSF-MLG-202404-0003")
+		require.NoError(t, err)
+		require.False(t, distribution.Late)
+	})
+
+	status, err = smartContract.GetPendingFitrah(transactionContext, "YDSF Malang", 1445)
+	require.NoError(t, err)
+	require.Equal(t, []PendingFitrah{{ZakatID: "ZKT-YDSF-MLG-202404-0002", Muzakki: "Jane Doe", Timestamp: "2024-04-06T10:00:00Z", Remaining: 20000}}, status.Donations)
+}
+
+func TestSetEid(t *testing.T) {
+	chaincodeStub := new(MockStub)
+	transactionContext := new(contractapi.TransactionContext)
+	transactionContext.SetStub(chaincodeStub)
+	transactionContext.SetClientIdentity(&MockClientIdentity{MSPID: "YDSFMalangMSP", OUs: []string{"admin"}})
+	newWorldState(chaincodeStub)
+	now := time.Date(2024, 3, 1, 3, 0, 0, 0, time.UTC)
+	chaincodeStub.On("GetTxTimestamp").Return(timestamppb.New(now), nil).Maybe()
+
+	smartContract := new(SmartContract)
+
+	t.Run("Not an admin", func(t *testing.T) {
+		transactionContext.SetClientIdentity(&MockClientIdentity{MSPID: "YDSFMalangMSP", OUs: []string{"client"}})
+		defer transactionContext.SetClientIdentity(&MockClientIdentity{MSPID: "YDSFMalangMSP", OUs: []string{"admin"}})
+		err := smartContract.SetEid(transactionContext, 1445, "2024-04-10T06:30:00+07:00", latePolicySadaqah)
+		require.Error(t, err)
+		require.Contains(t, err.Error(), "only organization admins")
+	})
+
+	t.Run("Invalid policy", func(t *testing.T) {
+		err := smartContract.SetEid(transactionContext, 1445, "2024-04-10T06:30:00+07:00", "infaq")
+		require.Error(t, err)
+		require.Contains(t, err.Error(), "invalid late fitrah policy")
+	})
+
+	t.Run("Not near 1 Syawal", func(t *testing.T) {
+		err := smartContract.SetEid(transactionContext, 1445, "2024-04-20T06:30:00+07:00", latePolicySadaqah)
+		require.Error(t, err)
+		require.Contains(t, err.Error(), "cannot be 10 days from 1 Syawal")
+	})
+
+	t.Run("Deadline passed", func(t *testing.T) {
+		err := smartContract.SetEid(transactionContext, 1444, "2023-04-21T06:30:00+07:00", latePolicySadaqah)
+		require.Error(t, err)
+		require.Contains(t, err.Error(), "has already passed")
+	})
+
+	require.NoError(t, smartContract.SetEid(transactionContext, 1445, "2024-04-10T06:30:00+07:00", latePolicySadaqah))
+	eid, err := smartContract.GetEid(transactionContext, 1445)
+	require.NoError(t, err)
+	require.Equal(t, Eid{HijriYear: 1445, Prayer: "2024-04-09T23:30:00Z", Policy: latePolicySadaqah, Set: true}, eid)
+
+	t.Run("Late fitrah recorded as sadaqah", func(t *testing.T) {
+		require.NoError(t, smartContract.AddZakat(transactionContext, "ZKT-YDSF-MLG-202404-0001", "John Doe", 45000, "fitrah", "YDSF Malang", "2024-04-05T10:00:00Z"))
+		require.NoError(t, smartContract.DistributeZakat(transactionContext, "DST-YDSF-MLG-202404-0001", "POOL-YDSF-MLG-FITRAH", "", "Mustahik1", 45000, "2024-04-10T03:00:00Z"))
+
+		distribution, err := smartContract.QueryDistribution(transactionContext, "DST-YDSF-MLG-202404-0001")
+		require.NoError(t, err)
+		require.True(t, distribution.Late)
+		require.True(t, distribution.Sadaqah)
+
+		entries, err := smartContract.GetJournalEntries(transactionContext, "YDSF Malang", "202404")
+		require.NoError(t, err)
+		require.Len(t, entries, 2)
+		require.Equal(t, []JournalLine{
+			{Fund: fundZakat, DebitAccount: accountFitrahToSadaqah, CreditAccount: accountCash, Amount: 45000},
+			{Fund: fundInfaq, DebitAccount: accountCash, CreditAccount: accountSadaqahReceipt, Amount: 45000},
+			{Fund: fundInfaq, DebitAccount: "Penyaluran sedekah - mustahik", CreditAccount: accountCash, Amount: 45000},
+		}, entries[0].Lines)
+	})
+}
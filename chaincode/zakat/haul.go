@@ -0,0 +1,274 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/hyperledger/fabric-chaincode-go/shim"
+	"github.com/hyperledger/fabric-contract-api-go/contractapi"
+)
+
+// donorObjectType is the composite key namespace for the donor registry
+const donorObjectType = "donor"
+
+// maxHaulYears bounds the lunar years a haul is followed for
+const maxHaulYears = 200
+
+// Donor is a muzakki's entry in an organization's donor registry. Zakat maal falls due
+// each time wealth has been held for a lunar year (haul), counted from the haul start.
+// The registry entry is created by the donor's first maal donation, whose date starts
+// the haul unless an earlier start is set.
+type Donor struct {
+	Muzakki         string `json:"muzakki"`                   // Donor's name, as on donations
+	Organization    string `json:"organization"`              // Organization the donor pays to
+	HaulStart       string `json:"haulStart"`                 // Date the wealth reached the nisab (ISO 8601)
+	LastMaalPayment string `json:"lastMaalPayment,omitempty"` // Timestamp of the latest maal donation
+	LastMaalZakatID string `json:"lastMaalZakatId,omitempty"` // ID of the latest maal donation
+}
+
+// HaulDue is a donor whose haul falls due, or fell due without a maal donation
+type HaulDue struct {
+	Muzakki         string    `json:"muzakki"`
+	HaulStart       string    `json:"haulStart"`
+	LastMaalPayment string    `json:"lastMaalPayment,omitempty"`
+	DueDate         string    `json:"dueDate"`  // Start of the day in WIB (ISO 8601)
+	Hijri           HijriDate `json:"hijri"`    // Hijri date of the due date
+	DaysLeft        int       `json:"daysLeft"` // Days until the due date, negative once passed
+}
+
+// donorKey returns the world state key of a muzakki's entry in an organization's registry
+func donorKey(organization string, muzakki string) (string, error) {
+	return shim.CreateCompositeKey(donorObjectType, []string{organization, muzakki})
+}
+
+// readDonor returns a muzakki's registry entry, or nil if the muzakki has none
+func readDonor(ctx contractapi.TransactionContextInterface, organization string, muzakki string) (*Donor, error) {
+	key, err := donorKey(organization, muzakki)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create donor key: %v", err)
+	}
+	donorJSON, err := ctx.GetStub().GetState(key)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read donor from world state: %v", err)
+	}
+	if donorJSON == nil {
+		return nil, nil
+	}
+	var donor Donor
+	if err := json.Unmarshal(donorJSON, &donor); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal donor: %v", err)
+	}
+	return &donor, nil
+}
+
+// getOrCreateDonor returns a muzakki's registry entry, or a new one bound to the
+// organization with a key-level endorsement policy
+func getOrCreateDonor(ctx contractapi.TransactionContextInterface, organization Organization, muzakki string) (*Donor, error) {
+	donor, err := readDonor(ctx, organization.Name, muzakki)
+	if err != nil || donor != nil {
+		return donor, err
+	}
+
+	policy, err := orgEndorsementPolicy(organization.MSPID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create endorsement policy for donor %s: %v", muzakki, err)
+	}
+	key, err := donorKey(organization.Name, muzakki)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create donor key: %v", err)
+	}
+	if err := ctx.GetStub().SetStateValidationParameter(key, policy); err != nil {
+		return nil, fmt.Errorf("failed to set endorsement policy for donor %s: %v", muzakki, err)
+	}
+	return &Donor{Muzakki: muzakki, Organization: organization.Name}, nil
+}
+
+// writeDonor stores the registry entry in world state
+func writeDonor(ctx contractapi.TransactionContextInterface, donor Donor) error {
+	key, err := donorKey(donor.Organization, donor.Muzakki)
+	if err != nil {
+		return fmt.Errorf("failed to create donor key: %v", err)
+	}
+	donorJSON, err := json.Marshal(donor)
+	if err != nil {
+		return err
+	}
+	return ctx.GetStub().PutState(key, donorJSON)
+}
+
+// recordMaalPayment records a maal donation as the donor's last maal payment, unless a
+// later one was recorded before, starting the donor's haul on the first one. Anonymous
+// donations name no donor to follow.
+func recordMaalPayment(ctx contractapi.TransactionContextInterface, organization Organization, zakat Zakat) error {
+	if zakat.Type != "maal" || zakat.DonorCommitment != "" {
+		return nil
+	}
+	donor, err := getOrCreateDonor(ctx, organization, zakat.Muzakki)
+	if err != nil {
+		return err
+	}
+	if donor.HaulStart == "" {
+		donor.HaulStart = zakat.Timestamp
+	}
+	paid, _ := time.Parse(time.RFC3339, zakat.Timestamp)
+	if last, err := time.Parse(time.RFC3339, donor.LastMaalPayment); err == nil && last.After(paid) {
+		return writeDonor(ctx, *donor)
+	}
+	donor.LastMaalPayment = zakat.Timestamp
+	donor.LastMaalZakatID = zakat.ID
+	return writeDonor(ctx, *donor)
+}
+
+// anniversary returns the day the given number of Hijri years after a date, on the
+// same day of the month or the last day of a shorter month
+func (c HijriCalendar) anniversary(date HijriDate, years int) (int, error) {
+	start, err := c.monthStart(date.Year+years, date.Month)
+	if err != nil {
+		return 0, err
+	}
+	nextYear, nextMonth := addMonths(date.Year+years, date.Month, 1)
+	next, err := c.monthStart(nextYear, nextMonth)
+	if err != nil {
+		return 0, err
+	}
+	day := date.Day
+	if length := next - start; day > length {
+		day = length
+	}
+	return start + day - 1, nil
+}
+
+// haulDueDay returns the day the donor's next haul falls due. A maal payment pays the
+// haul whose anniversary is nearest to it, so a donor who pays a little before or after
+// the anniversary is next due a lunar year later. Payments before the haul start do not
+// count.
+func haulDueDay(calendar HijriCalendar, donor Donor) (int, error) {
+	haulStart, err := time.Parse(time.RFC3339, donor.HaulStart)
+	if err != nil {
+		return 0, fmt.Errorf("invalid haul start of %s: %q", donor.Muzakki, donor.HaulStart)
+	}
+	startDay := dayNumber(haulStart)
+	start, err := calendar.date(startDay)
+	if err != nil {
+		return 0, err
+	}
+
+	paid := 0
+	if last, err := time.Parse(time.RFC3339, donor.LastMaalPayment); err == nil && dayNumber(last) >= startDay {
+		day := dayNumber(last)
+		// Find the anniversaries before and after the payment
+		before := startDay
+		for ; paid < maxHaulYears; paid++ {
+			after, err := calendar.anniversary(start, paid+1)
+			if err != nil {
+				return 0, err
+			}
+			if after > day {
+				if after-day < day-before {
+					paid++
+				}
+				break
+			}
+			before = after
+		}
+	}
+	return calendar.anniversary(start, paid+1)
+}
+
+// SetHaulStart sets the date a muzakki's wealth reached the nisab, from which the haul
+// is counted, e.g. SetHaulStart("YDSF Malang", "Ahmad", "2025-06-01T00:00:00+07:00").
+// It is set again when the wealth falls below the nisab and later reaches it.
+func (s *SmartContract) SetHaulStart(ctx contractapi.TransactionContextInterface, organization string, muzakki string, haulStart string) error {
+	org, err := validateOrganization(ctx, organization)
+	if err != nil {
+		return err
+	}
+	muzakki = strings.TrimSpace(muzakki)
+	if muzakki == "" {
+		return fmt.Errorf("muzakki must not be empty")
+	}
+	if err := validateTimestamp(haulStart); err != nil {
+		return err
+	}
+	donor, err := getOrCreateDonor(ctx, org, muzakki)
+	if err != nil {
+		return err
+	}
+	donor.HaulStart = haulStart
+	return writeDonor(ctx, *donor)
+}
+
+// QueryDonor returns a muzakki's entry in an organization's donor registry
+func (s *SmartContract) QueryDonor(ctx contractapi.TransactionContextInterface, organization string, muzakki string) (Donor, error) {
+	donor, err := readDonor(ctx, organization, muzakki)
+	if err != nil {
+		return Donor{}, err
+	}
+	if donor == nil {
+		return Donor{}, fmt.Errorf("%s is not in the donor registry of %s", muzakki, organization)
+	}
+	return *donor, nil
+}
+
+// GetHaulsDue returns the donors of an organization whose haul falls due within the
+// given number of days of the transaction, or fell due without a maal donation since,
+// by due date, so branches can remind them
+func (s *SmartContract) GetHaulsDue(ctx contractapi.TransactionContextInterface, organization string, days int) ([]HaulDue, error) {
+	if _, err := getOrganization(ctx, organization); err != nil {
+		return nil, err
+	}
+	if days < 0 {
+		return nil, fmt.Errorf("invalid number of days %d. Must not be negative", days)
+	}
+	now, err := txTime(ctx)
+	if err != nil {
+		return nil, err
+	}
+	today := dayNumber(now)
+	calendar, err := readCalendar(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	resultsIterator, err := ctx.GetStub().GetStateByPartialCompositeKey(donorObjectType, []string{organization})
+	if err != nil {
+		return nil, err
+	}
+	defer resultsIterator.Close()
+
+	due := []HaulDue{}
+	for resultsIterator.HasNext() {
+		queryResponse, err := resultsIterator.Next()
+		if err != nil {
+			return nil, err
+		}
+		var donor Donor
+		if err := json.Unmarshal(queryResponse.Value, &donor); err != nil {
+			return nil, err
+		}
+		day, err := haulDueDay(calendar, donor)
+		if err != nil {
+			return nil, err
+		}
+		if day-today > days {
+			continue
+		}
+		hijri, err := calendar.date(day)
+		if err != nil {
+			return nil, err
+		}
+		due = append(due, HaulDue{
+			Muzakki:         donor.Muzakki,
+			HaulStart:       donor.HaulStart,
+			LastMaalPayment: donor.LastMaalPayment,
+			DueDate:         timeOfDay(day, 0).Format(time.RFC3339),
+			Hijri:           hijri,
+			DaysLeft:        day - today,
+		})
+	}
+	sort.SliceStable(due, func(i, j int) bool { return due[i].DueDate < due[j].DueDate })
+	return due, nil
+}
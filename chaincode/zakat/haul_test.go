@@ -0,0 +1,89 @@
+package main
+
+import (
+	"testing"
+	"time"
+
+	"github.com/hyperledger/fabric-contract-api-go/contractapi"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+func TestHaul(t *testing.T) {
+	chaincodeStub := new(MockStub)
+	transactionContext := new(contractapi.TransactionContext)
+	transactionContext.SetStub(chaincodeStub)
+	transactionContext.SetClientIdentity(&MockClientIdentity{MSPID: "YDSFMalangMSP", OUs: []string{"admin"}})
+	newWorldState(chaincodeStub)
+	now := time.Date(2026, 10, 19, 3, 0, 0, 0, time.UTC)
+	chaincodeStub.On("GetTxTimestamp").Return(timestamppb.New(now), nil).Maybe()
+
+	smartContract := new(SmartContract)
+	// Ahmad's haul starts with his first maal donation, 3 Jumadil Awal 1446
+	require.NoError(t, smartContract.AddZakat(transactionContext, "ZKT-YDSF-MLG-202411-0001", "Ahmad", 2500000, "maal", "YDSF Malang", "2024-11-05T10:00:00+07:00"))
+	// He pays five days before the first anniversary, so the second is due next
+	require.NoError(t, smartContract.AddZakat(transactionContext, "ZKT-YDSF-MLG-202510-0001", "Ahmad", 2600000, "maal", "YDSF Malang", "2025-10-20T10:00:00+07:00"))
+	// An earlier donation imported later is not his last payment
+	require.NoError(t, smartContract.AddZakat(transactionContext, "ZKT-YDSF-MLG-202501-0001", "Ahmad", 100000, "maal", "YDSF Malang", "2025-01-10T10:00:00+07:00"))
+	require.NoError(t, smartContract.SetHaulStart(transactionContext, "YDSF Malang", " Budi ", "2025-11-10T10:00:00+07:00"))
+	require.NoError(t, smartContract.AddZakat(transactionContext, "ZKT-YDSF-MLG-202511-0001", "Citra", 1000000, "maal", "YDSF Malang", "2025-11-01T10:00:00+07:00"))
+	// Dewi pays four days after her first anniversary, so the second is due next
+	require.NoError(t, smartContract.AddZakat(transactionContext, "ZKT-YDSF-MLG-202506-0001", "Dewi", 1000000, "maal", "YDSF Malang", "2025-06-01T10:00:00+07:00"))
+	require.NoError(t, smartContract.AddZakat(transactionContext, "ZKT-YDSF-MLG-202605-0001", "Dewi", 1000000, "maal", "YDSF Malang", "2026-05-25T10:00:00+07:00"))
+	require.NoError(t, smartContract.AddZakat(transactionContext, "ZKT-YDSF-MLG-202603-0001", "Eka", 45000, "fitrah", "YDSF Malang", "2026-03-10T10:00:00+07:00"))
+
+	donor, err := smartContract.QueryDonor(transactionContext, "YDSF Malang", "Ahmad")
+	require.NoError(t, err)
+	require.Equal(t, Donor{
+		Muzakki:         "Ahmad",
+		Organization:    "YDSF Malang",
+		HaulStart:       "2024-11-05T10:00:00+07:00",
+		LastMaalPayment: "2025-10-20T10:00:00+07:00",
+		LastMaalZakatID: "ZKT-YDSF-MLG-202510-0001",
+	}, donor)
+
+	_, err = smartContract.QueryDonor(transactionContext, "YDSF Malang", "Eka")
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "is not in the donor registry")
+
+	t.Run("Due", func(t *testing.T) {
+		due, err := smartContract.GetHaulsDue(transactionContext, "YDSF Malang", 30)
+		require.NoError(t, err)
+		require.Equal(t, []HaulDue{
+			{Muzakki: "Ahmad", HaulStart: "2024-11-05T10:00:00+07:00", LastMaalPayment: "2025-10-20T10:00:00+07:00", DueDate: "2026-10-15T00:00:00+07:00", Hijri: HijriDate{Year: 1448, Month: 5, Day: 3}, DaysLeft: -4},
+			{Muzakki: "Citra", HaulStart: "2025-11-01T10:00:00+07:00", LastMaalPayment: "2025-11-01T10:00:00+07:00", DueDate: "2026-10-22T00:00:00+07:00", Hijri: HijriDate{Year: 1448, Month: 5, Day: 10}, DaysLeft: 3},
+			{Muzakki: "Budi", HaulStart: "2025-11-10T10:00:00+07:00", DueDate: "2026-10-31T00:00:00+07:00", Hijri: HijriDate{Year: 1448, Month: 5, Day: 19}, DaysLeft: 12},
+		}, due)
+
+		due, err = smartContract.GetHaulsDue(transactionContext, "YDSF Malang", 0)
+		require.NoError(t, err)
+		require.Len(t, due, 1)
+		require.Equal(t, "Ahmad", due[0].Muzakki)
+
+		due, err = smartContract.GetHaulsDue(transactionContext, "YDSF Jatim", 30)
+		require.NoError(t, err)
+		require.Empty(t, due)
+	})
+
+	t.Run("Paid", func(t *testing.T) {
+		require.NoError(t, smartContract.AddZakat(transactionContext, "ZKT-YDSF-MLG-202610-0001", "Ahmad", 2700000, "maal", "YDSF Malang", "2026-10-18T10:00:00+07:00"))
+
+		due, err := smartContract.GetHaulsDue(transactionContext, "YDSF Malang", 0)
+		require.NoError(t, err)
+		require.Empty(t, due)
+	})
+
+	t.Run("Invalid", func(t *testing.T) {
+		err := smartContract.SetHaulStart(transactionContext, "YDSF Malang", " ", "2025-11-10T10:00:00+07:00")
+		require.Error(t, err)
+		require.Contains(t, err.Error(), "muzakki must not be empty")
+
+		err = smartContract.SetHaulStart(transactionContext, "YDSF Malang", "Budi", "2025-11-10")
+		require.Error(t, err)
+		require.Contains(t, err.Error(), "invalid timestamp format")
+
+		_, err = smartContract.GetHaulsDue(transactionContext, "YDSF Malang", -1)
+		require.Error(t, err)
+		require.Contains(t, err.Error(), "invalid number of days")
+	})
+}
@@ -0,0 +1,237 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/hyperledger/fabric-chaincode-go/shim"
+	"github.com/hyperledger/fabric-contract-api-go/contractapi"
+)
+
+const (
+	// calendarObjectType is the composite key namespace of the Hijri calendar record
+	calendarObjectType = "calendar"
+	// islamicEpoch is the Julian day number of 1 Muharram 1 AH (16 July 622, Julian)
+	islamicEpoch = 1948440
+	// unixEpochDay is the Julian day number of 1 January 1970
+	unixEpochDay = 2440588
+	// maxIsbatShift is how many days an announced month start may differ from the
+	// arithmetic calendar
+	maxIsbatShift = 2
+)
+
+// wib is the time zone Hijri dates are reckoned in
+var wib = time.FixedZone("WIB", 7*60*60)
+
+// HijriDate is a date of the Hijri calendar
+type HijriDate struct {
+	Year  int `json:"year"`  // e.g. 1445
+	Month int `json:"month"` // 1 (Muharram) to 12 (Dzulhijjah); 9 is Ramadan, 10 Syawal
+	Day   int `json:"day"`   // 1 to 30
+}
+
+// HijriCalendar holds the month starts announced after the government's isbat,
+// which take precedence over the arithmetic calendar. Months without an announced
+// start follow the arithmetic (tabular) calendar.
+type HijriCalendar struct {
+	MonthStarts map[string]string `json:"monthStarts"` // "1445-09" to the first day, "2024-03-12"
+}
+
+// monthKey returns the key of a Hijri month in HijriCalendar.MonthStarts
+func monthKey(year int, month int) string {
+	return fmt.Sprintf("%04d-%02d", year, month)
+}
+
+// tabularMonthStart returns the Julian day number of the first day of a month of the
+// arithmetic Hijri calendar, with leap years 2, 5, 7, 10, 13, 16, 18, 21, 24, 26 and
+// 29 of each 30-year cycle
+func tabularMonthStart(year int, month int) int {
+	return islamicEpoch + (year-1)*354 + (3+11*year)/30 + (59*(month-1)+1)/2
+}
+
+// dayNumber returns the Julian day number of the WIB date of a time
+func dayNumber(t time.Time) int {
+	year, month, day := t.In(wib).Date()
+	return int(time.Date(year, month, day, 0, 0, 0, 0, time.UTC).Unix()/86400) + unixEpochDay
+}
+
+// timeOfDay returns the time at an hour in WIB of the day with a Julian day number
+func timeOfDay(day int, hour int) time.Time {
+	year, month, date := time.Unix(int64(day-unixEpochDay)*86400, 0).UTC().Date()
+	return time.Date(year, month, date, hour, 0, 0, 0, wib)
+}
+
+// addMonths moves a Hijri year and month by n months
+func addMonths(year int, month int, n int) (int, int) {
+	months := year*12 + month - 1 + n
+	return months / 12, months%12 + 1
+}
+
+// monthStart returns the first day of a month, as announced or else tabular
+func (c HijriCalendar) monthStart(year int, month int) (int, error) {
+	announced, ok := c.MonthStarts[monthKey(year, month)]
+	if !ok {
+		return tabularMonthStart(year, month), nil
+	}
+	t, err := time.Parse("2006-01-02", announced)
+	if err != nil {
+		return 0, fmt.Errorf("invalid start %q of Hijri month %s", announced, monthKey(year, month))
+	}
+	return dayNumber(t), nil
+}
+
+// date converts a Julian day number to a Hijri date
+func (c HijriCalendar) date(day int) (HijriDate, error) {
+	// Month of the day in the arithmetic calendar
+	year := (30*(day-islamicEpoch) + 10646) / 10631
+	for tabularMonthStart(year+1, 1) <= day {
+		year++
+	}
+	for tabularMonthStart(year, 1) > day {
+		year--
+	}
+	month := 12
+	for tabularMonthStart(year, month) > day {
+		month--
+	}
+
+	// An announced start moves the day at most into a neighbouring month
+	for _, n := range []int{1, 0, -1} {
+		y, m := addMonths(year, month, n)
+		start, err := c.monthStart(y, m)
+		if err != nil {
+			return HijriDate{}, err
+		}
+		if start <= day {
+			return HijriDate{Year: y, Month: m, Day: day - start + 1}, nil
+		}
+	}
+	return HijriDate{}, fmt.Errorf("no Hijri month contains day %d", day)
+}
+
+// calendarKey returns the world state key of the Hijri calendar record
+func calendarKey() (string, error) {
+	return shim.CreateCompositeKey(calendarObjectType, []string{})
+}
+
+// readCalendar returns the Hijri calendar, without announced starts if none were set
+func readCalendar(ctx contractapi.TransactionContextInterface) (HijriCalendar, error) {
+	calendar := HijriCalendar{MonthStarts: map[string]string{}}
+	key, err := calendarKey()
+	if err != nil {
+		return calendar, fmt.Errorf("failed to create calendar key: %v", err)
+	}
+	calendarJSON, err := ctx.GetStub().GetState(key)
+	if err != nil {
+		return calendar, fmt.Errorf("failed to read calendar from world state: %v", err)
+	}
+	if calendarJSON == nil {
+		return calendar, nil
+	}
+	if err := json.Unmarshal(calendarJSON, &calendar); err != nil {
+		return calendar, fmt.Errorf("failed to unmarshal calendar: %v", err)
+	}
+	if calendar.MonthStarts == nil {
+		calendar.MonthStarts = map[string]string{}
+	}
+	return calendar, nil
+}
+
+// hijriOf returns the Hijri date of a timestamp, taken in WIB
+func hijriOf(ctx contractapi.TransactionContextInterface, timestamp string) (*HijriDate, error) {
+	t, err := time.Parse(time.RFC3339, timestamp)
+	if err != nil {
+		return nil, fmt.Errorf("invalid timestamp format. Expected ISO 8601 format (e.g., 2023-11-28T12:00:00Z)")
+	}
+	calendar, err := readCalendar(ctx)
+	if err != nil {
+		return nil, err
+	}
+	date, err := calendar.date(dayNumber(t))
+	if err != nil {
+		return nil, err
+	}
+	return &date, nil
+}
+
+// GetHijriDate returns the Hijri date of a timestamp, as records are stamped
+func (s *SmartContract) GetHijriDate(ctx contractapi.TransactionContextInterface, timestamp string) (HijriDate, error) {
+	date, err := hijriOf(ctx, timestamp)
+	if err != nil {
+		return HijriDate{}, err
+	}
+	return *date, nil
+}
+
+// GetHijriCalendar returns the announced Hijri month starts
+func (s *SmartContract) GetHijriCalendar(ctx contractapi.TransactionContextInterface) (HijriCalendar, error) {
+	return readCalendar(ctx)
+}
+
+// SetHijriMonthStart records the first day of a Hijri month as announced after the
+// isbat, e.g. SetHijriMonthStart(1445, 9, "2024-03-12") for Ramadan 1445. The start
+// can only be set before the month begins, so records already stamped keep their
+// Hijri date. Like the organization registry, the calendar is changed by an admin
+// with the endorsement of a majority of the active organizations.
+func (s *SmartContract) SetHijriMonthStart(ctx contractapi.TransactionContextInterface, year int, month int, date string) error {
+	registry, err := readRegistry(ctx)
+	if err != nil {
+		return err
+	}
+	if err := requireAdmin(ctx, registry); err != nil {
+		return err
+	}
+	if year < 1 || month < 1 || month > 12 {
+		return fmt.Errorf("invalid Hijri month %d of year %d", month, year)
+	}
+	t, err := time.Parse("2006-01-02", date)
+	if err != nil {
+		return fmt.Errorf("invalid date format. Expected YYYY-MM-DD (e.g., 2024-03-12)")
+	}
+	start := dayNumber(t)
+	if shift := start - tabularMonthStart(year, month); shift < -maxIsbatShift || shift > maxIsbatShift {
+		return fmt.Errorf("the start of Hijri month %s cannot be %d days from the calendar's", monthKey(year, month), shift)
+	}
+
+	calendar, err := readCalendar(ctx)
+	if err != nil {
+		return err
+	}
+	current, err := calendar.monthStart(year, month)
+	if err != nil {
+		return err
+	}
+	txTimestamp, err := ctx.GetStub().GetTxTimestamp()
+	if err != nil {
+		return fmt.Errorf("failed to read transaction timestamp: %v", err)
+	}
+	today := dayNumber(txTimestamp.AsTime())
+	if today >= start || today >= current {
+		return fmt.Errorf("the Hijri month %s has already begun", monthKey(year, month))
+	}
+
+	// Announced neighbours must leave both months 29 or 30 days long
+	for _, n := range []int{-1, 1} {
+		y, m := addMonths(year, month, n)
+		neighbour, ok := calendar.MonthStarts[monthKey(y, m)]
+		if !ok {
+			continue
+		}
+		other, err := calendar.monthStart(y, m)
+		if err != nil {
+			return err
+		}
+		length := (other - start) * n
+		if length < 29 || length > 30 {
+			return fmt.Errorf("the start of Hijri month %s would make a month of %d days with %s starting %s", monthKey(year, month), length, monthKey(y, m), neighbour)
+		}
+	}
+
+	calendar.MonthStarts[monthKey(year, month)] = date
+	key, err := calendarKey()
+	if err != nil {
+		return fmt.Errorf("failed to create calendar key: %v", err)
+	}
+	return putEndorsedByMajority(ctx, registry, key, "calendar", calendar)
+}
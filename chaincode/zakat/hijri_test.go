@@ -0,0 +1,147 @@
+package main
+
+import (
+	"testing"
+	"time"
+
+	"github.com/hyperledger/fabric-contract-api-go/contractapi"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+func TestGetHijriDate(t *testing.T) {
+	chaincodeStub := new(MockStub)
+	transactionContext := new(contractapi.TransactionContext)
+	transactionContext.SetStub(chaincodeStub)
+	newWorldState(chaincodeStub)
+
+	smartContract := new(SmartContract)
+	for timestamp, expected := range map[string]HijriDate{
+		"2023-07-19T03:00:00Z": {Year: 1445, Month: 1, Day: 1},
+		"2024-03-11T03:00:00Z": {Year: 1445, Month: 9, Day: 1},
+		"2024-04-09T03:00:00Z": {Year: 1445, Month: 9, Day: 30},
+		"2024-04-10T03:00:00Z": {Year: 1445, Month: 10, Day: 1},
+		"2026-02-18T03:00:00Z": {Year: 1447, Month: 9, Day: 1},
+		// Late on 10 March UTC is already 11 March in WIB
+		"2024-03-10T18:00:00Z": {Year: 1445, Month: 9, Day: 1},
+	} {
+		date, err := smartContract.GetHijriDate(transactionContext, timestamp)
+		require.NoError(t, err)
+		require.Equal(t, expected, date, timestamp)
+	}
+
+	_, err := smartContract.GetHijriDate(transactionContext, "2024-03-11")
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "invalid timestamp format")
+}
+
+func TestSetHijriMonthStart(t *testing.T) {
+	chaincodeStub := new(MockStub)
+	transactionContext := new(contractapi.TransactionContext)
+	transactionContext.SetStub(chaincodeStub)
+	transactionContext.SetClientIdentity(&MockClientIdentity{MSPID: "YDSFMalangMSP", OUs: []string{"admin"}})
+	newWorldState(chaincodeStub)
+	now := time.Date(2024, 3, 1, 3, 0, 0, 0, time.UTC)
+	chaincodeStub.On("GetTxTimestamp").Return(timestamppb.New(now), nil).Maybe()
+
+	smartContract := new(SmartContract)
+
+	t.Run("Not an admin", func(t *testing.T) {
+		transactionContext.SetClientIdentity(&MockClientIdentity{MSPID: "YDSFMalangMSP", OUs: []string{"client"}})
+		defer transactionContext.SetClientIdentity(&MockClientIdentity{MSPID: "YDSFMalangMSP", OUs: []string{"admin"}})
+		err := smartContract.SetHijriMonthStart(transactionContext, 1445, 9, "2024-03-12")
+		require.Error(t, err)
+		require.Contains(t, err.Error(), "only organization admins")
+	})
+
+	t.Run("Too far from the calendar", func(t *testing.T) {
+		err := smartContract.SetHijriMonthStart(transactionContext, 1445, 9, "2024-03-20")
+		require.Error(t, err)
+		require.Contains(t, err.Error(), "cannot be 9 days")
+	})
+
+	t.Run("Month already begun", func(t *testing.T) {
+		err := smartContract.SetHijriMonthStart(transactionContext, 1445, 8, "2024-02-11")
+		require.Error(t, err)
+		require.Contains(t, err.Error(), "has already begun")
+	})
+
+	t.Run("Isbat", func(t *testing.T) {
+		require.NoError(t, smartContract.SetHijriMonthStart(transactionContext, 1445, 9, "2024-03-12"))
+
+		calendar, err := smartContract.GetHijriCalendar(transactionContext)
+		require.NoError(t, err)
+		require.Equal(t, map[string]string{"1445-09": "2024-03-12"}, calendar.MonthStarts)
+
+		date, err := smartContract.GetHijriDate(transactionContext, "2024-03-11T03:00:00Z")
+		require.NoError(t, err)
+		require.Equal(t, HijriDate{Year: 1445, Month: 8, Day: 30}, date)
+		date, err = smartContract.GetHijriDate(transactionContext, "2024-03-12T03:00:00Z")
+		require.NoError(t, err)
+		require.Equal(t, HijriDate{Year: 1445, Month: 9, Day: 1}, date)
+	})
+
+	t.Run("Month of 31 days", func(t *testing.T) {
+		err := smartContract.SetHijriMonthStart(transactionContext, 1445, 10, "2024-04-12")
+		require.Error(t, err)
+		require.Contains(t, err.Error(), "would make a month of 31 days")
+	})
+
+	t.Run("Syawal after the isbat", func(t *testing.T) {
+		require.NoError(t, smartContract.SetHijriMonthStart(transactionContext, 1445, 10, "2024-04-10"))
+		date, err := smartContract.GetHijriDate(transactionContext, "2024-04-09T03:00:00Z")
+		require.NoError(t, err)
+		require.Equal(t, HijriDate{Year: 1445, Month: 9, Day: 29}, date)
+	})
+}
+
+func TestHijriReport(t *testing.T) {
+	chaincodeStub := new(MockStub)
+	transactionContext := new(contractapi.TransactionContext)
+	transactionContext.SetStub(chaincodeStub)
+	newWorldState(chaincodeStub)
+
+	smartContract := new(SmartContract)
+	// Two fitrah donations in Ramadan 1445, which spans March and April 2024, and one on 1 Syawal
+	require.NoError(t, smartContract.AddZakat(transactionContext, "ZKT-YDSF-MLG-202403-0001", "John Doe", 45000, "fitrah", "YDSF Malang", "2024-03-30T10:00:00Z"))
+	require.NoError(t, smartContract.AddZakat(transactionContext, "ZKT-YDSF-MLG-202404-0001", "Jane Doe", 90000, "fitrah", "YDSF Malang", "2024-04-08T10:00:00Z"))
+	require.NoError(t, smartContract.AddZakat(transactionContext, "ZKT-YDSF-MLG-202404-0002", "Jane Doe", 1000000, "maal", "YDSF Malang", "2024-04-10T10:00:00Z"))
+
+	zakat, err := smartContract.QueryZakat(transactionContext, "ZKT-YDSF-MLG-202404-0001")
+	require.NoError(t, err)
+	require.Equal(t, &HijriDate{Year: 1445, Month: 9, Day: 29}, zakat.Hijri)
+
+	t.Run("Ramadan", func(t *testing.T) {
+		report, err := smartContract.GetReport(transactionContext, "YDSF Malang", "1445H09")
+		require.NoError(t, err)
+		require.Equal(t, "1445H09", report.Period)
+		require.Equal(t, float64(135000), report.Total.Collected)
+		require.Equal(t, 2, report.Total.Donations)
+	})
+
+	t.Run("Syawal", func(t *testing.T) {
+		report, err := smartContract.GetReport(transactionContext, "YDSF Malang", "1445H10")
+		require.NoError(t, err)
+		require.Equal(t, float64(1000000), report.Total.Collected)
+	})
+
+	t.Run("Year", func(t *testing.T) {
+		report, err := smartContract.GetReport(transactionContext, "YDSF Malang", "1445H")
+		require.NoError(t, err)
+		require.Equal(t, float64(1135000), report.Total.Collected)
+		require.Equal(t, 3, report.Total.Donations)
+	})
+
+	t.Run("BAZNAS report", func(t *testing.T) {
+		report, err := smartContract.GetBaznasReport(transactionContext, "YDSF Malang", "1445H09")
+		require.NoError(t, err)
+		require.Equal(t, float64(135000), report.TotalCollection)
+		require.Equal(t, 2, report.Muzakki)
+	})
+
+	t.Run("Invalid month", func(t *testing.T) {
+		_, err := smartContract.GetReport(transactionContext, "YDSF Malang", "1445H13")
+		require.Error(t, err)
+		require.Contains(t, err.Error(), "invalid period format")
+	})
+}
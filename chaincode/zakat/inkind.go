@@ -0,0 +1,228 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/hyperledger/fabric-chaincode-go/shim"
+	"github.com/hyperledger/fabric-contract-api-go/contractapi"
+)
+
+const (
+	// unitIDR is the unit of donations paid in rupiah, the default
+	unitIDR = "IDR"
+	// pricesObjectType is the composite key namespace of the reference price list
+	pricesObjectType = "prices"
+	// livestockUnitPrefix marks units counted in whole animals
+	livestockUnitPrefix = "ekor_"
+)
+
+// inKindUnits are the units donations can be given in besides rupiah
+var inKindUnits = []string{"kg_beras", "liter_beras", "kg_gabah", "gram_emas", "ekor_kambing", "ekor_sapi"}
+
+// ReferencePrice is the IDR value of one unit of an in-kind donation from a date on,
+// e.g. the price of a kg of rice set by BAZNAS for the year's fitrah
+type ReferencePrice struct {
+	Unit  string  `json:"unit"`  // e.g. "kg_beras"
+	Price float64 `json:"price"` // IDR per unit
+	Since string  `json:"since"` // First day the price applies to, YYYY-MM-DD in WIB
+}
+
+// PriceList holds the reference prices of every unit, oldest first
+type PriceList struct {
+	Prices []ReferencePrice `json:"prices"`
+}
+
+// normalizeUnit returns the unit in canonical form, IDR for an empty unit
+func normalizeUnit(unit string) string {
+	unit = strings.ToLower(strings.TrimSpace(unit))
+	if unit == "" || unit == "idr" {
+		return unitIDR
+	}
+	return unit
+}
+
+// validateUnit checks if the provided unit is rupiah or a known in-kind unit
+func validateUnit(unit string) error {
+	if unit == unitIDR {
+		return nil
+	}
+	for _, inKind := range inKindUnits {
+		if unit == inKind {
+			return nil
+		}
+	}
+	return fmt.Errorf("invalid unit. Must be 'IDR' or one of '%s'", strings.Join(inKindUnits, "', '"))
+}
+
+// validateQuantity checks that a quantity suits its unit; livestock is given in whole animals
+func validateQuantity(quantity float64, unit string) error {
+	if strings.HasPrefix(unit, livestockUnitPrefix) && quantity != math.Trunc(quantity) {
+		return fmt.Errorf("invalid quantity %v. Livestock is given in whole animals", quantity)
+	}
+	return nil
+}
+
+// isIDR reports whether amounts in a unit are rupiah; records made before units
+// were introduced have none
+func isIDR(unit string) bool {
+	return unit == "" || unit == unitIDR
+}
+
+// idrValue returns the IDR value of a donation: its amount, or for an in-kind donation
+// its valuation at the reference price
+func (z Zakat) idrValue() float64 {
+	if isIDR(z.Unit) {
+		return z.Amount
+	}
+	return z.Value
+}
+
+// idrValue returns the IDR value of a distribution
+func (d Distribution) idrValue() float64 {
+	if isIDR(d.Unit) {
+		return d.Amount
+	}
+	return d.Value
+}
+
+// roundIDR rounds an IDR value to the sen
+func roundIDR(value float64) float64 {
+	return math.Round(value*100) / 100
+}
+
+// pricesKey returns the world state key of the reference price list
+func pricesKey() (string, error) {
+	return shim.CreateCompositeKey(pricesObjectType, []string{})
+}
+
+// readPrices returns the reference price list, empty if no price was set
+func readPrices(ctx contractapi.TransactionContextInterface) (PriceList, error) {
+	prices := PriceList{Prices: []ReferencePrice{}}
+	key, err := pricesKey()
+	if err != nil {
+		return prices, fmt.Errorf("failed to create price list key: %v", err)
+	}
+	pricesJSON, err := ctx.GetStub().GetState(key)
+	if err != nil {
+		return prices, fmt.Errorf("failed to read price list from world state: %v", err)
+	}
+	if pricesJSON == nil {
+		return prices, nil
+	}
+	if err := json.Unmarshal(pricesJSON, &prices); err != nil {
+		return prices, fmt.Errorf("failed to unmarshal price list: %v", err)
+	}
+	return prices, nil
+}
+
+// priceAt returns the reference price of a unit in force on the WIB day of a
+// timestamp, or false if none was set by then
+func (l PriceList) priceAt(unit string, timestamp string) (float64, bool, error) {
+	t, err := time.Parse(time.RFC3339, timestamp)
+	if err != nil {
+		return 0, false, fmt.Errorf("invalid timestamp format. Expected ISO 8601 format (e.g., 2023-11-28T12:00:00Z)")
+	}
+	day := t.In(wib).Format("2006-01-02")
+	price, found := 0.0, false
+	for _, p := range l.Prices {
+		if p.Unit == unit && p.Since <= day {
+			price, found = p.Price, true
+		}
+	}
+	return price, found, nil
+}
+
+// valueInKind values a quantity of an in-kind unit at the reference price in force at
+// the timestamp, or 0 when there is none
+func valueInKind(ctx contractapi.TransactionContextInterface, quantity float64, unit string, timestamp string) (float64, error) {
+	prices, err := readPrices(ctx)
+	if err != nil {
+		return 0, err
+	}
+	price, found, err := prices.priceAt(unit, timestamp)
+	if err != nil || !found {
+		return 0, err
+	}
+	return roundIDR(quantity * price), nil
+}
+
+// AddZakatInKind records a donation given in kind, e.g. 2.5 kg_beras of fitrah or a
+// harvest of kg_gabah, and credits it to the organization's pool of that type and
+// unit. It is valued in IDR at the reference price of the unit, when one is set.
+func (s *SmartContract) AddZakatInKind(ctx contractapi.TransactionContextInterface, id string, muzakki string, quantity float64, unit string, zakatType string, organization string, timestamp string) error {
+	return s.addZakat(ctx, ZakatInput{
+		ID:           id,
+		Muzakki:      muzakki,
+		Amount:       quantity,
+		Unit:         unit,
+		Type:         zakatType,
+		Organization: organization,
+		Timestamp:    timestamp,
+	})
+}
+
+// GetReferencePrices returns the reference prices of the in-kind units, oldest first
+func (s *SmartContract) GetReferencePrices(ctx contractapi.TransactionContextInterface) ([]ReferencePrice, error) {
+	prices, err := readPrices(ctx)
+	return prices.Prices, err
+}
+
+// SetReferencePrice sets the IDR price of one unit of an in-kind donation from a day
+// on, e.g. SetReferencePrice("kg_beras", 15000, "2026-02-18"). The day cannot be in
+// the past, so donations already valued keep their value. Like the Hijri calendar,
+// prices are set by an admin with the endorsement of a majority of the active
+// organizations.
+func (s *SmartContract) SetReferencePrice(ctx contractapi.TransactionContextInterface, unit string, price float64, since string) error {
+	registry, err := readRegistry(ctx)
+	if err != nil {
+		return err
+	}
+	if err := requireAdmin(ctx, registry); err != nil {
+		return err
+	}
+	unit = normalizeUnit(unit)
+	if unit == unitIDR {
+		return fmt.Errorf("IDR has no reference price")
+	}
+	if err := validateUnit(unit); err != nil {
+		return err
+	}
+	if err := validateAmount(price); err != nil {
+		return err
+	}
+	if _, err := time.Parse("2006-01-02", since); err != nil {
+		return fmt.Errorf("invalid date format. Expected YYYY-MM-DD (e.g., 2026-02-18)")
+	}
+	txTimestamp, err := ctx.GetStub().GetTxTimestamp()
+	if err != nil {
+		return fmt.Errorf("failed to read transaction timestamp: %v", err)
+	}
+	if today := txTimestamp.AsTime().In(wib).Format("2006-01-02"); since < today {
+		return fmt.Errorf("a reference price cannot apply from %s, before today %s", since, today)
+	}
+
+	prices, err := readPrices(ctx)
+	if err != nil {
+		return err
+	}
+	kept := []ReferencePrice{}
+	for _, p := range prices.Prices {
+		// A price set again for the same unit and day replaces the earlier one
+		if p.Unit != unit || p.Since != since {
+			kept = append(kept, p)
+		}
+	}
+	prices.Prices = append(kept, ReferencePrice{Unit: unit, Price: price, Since: since})
+	sort.SliceStable(prices.Prices, func(i, j int) bool { return prices.Prices[i].Since < prices.Prices[j].Since })
+
+	key, err := pricesKey()
+	if err != nil {
+		return fmt.Errorf("failed to create price list key: %v", err)
+	}
+	return putEndorsedByMajority(ctx, registry, key, "price list", prices)
+}
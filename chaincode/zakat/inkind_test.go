@@ -0,0 +1,154 @@
+package main
+
+import (
+	"testing"
+	"time"
+
+	"github.com/hyperledger/fabric-contract-api-go/contractapi"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+func TestSetReferencePrice(t *testing.T) {
+	chaincodeStub := new(MockStub)
+	transactionContext := new(contractapi.TransactionContext)
+	transactionContext.SetStub(chaincodeStub)
+	transactionContext.SetClientIdentity(&MockClientIdentity{MSPID: "YDSFMalangMSP", OUs: []string{"admin"}})
+	newWorldState(chaincodeStub)
+	now := time.Date(2024, 3, 1, 3, 0, 0, 0, time.UTC)
+	chaincodeStub.On("GetTxTimestamp").Return(timestamppb.New(now), nil).Maybe()
+
+	smartContract := new(SmartContract)
+
+	t.Run("Not an admin", func(t *testing.T) {
+		transactionContext.SetClientIdentity(&MockClientIdentity{MSPID: "YDSFMalangMSP", OUs: []string{"client"}})
+		defer transactionContext.SetClientIdentity(&MockClientIdentity{MSPID: "YDSFMalangMSP", OUs: []string{"admin"}})
+		err := smartContract.SetReferencePrice(transactionContext, "kg_beras", 15000, "2024-03-01")
+		require.Error(t, err)
+		require.Contains(t, err.Error(), "only organization admins")
+	})
+
+	t.Run("Unknown unit", func(t *testing.T) {
+		err := smartContract.SetReferencePrice(transactionContext, "kg_jagung", 8000, "2024-03-01")
+		require.Error(t, err)
+		require.Contains(t, err.Error(), "invalid unit")
+	})
+
+	t.Run("In the past", func(t *testing.T) {
+		err := smartContract.SetReferencePrice(transactionContext, "kg_beras", 15000, "2024-02-29")
+		require.Error(t, err)
+		require.Contains(t, err.Error(), "before today")
+	})
+
+	t.Run("Price history", func(t *testing.T) {
+		require.NoError(t, smartContract.SetReferencePrice(transactionContext, "kg_beras", 16000, "2024-04-01"))
+		require.NoError(t, smartContract.SetReferencePrice(transactionContext, "kg_beras", 15000, "2024-03-01"))
+		require.NoError(t, smartContract.SetReferencePrice(transactionContext, "KG_BERAS", 15500, "2024-04-01"))
+
+		prices, err := smartContract.GetReferencePrices(transactionContext)
+		require.NoError(t, err)
+		require.Equal(t, []ReferencePrice{
+			{Unit: "kg_beras", Price: 15000, Since: "2024-03-01"},
+			{Unit: "kg_beras", Price: 15500, Since: "2024-04-01"},
+		}, prices)
+	})
+}
+
+func TestZakatInKind(t *testing.T) {
+	chaincodeStub := new(MockStub)
+	transactionContext := new(contractapi.TransactionContext)
+	transactionContext.SetStub(chaincodeStub)
+	transactionContext.SetClientIdentity(&MockClientIdentity{MSPID: "YDSFMalangMSP", OUs: []string{"admin"}})
+	newWorldState(chaincodeStub)
+	now := time.Date(2024, 3, 1, 3, 0, 0, 0, time.UTC)
+	chaincodeStub.On("GetTxTimestamp").Return(timestamppb.New(now), nil).Maybe()
+
+	smartContract := new(SmartContract)
+	require.NoError(t, smartContract.SetReferencePrice(transactionContext, "kg_beras", 15000, "2024-03-01"))
+	require.NoError(t, smartContract.AddZakatInKind(transactionContext, "ZKT-YDSF-MLG-202404-0001", "John Doe", 10, "kg_beras", "fitrah", "YDSF Malang", "2024-04-05T10:00:00Z"))
+	require.NoError(t, smartContract.AddZakat(transactionContext, "ZKT-YDSF-MLG-202404-0002", "Jane Doe", 45000, "fitrah", "YDSF Malang", "2024-04-05T11:00:00Z"))
+
+	zakat, err := smartContract.QueryZakat(transactionContext, "ZKT-YDSF-MLG-202404-0001")
+	require.NoError(t, err)
+	require.Equal(t, "kg_beras", zakat.Unit)
+	require.Equal(t, float64(10), zakat.Amount)
+	require.Equal(t, float64(150000), zakat.Value)
+
+	// Rice and rupiah are kept in separate pools
+	pool, err := smartContract.QueryPool(transactionContext, "POOL-YDSF-MLG-FITRAH-KG_BERAS")
+	require.NoError(t, err)
+	require.Equal(t, "kg_beras", pool.Unit)
+	require.Equal(t, float64(10), pool.Balance)
+	pool, err = smartContract.QueryPool(transactionContext, "POOL-YDSF-MLG-FITRAH")
+	require.NoError(t, err)
+	require.Equal(t, float64(45000), pool.Balance)
+
+	t.Run("Invalid unit", func(t *testing.T) {
+		err := smartContract.AddZakatInKind(transactionContext, "ZKT-YDSF-MLG-202404-0003", "John Doe", 10, "kg_jagung", "maal", "YDSF Malang", "2024-04-05T10:00:00Z")
+		require.Error(t, err)
+		require.Contains(t, err.Error(), "invalid unit")
+	})
+
+	t.Run("Livestock in whole animals", func(t *testing.T) {
+		err := smartContract.AddZakatInKind(transactionContext, "ZKT-YDSF-MLG-202404-0003", "John Doe", 1.5, "ekor_kambing", "maal", "YDSF Malang", "2024-04-05T10:00:00Z")
+		require.Error(t, err)
+		require.Contains(t, err.Error(), "whole animals")
+	})
+
+	t.Run("Without a reference price", func(t *testing.T) {
+		require.NoError(t, smartContract.AddZakatInKind(transactionContext, "ZKT-YDSF-MLG-202404-0003", "John Doe", 1, "ekor_kambing", "maal", "YDSF Malang", "2024-04-05T10:00:00Z"))
+		zakat, err := smartContract.QueryZakat(transactionContext, "ZKT-YDSF-MLG-202404-0003")
+		require.NoError(t, err)
+		require.Equal(t, float64(0), zakat.Value)
+	})
+
+	t.Run("Distribution in kind", func(t *testing.T) {
+		require.NoError(t, smartContract.CreateProgram(transactionContext, "PRG-YDSF-MLG-2024-0001", "Beras Fitrah", "YDSF Malang", "miskin", 100000, "2024-01-01T00:00:00Z", "2024-12-31T23:59:59Z"))
+		require.NoError(t, smartContract.DistributeZakat(transactionContext, "DST-YDSF-MLG-202404-0001", "POOL-YDSF-MLG-FITRAH-KG_BERAS", "PRG-YDSF-MLG-2024-0001", "Mustahik1", 2.5, "2024-04-08T10:00:00Z"))
+
+		distribution, err := smartContract.QueryDistribution(transactionContext, "DST-YDSF-MLG-202404-0001")
+		require.NoError(t, err)
+		require.Equal(t, "kg_beras", distribution.Unit)
+		require.Equal(t, float64(2.5), distribution.Amount)
+		require.Equal(t, float64(37500), distribution.Value)
+
+		// The program is charged the rupiah value of the rice
+		program, err := smartContract.QueryProgram(transactionContext, "PRG-YDSF-MLG-2024-0001")
+		require.NoError(t, err)
+		require.Equal(t, float64(37500), program.Spent)
+
+		err = smartContract.DistributeZakat(transactionContext, "DST-YDSF-MLG-202404-0002", "POOL-YDSF-MLG-FITRAH-KG_BERAS", "PRG-YDSF-MLG-2024-0001", "Mustahik2", 5, "2024-04-08T10:00:00Z")
+		require.Error(t, err)
+		require.Contains(t, err.Error(), "exceeds program")
+
+		// Livestock without a reference price cannot be charged to the budget
+		require.NoError(t, smartContract.CreateProgram(transactionContext, "PRG-YDSF-MLG-2024-0002", "Kambing Qurban", "YDSF Malang", "miskin", 5000000, "2024-01-01T00:00:00Z", "2024-12-31T23:59:59Z"))
+		err = smartContract.DistributeZakat(transactionContext, "DST-YDSF-MLG-202404-0002", "POOL-YDSF-MLG-MAAL-EKOR_KAMBING", "PRG-YDSF-MLG-2024-0002", "Mustahik2", 1, "2024-04-08T10:00:00Z")
+		require.Error(t, err)
+		require.Contains(t, err.Error(), "donation ZKT-YDSF-MLG-202404-0003 in ekor_kambing has no value")
+	})
+
+	t.Run("Reports and journal in rupiah", func(t *testing.T) {
+		report, err := smartContract.GetReport(transactionContext, "YDSF Malang", "202404")
+		require.NoError(t, err)
+		require.Equal(t, float64(195000), report.Total.Collected)
+		require.Equal(t, float64(37500), report.Total.Distributed)
+
+		entries, err := smartContract.GetJournalEntries(transactionContext, "YDSF Malang", "202404")
+		require.NoError(t, err)
+		// The unvalued goat has no entry
+		require.Len(t, entries, 3)
+		require.Equal(t, "DST-YDSF-MLG-202404-0001", entries[0].Reference)
+		require.Equal(t, JournalLine{Fund: fundZakat, DebitAccount: "Penyaluran zakat - miskin", CreditAccount: "Aset nonkas - kg_beras", Amount: 37500}, entries[0].Lines[0])
+		require.Equal(t, JournalLine{Fund: fundZakat, DebitAccount: "Aset nonkas - kg_beras", CreditAccount: "Penerimaan zakat fitrah", Amount: 150000}, entries[1].Lines[0])
+	})
+
+	t.Run("Pending fitrah by unit", func(t *testing.T) {
+		status, err := smartContract.GetPendingFitrah(transactionContext, "YDSF Malang", 1445)
+		require.NoError(t, err)
+		require.Equal(t, float64(45000), status.Outstanding)
+		require.Equal(t, map[string]float64{"kg_beras": 7.5}, status.OutstandingInKind)
+		require.Len(t, status.Donations, 2)
+		require.Equal(t, "kg_beras", status.Donations[1].Unit)
+	})
+}
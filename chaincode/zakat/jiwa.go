@@ -0,0 +1,236 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/hyperledger/fabric-chaincode-go/shim"
+	"github.com/hyperledger/fabric-contract-api-go/contractapi"
+)
+
+// fitrahRateObjectType is the composite key namespace of the per-person fitrah rates
+const fitrahRateObjectType = "fitrahRate"
+
+// fitrahInKindPerPerson is the fitrah due for one person in the in-kind units it can
+// be paid in: a sha' of rice, taken as 2.5 kg or 3.5 liters
+var fitrahInKindPerPerson = map[string]float64{
+	"kg_beras":    2.5,
+	"liter_beras": 3.5,
+}
+
+// FitrahRate is the fitrah due in IDR for one person, set by an organization for a
+// Hijri year, e.g. Rp 45.000 in Malang for 1447 H
+type FitrahRate struct {
+	Organization string  `json:"organization"`
+	HijriYear    int     `json:"hijriYear"`
+	Amount       float64 `json:"amount"` // IDR per person
+}
+
+// jiwa returns the number of persons a donation covers for the reports: those listed,
+// or the muzakki alone for fitrah recorded without them, and none for zakat maal
+func (z Zakat) jiwa() int {
+	if z.Type != "fitrah" {
+		return 0
+	}
+	if z.Jiwa == 0 {
+		return 1
+	}
+	return z.Jiwa
+}
+
+// validatePersons checks the persons a fitrah donation covers, given by name, by
+// count or both, and returns their number
+func validatePersons(persons []string, jiwa int) (int, error) {
+	for _, person := range persons {
+		if strings.TrimSpace(person) == "" {
+			return 0, fmt.Errorf("invalid persons. Names must not be empty")
+		}
+	}
+	switch {
+	case jiwa < 0:
+		return 0, fmt.Errorf("invalid jiwa %d. Must be greater than 0", jiwa)
+	case len(persons) > 0 && jiwa > 0 && jiwa != len(persons):
+		return 0, fmt.Errorf("jiwa %d does not match the %d persons named", jiwa, len(persons))
+	case len(persons) > 0:
+		return len(persons), nil
+	case jiwa > 0:
+		return jiwa, nil
+	}
+	return 0, fmt.Errorf("a fitrah donation must cover at least one person")
+}
+
+// fitrahRateKey returns the world state key of an organization's fitrah rate for a Hijri year
+func fitrahRateKey(organization string, hijriYear int) (string, error) {
+	return shim.CreateCompositeKey(fitrahRateObjectType, []string{organization, strconv.Itoa(hijriYear)})
+}
+
+// readFitrahRate returns an organization's fitrah rate for a Hijri year, or nil if none is set
+func readFitrahRate(ctx contractapi.TransactionContextInterface, organization string, hijriYear int) (*FitrahRate, error) {
+	key, err := fitrahRateKey(organization, hijriYear)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create fitrah rate key: %v", err)
+	}
+	rateJSON, err := ctx.GetStub().GetState(key)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read fitrah rate from world state: %v", err)
+	}
+	if rateJSON == nil {
+		return nil, nil
+	}
+	var rate FitrahRate
+	if err := json.Unmarshal(rateJSON, &rate); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal fitrah rate: %v", err)
+	}
+	return &rate, nil
+}
+
+// checkFitrahAmount checks that a fitrah donation covering a number of persons is the
+// per-person rate of its unit times that number: the organization's rate for the
+// Hijri year in IDR, or the fixed measure of rice
+func checkFitrahAmount(ctx contractapi.TransactionContextInterface, organization string, amount float64, unit string, jiwa int, hijri *HijriDate) error {
+	perPerson := 0.0
+	if unit == unitIDR {
+		rate, err := readFitrahRate(ctx, organization, hijri.Year)
+		if err != nil {
+			return err
+		}
+		if rate == nil {
+			return fmt.Errorf("no fitrah rate is set for %s in %dH", organization, hijri.Year)
+		}
+		perPerson = rate.Amount
+	} else {
+		var ok bool
+		if perPerson, ok = fitrahInKindPerPerson[unit]; !ok {
+			return fmt.Errorf("fitrah in %s has no per-person rate", unit)
+		}
+	}
+	if due := perPerson * float64(jiwa); !amountsEqual(amount, due) {
+		return fmt.Errorf("fitrah amount %v does not match %d jiwa at %v per person (%v)", amount, jiwa, perPerson, due)
+	}
+	return nil
+}
+
+// AddZakatFitrah records the fitrah a head of household pays for the persons it
+// covers, given by name, by count (jiwa) or both. The amount must be the per-person
+// rate times the number of persons: the organization's fitrah rate for the Hijri year
+// of the donation in IDR, or 2.5 kg_beras or 3.5 liter_beras per person in kind.
+func (s *SmartContract) AddZakatFitrah(ctx contractapi.TransactionContextInterface, id string, muzakki string, amount float64, unit string, persons []string, jiwa int, organization string, timestamp string) error {
+	if _, err := validatePersons(persons, jiwa); err != nil {
+		return err
+	}
+	return s.addZakat(ctx, ZakatInput{
+		ID:           id,
+		Muzakki:      muzakki,
+		Amount:       amount,
+		Unit:         unit,
+		Type:         "fitrah",
+		Organization: organization,
+		Timestamp:    timestamp,
+		Persons:      persons,
+		Jiwa:         jiwa,
+	})
+}
+
+// GetFitrahRate returns an organization's per-person fitrah rate for a Hijri year
+func (s *SmartContract) GetFitrahRate(ctx contractapi.TransactionContextInterface, organization string, hijriYear int) (FitrahRate, error) {
+	if _, err := getOrganization(ctx, organization); err != nil {
+		return FitrahRate{}, err
+	}
+	rate, err := readFitrahRate(ctx, organization, hijriYear)
+	if err != nil {
+		return FitrahRate{}, err
+	}
+	if rate == nil {
+		return FitrahRate{}, fmt.Errorf("no fitrah rate is set for %s in %dH", organization, hijriYear)
+	}
+	return *rate, nil
+}
+
+// checkFitrahRateOpen returns an error if an organization's fitrah rate for a Hijri year
+// can no longer change: Ramadan of the year has begun, or fitrah has been recorded for it
+func checkFitrahRateOpen(ctx contractapi.TransactionContextInterface, organization string, hijriYear int) error {
+	fitrah, err := readAggregate(ctx, organization, fmt.Sprintf("%04dH", hijriYear), "fitrah")
+	if err != nil {
+		return err
+	}
+	if fitrah.Donations > 0 {
+		return fmt.Errorf("the fitrah rate of %s for %dH cannot change: %d fitrah donations were recorded at it", organization, hijriYear, fitrah.Donations)
+	}
+	calendar, err := readCalendar(ctx)
+	if err != nil {
+		return err
+	}
+	ramadan, err := calendar.monthStart(hijriYear, 9)
+	if err != nil {
+		return err
+	}
+	now, err := txTime(ctx)
+	if err != nil {
+		return err
+	}
+	if dayNumber(now) >= ramadan {
+		return fmt.Errorf("the fitrah rate of %s for %dH cannot change: Ramadan %dH has begun", organization, hijriYear, hijriYear)
+	}
+	return nil
+}
+
+// SetFitrahRate sets the fitrah due in IDR for one person for a Hijri year, e.g.
+// SetFitrahRate("YDSF Malang", 1447, 45000), as announced by the local BAZNAS. Rates
+// differ between regions, so each organization's admins set their own, and the rate is
+// bound to the organization like its pools. Like the Eid prayer, a rate cannot be
+// changed once Ramadan of its year has begun or fitrah has been recorded for the year,
+// so donations already checked against it keep their standing.
+func (s *SmartContract) SetFitrahRate(ctx contractapi.TransactionContextInterface, organization string, hijriYear int, amount float64) error {
+	registry, err := readRegistry(ctx)
+	if err != nil {
+		return err
+	}
+	if err := requireAdmin(ctx, registry); err != nil {
+		return err
+	}
+	org, err := validateOrganization(ctx, organization)
+	if err != nil {
+		return err
+	}
+	mspID, err := ctx.GetClientIdentity().GetMSPID()
+	if err != nil {
+		return fmt.Errorf("failed to read client MSP ID: %v", err)
+	}
+	if mspID != org.MSPID {
+		return fmt.Errorf("only admins of %s can set its fitrah rate", organization)
+	}
+	if hijriYear < 1 {
+		return fmt.Errorf("invalid Hijri year %d", hijriYear)
+	}
+	if err := validateAmount(amount); err != nil {
+		return err
+	}
+	current, err := readFitrahRate(ctx, organization, hijriYear)
+	if err != nil {
+		return err
+	}
+	if current != nil && !amountsEqual(current.Amount, amount) {
+		if err := checkFitrahRateOpen(ctx, organization, hijriYear); err != nil {
+			return err
+		}
+	}
+
+	key, err := fitrahRateKey(organization, hijriYear)
+	if err != nil {
+		return fmt.Errorf("failed to create fitrah rate key: %v", err)
+	}
+	policy, err := orgEndorsementPolicy(org.MSPID)
+	if err != nil {
+		return fmt.Errorf("failed to create endorsement policy for the fitrah rate: %v", err)
+	}
+	if err := ctx.GetStub().SetStateValidationParameter(key, policy); err != nil {
+		return fmt.Errorf("failed to set endorsement policy for the fitrah rate: %v", err)
+	}
+	rateJSON, err := json.Marshal(FitrahRate{Organization: organization, HijriYear: hijriYear, Amount: amount})
+	if err != nil {
+		return err
+	}
+	return ctx.GetStub().PutState(key, rateJSON)
+}
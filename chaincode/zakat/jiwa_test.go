@@ -0,0 +1,148 @@
+package main
+
+import (
+	"testing"
+	"time"
+
+	"github.com/hyperledger/fabric-contract-api-go/contractapi"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+func TestSetFitrahRate(t *testing.T) {
+	chaincodeStub := new(MockStub)
+	transactionContext := new(contractapi.TransactionContext)
+	transactionContext.SetStub(chaincodeStub)
+	transactionContext.SetClientIdentity(&MockClientIdentity{MSPID: "YDSFMalangMSP", OUs: []string{"admin"}})
+	newWorldState(chaincodeStub)
+
+	smartContract := new(SmartContract)
+
+	t.Run("Not an admin", func(t *testing.T) {
+		transactionContext.SetClientIdentity(&MockClientIdentity{MSPID: "YDSFMalangMSP", OUs: []string{"client"}})
+		defer transactionContext.SetClientIdentity(&MockClientIdentity{MSPID: "YDSFMalangMSP", OUs: []string{"admin"}})
+		err := smartContract.SetFitrahRate(transactionContext, "YDSF Malang", 1445, 45000)
+		require.Error(t, err)
+		require.Contains(t, err.Error(), "only organization admins")
+	})
+
+	t.Run("Another organization", func(t *testing.T) {
+		err := smartContract.SetFitrahRate(transactionContext, "YDSF Jatim", 1445, 50000)
+		require.Error(t, err)
+		require.Contains(t, err.Error(), "only admins of YDSF Jatim")
+	})
+
+	t.Run("Not set", func(t *testing.T) {
+		_, err := smartContract.GetFitrahRate(transactionContext, "YDSF Malang", 1445)
+		require.Error(t, err)
+		require.Contains(t, err.Error(), "no fitrah rate is set for YDSF Malang in 1445H")
+	})
+
+	t.Run("Set", func(t *testing.T) {
+		require.NoError(t, smartContract.SetFitrahRate(transactionContext, "YDSF Malang", 1445, 45000))
+		rate, err := smartContract.GetFitrahRate(transactionContext, "YDSF Malang", 1445)
+		require.NoError(t, err)
+		require.Equal(t, FitrahRate{Organization: "YDSF Malang", HijriYear: 1445, Amount: 45000}, rate)
+	})
+
+	t.Run("Locked", func(t *testing.T) {
+		// 1 Ramadan 1447 is 2026-02-18 by the tabular calendar
+		chaincodeStub.On("GetTxTimestamp").Return(timestamppb.New(time.Date(2026, 2, 10, 3, 0, 0, 0, time.UTC)), nil).Once()
+		require.NoError(t, smartContract.SetFitrahRate(transactionContext, "YDSF Malang", 1447, 45000))
+		require.NoError(t, smartContract.SetFitrahRate(transactionContext, "YDSF Malang", 1447, 50000))
+
+		// Setting the same rate again changes nothing and is allowed
+		require.NoError(t, smartContract.SetFitrahRate(transactionContext, "YDSF Malang", 1447, 50000))
+
+		chaincodeStub.On("GetTxTimestamp").Return(timestamppb.New(time.Date(2026, 2, 18, 3, 0, 0, 0, time.UTC)), nil).Once()
+		err := smartContract.SetFitrahRate(transactionContext, "YDSF Malang", 1447, 45000)
+		require.Error(t, err)
+		require.Contains(t, err.Error(), "Ramadan 1447H has begun")
+
+		// Fitrah paid early in the year fixes the rate before Ramadan
+		require.NoError(t, smartContract.SetFitrahRate(transactionContext, "YDSF Malang", 1448, 45000))
+		require.NoError(t, smartContract.AddZakatFitrah(transactionContext, "ZKT-YDSF-MLG-202607-0001", "Ahmad", 90000, "IDR", []string{"Ahmad", "Fatimah"}, 0, "YDSF Malang", "2026-07-01T10:00:00+07:00"))
+		err = smartContract.SetFitrahRate(transactionContext, "YDSF Malang", 1448, 50000)
+		require.Error(t, err)
+		require.Contains(t, err.Error(), "1 fitrah donations were recorded at it")
+	})
+}
+
+func TestAddZakatFitrah(t *testing.T) {
+	chaincodeStub := new(MockStub)
+	transactionContext := new(contractapi.TransactionContext)
+	transactionContext.SetStub(chaincodeStub)
+	transactionContext.SetClientIdentity(&MockClientIdentity{MSPID: "YDSFMalangMSP", OUs: []string{"admin"}})
+	newWorldState(chaincodeStub)
+	now := time.Date(2024, 3, 1, 3, 0, 0, 0, time.UTC)
+	chaincodeStub.On("GetTxTimestamp").Return(timestamppb.New(now), nil).Maybe()
+
+	smartContract := new(SmartContract)
+
+	t.Run("No rate for the year", func(t *testing.T) {
+		err := smartContract.AddZakatFitrah(transactionContext, "ZKT-YDSF-MLG-202403-0001", "Ahmad", 135000, "", []string{"Ahmad", "Fatimah", "Ali"}, 0, "YDSF Malang", "2024-03-30T10:00:00Z")
+		require.Error(t, err)
+		require.Contains(t, err.Error(), "no fitrah rate is set for YDSF Malang in 1445H")
+	})
+
+	require.NoError(t, smartContract.SetFitrahRate(transactionContext, "YDSF Malang", 1445, 45000))
+
+	t.Run("Named persons", func(t *testing.T) {
+		require.NoError(t, smartContract.AddZakatFitrah(transactionContext, "ZKT-YDSF-MLG-202403-0001", "Ahmad", 135000, "", []string{"Ahmad", "Fatimah", "Ali"}, 0, "YDSF Malang", "2024-03-30T10:00:00Z"))
+		zakat, err := smartContract.QueryZakat(transactionContext, "ZKT-YDSF-MLG-202403-0001")
+		require.NoError(t, err)
+		require.Equal(t, "fitrah", zakat.Type)
+		require.Equal(t, []string{"Ahmad", "Fatimah", "Ali"}, zakat.Persons)
+		require.Equal(t, 3, zakat.Jiwa)
+	})
+
+	t.Run("Count in rice", func(t *testing.T) {
+		require.NoError(t, smartContract.AddZakatFitrah(transactionContext, "ZKT-YDSF-MLG-202403-0002", "Budi", 10, "kg_beras", nil, 4, "YDSF Malang", "2024-03-31T10:00:00Z"))
+		zakat, err := smartContract.QueryZakat(transactionContext, "ZKT-YDSF-MLG-202403-0002")
+		require.NoError(t, err)
+		require.Equal(t, 4, zakat.Jiwa)
+	})
+
+	for name, test := range map[string]struct {
+		amount  float64
+		unit    string
+		persons []string
+		jiwa    int
+		err     string
+	}{
+		"Amount off the rate":  {100000, "", nil, 2, "does not match 2 jiwa at 45000 per person"},
+		"Count and names":      {90000, "", []string{"Ahmad"}, 2, "jiwa 2 does not match the 1 persons named"},
+		"Nobody":               {45000, "", nil, 0, "must cover at least one person"},
+		"Empty name":           {90000, "", []string{"Ahmad", " "}, 0, "Names must not be empty"},
+		"Unit without a rate":  {10, "kg_gabah", nil, 1, "fitrah in kg_gabah has no per-person rate"},
+		"Rice off the measure": {3, "kg_beras", nil, 1, "does not match 1 jiwa at 2.5 per person"},
+	} {
+		t.Run(name, func(t *testing.T) {
+			err := smartContract.AddZakatFitrah(transactionContext, "ZKT-YDSF-MLG-202403-0003", "Citra", test.amount, test.unit, test.persons, test.jiwa, "YDSF Malang", "2024-03-31T11:00:00Z")
+			require.Error(t, err)
+			require.Contains(t, err.Error(), test.err)
+		})
+	}
+
+	t.Run("Maal covers no persons", func(t *testing.T) {
+		err := smartContract.addZakat(transactionContext, ZakatInput{ID: "ZKT-YDSF-MLG-202403-0003", Muzakki: "Citra", Amount: 1000000, Type: "maal",
+			Organization: "YDSF Malang", Timestamp: "2024-03-31T11:00:00Z", Jiwa: 2})
+		require.Error(t, err)
+		require.Contains(t, err.Error(), "only zakat fitrah covers persons")
+	})
+
+	t.Run("Jiwa in the Ramadan report", func(t *testing.T) {
+		// Fitrah recorded without persons covers the muzakki alone
+		require.NoError(t, smartContract.AddZakat(transactionContext, "ZKT-YDSF-MLG-202403-0003", "Citra", 45000, "fitrah", "YDSF Malang", "2024-03-31T11:00:00Z"))
+		require.NoError(t, smartContract.AddZakat(transactionContext, "ZKT-YDSF-MLG-202403-0004", "Citra", 1000000, "maal", "YDSF Malang", "2024-03-31T12:00:00Z"))
+
+		report, err := smartContract.GetReport(transactionContext, "YDSF Malang", "1445H09")
+		require.NoError(t, err)
+		require.Equal(t, 8, report.Total.Jiwa)
+		require.Equal(t, 4, report.Total.Donations)
+
+		baznas, err := smartContract.GetBaznasReport(transactionContext, "YDSF Malang", "1445H09")
+		require.NoError(t, err)
+		require.Equal(t, 8, baznas.Collection[0].Jiwa)
+	})
+}
@@ -0,0 +1,201 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/hyperledger/fabric-chaincode-go/shim"
+	"github.com/hyperledger/fabric-contract-api-go/contractapi"
+)
+
+// journalObjectType is the composite key namespace for journal entries. Entries are
+// keyed by organization, year and month so a period can be exported with one range.
+const journalObjectType = "journal"
+
+// Funds and accounts used in the PSAK 109 journal entries
+const (
+	fundZakat = "zakat"
+	fundAmil  = "amil"
+	fundInfaq = "infak"
+
+	accountCash                = "Kas dan setara kas"
+	accountNonCash             = "Aset nonkas - %s"
+	accountZakatReceipt        = "Penerimaan zakat %s"
+	accountZakatDistribution   = "Penyaluran zakat - %s"
+	accountAmilShareReceipt    = "Penerimaan bagian amil dari dana zakat"
+	accountTransferOut         = "Pengalihan dana zakat ke cabang lain"
+	accountTransferIn          = "Penerimaan pengalihan dana zakat dari cabang lain"
+	accountFitrahToSadaqah     = "Pengalihan zakat fitrah menjadi sedekah"
+	accountSadaqahReceipt      = "Penerimaan sedekah dari zakat fitrah"
+	accountSadaqahDistribution = "Penyaluran sedekah - %s"
+
+	// unspecifiedAsnaf names the distribution account when no asnaf was recorded
+	unspecifiedAsnaf = "mustahik"
+)
+
+// JournalLine is one double-entry line of a journal entry
+type JournalLine struct {
+	Fund          string  `json:"fund"`          // PSAK 109 fund: "zakat", "amil" or "infak"
+	DebitAccount  string  `json:"debitAccount"`  // Account debited
+	CreditAccount string  `json:"creditAccount"` // Account credited
+	Amount        float64 `json:"amount"`        // Amount in IDR
+}
+
+// JournalEntry holds the journal lines an organization books for one ledger movement
+type JournalEntry struct {
+	Reference    string        `json:"reference"`    // ID of the zakat, distribution or transfer
+	Organization string        `json:"organization"` // Organization whose books the entry belongs to
+	Date         string        `json:"date"`         // Timestamp of the movement (ISO 8601)
+	Description  string        `json:"description"`  // What the movement was
+	Lines        []JournalLine `json:"lines"`
+}
+
+// recordJournal stores a journal entry for the organization
+func recordJournal(ctx contractapi.TransactionContextInterface, entry JournalEntry) error {
+	date, err := time.Parse(time.RFC3339, entry.Date)
+	if err != nil {
+		return fmt.Errorf("invalid timestamp format. Expected ISO 8601 format (e.g., 2023-11-28T12:00:00Z)")
+	}
+	key, err := shim.CreateCompositeKey(journalObjectType, []string{entry.Organization, date.Format("2006"), date.Format("01"), entry.Reference})
+	if err != nil {
+		return fmt.Errorf("failed to create journal key: %v", err)
+	}
+	entryJSON, err := json.Marshal(entry)
+	if err != nil {
+		return err
+	}
+	return ctx.GetStub().PutState(key, entryJSON)
+}
+
+// assetAccount returns the account holding amounts of a unit: cash for IDR and a
+// noncash asset account for each in-kind unit
+func assetAccount(unit string) string {
+	if isIDR(unit) {
+		return accountCash
+	}
+	return fmt.Sprintf(accountNonCash, unit)
+}
+
+// journalCollection books a donation into the zakat fund, at its IDR value when given
+// in kind. In-kind donations without a reference price have no value to book.
+func journalCollection(ctx contractapi.TransactionContextInterface, zakat Zakat) error {
+	if zakat.idrValue() == 0 {
+		return nil
+	}
+	return recordJournal(ctx, JournalEntry{
+		Reference:    zakat.ID,
+		Organization: zakat.Organization,
+		Date:         zakat.Timestamp,
+		Description:  fmt.Sprintf("Zakat %s from %s", zakat.Type, zakat.Muzakki),
+		Lines: []JournalLine{
+			{Fund: fundZakat, DebitAccount: assetAccount(zakat.Unit), CreditAccount: fmt.Sprintf(accountZakatReceipt, zakat.Type), Amount: zakat.idrValue()},
+		},
+	})
+}
+
+// journalDistribution books a distribution out of the zakat fund. The amil share also
+// enters the amil fund, as PSAK 109 keeps it separate from the zakat fund. Late fitrah
+// recorded as sadaqah moves to the infak/sedekah fund and is distributed from there.
+func journalDistribution(ctx contractapi.TransactionContextInterface, distribution Distribution) error {
+	amount, asset := distribution.idrValue(), assetAccount(distribution.Unit)
+	if amount == 0 {
+		return nil
+	}
+	asnaf := distribution.Asnaf
+	if asnaf == "" {
+		asnaf = unspecifiedAsnaf
+	}
+	lines := []JournalLine{
+		{Fund: fundZakat, DebitAccount: fmt.Sprintf(accountZakatDistribution, asnaf), CreditAccount: asset, Amount: amount},
+	}
+	description := fmt.Sprintf("Zakat %s distributed to %s", distribution.Type, distribution.Mustahik)
+	if distribution.Asnaf == amilAsnaf {
+		lines = append(lines, JournalLine{Fund: fundAmil, DebitAccount: asset, CreditAccount: accountAmilShareReceipt, Amount: amount})
+		description = fmt.Sprintf("Amil share of zakat %s", distribution.Type)
+	}
+	if distribution.Sadaqah {
+		lines = []JournalLine{
+			{Fund: fundZakat, DebitAccount: accountFitrahToSadaqah, CreditAccount: asset, Amount: amount},
+			{Fund: fundInfaq, DebitAccount: asset, CreditAccount: accountSadaqahReceipt, Amount: amount},
+			{Fund: fundInfaq, DebitAccount: fmt.Sprintf(accountSadaqahDistribution, asnaf), CreditAccount: asset, Amount: amount},
+		}
+		description = fmt.Sprintf("Late zakat fitrah distributed to %s as sadaqah", distribution.Mustahik)
+	}
+
+	return recordJournal(ctx, JournalEntry{
+		Reference:    distribution.ID,
+		Organization: distribution.Organization,
+		Date:         distribution.Timestamp,
+		Description:  description,
+		Lines:        lines,
+	})
+}
+
+// journalTransfer books a transfer in the books of both organizations
+func journalTransfer(ctx contractapi.TransactionContextInterface, transfer Transfer) error {
+	err := recordJournal(ctx, JournalEntry{
+		Reference:    transfer.ID,
+		Organization: transfer.FromOrganization,
+		Date:         transfer.Timestamp,
+		Description:  fmt.Sprintf("Zakat %s transferred to %s", transfer.Type, transfer.ToOrganization),
+		Lines: []JournalLine{
+			{Fund: fundZakat, DebitAccount: accountTransferOut, CreditAccount: accountCash, Amount: transfer.Amount},
+		},
+	})
+	if err != nil {
+		return err
+	}
+	return recordJournal(ctx, JournalEntry{
+		Reference:    transfer.ID,
+		Organization: transfer.ToOrganization,
+		Date:         transfer.Timestamp,
+		Description:  fmt.Sprintf("Zakat %s received from %s", transfer.Type, transfer.FromOrganization),
+		Lines: []JournalLine{
+			{Fund: fundZakat, DebitAccount: accountCash, CreditAccount: accountTransferIn, Amount: transfer.Amount},
+		},
+	})
+}
+
+// GetJournalEntries returns the journal entries of an organization for a year (YYYY)
+// or month (YYYYMM), for reconciliation with the organization's books
+func (s *SmartContract) GetJournalEntries(ctx contractapi.TransactionContextInterface, organization string, period string) ([]JournalEntry, error) {
+	if _, err := getOrganization(ctx, organization); err != nil {
+		return nil, err
+	}
+	if err := validatePeriod(period); err != nil {
+		return nil, err
+	}
+	if strings.Contains(period, "H") {
+		return nil, fmt.Errorf("journal entries are kept by Gregorian period. Expected YYYY or YYYYMM (e.g., 2023 or 202311)")
+	}
+
+	attributes := []string{organization, period[:4]}
+	if len(period) == 6 {
+		attributes = append(attributes, period[4:])
+	}
+
+	resultsIterator, err := ctx.GetStub().GetStateByPartialCompositeKey(journalObjectType, attributes)
+	if err != nil {
+		return nil, err
+	}
+	defer resultsIterator.Close()
+
+	var entries []JournalEntry
+	for resultsIterator.HasNext() {
+		queryResponse, err := resultsIterator.Next()
+		if err != nil {
+			return nil, err
+		}
+
+		var entry JournalEntry
+		err = json.Unmarshal(queryResponse.Value, &entry)
+		if err != nil {
+			return nil, err
+		}
+		entries = append(entries, entry)
+	}
+
+	return entries, nil
+}
@@ -0,0 +1,68 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/hyperledger/fabric-contract-api-go/contractapi"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGetJournalEntries(t *testing.T) {
+	chaincodeStub := new(MockStub)
+	transactionContext := new(contractapi.TransactionContext)
+	transactionContext.SetStub(chaincodeStub)
+	newWorldState(chaincodeStub)
+
+	smartContract := new(SmartContract)
+	require.NoError(t, smartContract.AddZakat(transactionContext, "ZKT-YDSF-MLG-202311-0001", "John Doe", 1000000, "maal", "YDSF Malang", "2023-11-01T10:00:00Z"))
+	require.NoError(t, smartContract.AllocateAmilShare(transactionContext, "DST-YDSF-MLG-202311-0001", "POOL-YDSF-MLG-MAAL", 125000, "2023-11-02T10:00:00Z"))
+	require.NoError(t, smartContract.DistributeZakat(transactionContext, "DST-YDSF-MLG-202311-0002", "POOL-YDSF-MLG-MAAL", "", "Mustahik1", 500000, "2023-11-03T10:00:00Z"))
+	openPool(t, transactionContext, "YDSF Jatim", "maal")
+	require.NoError(t, smartContract.TransferFunds(transactionContext, "TRF-YDSF-MLG-JTM-202312-0001", "YDSF Malang", "YDSF Jatim", "maal", 300000, "Bantuan Banjir Lumajang", "2023-12-01T10:00:00Z"))
+
+	t.Run("Month", func(t *testing.T) {
+		entries, err := smartContract.GetJournalEntries(transactionContext, "YDSF Malang", "202311")
+		require.NoError(t, err)
+		require.Len(t, entries, 3)
+
+		require.Equal(t, "ZKT-YDSF-MLG-202311-0001", entries[2].Reference)
+		require.Equal(t, []JournalLine{
+			{Fund: fundZakat, DebitAccount: accountCash, CreditAccount: "Penerimaan zakat maal", Amount: 1000000},
+		}, entries[2].Lines)
+
+		require.Equal(t, "DST-YDSF-MLG-202311-0001", entries[0].Reference)
+		require.Equal(t, []JournalLine{
+			{Fund: fundZakat, DebitAccount: "Penyaluran zakat - amil", CreditAccount: accountCash, Amount: 125000},
+			{Fund: fundAmil, DebitAccount: accountCash, CreditAccount: accountAmilShareReceipt, Amount: 125000},
+		}, entries[0].Lines)
+
+		require.Equal(t, []JournalLine{
+			{Fund: fundZakat, DebitAccount: "Penyaluran zakat - mustahik", CreditAccount: accountCash, Amount: 500000},
+		}, entries[1].Lines)
+	})
+
+	t.Run("Year covers both organizations' sides of a transfer", func(t *testing.T) {
+		entries, err := smartContract.GetJournalEntries(transactionContext, "YDSF Malang", "2023")
+		require.NoError(t, err)
+		require.Len(t, entries, 4)
+		require.Equal(t, accountTransferOut, entries[3].Lines[0].DebitAccount)
+
+		entries, err = smartContract.GetJournalEntries(transactionContext, "YDSF Jatim", "202312")
+		require.NoError(t, err)
+		require.Len(t, entries, 1)
+		require.Equal(t, accountTransferIn, entries[0].Lines[0].CreditAccount)
+	})
+
+	t.Run("Amil share is a distribution to the amil", func(t *testing.T) {
+		distribution, err := smartContract.QueryDistribution(transactionContext, "DST-YDSF-MLG-202311-0001")
+		require.NoError(t, err)
+		require.Equal(t, amilAsnaf, distribution.Asnaf)
+		require.Equal(t, "YDSF Malang", distribution.Mustahik)
+	})
+
+	t.Run("Hijri period", func(t *testing.T) {
+		_, err := smartContract.GetJournalEntries(transactionContext, "YDSF Malang", "1445H")
+		require.Error(t, err)
+		require.Contains(t, err.Error(), "kept by Gregorian period")
+	})
+}
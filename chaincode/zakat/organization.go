@@ -0,0 +1,457 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"sort"
+
+	"github.com/golang/protobuf/proto"
+	"github.com/hyperledger/fabric-chaincode-go/shim"
+	"github.com/hyperledger/fabric-contract-api-go/contractapi"
+	"github.com/hyperledger/fabric-protos-go/common"
+	"github.com/hyperledger/fabric-protos-go/msp"
+)
+
+const (
+	// organizationObjectType is the composite key namespace for registered organizations
+	organizationObjectType = "organization"
+	// organizationCodeIndex maps the code used in IDs to the organization's name
+	organizationCodeIndex = "organization~code"
+	// registryObjectType is the composite key namespace of the registry membership
+	// record, which carries the majority endorsement policy of the registry
+	registryObjectType = "registry"
+
+	// adminOU is the organizational unit of admin identities under NodeOUs
+	adminOU = "admin"
+)
+
+// Organization statuses. An inactive organization can no longer collect zakat, create
+// programs or receive transfers; the funds left in its pools can still be distributed
+// or transferred out.
+const (
+	organizationActive   = "active"
+	organizationInactive = "inactive"
+)
+
+// Organization is a collecting organization registered on the ledger
+type Organization struct {
+	Name   string `json:"name"`   // Display name used in records, e.g. "YDSF Malang"
+	MSPID  string `json:"mspId"`  // MSP of the organization's peers and users
+	Code   string `json:"code"`   // Code used in IDs, e.g. "MLG"
+	Status string `json:"status"` // "active" or "inactive"
+}
+
+// Registry lists the registered organizations. Every change to the registry rewrites
+// this record, whose key-level policy requires a majority of the active members.
+type Registry struct {
+	Organizations []Organization `json:"organizations"` // Registered organizations, in registration order
+}
+
+// defaultOrganizations are registered by InitLedger
+var defaultOrganizations = []Organization{
+	{Name: "YDSF Malang", MSPID: "YDSFMalangMSP", Code: "MLG", Status: organizationActive},
+	{Name: "YDSF Jatim", MSPID: "YDSFJatimMSP", Code: "JTM", Status: organizationActive},
+}
+
+// validateOrganizationCode checks if the provided code can be used in IDs
+func validateOrganizationCode(code string) error {
+	if !regexp.MustCompile(`^[A-Z]{3}$`).MatchString(code) {
+		return fmt.Errorf("invalid organization code. Expected three uppercase letters (e.g., SBY)")
+	}
+	return nil
+}
+
+// validateOrganizationStatus checks if the provided organization status is valid
+func validateOrganizationStatus(status string) error {
+	if status != organizationActive && status != organizationInactive {
+		return fmt.Errorf("invalid organization status. Must be either 'active' or 'inactive'")
+	}
+	return nil
+}
+
+// organizationKey returns the world state key of the organization with the given name
+func organizationKey(name string) (string, error) {
+	return shim.CreateCompositeKey(organizationObjectType, []string{name})
+}
+
+// registryKey returns the world state key of the registry membership record
+func registryKey() (string, error) {
+	return shim.CreateCompositeKey(registryObjectType, []string{"members"})
+}
+
+// readOrganization returns the organization with the given name, or nil if it is not registered
+func readOrganization(ctx contractapi.TransactionContextInterface, name string) (*Organization, error) {
+	key, err := organizationKey(name)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create organization key: %v", err)
+	}
+	organizationJSON, err := ctx.GetStub().GetState(key)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read organization %s from world state: %v", name, err)
+	}
+	if organizationJSON == nil {
+		return nil, nil
+	}
+
+	var organization Organization
+	if err := json.Unmarshal(organizationJSON, &organization); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal organization %s: %v", name, err)
+	}
+	return &organization, nil
+}
+
+// getOrganization returns a registered organization, active or not
+func getOrganization(ctx contractapi.TransactionContextInterface, name string) (Organization, error) {
+	organization, err := readOrganization(ctx, name)
+	if err != nil {
+		return Organization{}, err
+	}
+	if organization == nil {
+		return Organization{}, fmt.Errorf("invalid organization. %s is not registered", name)
+	}
+	return *organization, nil
+}
+
+// validateOrganization checks that the organization is registered and active
+func validateOrganization(ctx contractapi.TransactionContextInterface, name string) (Organization, error) {
+	organization, err := getOrganization(ctx, name)
+	if err != nil {
+		return Organization{}, err
+	}
+	if organization.Status != organizationActive {
+		return Organization{}, fmt.Errorf("invalid organization. %s is %s", name, organization.Status)
+	}
+	return organization, nil
+}
+
+// organizationByCode returns the organization registered with the given ID code
+func organizationByCode(ctx contractapi.TransactionContextInterface, code string) (Organization, error) {
+	indexKey, err := shim.CreateCompositeKey(organizationCodeIndex, []string{code})
+	if err != nil {
+		return Organization{}, fmt.Errorf("failed to create organization code key: %v", err)
+	}
+	name, err := ctx.GetStub().GetState(indexKey)
+	if err != nil {
+		return Organization{}, fmt.Errorf("failed to read organization code from world state: %v", err)
+	}
+	if name == nil {
+		return Organization{}, fmt.Errorf("no organization is registered with code %s", code)
+	}
+	return getOrganization(ctx, string(name))
+}
+
+// writeOrganization stores the organization and its code index in world state, for
+// writeRegistry. Both are bound to a majority of the registry's active members like the
+// registry record, as IDs and records are validated against them.
+func writeOrganization(ctx contractapi.TransactionContextInterface, registry Registry, organization Organization) error {
+	key, err := organizationKey(organization.Name)
+	if err != nil {
+		return fmt.Errorf("failed to create organization key: %v", err)
+	}
+	if err := putEndorsedByMajority(ctx, registry, key, "organization "+organization.Name, organization); err != nil {
+		return err
+	}
+
+	indexKey, err := shim.CreateCompositeKey(organizationCodeIndex, []string{organization.Code})
+	if err != nil {
+		return fmt.Errorf("failed to create organization code key: %v", err)
+	}
+	if err := endorseByMajority(ctx, registry, indexKey, "organization code "+organization.Code); err != nil {
+		return err
+	}
+	return ctx.GetStub().PutState(indexKey, []byte(organization.Name))
+}
+
+// readRegistry returns the registry membership record, empty before any registration
+func readRegistry(ctx contractapi.TransactionContextInterface) (Registry, error) {
+	key, err := registryKey()
+	if err != nil {
+		return Registry{}, fmt.Errorf("failed to create registry key: %v", err)
+	}
+	registryJSON, err := ctx.GetStub().GetState(key)
+	if err != nil {
+		return Registry{}, fmt.Errorf("failed to read registry from world state: %v", err)
+	}
+	if registryJSON == nil {
+		return Registry{}, nil
+	}
+
+	var registry Registry
+	if err := json.Unmarshal(registryJSON, &registry); err != nil {
+		return Registry{}, fmt.Errorf("failed to unmarshal registry: %v", err)
+	}
+	return registry, nil
+}
+
+// writeRegistry stores the registry membership record and the records of its
+// organizations, and binds them all to a majority of the active members' MSPs. The
+// policy in force when a transaction commits is the one set before it, so each change
+// is approved by a majority of the existing members.
+func writeRegistry(ctx contractapi.TransactionContextInterface, registry Registry) error {
+	if len(activeMSPIDs(registry)) == 0 {
+		return fmt.Errorf("the registry must keep at least one active organization")
+	}
+	for _, organization := range registry.Organizations {
+		if err := writeOrganization(ctx, registry, organization); err != nil {
+			return err
+		}
+	}
+
+	key, err := registryKey()
+	if err != nil {
+		return fmt.Errorf("failed to create registry key: %v", err)
+	}
+	return putEndorsedByMajority(ctx, registry, key, "registry", registry)
+}
+
+// putEndorsedByMajority stores a shared record, such as the Hijri calendar, and binds
+// it to a majority of the registry's active members, like the registry itself
+func putEndorsedByMajority(ctx contractapi.TransactionContextInterface, registry Registry, key string, name string, value interface{}) error {
+	if err := endorseByMajority(ctx, registry, key, name); err != nil {
+		return err
+	}
+
+	valueJSON, err := json.Marshal(value)
+	if err != nil {
+		return err
+	}
+	return ctx.GetStub().PutState(key, valueJSON)
+}
+
+// endorseByMajority sets the key-level policy of a shared record to a majority of the
+// registry's active members
+func endorseByMajority(ctx contractapi.TransactionContextInterface, registry Registry, key string, name string) error {
+	policy, err := majorityEndorsementPolicy(activeMSPIDs(registry))
+	if err != nil {
+		return fmt.Errorf("failed to create endorsement policy for the %s: %v", name, err)
+	}
+	if err := ctx.GetStub().SetStateValidationParameter(key, policy); err != nil {
+		return fmt.Errorf("failed to set endorsement policy for the %s: %v", name, err)
+	}
+	return nil
+}
+
+// activeMSPIDs returns the MSP IDs of the registry's active members
+func activeMSPIDs(registry Registry) []string {
+	var mspIDs []string
+	for _, organization := range registry.Organizations {
+		if organization.Status == organizationActive {
+			mspIDs = append(mspIDs, organization.MSPID)
+		}
+	}
+	return mspIDs
+}
+
+// majorityEndorsementPolicy returns a key-level endorsement policy requiring peers of
+// more than half of the given MSPs
+func majorityEndorsementPolicy(mspIDs []string) ([]byte, error) {
+	mspIDs = uniqueSorted(mspIDs)
+
+	var identities []*msp.MSPPrincipal
+	var rules []*common.SignaturePolicy
+	for i, mspID := range mspIDs {
+		role, err := proto.Marshal(&msp.MSPRole{MspIdentifier: mspID, Role: msp.MSPRole_PEER})
+		if err != nil {
+			return nil, err
+		}
+		identities = append(identities, &msp.MSPPrincipal{
+			PrincipalClassification: msp.MSPPrincipal_ROLE,
+			Principal:               role,
+		})
+		rules = append(rules, &common.SignaturePolicy{
+			Type: &common.SignaturePolicy_SignedBy{SignedBy: int32(i)},
+		})
+	}
+
+	return proto.Marshal(&common.SignaturePolicyEnvelope{
+		Rule: &common.SignaturePolicy{
+			Type: &common.SignaturePolicy_NOutOf_{NOutOf: &common.SignaturePolicy_NOutOf{
+				N:     int32(len(mspIDs)/2 + 1),
+				Rules: rules,
+			}},
+		},
+		Identities: identities,
+	})
+}
+
+// uniqueSorted returns the distinct values in sorted order
+func uniqueSorted(values []string) []string {
+	seen := map[string]bool{}
+	var unique []string
+	for _, value := range values {
+		if !seen[value] {
+			seen[value] = true
+			unique = append(unique, value)
+		}
+	}
+	sort.Strings(unique)
+	return unique
+}
+
+// requireAdmin checks that the client is an admin of an active registered organization.
+// Before the registry is seeded, the default organizations are its members, so only
+// their admins can seed it.
+func requireAdmin(ctx contractapi.TransactionContextInterface, registry Registry) error {
+	certificate, err := ctx.GetClientIdentity().GetX509Certificate()
+	if err != nil {
+		return fmt.Errorf("failed to read client certificate: %v", err)
+	}
+	isAdmin := false
+	for _, ou := range certificate.Subject.OrganizationalUnit {
+		if ou == adminOU {
+			isAdmin = true
+		}
+	}
+	if !isAdmin {
+		return fmt.Errorf("only organization admins can manage the organization registry")
+	}
+	members := registry.Organizations
+	if len(members) == 0 {
+		members = defaultOrganizations
+	}
+
+	mspID, err := ctx.GetClientIdentity().GetMSPID()
+	if err != nil {
+		return fmt.Errorf("failed to read client MSP ID: %v", err)
+	}
+	for _, organization := range members {
+		if organization.MSPID == mspID && organization.Status == organizationActive {
+			return nil
+		}
+	}
+	return fmt.Errorf("client MSP %s is not an active registered organization", mspID)
+}
+
+// find returns the index of the registered organization with the given name, or -1
+func (r Registry) find(name string) int {
+	for i, organization := range r.Organizations {
+		if organization.Name == name {
+			return i
+		}
+	}
+	return -1
+}
+
+// registerOrganization adds an organization to the registry without authorization
+// checks, for RegisterOrganization and InitLedger. writeRegistry stores it.
+func registerOrganization(ctx contractapi.TransactionContextInterface, registry *Registry, organization Organization) error {
+	for _, registered := range registry.Organizations {
+		if registered.Name == organization.Name {
+			return fmt.Errorf("the organization %s is already registered", organization.Name)
+		}
+		if registered.Code == organization.Code {
+			return fmt.Errorf("the organization code %s is already in use by %s", organization.Code, registered.Name)
+		}
+	}
+
+	registry.Organizations = append(registry.Organizations, organization)
+	return nil
+}
+
+// seedOrganizations registers the default organizations that are not in the registry
+// yet and returns the resulting registry. Callers look organizations up in the
+// returned registry, as reads in the same transaction do not see the new records.
+func seedOrganizations(ctx contractapi.TransactionContextInterface, registry Registry) (Registry, error) {
+	changed := false
+	for _, organization := range defaultOrganizations {
+		if registry.find(organization.Name) >= 0 {
+			continue
+		}
+		if err := registerOrganization(ctx, &registry, organization); err != nil {
+			return Registry{}, err
+		}
+		changed = true
+	}
+	if !changed {
+		return registry, nil
+	}
+	return registry, writeRegistry(ctx, registry)
+}
+
+// RegisterOrganization adds a collecting organization to the registry, after which
+// IDs with its code and records under its name are accepted. Only an admin of an
+// active member can submit it and the registry's key-level policy requires peers of a
+// majority of the active members to endorse it.
+func (s *SmartContract) RegisterOrganization(ctx contractapi.TransactionContextInterface, name string, mspID string, code string) error {
+	registry, err := readRegistry(ctx)
+	if err != nil {
+		return err
+	}
+	if err := requireAdmin(ctx, registry); err != nil {
+		return err
+	}
+	if len(registry.Organizations) == 0 {
+		return fmt.Errorf("the organization registry is empty: seed it with InitLedger first")
+	}
+
+	// Validate input parameters
+	if name == "" {
+		return fmt.Errorf("organization name must not be empty")
+	}
+	if mspID == "" {
+		return fmt.Errorf("organization MSP ID must not be empty")
+	}
+	if err := validateOrganizationCode(code); err != nil {
+		return err
+	}
+
+	organization := Organization{Name: name, MSPID: mspID, Code: code, Status: organizationActive}
+	if err := registerOrganization(ctx, &registry, organization); err != nil {
+		return err
+	}
+	return writeRegistry(ctx, registry)
+}
+
+// SetOrganizationStatus activates or deactivates a registered organization. It is
+// authorized and endorsed like RegisterOrganization.
+func (s *SmartContract) SetOrganizationStatus(ctx contractapi.TransactionContextInterface, name string, status string) error {
+	registry, err := readRegistry(ctx)
+	if err != nil {
+		return err
+	}
+	if err := requireAdmin(ctx, registry); err != nil {
+		return err
+	}
+	if err := validateOrganizationStatus(status); err != nil {
+		return err
+	}
+
+	i := registry.find(name)
+	if i < 0 {
+		return fmt.Errorf("invalid organization. %s is not registered", name)
+	}
+	registry.Organizations[i].Status = status
+	return writeRegistry(ctx, registry)
+}
+
+// QueryOrganization returns the registered organization with the given name
+func (s *SmartContract) QueryOrganization(ctx contractapi.TransactionContextInterface, name string) (Organization, error) {
+	return getOrganization(ctx, name)
+}
+
+// GetAllOrganizations returns all registered organizations
+func (s *SmartContract) GetAllOrganizations(ctx contractapi.TransactionContextInterface) ([]Organization, error) {
+	resultsIterator, err := ctx.GetStub().GetStateByPartialCompositeKey(organizationObjectType, []string{})
+	if err != nil {
+		return nil, err
+	}
+	defer resultsIterator.Close()
+
+	var organizations []Organization
+	for resultsIterator.HasNext() {
+		queryResponse, err := resultsIterator.Next()
+		if err != nil {
+			return nil, err
+		}
+
+		var organization Organization
+		err = json.Unmarshal(queryResponse.Value, &organization)
+		if err != nil {
+			return nil, err
+		}
+		organizations = append(organizations, organization)
+	}
+
+	return organizations, nil
+}
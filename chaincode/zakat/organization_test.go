@@ -0,0 +1,173 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/golang/protobuf/proto"
+	"github.com/hyperledger/fabric-chaincode-go/shim"
+	"github.com/hyperledger/fabric-contract-api-go/contractapi"
+	"github.com/hyperledger/fabric-protos-go/common"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRegisterOrganization(t *testing.T) {
+	chaincodeStub := new(MockStub)
+	transactionContext := new(contractapi.TransactionContext)
+	transactionContext.SetStub(chaincodeStub)
+	worldState := newWorldState(chaincodeStub)
+
+	smartContract := new(SmartContract)
+
+	t.Run("Not an admin", func(t *testing.T) {
+		transactionContext.SetClientIdentity(&MockClientIdentity{MSPID: "YDSFMalangMSP", OUs: []string{"client"}})
+		err := smartContract.RegisterOrganization(transactionContext, "YDSF Surabaya", "YDSFSurabayaMSP", "SBY")
+		require.Error(t, err)
+		require.Contains(t, err.Error(), "only organization admins")
+	})
+
+	t.Run("Empty registry", func(t *testing.T) {
+		seeded := worldState.State
+		defer func() { worldState.State = seeded }()
+		worldState.State = map[string][]byte{}
+		transactionContext.SetClientIdentity(&MockClientIdentity{MSPID: "YDSFMalangMSP", OUs: []string{"admin"}})
+		err := smartContract.RegisterOrganization(transactionContext, "YDSF Surabaya", "YDSFSurabayaMSP", "SBY")
+		require.Error(t, err)
+		require.Contains(t, err.Error(), "seed it with InitLedger first")
+	})
+
+	t.Run("Not a member", func(t *testing.T) {
+		transactionContext.SetClientIdentity(&MockClientIdentity{MSPID: "YDSFSurabayaMSP", OUs: []string{"admin"}})
+		err := smartContract.RegisterOrganization(transactionContext, "YDSF Surabaya", "YDSFSurabayaMSP", "SBY")
+		require.Error(t, err)
+		require.Contains(t, err.Error(), "is not an active registered organization")
+	})
+
+	transactionContext.SetClientIdentity(&MockClientIdentity{MSPID: "YDSFMalangMSP", OUs: []string{"admin"}})
+
+	t.Run("Code in use", func(t *testing.T) {
+		err := smartContract.RegisterOrganization(transactionContext, "YDSF Surabaya", "YDSFSurabayaMSP", "MLG")
+		require.Error(t, err)
+		require.Contains(t, err.Error(), "already in use by YDSF Malang")
+	})
+
+	t.Run("Invalid code", func(t *testing.T) {
+		err := smartContract.RegisterOrganization(transactionContext, "YDSF Surabaya", "YDSFSurabayaMSP", "sby")
+		require.Error(t, err)
+		require.Contains(t, err.Error(), "invalid organization code")
+	})
+
+	t.Run("Unregistered code in zakat ID", func(t *testing.T) {
+		err := smartContract.AddZakat(transactionContext, "ZKT-YDSF-SBY-202401-0001", "John Doe", 1000000, "maal", "YDSF Malang", "2024-01-15T10:00:00Z")
+		require.Error(t, err)
+		require.Contains(t, err.Error(), "no organization is registered with code SBY")
+	})
+
+	t.Run("Zakat ID of another organization", func(t *testing.T) {
+		err := smartContract.AddZakat(transactionContext, "ZKT-YDSF-MLG-202401-0001", "John Doe", 1000000, "maal", "YDSF Jatim", "2024-01-15T10:00:00Z")
+		require.Error(t, err)
+		require.Contains(t, err.Error(), "does not belong to organization YDSF Jatim")
+	})
+
+	t.Run("Success", func(t *testing.T) {
+		require.NoError(t, smartContract.RegisterOrganization(transactionContext, "YDSF Surabaya", "YDSFSurabayaMSP", "SBY"))
+
+		organization, err := smartContract.QueryOrganization(transactionContext, "YDSF Surabaya")
+		require.NoError(t, err)
+		require.Equal(t, Organization{Name: "YDSF Surabaya", MSPID: "YDSFSurabayaMSP", Code: "SBY", Status: "active"}, organization)
+
+		var registry Registry
+		key, err := registryKey()
+		require.NoError(t, err)
+		worldState.Get(t, key, &registry)
+		require.Len(t, registry.Organizations, 3)
+
+		// The records IDs are validated against need a majority of the members too
+		policy, err := majorityEndorsementPolicy([]string{"YDSFMalangMSP", "YDSFJatimMSP", "YDSFSurabayaMSP"})
+		require.NoError(t, err)
+		for _, organization := range registry.Organizations {
+			organizationStateKey, err := organizationKey(organization.Name)
+			require.NoError(t, err)
+			codeKey, err := shim.CreateCompositeKey(organizationCodeIndex, []string{organization.Code})
+			require.NoError(t, err)
+			require.Equal(t, policy, worldState.Policies[organizationStateKey], organization.Name)
+			require.Equal(t, policy, worldState.Policies[codeKey], organization.Code)
+		}
+
+		// The new organization can collect zakat under its own code
+		require.NoError(t, smartContract.AddZakat(transactionContext, "ZKT-YDSF-SBY-202401-0001", "John Doe", 1000000, "maal", "YDSF Surabaya", "2024-01-15T10:00:00Z"))
+		pool, err := smartContract.QueryPool(transactionContext, "POOL-YDSF-SBY-MAAL")
+		require.NoError(t, err)
+		require.Equal(t, float64(1000000), pool.Balance)
+
+		organizations, err := smartContract.GetAllOrganizations(transactionContext)
+		require.NoError(t, err)
+		require.Len(t, organizations, 3)
+	})
+
+	t.Run("Already registered", func(t *testing.T) {
+		err := smartContract.RegisterOrganization(transactionContext, "YDSF Surabaya", "YDSFSurabayaMSP", "SUB")
+		require.Error(t, err)
+		require.Contains(t, err.Error(), "already registered")
+	})
+}
+
+func TestSetOrganizationStatus(t *testing.T) {
+	chaincodeStub := new(MockStub)
+	transactionContext := new(contractapi.TransactionContext)
+	transactionContext.SetStub(chaincodeStub)
+	transactionContext.SetClientIdentity(&MockClientIdentity{MSPID: "YDSFJatimMSP", OUs: []string{"admin"}})
+	newWorldState(chaincodeStub)
+
+	smartContract := new(SmartContract)
+	require.NoError(t, smartContract.AddZakat(transactionContext, "ZKT-YDSF-MLG-202401-0001", "John Doe", 1000000, "maal", "YDSF Malang", "2024-01-15T10:00:00Z"))
+	require.NoError(t, smartContract.SetOrganizationStatus(transactionContext, "YDSF Malang", "inactive"))
+
+	t.Run("Inactive organization cannot collect", func(t *testing.T) {
+		err := smartContract.AddZakat(transactionContext, "ZKT-YDSF-MLG-202401-0002", "John Doe", 1000000, "maal", "YDSF Malang", "2024-01-16T10:00:00Z")
+		require.Error(t, err)
+		require.Contains(t, err.Error(), "YDSF Malang is inactive")
+	})
+
+	t.Run("Inactive organization can still distribute", func(t *testing.T) {
+		require.NoError(t, smartContract.DistributeZakat(transactionContext, "DST-YDSF-MLG-202401-0001", "POOL-YDSF-MLG-MAAL", "", "Mustahik1", 500000, "2024-01-20T10:00:00Z"))
+	})
+
+	t.Run("Inactive admin cannot manage the registry", func(t *testing.T) {
+		transactionContext.SetClientIdentity(&MockClientIdentity{MSPID: "YDSFMalangMSP", OUs: []string{"admin"}})
+		defer transactionContext.SetClientIdentity(&MockClientIdentity{MSPID: "YDSFJatimMSP", OUs: []string{"admin"}})
+		err := smartContract.SetOrganizationStatus(transactionContext, "YDSF Malang", "active")
+		require.Error(t, err)
+	})
+
+	t.Run("Last active organization", func(t *testing.T) {
+		err := smartContract.SetOrganizationStatus(transactionContext, "YDSF Jatim", "inactive")
+		require.Error(t, err)
+		require.Contains(t, err.Error(), "at least one active organization")
+	})
+
+	t.Run("Invalid status", func(t *testing.T) {
+		err := smartContract.SetOrganizationStatus(transactionContext, "YDSF Malang", "closed")
+		require.Error(t, err)
+		require.Contains(t, err.Error(), "invalid organization status")
+	})
+}
+
+func TestMajorityEndorsementPolicy(t *testing.T) {
+	for _, test := range []struct {
+		mspIDs []string
+		n      int32
+	}{
+		{[]string{"YDSFMalangMSP"}, 1},
+		{[]string{"YDSFMalangMSP", "YDSFJatimMSP"}, 2},
+		{[]string{"YDSFMalangMSP", "YDSFJatimMSP", "YDSFSurabayaMSP"}, 2},
+		{[]string{"YDSFMalangMSP", "YDSFJatimMSP", "YDSFSurabayaMSP", "YDSFKediriMSP"}, 3},
+	} {
+		policy, err := majorityEndorsementPolicy(test.mspIDs)
+		require.NoError(t, err)
+
+		var envelope common.SignaturePolicyEnvelope
+		require.NoError(t, proto.Unmarshal(policy, &envelope))
+		require.Len(t, envelope.Identities, len(test.mspIDs))
+		require.Equal(t, test.n, envelope.Rule.GetNOutOf().N)
+	}
+}
@@ -0,0 +1,101 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/hyperledger/fabric-chaincode-go/shim"
+	"github.com/hyperledger/fabric-contract-api-go/contractapi"
+)
+
+const (
+	paymentReferenceIndex = "payment~reference"
+	paymentBankTransfer   = "bank_transfer"
+	paymentQRIS           = "qris"
+	paymentGateway        = "gateway"
+	maxReferenceLength    = 64
+)
+
+// Payment identifies the incoming payment a donation was received by
+type Payment struct {
+	Channel   string `json:"channel"`   // "bank_transfer", "qris" or "gateway"
+	Bank      string `json:"bank"`      // Receiving bank, QRIS acquirer or payment gateway, e.g. BSI
+	Reference string `json:"reference"` // Bank reference number, QRIS RRN or gateway payment ID
+}
+
+// normalizePayment trims the payment's fields and upper-cases the bank, so that the
+// same payment keyed in twice has the same reference key
+func normalizePayment(payment Payment) Payment {
+	return Payment{
+		Channel:   strings.ToLower(strings.TrimSpace(payment.Channel)),
+		Bank:      strings.ToUpper(strings.TrimSpace(payment.Bank)),
+		Reference: strings.TrimSpace(payment.Reference),
+	}
+}
+
+// validatePayment checks if the provided payment reference is complete
+func validatePayment(payment Payment) error {
+	if payment.Channel != paymentBankTransfer && payment.Channel != paymentQRIS && payment.Channel != paymentGateway {
+		return fmt.Errorf("invalid payment channel. Must be 'bank_transfer', 'qris' or 'gateway'")
+	}
+	if payment.Bank == "" {
+		return fmt.Errorf("invalid payment. The bank must not be empty")
+	}
+	if payment.Reference == "" || len(payment.Reference) > maxReferenceLength {
+		return fmt.Errorf("invalid payment reference. Must be 1 to %d characters", maxReferenceLength)
+	}
+	return nil
+}
+
+// paymentKey returns the key of the index entry that reserves a payment reference
+func paymentKey(payment Payment) (string, error) {
+	return shim.CreateCompositeKey(paymentReferenceIndex, []string{payment.Channel, payment.Bank, payment.Reference})
+}
+
+// claimPayment records that a payment funded a donation, failing if the payment was
+// already recorded for another donation
+func claimPayment(ctx contractapi.TransactionContextInterface, payment Payment, zakatID string) error {
+	key, err := paymentKey(payment)
+	if err != nil {
+		return fmt.Errorf("failed to create payment reference key: %v", err)
+	}
+	claimed, err := ctx.GetStub().GetState(key)
+	if err != nil {
+		return fmt.Errorf("failed to read payment reference from world state: %v", err)
+	}
+	if claimed != nil {
+		return fmt.Errorf("the payment %s %s %s is already recorded for zakat %s", payment.Channel, payment.Bank, payment.Reference, claimed)
+	}
+	return ctx.GetStub().PutState(key, []byte(zakatID))
+}
+
+// AddZakatWithPayment adds a donation like AddZakat, recording the bank transfer or
+// QRIS payment it was received by. A payment reference can be recorded only once.
+func (s *SmartContract) AddZakatWithPayment(ctx contractapi.TransactionContextInterface, id string, muzakki string, amount float64, zakatType string, organization string, timestamp string, channel string, bank string, reference string) error {
+	return s.addZakat(ctx, ZakatInput{
+		ID:           id,
+		Muzakki:      muzakki,
+		Amount:       amount,
+		Type:         zakatType,
+		Organization: organization,
+		Timestamp:    timestamp,
+		Payment:      &Payment{Channel: channel, Bank: bank, Reference: reference},
+	})
+}
+
+// GetZakatByPayment returns the donation a payment was recorded for
+func (s *SmartContract) GetZakatByPayment(ctx contractapi.TransactionContextInterface, channel string, bank string, reference string) (Zakat, error) {
+	payment := normalizePayment(Payment{Channel: channel, Bank: bank, Reference: reference})
+	key, err := paymentKey(payment)
+	if err != nil {
+		return Zakat{}, fmt.Errorf("failed to create payment reference key: %v", err)
+	}
+	zakatID, err := ctx.GetStub().GetState(key)
+	if err != nil {
+		return Zakat{}, fmt.Errorf("failed to read payment reference from world state: %v", err)
+	}
+	if zakatID == nil {
+		return Zakat{}, fmt.Errorf("the payment %s %s %s does not exist", payment.Channel, payment.Bank, payment.Reference)
+	}
+	return s.QueryZakat(ctx, string(zakatID))
+}
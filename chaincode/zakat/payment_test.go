@@ -0,0 +1,82 @@
+package main
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/hyperledger/fabric-contract-api-go/contractapi"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAddZakatWithPayment(t *testing.T) {
+	chaincodeStub := new(MockStub)
+	transactionContext := new(contractapi.TransactionContext)
+	transactionContext.SetStub(chaincodeStub)
+	newWorldState(chaincodeStub)
+
+	smartContract := new(SmartContract)
+	err := smartContract.AddZakatWithPayment(transactionContext, "ZKT-YDSF-MLG-202403-0001", "Ahmad", 2500000, "maal", "YDSF Malang", "2024-03-12T09:00:00Z",
+		"bank_transfer", "bsi", " FT24072ABCD ")
+	require.NoError(t, err)
+
+	zakat, err := smartContract.QueryZakat(transactionContext, "ZKT-YDSF-MLG-202403-0001")
+	require.NoError(t, err)
+	require.Equal(t, &Payment{Channel: "bank_transfer", Bank: "BSI", Reference: "FT24072ABCD"}, zakat.Payment)
+
+	found, err := smartContract.GetZakatByPayment(transactionContext, "bank_transfer", "BSI", "FT24072ABCD")
+	require.NoError(t, err)
+	require.Equal(t, zakat, found)
+
+	t.Run("Reference already recorded", func(t *testing.T) {
+		err := smartContract.AddZakatWithPayment(transactionContext, "ZKT-YDSF-MLG-202403-0002", "Ahmad", 2500000, "maal", "YDSF Malang", "2024-03-12T09:00:00Z",
+			"bank_transfer", "BSI", "FT24072ABCD")
+		require.ErrorContains(t, err, "is already recorded for zakat ZKT-YDSF-MLG-202403-0001")
+		exists, err := smartContract.ZakatExists(transactionContext, "ZKT-YDSF-MLG-202403-0002")
+		require.NoError(t, err)
+		require.False(t, exists)
+	})
+
+	t.Run("Same reference at another bank", func(t *testing.T) {
+		err := smartContract.AddZakatWithPayment(transactionContext, "ZKT-YDSF-MLG-202403-0003", "Budi", 45000, "fitrah", "YDSF Malang", "2024-03-30T08:00:00Z",
+			"qris", "BRI", "FT24072ABCD")
+		require.NoError(t, err)
+	})
+
+	t.Run("Invalid payment", func(t *testing.T) {
+		err := smartContract.AddZakatWithPayment(transactionContext, "ZKT-YDSF-MLG-202403-0004", "Budi", 45000, "fitrah", "YDSF Malang", "2024-03-30T08:00:00Z",
+			"cash", "BSI", "1")
+		require.ErrorContains(t, err, "invalid payment channel")
+		err = smartContract.AddZakatWithPayment(transactionContext, "ZKT-YDSF-MLG-202403-0004", "Budi", 45000, "fitrah", "YDSF Malang", "2024-03-30T08:00:00Z",
+			"qris", "BSI", "")
+		require.ErrorContains(t, err, "invalid payment reference")
+	})
+
+	t.Run("Unknown payment", func(t *testing.T) {
+		_, err := smartContract.GetZakatByPayment(transactionContext, "qris", "BSI", "000000")
+		require.ErrorContains(t, err, "does not exist")
+	})
+}
+
+func TestAddZakatBatchPaymentReferences(t *testing.T) {
+	chaincodeStub := new(MockStub)
+	transactionContext := new(contractapi.TransactionContext)
+	transactionContext.SetStub(chaincodeStub)
+	worldState := newWorldState(chaincodeStub)
+	worldState.DeferWrites()
+
+	payment := &Payment{Channel: "qris", Bank: "BSI", Reference: "240330123456"}
+	entries := []ZakatInput{
+		{ID: "ZKT-YDSF-MLG-202403-0001", Muzakki: "Ahmad", Amount: 45000, Type: "fitrah", Organization: "YDSF Malang", Timestamp: "2024-03-30T08:00:00Z", Payment: payment},
+		{ID: "ZKT-YDSF-MLG-202403-0002", Muzakki: "Budi", Amount: 45000, Type: "fitrah", Organization: "YDSF Malang", Timestamp: "2024-03-30T08:05:00Z", Payment: payment},
+	}
+	entriesJSON, err := json.Marshal(entries)
+	require.NoError(t, err)
+
+	// A reference repeated within the batch is caught although the first write is not committed yet
+	smartContract := new(SmartContract)
+	results, err := smartContract.AddZakatBatch(transactionContext, string(entriesJSON), "partial")
+	require.NoError(t, err)
+	require.Equal(t, "added", results[0].Status)
+	require.Equal(t, "failed", results[1].Status)
+	require.Contains(t, results[1].Error, "already recorded")
+}
@@ -0,0 +1,408 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/hyperledger/fabric-chaincode-go/shim"
+	"github.com/hyperledger/fabric-contract-api-go/contractapi"
+)
+
+// pledgeObjectType is the composite key namespace for pledges
+const pledgeObjectType = "pledge"
+
+// maxPledgeInstallments bounds the installments of a pledge, ten years of months
+const maxPledgeInstallments = 120
+
+// Pledge statuses
+const (
+	pledgeActive    = "active"
+	pledgeFulfilled = "fulfilled"
+)
+
+// Installment statuses. An unpaid installment is due from its due date until the next
+// installment falls due, and overdue after that.
+const (
+	installmentPaid     = "paid"
+	installmentDue      = "due"
+	installmentOverdue  = "overdue"
+	installmentUpcoming = "upcoming"
+)
+
+// pledgeFrequencies maps each pledge frequency to the months between installments
+var pledgeFrequencies = map[string]int{
+	"monthly":   1,
+	"quarterly": 3,
+	"yearly":    12,
+}
+
+// Pledge is a muzakki's standing commitment to pay a fixed amount at a regular
+// frequency, e.g. zakat profesi from a monthly salary. Donations that reference the
+// pledge count towards its installments, oldest first.
+type Pledge struct {
+	ID           string   `json:"ID"`                  // Format: PLG-YDSF-{ORG}-{YYYY}-{COUNTER}
+	Muzakki      string   `json:"muzakki"`             // Donor who pledged
+	Organization string   `json:"organization"`        // Organization the donations are paid to
+	Type         string   `json:"type"`                // "fitrah" or "maal"
+	Amount       float64  `json:"amount"`              // Amount of each installment in IDR
+	Frequency    string   `json:"frequency"`           // "monthly", "quarterly" or "yearly"
+	StartDate    string   `json:"startDate"`           // Due date of the first installment (ISO 8601)
+	EndDate      string   `json:"endDate"`             // No installment falls due after it (ISO 8601)
+	Total        float64  `json:"total"`               // Amount of all installments
+	Paid         float64  `json:"paid"`                // Paid towards the pledge to date
+	Status       string   `json:"status"`              // "active" or "fulfilled"
+	Donations    []string `json:"donations,omitempty"` // Donations paid towards the pledge, oldest first
+}
+
+// Installment is one payment of a pledge
+type Installment struct {
+	Number  int     `json:"number"`  // 1 for the first installment
+	DueDate string  `json:"dueDate"` // ISO 8601 format
+	Amount  float64 `json:"amount"`  // Amount due in IDR
+	Paid    float64 `json:"paid"`    // Part of the amount paid
+	Status  string  `json:"status"`  // "paid", "due", "overdue" or "upcoming"
+}
+
+// validatePledgeID checks if the provided ID follows the required format and
+// belongs to the given organization
+func validatePledgeID(id string, organization Organization) error {
+	pattern := `^PLG-YDSF-([A-Z]{3})-\d{4}-\d{4}$`
+	matches := regexp.MustCompile(pattern).FindStringSubmatch(id)
+	if matches == nil {
+		return fmt.Errorf("invalid pledge ID format. Expected format: PLG-YDSF-{ORG}-YYYY-NNNN (e.g., PLG-YDSF-MLG-2026-0001)")
+	}
+	if organization.Code != matches[1] {
+		return fmt.Errorf("pledge ID %s does not belong to organization %s", id, organization.Name)
+	}
+	return nil
+}
+
+// pledgeKey returns the world state key of the pledge with the given ID
+func pledgeKey(id string) (string, error) {
+	return shim.CreateCompositeKey(pledgeObjectType, []string{id})
+}
+
+// writePledge stores the pledge in world state
+func writePledge(ctx contractapi.TransactionContextInterface, pledge Pledge) error {
+	key, err := pledgeKey(pledge.ID)
+	if err != nil {
+		return fmt.Errorf("failed to create pledge key: %v", err)
+	}
+	pledgeJSON, err := json.Marshal(pledge)
+	if err != nil {
+		return err
+	}
+	return ctx.GetStub().PutState(key, pledgeJSON)
+}
+
+// monthsAfter returns the date the given number of months after t, on the same day of
+// the month or the last day of a shorter month, e.g. 28 February after 31 January
+func monthsAfter(t time.Time, months int) time.Time {
+	first := time.Date(t.Year(), t.Month()+time.Month(months), 1, t.Hour(), t.Minute(), t.Second(), t.Nanosecond(), t.Location())
+	day := t.Day()
+	if last := first.AddDate(0, 1, -1).Day(); day > last {
+		day = last
+	}
+	return first.AddDate(0, 0, day-1)
+}
+
+// dueDates returns the due dates of the pledge's installments, the first one on its
+// start date and none after its end date
+func dueDates(pledge Pledge) ([]time.Time, error) {
+	start, err := time.Parse(time.RFC3339, pledge.StartDate)
+	if err != nil {
+		return nil, fmt.Errorf("invalid start date format. Expected ISO 8601 format (e.g., 2023-11-28T12:00:00Z)")
+	}
+	end, err := time.Parse(time.RFC3339, pledge.EndDate)
+	if err != nil {
+		return nil, fmt.Errorf("invalid end date format. Expected ISO 8601 format (e.g., 2023-11-28T12:00:00Z)")
+	}
+	months, ok := pledgeFrequencies[pledge.Frequency]
+	if !ok {
+		return nil, fmt.Errorf("invalid frequency. Must be 'monthly', 'quarterly' or 'yearly'")
+	}
+	var dates []time.Time
+	for due := start; !due.After(end); due = monthsAfter(start, months*len(dates)) {
+		if len(dates) == maxPledgeInstallments {
+			return nil, fmt.Errorf("pledge of more than %d installments", maxPledgeInstallments)
+		}
+		dates = append(dates, due)
+	}
+	return dates, nil
+}
+
+// installments returns the installments of the pledge at the given time. The amount
+// paid is applied to the installments in order, so an overpayment counts towards the
+// next one.
+func installments(pledge Pledge, now time.Time) ([]Installment, error) {
+	dates, err := dueDates(pledge)
+	if err != nil {
+		return nil, err
+	}
+	months := pledgeFrequencies[pledge.Frequency]
+	start, _ := time.Parse(time.RFC3339, pledge.StartDate)
+
+	result := make([]Installment, len(dates))
+	paid := pledge.Paid
+	for i, due := range dates {
+		installment := Installment{Number: i + 1, DueDate: due.Format(time.RFC3339), Amount: pledge.Amount}
+		installment.Paid = roundIDR(minAmount(paid, pledge.Amount))
+		paid -= installment.Paid
+		switch {
+		case amountsEqual(installment.Paid, installment.Amount):
+			installment.Status = installmentPaid
+		case now.Before(due):
+			installment.Status = installmentUpcoming
+		case now.Before(monthsAfter(start, months*(i+1))):
+			installment.Status = installmentDue
+		default:
+			installment.Status = installmentOverdue
+		}
+		result[i] = installment
+	}
+	return result, nil
+}
+
+// minAmount returns the smaller of two amounts, and 0 for a negative one
+func minAmount(a float64, b float64) float64 {
+	if a > b {
+		a = b
+	}
+	if a < 0 {
+		return 0
+	}
+	return a
+}
+
+// CreatePledge records a muzakki's pledge to pay an amount in IDR at a frequency from
+// a start date to an end date, e.g. CreatePledge("PLG-YDSF-MLG-2026-0001", "Ahmad",
+// "YDSF Malang", "maal", 500000, "monthly", "2026-01-25T00:00:00+07:00",
+// "2026-12-25T00:00:00+07:00") for twelve monthly installments. The pledge is bound
+// to its organization with a key-level endorsement policy.
+func (s *SmartContract) CreatePledge(ctx contractapi.TransactionContextInterface, id string, muzakki string, organization string, zakatType string, amount float64, frequency string, startDate string, endDate string) error {
+	org, err := validateOrganization(ctx, organization)
+	if err != nil {
+		return err
+	}
+	if err := validatePledgeID(id, org); err != nil {
+		return err
+	}
+	muzakki = strings.TrimSpace(muzakki)
+	if muzakki == "" {
+		return fmt.Errorf("muzakki must not be empty")
+	}
+	if err := validateZakatType(zakatType); err != nil {
+		return err
+	}
+	if err := validateAmount(amount); err != nil {
+		return err
+	}
+	if err := validateTimestamp(startDate); err != nil {
+		return err
+	}
+	if err := validateTimestamp(endDate); err != nil {
+		return err
+	}
+	start, _ := time.Parse(time.RFC3339, startDate)
+	end, _ := time.Parse(time.RFC3339, endDate)
+	if end.Before(start) {
+		return fmt.Errorf("pledge end date must not be before its start date")
+	}
+
+	pledge := Pledge{
+		ID:           id,
+		Muzakki:      muzakki,
+		Organization: organization,
+		Type:         zakatType,
+		Amount:       amount,
+		Frequency:    strings.ToLower(strings.TrimSpace(frequency)),
+		StartDate:    startDate,
+		EndDate:      endDate,
+		Status:       pledgeActive,
+	}
+	dates, err := dueDates(pledge)
+	if err != nil {
+		return err
+	}
+	pledge.Total = roundIDR(amount * float64(len(dates)))
+
+	exists, err := s.PledgeExists(ctx, id)
+	if err != nil {
+		return err
+	}
+	if exists {
+		return fmt.Errorf("the pledge %s already exists", id)
+	}
+
+	policy, err := orgEndorsementPolicy(org.MSPID)
+	if err != nil {
+		return fmt.Errorf("failed to create endorsement policy for pledge %s: %v", id, err)
+	}
+	key, err := pledgeKey(id)
+	if err != nil {
+		return fmt.Errorf("failed to create pledge key: %v", err)
+	}
+	if err := ctx.GetStub().SetStateValidationParameter(key, policy); err != nil {
+		return fmt.Errorf("failed to set endorsement policy for pledge %s: %v", id, err)
+	}
+
+	return writePledge(ctx, pledge)
+}
+
+// checkPledge checks a donation referencing a pledge, filling in the muzakki, type
+// and organization of the pledge where the donation leaves them empty
+func (s *SmartContract) checkPledge(ctx contractapi.TransactionContextInterface, input *ZakatInput) (Pledge, error) {
+	pledge, err := s.QueryPledge(ctx, input.PledgeID)
+	if err != nil {
+		return Pledge{}, err
+	}
+	if input.Muzakki == "" {
+		input.Muzakki = pledge.Muzakki
+	}
+	if input.Type == "" {
+		input.Type = pledge.Type
+	}
+	if input.Organization == "" {
+		input.Organization = pledge.Organization
+	}
+	if input.Muzakki != pledge.Muzakki || input.Type != pledge.Type || input.Organization != pledge.Organization {
+		return Pledge{}, fmt.Errorf("the donation does not match pledge %s of %s for zakat %s to %s", pledge.ID, pledge.Muzakki, pledge.Type, pledge.Organization)
+	}
+	if pledge.Status == pledgeFulfilled {
+		return Pledge{}, fmt.Errorf("the pledge %s is already fulfilled", pledge.ID)
+	}
+	return pledge, nil
+}
+
+// fulfilPledge counts a donation towards its pledge, which is fulfilled once the
+// installments are paid in full
+func fulfilPledge(ctx contractapi.TransactionContextInterface, pledge Pledge, zakat Zakat) error {
+	pledge.Paid = roundIDR(pledge.Paid + zakat.Amount)
+	pledge.Donations = append(pledge.Donations, zakat.ID)
+	if pledge.Paid >= pledge.Total || amountsEqual(pledge.Paid, pledge.Total) {
+		pledge.Status = pledgeFulfilled
+	}
+	return writePledge(ctx, pledge)
+}
+
+// AddZakatForPledge records a donation paid towards a pledge, for the pledge's
+// muzakki, type and organization, e.g. AddZakatForPledge("ZKT-YDSF-MLG-202602-0001",
+// "PLG-YDSF-MLG-2026-0001", 500000, "2026-02-25T09:00:00+07:00")
+func (s *SmartContract) AddZakatForPledge(ctx contractapi.TransactionContextInterface, id string, pledgeID string, amount float64, timestamp string) error {
+	return s.addZakat(ctx, ZakatInput{
+		ID:        id,
+		Amount:    amount,
+		Timestamp: timestamp,
+		PledgeID:  pledgeID,
+	})
+}
+
+// QueryPledge returns the pledge stored in the world state with given id
+func (s *SmartContract) QueryPledge(ctx contractapi.TransactionContextInterface, id string) (Pledge, error) {
+	key, err := pledgeKey(id)
+	if err != nil {
+		return Pledge{}, fmt.Errorf("failed to create pledge key: %v", err)
+	}
+	pledgeJSON, err := ctx.GetStub().GetState(key)
+	if err != nil {
+		return Pledge{}, fmt.Errorf("failed to read from world state: %v", err)
+	}
+	if pledgeJSON == nil {
+		return Pledge{}, fmt.Errorf("the pledge %s does not exist", id)
+	}
+
+	var pledge Pledge
+	if err := json.Unmarshal(pledgeJSON, &pledge); err != nil {
+		return Pledge{}, fmt.Errorf("failed to unmarshal JSON: %v", err)
+	}
+	return pledge, nil
+}
+
+// PledgeExists returns true when a pledge with given ID exists in world state
+func (s *SmartContract) PledgeExists(ctx contractapi.TransactionContextInterface, id string) (bool, error) {
+	key, err := pledgeKey(id)
+	if err != nil {
+		return false, fmt.Errorf("failed to create pledge key: %v", err)
+	}
+	pledgeJSON, err := ctx.GetStub().GetState(key)
+	if err != nil {
+		return false, fmt.Errorf("failed to read from world state: %v", err)
+	}
+	return pledgeJSON != nil, nil
+}
+
+// GetPledgeInstallments returns the installments of a pledge with their status at the
+// time of the transaction
+func (s *SmartContract) GetPledgeInstallments(ctx contractapi.TransactionContextInterface, id string) ([]Installment, error) {
+	pledge, err := s.QueryPledge(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	now, err := txTime(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return installments(pledge, now)
+}
+
+// DueInstallment is an unpaid installment of a pledge that has fallen due
+type DueInstallment struct {
+	PledgeID    string      `json:"pledgeId"`
+	Muzakki     string      `json:"muzakki"`
+	Installment Installment `json:"installment"`
+}
+
+// GetDueInstallments returns the installments of an organization's active pledges that
+// are due or overdue at the time of the transaction, by pledge and installment
+func (s *SmartContract) GetDueInstallments(ctx contractapi.TransactionContextInterface, organization string) ([]DueInstallment, error) {
+	if _, err := getOrganization(ctx, organization); err != nil {
+		return nil, err
+	}
+	now, err := txTime(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	resultsIterator, err := ctx.GetStub().GetStateByPartialCompositeKey(pledgeObjectType, []string{})
+	if err != nil {
+		return nil, err
+	}
+	defer resultsIterator.Close()
+
+	due := []DueInstallment{}
+	for resultsIterator.HasNext() {
+		queryResponse, err := resultsIterator.Next()
+		if err != nil {
+			return nil, err
+		}
+		var pledge Pledge
+		if err := json.Unmarshal(queryResponse.Value, &pledge); err != nil {
+			return nil, err
+		}
+		if pledge.Organization != organization || pledge.Status != pledgeActive {
+			continue
+		}
+		list, err := installments(pledge, now)
+		if err != nil {
+			return nil, err
+		}
+		for _, installment := range list {
+			if installment.Status == installmentDue || installment.Status == installmentOverdue {
+				due = append(due, DueInstallment{PledgeID: pledge.ID, Muzakki: pledge.Muzakki, Installment: installment})
+			}
+		}
+	}
+	return due, nil
+}
+
+// txTime returns the timestamp of the transaction
+func txTime(ctx contractapi.TransactionContextInterface) (time.Time, error) {
+	txTimestamp, err := ctx.GetStub().GetTxTimestamp()
+	if err != nil {
+		return time.Time{}, fmt.Errorf("failed to read transaction timestamp: %v", err)
+	}
+	return txTimestamp.AsTime(), nil
+}
@@ -0,0 +1,112 @@
+package main
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/hyperledger/fabric-contract-api-go/contractapi"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+func TestPledge(t *testing.T) {
+	chaincodeStub := new(MockStub)
+	transactionContext := new(contractapi.TransactionContext)
+	transactionContext.SetStub(chaincodeStub)
+	transactionContext.SetClientIdentity(&MockClientIdentity{MSPID: "YDSFMalangMSP", OUs: []string{"admin"}})
+	newWorldState(chaincodeStub)
+	now := time.Date(2026, 5, 5, 3, 0, 0, 0, time.UTC)
+	chaincodeStub.On("GetTxTimestamp").Return(timestamppb.New(now), nil).Maybe()
+
+	smartContract := new(SmartContract)
+	require.NoError(t, smartContract.CreatePledge(transactionContext, "PLG-YDSF-MLG-2026-0001", "Ahmad", "YDSF Malang", "maal", 500000, "Monthly", "2026-01-31T09:00:00+07:00", "2026-06-30T23:59:59+07:00"))
+
+	pledge, err := smartContract.QueryPledge(transactionContext, "PLG-YDSF-MLG-2026-0001")
+	require.NoError(t, err)
+	require.Equal(t, "monthly", pledge.Frequency)
+	require.Equal(t, float64(3000000), pledge.Total)
+	require.Equal(t, pledgeActive, pledge.Status)
+
+	for name, test := range map[string]struct {
+		id, frequency, start, end string
+		err                       string
+	}{
+		"ID format":  {id: "PLG-MLG-2026-0002", frequency: "monthly", start: "2026-01-01T00:00:00Z", end: "2026-12-31T00:00:00Z", err: "invalid pledge ID format"},
+		"Other org":  {id: "PLG-YDSF-JTM-2026-0002", frequency: "monthly", start: "2026-01-01T00:00:00Z", end: "2026-12-31T00:00:00Z", err: "does not belong to organization YDSF Malang"},
+		"Frequency":  {id: "PLG-YDSF-MLG-2026-0002", frequency: "weekly", start: "2026-01-01T00:00:00Z", end: "2026-12-31T00:00:00Z", err: "invalid frequency"},
+		"Dates":      {id: "PLG-YDSF-MLG-2026-0002", frequency: "monthly", start: "2026-12-31T00:00:00Z", end: "2026-01-01T00:00:00Z", err: "must not be before its start date"},
+		"Too long":   {id: "PLG-YDSF-MLG-2026-0002", frequency: "monthly", start: "2026-01-01T00:00:00Z", end: "2037-01-01T00:00:00Z", err: "more than 120 installments"},
+		"Duplicate":  {id: "PLG-YDSF-MLG-2026-0001", frequency: "monthly", start: "2026-01-01T00:00:00Z", end: "2026-12-31T00:00:00Z", err: "already exists"},
+		"Start date": {id: "PLG-YDSF-MLG-2026-0002", frequency: "monthly", start: "2026-01-01", end: "2026-12-31T00:00:00Z", err: "invalid timestamp format"},
+	} {
+		t.Run(name, func(t *testing.T) {
+			err := smartContract.CreatePledge(transactionContext, test.id, "Budi", "YDSF Malang", "maal", 250000, test.frequency, test.start, test.end)
+			require.Error(t, err)
+			require.Contains(t, err.Error(), test.err)
+		})
+	}
+
+	require.NoError(t, smartContract.AddZakatForPledge(transactionContext, "ZKT-YDSF-MLG-202602-0001", "PLG-YDSF-MLG-2026-0001", 500000, "2026-02-02T09:00:00+07:00"))
+	// A batch entry may leave the muzakki, type and organization to the pledge
+	entries, err := json.Marshal([]ZakatInput{{ID: "ZKT-YDSF-MLG-202603-0001", Amount: 700000, Timestamp: "2026-03-02T09:00:00+07:00", PledgeID: "PLG-YDSF-MLG-2026-0001"}})
+	require.NoError(t, err)
+	_, err = smartContract.AddZakatBatch(transactionContext, string(entries), "atomic")
+	require.NoError(t, err)
+
+	zakat, err := smartContract.QueryZakat(transactionContext, "ZKT-YDSF-MLG-202603-0001")
+	require.NoError(t, err)
+	require.Equal(t, "Ahmad", zakat.Muzakki)
+	require.Equal(t, "maal", zakat.Type)
+	require.Equal(t, "PLG-YDSF-MLG-2026-0001", zakat.PledgeID)
+
+	t.Run("Installments", func(t *testing.T) {
+		list, err := smartContract.GetPledgeInstallments(transactionContext, "PLG-YDSF-MLG-2026-0001")
+		require.NoError(t, err)
+		require.Equal(t, []Installment{
+			{Number: 1, DueDate: "2026-01-31T09:00:00+07:00", Amount: 500000, Paid: 500000, Status: installmentPaid},
+			{Number: 2, DueDate: "2026-02-28T09:00:00+07:00", Amount: 500000, Paid: 500000, Status: installmentPaid},
+			{Number: 3, DueDate: "2026-03-31T09:00:00+07:00", Amount: 500000, Paid: 200000, Status: installmentOverdue},
+			{Number: 4, DueDate: "2026-04-30T09:00:00+07:00", Amount: 500000, Status: installmentDue},
+			{Number: 5, DueDate: "2026-05-31T09:00:00+07:00", Amount: 500000, Status: installmentUpcoming},
+			{Number: 6, DueDate: "2026-06-30T09:00:00+07:00", Amount: 500000, Status: installmentUpcoming},
+		}, list)
+
+		due, err := smartContract.GetDueInstallments(transactionContext, "YDSF Malang")
+		require.NoError(t, err)
+		require.Len(t, due, 2)
+		require.Equal(t, "Ahmad", due[0].Muzakki)
+		require.Equal(t, 3, due[0].Installment.Number)
+		require.Equal(t, 4, due[1].Installment.Number)
+	})
+
+	t.Run("Mismatch", func(t *testing.T) {
+		for name, input := range map[string]ZakatInput{
+			"Muzakki": {ID: "ZKT-YDSF-MLG-202605-0001", Muzakki: "Budi", Amount: 500000, Timestamp: "2026-05-02T09:00:00+07:00", PledgeID: "PLG-YDSF-MLG-2026-0001"},
+			"Type":    {ID: "ZKT-YDSF-MLG-202605-0001", Type: "fitrah", Amount: 500000, Timestamp: "2026-05-02T09:00:00+07:00", PledgeID: "PLG-YDSF-MLG-2026-0001"},
+			"Unknown": {ID: "ZKT-YDSF-MLG-202605-0001", Amount: 500000, Timestamp: "2026-05-02T09:00:00+07:00", PledgeID: "PLG-YDSF-MLG-2026-0099"},
+			"In kind": {ID: "ZKT-YDSF-MLG-202605-0001", Amount: 10, Unit: "kg_beras", Timestamp: "2026-05-02T09:00:00+07:00", PledgeID: "PLG-YDSF-MLG-2026-0001"},
+		} {
+			err := smartContract.addZakat(transactionContext, input)
+			require.Error(t, err, name)
+		}
+	})
+
+	t.Run("Fulfilled", func(t *testing.T) {
+		require.NoError(t, smartContract.AddZakatForPledge(transactionContext, "ZKT-YDSF-MLG-202605-0001", "PLG-YDSF-MLG-2026-0001", 1800000, "2026-05-02T09:00:00+07:00"))
+
+		pledge, err := smartContract.QueryPledge(transactionContext, "PLG-YDSF-MLG-2026-0001")
+		require.NoError(t, err)
+		require.Equal(t, pledgeFulfilled, pledge.Status)
+		require.Equal(t, float64(3000000), pledge.Paid)
+		require.Equal(t, []string{"ZKT-YDSF-MLG-202602-0001", "ZKT-YDSF-MLG-202603-0001", "ZKT-YDSF-MLG-202605-0001"}, pledge.Donations)
+
+		due, err := smartContract.GetDueInstallments(transactionContext, "YDSF Malang")
+		require.NoError(t, err)
+		require.Empty(t, due)
+
+		err = smartContract.AddZakatForPledge(transactionContext, "ZKT-YDSF-MLG-202605-0002", "PLG-YDSF-MLG-2026-0001", 500000, "2026-05-03T09:00:00+07:00")
+		require.Error(t, err)
+		require.Contains(t, err.Error(), "already fulfilled")
+	})
+}
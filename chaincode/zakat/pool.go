@@ -0,0 +1,310 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"strings"
+
+	"github.com/hyperledger/fabric-chaincode-go/pkg/statebased"
+	"github.com/hyperledger/fabric-chaincode-go/shim"
+	"github.com/hyperledger/fabric-contract-api-go/contractapi"
+)
+
+// poolObjectType is the composite key namespace for fund pools. Composite keys
+// keep pools out of the simple-key range scanned by GetAllZakat.
+const poolObjectType = "pool"
+
+// Pool holds the undistributed balance of one organization for one zakat type
+type Pool struct {
+	ID             string       `json:"ID"`             // Format: POOL-YDSF-{ORG}-{TYPE}
+	Organization   string       `json:"organization"`   // Owning organization
+	Type           string       `json:"type"`           // "fitrah" or "maal"
+	Unit           string       `json:"unit,omitempty"` // In-kind unit of the amounts, empty for IDR
+	Balance        float64      `json:"balance"`        // Amount available for distribution, in the pool's unit (IDR when Unit is empty)
+	Collected      float64      `json:"collected"`      // Total credited by donations
+	Distributed    float64      `json:"distributed"`    // Total debited by distributions
+	TransferredIn  float64      `json:"transferredIn"`  // Total credited by transfers from other organizations
+	TransferredOut float64      `json:"transferredOut"` // Total debited by transfers to other organizations
+	Sources        []PoolSource `json:"sources"`        // Undistributed donations, oldest first
+}
+
+// PoolSource is the part of a donation that is still held in a pool
+type PoolSource struct {
+	ZakatID     string       `json:"zakatId"`               // Donation the funds came from
+	TransferID  string       `json:"transferId,omitempty"`  // Transfer that brought the funds into this pool, if any
+	Remaining   float64      `json:"remaining"`             // Amount not yet distributed
+	Restriction *Restriction `json:"restriction,omitempty"` // Purpose the donor restricted the funds to, if any
+}
+
+// Allocation is the amount drawn from a single donation
+type Allocation struct {
+	ZakatID     string       `json:"zakatId"`
+	TransferID  string       `json:"transferId,omitempty"`
+	Amount      float64      `json:"amount"`
+	Restriction *Restriction `json:"restriction,omitempty"` // Purpose the funds were restricted to, if any
+}
+
+// poolID returns the ID of the pool holding the given organization's zakat type in a
+// unit, e.g. POOL-YDSF-MLG-FITRAH for rupiah and POOL-YDSF-MLG-FITRAH-KG_BERAS for rice
+func poolID(organization Organization, zakatType string, unit string) string {
+	id := fmt.Sprintf("POOL-YDSF-%s-%s", organization.Code, strings.ToUpper(zakatType))
+	if !isIDR(unit) {
+		id += "-" + strings.ToUpper(unit)
+	}
+	return id
+}
+
+// poolKey returns the world state key of the pool with the given ID
+func poolKey(id string) (string, error) {
+	return shim.CreateCompositeKey(poolObjectType, []string{id})
+}
+
+// readPool returns the pool with the given ID, or nil if it has not been created yet
+func readPool(ctx contractapi.TransactionContextInterface, id string) (*Pool, error) {
+	key, err := poolKey(id)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create pool key: %v", err)
+	}
+	poolJSON, err := ctx.GetStub().GetState(key)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read pool %s from world state: %v", id, err)
+	}
+	if poolJSON == nil {
+		return nil, nil
+	}
+
+	var pool Pool
+	if err := json.Unmarshal(poolJSON, &pool); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal pool %s: %v", id, err)
+	}
+	return &pool, nil
+}
+
+// writePool stores the pool in world state
+func writePool(ctx contractapi.TransactionContextInterface, pool *Pool) error {
+	key, err := poolKey(pool.ID)
+	if err != nil {
+		return fmt.Errorf("failed to create pool key: %v", err)
+	}
+	poolJSON, err := json.Marshal(pool)
+	if err != nil {
+		return err
+	}
+	return ctx.GetStub().PutState(key, poolJSON)
+}
+
+// orgEndorsementPolicy returns a key-level endorsement policy requiring a peer of
+// every given MSP
+func orgEndorsementPolicy(mspIDs ...string) ([]byte, error) {
+	endorsementPolicy, err := statebased.NewStateEP(nil)
+	if err != nil {
+		return nil, err
+	}
+	if err := endorsementPolicy.AddOrgs(statebased.RoleTypePeer, mspIDs...); err != nil {
+		return nil, err
+	}
+	return endorsementPolicy.Policy()
+}
+
+// getOrCreatePool returns the pool of the given organization, zakat type and unit. A
+// pool created here is bound to its organization with a key-level endorsement policy,
+// so only that organization's peers can endorse changes to it.
+func getOrCreatePool(ctx contractapi.TransactionContextInterface, organization Organization, zakatType string, unit string) (*Pool, error) {
+	id := poolID(organization, zakatType, unit)
+	pool, err := readPool(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if pool != nil {
+		return pool, nil
+	}
+
+	policy, err := orgEndorsementPolicy(organization.MSPID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create endorsement policy for pool %s: %v", id, err)
+	}
+	key, err := poolKey(id)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create pool key: %v", err)
+	}
+	if err := ctx.GetStub().SetStateValidationParameter(key, policy); err != nil {
+		return nil, fmt.Errorf("failed to set endorsement policy for pool %s: %v", id, err)
+	}
+
+	return &Pool{
+		ID:           id,
+		Organization: organization.Name,
+		Type:         zakatType,
+		Unit:         unit,
+		Sources:      []PoolSource{},
+	}, nil
+}
+
+// creditPool adds a newly collected donation to the pool of its organization, type
+// and unit, creating the pool on first use
+func creditPool(ctx contractapi.TransactionContextInterface, organization Organization, zakat Zakat) error {
+	pool, err := getOrCreatePool(ctx, organization, zakat.Type, zakat.Unit)
+	if err != nil {
+		return err
+	}
+
+	pool.Balance += zakat.Amount
+	pool.Collected += zakat.Amount
+	pool.Sources = append(pool.Sources, PoolSource{ZakatID: zakat.ID, Remaining: zakat.Amount, Restriction: zakat.Restriction})
+
+	return writePool(ctx, pool)
+}
+
+// takeFromPool removes amount from the pool's sources the spending may draw on,
+// consuming the oldest donations first, and returns the allocations that make up the
+// amount. Funds restricted to what is spent on are drawn before unrestricted funds, and
+// funds restricted to other purposes are left alone. Callers adjust the pool totals
+// for the kind of movement.
+func takeFromPool(pool *Pool, amount float64, spending spending) ([]Allocation, error) {
+	if amount > pool.Balance && !amountsEqual(amount, pool.Balance) {
+		return nil, fmt.Errorf("amount %f exceeds pool %s balance %f", amount, pool.ID, pool.Balance)
+	}
+
+	var order []int
+	available := 0.0
+	for _, restricted := range []bool{true, false} {
+		for i, source := range pool.Sources {
+			if (source.Restriction != nil) == restricted && spending.allows(source.Restriction) {
+				order = append(order, i)
+				available += source.Remaining
+			}
+		}
+	}
+	if amount > available && !amountsEqual(amount, available) {
+		return nil, fmt.Errorf("amount %f exceeds the %f of pool %s that may be spent on this purpose; %f is restricted to other purposes",
+			amount, available, pool.ID, pool.Balance-available)
+	}
+
+	var allocations []Allocation
+	needed := amount
+	for _, i := range order {
+		if needed <= 0 {
+			break
+		}
+		source := &pool.Sources[i]
+		taken := source.Remaining
+		if taken > needed {
+			taken = needed
+		}
+		source.Remaining -= taken
+		needed -= taken
+		// Rounding error must not leave a dust remainder on either side
+		if amountsEqual(source.Remaining, 0) {
+			source.Remaining = 0
+		}
+		if amountsEqual(needed, 0) {
+			needed = 0
+		}
+		allocations = append(allocations, Allocation{ZakatID: source.ZakatID, TransferID: source.TransferID, Amount: taken, Restriction: source.Restriction})
+	}
+	if needed > 0 {
+		return nil, fmt.Errorf("pool %s sources do not cover its balance", pool.ID)
+	}
+
+	// Drop the sources spent in full
+	sources := pool.Sources[:0]
+	for _, source := range pool.Sources {
+		if source.Remaining > 0 {
+			sources = append(sources, source)
+		}
+	}
+	pool.Sources = sources
+	pool.Balance -= amount
+	return allocations, nil
+}
+
+// debitPool takes a distribution out of the pool and returns the allocations that
+// make up the amount
+func debitPool(pool *Pool, amount float64, spending spending) ([]Allocation, error) {
+	allocations, err := takeFromPool(pool, amount, spending)
+	if err != nil {
+		return nil, err
+	}
+	pool.Distributed += amount
+	return allocations, nil
+}
+
+// amountsEqual compares two amounts, in IDR or an in-kind unit, ignoring floating
+// point rounding
+func amountsEqual(a float64, b float64) bool {
+	return math.Abs(a-b) < 0.005
+}
+
+// OpenPool creates the empty rupiah pool of an organization and zakat type, so it can
+// receive transfers before it has collected any donations of that type. Only members
+// of the organization can open its pools: the key-level policy a pool is created with
+// applies once the creating transaction has committed, so the pool must be created
+// before another organization can write to it.
+func (s *SmartContract) OpenPool(ctx contractapi.TransactionContextInterface, organization string, zakatType string) error {
+	org, err := validateOrganization(ctx, organization)
+	if err != nil {
+		return err
+	}
+	mspID, err := ctx.GetClientIdentity().GetMSPID()
+	if err != nil {
+		return fmt.Errorf("failed to read client MSP ID: %v", err)
+	}
+	if mspID != org.MSPID {
+		return fmt.Errorf("only members of %s can open its pools", organization)
+	}
+	if err := validateZakatType(zakatType); err != nil {
+		return err
+	}
+
+	existing, err := readPool(ctx, poolID(org, zakatType, unitIDR))
+	if err != nil {
+		return err
+	}
+	if existing != nil {
+		return fmt.Errorf("the pool %s already exists", existing.ID)
+	}
+	pool, err := getOrCreatePool(ctx, org, zakatType, "")
+	if err != nil {
+		return err
+	}
+	return writePool(ctx, pool)
+}
+
+// QueryPool returns the fund pool stored in the world state with given id
+func (s *SmartContract) QueryPool(ctx contractapi.TransactionContextInterface, id string) (Pool, error) {
+	pool, err := readPool(ctx, id)
+	if err != nil {
+		return Pool{}, err
+	}
+	if pool == nil {
+		return Pool{}, fmt.Errorf("the pool %s does not exist", id)
+	}
+	return *pool, nil
+}
+
+// GetAllPools returns all fund pools found in world state
+func (s *SmartContract) GetAllPools(ctx contractapi.TransactionContextInterface) ([]Pool, error) {
+	resultsIterator, err := ctx.GetStub().GetStateByPartialCompositeKey(poolObjectType, []string{})
+	if err != nil {
+		return nil, err
+	}
+	defer resultsIterator.Close()
+
+	var pools []Pool
+	for resultsIterator.HasNext() {
+		queryResponse, err := resultsIterator.Next()
+		if err != nil {
+			return nil, err
+		}
+
+		var pool Pool
+		err = json.Unmarshal(queryResponse.Value, &pool)
+		if err != nil {
+			return nil, err
+		}
+		pools = append(pools, pool)
+	}
+
+	return pools, nil
+}
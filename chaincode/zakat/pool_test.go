@@ -0,0 +1,140 @@
+package main
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/hyperledger/fabric-contract-api-go/contractapi"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDebitPool(t *testing.T) {
+	t.Run("Consumes oldest donations first", func(t *testing.T) {
+		pool := &Pool{
+			ID:        "POOL-YDSF-MLG-FITRAH",
+			Balance:   800000,
+			Collected: 800000,
+			Sources: []PoolSource{
+				{ZakatID: "ZKT-YDSF-MLG-202311-0001", Remaining: 300000},
+				{ZakatID: "ZKT-YDSF-MLG-202311-0002", Remaining: 500000},
+			},
+		}
+
+		allocations, err := debitPool(pool, 500000, spending{})
+		require.NoError(t, err)
+		require.Equal(t, []Allocation{
+			{ZakatID: "ZKT-YDSF-MLG-202311-0001", Amount: 300000},
+			{ZakatID: "ZKT-YDSF-MLG-202311-0002", Amount: 200000},
+		}, allocations)
+		require.Equal(t, float64(300000), pool.Balance)
+		require.Equal(t, float64(500000), pool.Distributed)
+		require.Equal(t, []PoolSource{{ZakatID: "ZKT-YDSF-MLG-202311-0002", Remaining: 300000}}, pool.Sources)
+	})
+
+	t.Run("Fractional quantities", func(t *testing.T) {
+		pool := &Pool{
+			ID:      "POOL-YDSF-MLG-FITRAH-KG_BERAS",
+			Unit:    "kg_beras",
+			Balance: 1,
+			Sources: []PoolSource{
+				{ZakatID: "ZKT-YDSF-MLG-202403-0001", Remaining: 0.1},
+				{ZakatID: "ZKT-YDSF-MLG-202403-0002", Remaining: 0.2},
+				{ZakatID: "ZKT-YDSF-MLG-202403-0003", Remaining: 0.7},
+			},
+		}
+
+		// 0.3 - 0.1 is not exactly 0.2 in floating point, which must not leave a
+		// remainder of the second donation behind
+		_, err := debitPool(pool, 0.3, spending{})
+		require.NoError(t, err)
+		require.Equal(t, []PoolSource{{ZakatID: "ZKT-YDSF-MLG-202403-0003", Remaining: 0.7}}, pool.Sources)
+
+		pool.Sources = []PoolSource{
+			{ZakatID: "ZKT-YDSF-MLG-202403-0003", Remaining: 0.1},
+			{ZakatID: "ZKT-YDSF-MLG-202403-0004", Remaining: 0.3 - 0.1},
+		}
+		pool.Balance = 0.3
+		_, err = debitPool(pool, 0.3, spending{})
+		require.NoError(t, err)
+		require.Empty(t, pool.Sources)
+	})
+
+	t.Run("Insufficient balance", func(t *testing.T) {
+		pool := &Pool{
+			ID:      "POOL-YDSF-MLG-FITRAH",
+			Balance: 100000,
+			Sources: []PoolSource{{ZakatID: "ZKT-YDSF-MLG-202311-0001", Remaining: 100000}},
+		}
+
+		_, err := debitPool(pool, 200000, spending{})
+		require.Error(t, err)
+		require.Contains(t, err.Error(), "exceeds pool")
+		require.Equal(t, float64(100000), pool.Balance)
+	})
+}
+
+func TestQueryPool(t *testing.T) {
+	chaincodeStub := new(MockStub)
+	transactionContext := new(contractapi.TransactionContext)
+	transactionContext.SetStub(chaincodeStub)
+
+	expectedPool := Pool{
+		ID:           "POOL-YDSF-JTM-MAAL",
+		Organization: "YDSF Jatim",
+		Type:         "maal",
+		Balance:      1000000,
+		Collected:    1000000,
+		Sources:      []PoolSource{{ZakatID: "ZKT-YDSF-JTM-202311-0001", Remaining: 1000000}},
+	}
+	poolJSON, err := json.Marshal(expectedPool)
+	require.NoError(t, err)
+
+	poolStateKey, err := poolKey(expectedPool.ID)
+	require.NoError(t, err)
+	chaincodeStub.On("GetState", poolStateKey).Return(poolJSON, nil)
+
+	missingKey, err := poolKey("POOL-YDSF-JTM-FITRAH")
+	require.NoError(t, err)
+	chaincodeStub.On("GetState", missingKey).Return(nil, nil)
+
+	smartContract := new(SmartContract)
+	pool, err := smartContract.QueryPool(transactionContext, expectedPool.ID)
+	require.NoError(t, err)
+	require.Equal(t, expectedPool, pool)
+
+	_, err = smartContract.QueryPool(transactionContext, "POOL-YDSF-JTM-FITRAH")
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "does not exist")
+
+	chaincodeStub.AssertExpectations(t)
+}
+
+func TestGetAllPools(t *testing.T) {
+	chaincodeStub := new(MockStub)
+	transactionContext := new(contractapi.TransactionContext)
+	transactionContext.SetStub(chaincodeStub)
+
+	expectedPool := Pool{
+		ID:           "POOL-YDSF-MLG-FITRAH",
+		Organization: "YDSF Malang",
+		Type:         "fitrah",
+		Balance:      45000,
+		Collected:    45000,
+		Sources:      []PoolSource{{ZakatID: "ZKT-YDSF-MLG-202311-0002", Remaining: 45000}},
+	}
+	poolJSON, err := json.Marshal(expectedPool)
+	require.NoError(t, err)
+
+	iterator := &MockQueryIterator{
+		Current: -1,
+		Items:   []QueryResult{{Key: expectedPool.ID, Value: poolJSON}},
+	}
+	chaincodeStub.On("GetStateByPartialCompositeKey", poolObjectType, []string{}).Return(iterator, nil)
+
+	smartContract := new(SmartContract)
+	pools, err := smartContract.GetAllPools(transactionContext)
+	require.NoError(t, err)
+	require.Equal(t, []Pool{expectedPool}, pools)
+
+	chaincodeStub.AssertExpectations(t)
+}
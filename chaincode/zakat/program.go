@@ -0,0 +1,251 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"time"
+
+	"github.com/hyperledger/fabric-chaincode-go/shim"
+	"github.com/hyperledger/fabric-contract-api-go/contractapi"
+)
+
+const (
+	// programObjectType is the composite key namespace for programs
+	programObjectType = "program"
+	// programDistributionIndex indexes distributions by the program they were made under
+	programDistributionIndex = "program~distribution"
+)
+
+// amilAsnaf is the asnaf of the zakat administrators, whose share funds the organization
+const amilAsnaf = "amil"
+
+// asnafCategories lists the eight groups entitled to receive zakat
+var asnafCategories = []string{"fakir", "miskin", "amil", "mualaf", "riqab", "gharimin", "fisabilillah", "ibnusabil"}
+
+// Program is a distribution program run by an organization with a budget
+type Program struct {
+	ID           string  `json:"ID"`           // Format: PRG-YDSF-{ORG}-{YYYY}-{COUNTER}
+	Name         string  `json:"name"`         // e.g. "Beasiswa Yatim 2026"
+	Organization string  `json:"organization"` // Owning organization
+	Asnaf        string  `json:"asnaf"`        // Asnaf the program serves
+	Budget       float64 `json:"budget"`       // Budget in IDR
+	Spent        float64 `json:"spent"`        // Distributed under the program to date
+	StartDate    string  `json:"startDate"`    // Start of the active period (ISO 8601)
+	EndDate      string  `json:"endDate"`      // End of the active period (ISO 8601)
+}
+
+// validateAsnaf checks if the provided asnaf is one of the eight categories
+func validateAsnaf(asnaf string) error {
+	for _, category := range asnafCategories {
+		if asnaf == category {
+			return nil
+		}
+	}
+	return fmt.Errorf("invalid asnaf. Must be one of %v", asnafCategories)
+}
+
+// validateProgramID checks if the provided ID follows the required format and
+// belongs to the given organization
+func validateProgramID(id string, organization Organization) error {
+	pattern := `^PRG-YDSF-([A-Z]{3})-\d{4}-\d{4}$`
+	matches := regexp.MustCompile(pattern).FindStringSubmatch(id)
+	if matches == nil {
+		return fmt.Errorf("invalid program ID format. Expected format: PRG-YDSF-{ORG}-YYYY-NNNN (e.g., PRG-YDSF-JTM-2026-0001)")
+	}
+	if organization.Code != matches[1] {
+		return fmt.Errorf("program ID %s does not belong to organization %s", id, organization.Name)
+	}
+	return nil
+}
+
+// programKey returns the world state key of the program with the given ID
+func programKey(id string) (string, error) {
+	return shim.CreateCompositeKey(programObjectType, []string{id})
+}
+
+// writeProgram stores the program in world state
+func writeProgram(ctx contractapi.TransactionContextInterface, program Program) error {
+	key, err := programKey(program.ID)
+	if err != nil {
+		return fmt.Errorf("failed to create program key: %v", err)
+	}
+	programJSON, err := json.Marshal(program)
+	if err != nil {
+		return err
+	}
+	return ctx.GetStub().PutState(key, programJSON)
+}
+
+// chargeProgram checks that a distribution fits the program's active period and
+// remaining budget, then adds it to the program's spending
+func chargeProgram(program *Program, amount float64, timestamp string) error {
+	distributedAt, err := time.Parse(time.RFC3339, timestamp)
+	if err != nil {
+		return fmt.Errorf("invalid timestamp format. Expected ISO 8601 format (e.g., 2023-11-28T12:00:00Z)")
+	}
+	startDate, _ := time.Parse(time.RFC3339, program.StartDate)
+	endDate, _ := time.Parse(time.RFC3339, program.EndDate)
+	if distributedAt.Before(startDate) || distributedAt.After(endDate) {
+		return fmt.Errorf("program %s is not active at %s", program.ID, timestamp)
+	}
+
+	remaining := program.Budget - program.Spent
+	if amount > remaining && !amountsEqual(amount, remaining) {
+		return fmt.Errorf("distribution amount %f exceeds program %s remaining budget %f", amount, program.ID, remaining)
+	}
+
+	program.Spent += amount
+	return nil
+}
+
+// CreateProgram adds a new distribution program with a budget and active period.
+// The program is bound to its organization with a key-level endorsement policy.
+func (s *SmartContract) CreateProgram(ctx contractapi.TransactionContextInterface, id string, name string, organization string, asnaf string, budget float64, startDate string, endDate string) error {
+	// Validate input parameters
+	org, err := validateOrganization(ctx, organization)
+	if err != nil {
+		return err
+	}
+	if err := validateProgramID(id, org); err != nil {
+		return err
+	}
+	if name == "" {
+		return fmt.Errorf("program name must not be empty")
+	}
+	if err := validateAsnaf(asnaf); err != nil {
+		return err
+	}
+	if err := validateAmount(budget); err != nil {
+		return err
+	}
+	if err := validateTimestamp(startDate); err != nil {
+		return err
+	}
+	if err := validateTimestamp(endDate); err != nil {
+		return err
+	}
+	start, _ := time.Parse(time.RFC3339, startDate)
+	end, _ := time.Parse(time.RFC3339, endDate)
+	if !end.After(start) {
+		return fmt.Errorf("program end date must be after its start date")
+	}
+
+	exists, err := s.ProgramExists(ctx, id)
+	if err != nil {
+		return err
+	}
+	if exists {
+		return fmt.Errorf("the program %s already exists", id)
+	}
+
+	policy, err := orgEndorsementPolicy(org.MSPID)
+	if err != nil {
+		return fmt.Errorf("failed to create endorsement policy for program %s: %v", id, err)
+	}
+	key, err := programKey(id)
+	if err != nil {
+		return fmt.Errorf("failed to create program key: %v", err)
+	}
+	if err := ctx.GetStub().SetStateValidationParameter(key, policy); err != nil {
+		return fmt.Errorf("failed to set endorsement policy for program %s: %v", id, err)
+	}
+
+	return writeProgram(ctx, Program{
+		ID:           id,
+		Name:         name,
+		Organization: organization,
+		Asnaf:        asnaf,
+		Budget:       budget,
+		StartDate:    startDate,
+		EndDate:      endDate,
+	})
+}
+
+// QueryProgram returns the program stored in the world state with given id
+func (s *SmartContract) QueryProgram(ctx contractapi.TransactionContextInterface, id string) (Program, error) {
+	key, err := programKey(id)
+	if err != nil {
+		return Program{}, fmt.Errorf("failed to create program key: %v", err)
+	}
+	programJSON, err := ctx.GetStub().GetState(key)
+	if err != nil {
+		return Program{}, fmt.Errorf("failed to read from world state: %v", err)
+	}
+	if programJSON == nil {
+		return Program{}, fmt.Errorf("the program %s does not exist", id)
+	}
+
+	var program Program
+	err = json.Unmarshal(programJSON, &program)
+	if err != nil {
+		return Program{}, fmt.Errorf("failed to unmarshal JSON: %v", err)
+	}
+
+	return program, nil
+}
+
+// GetAllPrograms returns all programs found in world state
+func (s *SmartContract) GetAllPrograms(ctx contractapi.TransactionContextInterface) ([]Program, error) {
+	resultsIterator, err := ctx.GetStub().GetStateByPartialCompositeKey(programObjectType, []string{})
+	if err != nil {
+		return nil, err
+	}
+	defer resultsIterator.Close()
+
+	var programs []Program
+	for resultsIterator.HasNext() {
+		queryResponse, err := resultsIterator.Next()
+		if err != nil {
+			return nil, err
+		}
+
+		var program Program
+		err = json.Unmarshal(queryResponse.Value, &program)
+		if err != nil {
+			return nil, err
+		}
+		programs = append(programs, program)
+	}
+
+	return programs, nil
+}
+
+// GetProgramDistributions returns the distributions made under a program
+func (s *SmartContract) GetProgramDistributions(ctx contractapi.TransactionContextInterface, programID string) ([]Distribution, error) {
+	resultsIterator, err := ctx.GetStub().GetStateByPartialCompositeKey(programDistributionIndex, []string{programID})
+	if err != nil {
+		return nil, err
+	}
+	defer resultsIterator.Close()
+
+	var distributions []Distribution
+	for resultsIterator.HasNext() {
+		queryResponse, err := resultsIterator.Next()
+		if err != nil {
+			return nil, err
+		}
+
+		distribution, err := s.QueryDistribution(ctx, string(queryResponse.Value))
+		if err != nil {
+			return nil, err
+		}
+		distributions = append(distributions, distribution)
+	}
+
+	return distributions, nil
+}
+
+// ProgramExists returns true when a program with given ID exists in world state
+func (s *SmartContract) ProgramExists(ctx contractapi.TransactionContextInterface, id string) (bool, error) {
+	key, err := programKey(id)
+	if err != nil {
+		return false, fmt.Errorf("failed to create program key: %v", err)
+	}
+	programJSON, err := ctx.GetStub().GetState(key)
+	if err != nil {
+		return false, fmt.Errorf("failed to read from world state: %v", err)
+	}
+
+	return programJSON != nil, nil
+}
@@ -0,0 +1,147 @@
+package main
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/hyperledger/fabric-chaincode-go/shim"
+	"github.com/hyperledger/fabric-contract-api-go/contractapi"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCreateProgram(t *testing.T) {
+	chaincodeStub := new(MockStub)
+	transactionContext := new(contractapi.TransactionContext)
+	transactionContext.SetStub(chaincodeStub)
+	expectOrganizations(chaincodeStub)
+
+	key, err := programKey("PRG-YDSF-JTM-2026-0001")
+	require.NoError(t, err)
+
+	chaincodeStub.On("GetState", key).Return(nil, nil)
+	chaincodeStub.On("SetStateValidationParameter", key, mock.Anything).Return(nil)
+	chaincodeStub.On("PutState", key, mock.Anything).Return(nil).Run(func(args mock.Arguments) {
+		var program Program
+		err := json.Unmarshal(args.Get(1).([]byte), &program)
+		require.NoError(t, err)
+		require.Equal(t, "Bantuan Banjir Lumajang", program.Name)
+		require.Equal(t, "fakir", program.Asnaf)
+		require.Equal(t, float64(50000000), program.Budget)
+		require.Zero(t, program.Spent)
+	})
+
+	smartContract := new(SmartContract)
+	err = smartContract.CreateProgram(transactionContext, "PRG-YDSF-JTM-2026-0001", "Bantuan Banjir Lumajang", "YDSF Jatim", "fakir", 50000000, "2026-01-01T00:00:00Z", "2026-06-30T23:59:59Z")
+	require.NoError(t, err)
+
+	t.Run("Invalid asnaf", func(t *testing.T) {
+		err := smartContract.CreateProgram(transactionContext, "PRG-YDSF-JTM-2026-0002", "Beasiswa Yatim 2026", "YDSF Jatim", "yatim", 10000000, "2026-01-01T00:00:00Z", "2026-12-31T23:59:59Z")
+		require.Error(t, err)
+		require.Contains(t, err.Error(), "invalid asnaf")
+	})
+
+	t.Run("End before start", func(t *testing.T) {
+		err := smartContract.CreateProgram(transactionContext, "PRG-YDSF-JTM-2026-0002", "Beasiswa Yatim 2026", "YDSF Jatim", "miskin", 10000000, "2026-12-31T00:00:00Z", "2026-01-01T00:00:00Z")
+		require.Error(t, err)
+		require.Contains(t, err.Error(), "end date must be after")
+	})
+
+	chaincodeStub.AssertExpectations(t)
+}
+
+func TestDistributeZakatUnderProgram(t *testing.T) {
+	chaincodeStub := new(MockStub)
+	transactionContext := new(contractapi.TransactionContext)
+	transactionContext.SetStub(chaincodeStub)
+	expectOrganizations(chaincodeStub)
+
+	zakat := Zakat{
+		ID:           "ZKT-YDSF-JTM-202601-0001",
+		Muzakki:      "John Doe",
+		Amount:       5000000,
+		Type:         "maal",
+		Organization: "YDSF Jatim",
+		Status:       "collected",
+		Timestamp:    "2026-01-05T10:00:00Z",
+	}
+	pool := Pool{
+		ID:           "POOL-YDSF-JTM-MAAL",
+		Organization: "YDSF Jatim",
+		Type:         "maal",
+		Balance:      5000000,
+		Collected:    5000000,
+		Sources:      []PoolSource{{ZakatID: zakat.ID, Remaining: zakat.Amount}},
+	}
+	program := Program{
+		ID:           "PRG-YDSF-JTM-2026-0001",
+		Name:         "Beasiswa Yatim 2026",
+		Organization: "YDSF Jatim",
+		Asnaf:        "fakir",
+		Budget:       3000000,
+		Spent:        1000000,
+		StartDate:    "2026-01-01T00:00:00Z",
+		EndDate:      "2026-12-31T23:59:59Z",
+	}
+
+	zakatJSON, err := json.Marshal(zakat)
+	require.NoError(t, err)
+	poolJSON, err := json.Marshal(pool)
+	require.NoError(t, err)
+	programJSON, err := json.Marshal(program)
+	require.NoError(t, err)
+
+	poolStateKey, err := poolKey(pool.ID)
+	require.NoError(t, err)
+	programStateKey, err := programKey(program.ID)
+	require.NoError(t, err)
+	distributionStateKey, err := distributionKey("DST-YDSF-JTM-202602-0001")
+	require.NoError(t, err)
+
+	written := map[string][]byte{}
+	chaincodeStub.On("GetState", poolStateKey).Return(poolJSON, nil)
+	chaincodeStub.On("GetState", programStateKey).Return(programJSON, nil)
+	chaincodeStub.On("GetState", distributionStateKey).Return(nil, nil)
+	chaincodeStub.On("GetState", zakat.ID).Return(zakatJSON, nil)
+	expectBookkeeping(chaincodeStub)
+	chaincodeStub.On("PutState", mock.Anything, mock.Anything).Return(nil).Run(func(args mock.Arguments) {
+		written[args.String(0)] = args.Get(1).([]byte)
+	})
+
+	smartContract := new(SmartContract)
+	err = smartContract.DistributeZakat(transactionContext, "DST-YDSF-JTM-202602-0001", pool.ID, program.ID, "Mustahik1", 1500000, "2026-02-01T09:00:00Z")
+	require.NoError(t, err)
+
+	var updatedProgram Program
+	require.NoError(t, json.Unmarshal(written[programStateKey], &updatedProgram))
+	require.Equal(t, float64(2500000), updatedProgram.Spent)
+
+	var distribution Distribution
+	require.NoError(t, json.Unmarshal(written[distributionStateKey], &distribution))
+	require.Equal(t, program.ID, distribution.ProgramID)
+	require.Equal(t, "fakir", distribution.Asnaf)
+
+	indexKey, err := shim.CreateCompositeKey(programDistributionIndex, []string{program.ID, "DST-YDSF-JTM-202602-0001"})
+	require.NoError(t, err)
+	require.Equal(t, []byte("DST-YDSF-JTM-202602-0001"), written[indexKey])
+
+	t.Run("Exceeds remaining budget", func(t *testing.T) {
+		err := smartContract.DistributeZakat(transactionContext, "DST-YDSF-JTM-202602-0001", pool.ID, program.ID, "Mustahik2", 2500000, "2026-02-01T09:00:00Z")
+		require.Error(t, err)
+		require.Contains(t, err.Error(), "exceeds program")
+	})
+
+	t.Run("Exactly the remaining budget", func(t *testing.T) {
+		// 0.3 - 0.1 is 0.19999999999999998 in floating point
+		fractional := Program{ID: program.ID, Budget: 0.3, Spent: 0.1, StartDate: program.StartDate, EndDate: program.EndDate}
+		require.NoError(t, chargeProgram(&fractional, 0.2, "2026-02-01T09:00:00Z"))
+	})
+
+	t.Run("Outside active period", func(t *testing.T) {
+		err := smartContract.DistributeZakat(transactionContext, "DST-YDSF-JTM-202602-0001", pool.ID, program.ID, "Mustahik2", 100000, "2027-02-01T09:00:00Z")
+		require.Error(t, err)
+		require.Contains(t, err.Error(), "is not active")
+	})
+
+	chaincodeStub.AssertExpectations(t)
+}
@@ -0,0 +1,224 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"time"
+
+	"github.com/hyperledger/fabric-chaincode-go/shim"
+	"github.com/hyperledger/fabric-contract-api-go/contractapi"
+)
+
+const (
+	// aggregateObjectType is the composite key namespace for running aggregates
+	aggregateObjectType = "aggregate"
+	// aggregateDonorIndex marks donors already counted in an aggregate
+	aggregateDonorIndex = "aggregate~donor"
+	// allTypes is the aggregate type covering every zakat type
+	allTypes = "all"
+)
+
+// Aggregate holds the running totals of one organization, period and zakat type.
+// Aggregates are kept for each Gregorian month (YYYYMM) and year (YYYY) and each
+// Hijri month (YYYYHMM) and year (YYYYH), and updated on every write, so reports
+// never scan the ledger.
+type Aggregate struct {
+	Organization   string  `json:"organization"`
+	Period         string  `json:"period"`         // "YYYY", "YYYYMM", "YYYYH" or "YYYYHMM"
+	Type           string  `json:"type"`           // "fitrah", "maal" or "all"
+	Collected      float64 `json:"collected"`      // Donations received in IDR
+	Distributed    float64 `json:"distributed"`    // Distributions made in IDR
+	TransferredIn  float64 `json:"transferredIn"`  // Received from other organizations in IDR
+	TransferredOut float64 `json:"transferredOut"` // Sent to other organizations in IDR
+	Outstanding    float64 `json:"outstanding"`    // Received in the period and not yet distributed or sent
+	Donations      int     `json:"donations"`      // Number of donations
+	Donors         int     `json:"donors"`         // Number of distinct muzakki
+	Jiwa           int     `json:"jiwa"`           // Persons covered by fitrah donations
+}
+
+// Report is the collection and distribution summary of an organization for a period
+type Report struct {
+	Organization string      `json:"organization"`
+	Period       string      `json:"period"`
+	Types        []Aggregate `json:"types"`       // Totals per zakat type
+	Total        Aggregate   `json:"total"`       // Totals over all zakat types
+	PoolBalance  float64     `json:"poolBalance"` // Current balance of the organization's pools
+}
+
+// reportTypes lists the zakat types reported separately
+var reportTypes = []string{"fitrah", "maal"}
+
+// validatePeriod checks if the provided period is a year (YYYY) or month (YYYYMM), or a
+// Hijri year (YYYYH) or month (YYYYHMM)
+func validatePeriod(period string) error {
+	matched, err := regexp.MatchString(`^\d{4}H?(0[1-9]|1[0-2])?$`, period)
+	if err != nil {
+		return fmt.Errorf("error validating period format: %v", err)
+	}
+	if !matched {
+		return fmt.Errorf("invalid period format. Expected YYYY or YYYYMM, or YYYYH or YYYYHMM for a Hijri period (e.g., 2023, 202311, 1445H or 1445H09)")
+	}
+	return nil
+}
+
+// periodsOf returns the year and month periods a timestamp falls in, with the Hijri
+// year and month of a record stamped with its Hijri date
+func periodsOf(timestamp string, hijri *HijriDate) ([]string, error) {
+	t, err := time.Parse(time.RFC3339, timestamp)
+	if err != nil {
+		return nil, fmt.Errorf("invalid timestamp format. Expected ISO 8601 format (e.g., 2023-11-28T12:00:00Z)")
+	}
+	periods := []string{t.Format("2006"), t.Format("200601")}
+	if hijri != nil {
+		periods = append(periods, fmt.Sprintf("%04dH", hijri.Year), fmt.Sprintf("%04dH%02d", hijri.Year, hijri.Month))
+	}
+	return periods, nil
+}
+
+// aggregateKey returns the world state key of an aggregate
+func aggregateKey(organization string, period string, zakatType string) (string, error) {
+	return shim.CreateCompositeKey(aggregateObjectType, []string{organization, period, zakatType})
+}
+
+// readAggregate returns the aggregate for the organization, period and type, or an
+// empty one if nothing has been recorded yet
+func readAggregate(ctx contractapi.TransactionContextInterface, organization string, period string, zakatType string) (Aggregate, error) {
+	aggregate := Aggregate{Organization: organization, Period: period, Type: zakatType}
+
+	key, err := aggregateKey(organization, period, zakatType)
+	if err != nil {
+		return aggregate, fmt.Errorf("failed to create aggregate key: %v", err)
+	}
+	aggregateJSON, err := ctx.GetStub().GetState(key)
+	if err != nil {
+		return aggregate, fmt.Errorf("failed to read aggregate from world state: %v", err)
+	}
+	if aggregateJSON == nil {
+		return aggregate, nil
+	}
+
+	if err := json.Unmarshal(aggregateJSON, &aggregate); err != nil {
+		return aggregate, fmt.Errorf("failed to unmarshal aggregate: %v", err)
+	}
+	return aggregate, nil
+}
+
+// updateAggregates applies a change to the month and year aggregates of the zakat
+// type and of all types for the organization at the given timestamp and Hijri date
+func updateAggregates(ctx contractapi.TransactionContextInterface, organization string, zakatType string, timestamp string, hijri *HijriDate, apply func(*Aggregate) error) error {
+	periods, err := periodsOf(timestamp, hijri)
+	if err != nil {
+		return err
+	}
+
+	for _, period := range periods {
+		for _, aggregateType := range []string{zakatType, allTypes} {
+			aggregate, err := readAggregate(ctx, organization, period, aggregateType)
+			if err != nil {
+				return err
+			}
+			if err := apply(&aggregate); err != nil {
+				return err
+			}
+			aggregate.Outstanding = aggregate.Collected + aggregate.TransferredIn - aggregate.Distributed - aggregate.TransferredOut
+
+			key, err := aggregateKey(organization, period, aggregateType)
+			if err != nil {
+				return fmt.Errorf("failed to create aggregate key: %v", err)
+			}
+			aggregateJSON, err := json.Marshal(aggregate)
+			if err != nil {
+				return err
+			}
+			if err := ctx.GetStub().PutState(key, aggregateJSON); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// recordCollection adds a donation to the running aggregates, counting its donor
+// once per aggregate
+func recordCollection(ctx contractapi.TransactionContextInterface, zakat Zakat) error {
+	return updateAggregates(ctx, zakat.Organization, zakat.Type, zakat.Timestamp, zakat.Hijri, func(aggregate *Aggregate) error {
+		aggregate.Collected += zakat.idrValue()
+		aggregate.Donations++
+		aggregate.Jiwa += zakat.jiwa()
+
+		donorKey, err := shim.CreateCompositeKey(aggregateDonorIndex, []string{aggregate.Organization, aggregate.Period, aggregate.Type, zakat.donor()})
+		if err != nil {
+			return fmt.Errorf("failed to create donor index key: %v", err)
+		}
+		seen, err := ctx.GetStub().GetState(donorKey)
+		if err != nil {
+			return fmt.Errorf("failed to read donor index from world state: %v", err)
+		}
+		if seen == nil {
+			aggregate.Donors++
+			return ctx.GetStub().PutState(donorKey, []byte{0x00})
+		}
+		return nil
+	})
+}
+
+// recordDistribution adds a distribution to the running aggregates
+func recordDistribution(ctx contractapi.TransactionContextInterface, distribution Distribution) error {
+	return updateAggregates(ctx, distribution.Organization, distribution.Type, distribution.Timestamp, distribution.Hijri, func(aggregate *Aggregate) error {
+		aggregate.Distributed += distribution.idrValue()
+		return nil
+	})
+}
+
+// recordTransfer adds a transfer to the running aggregates of both organizations
+func recordTransfer(ctx contractapi.TransactionContextInterface, transfer Transfer) error {
+	err := updateAggregates(ctx, transfer.FromOrganization, transfer.Type, transfer.Timestamp, transfer.Hijri, func(aggregate *Aggregate) error {
+		aggregate.TransferredOut += transfer.Amount
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	return updateAggregates(ctx, transfer.ToOrganization, transfer.Type, transfer.Timestamp, transfer.Hijri, func(aggregate *Aggregate) error {
+		aggregate.TransferredIn += transfer.Amount
+		return nil
+	})
+}
+
+// GetReport returns the collection, distribution, transfer and donor totals of an
+// organization for a year (YYYY) or month (YYYYMM), per zakat type and overall
+func (s *SmartContract) GetReport(ctx contractapi.TransactionContextInterface, organization string, period string) (Report, error) {
+	org, err := getOrganization(ctx, organization)
+	if err != nil {
+		return Report{}, err
+	}
+	if err := validatePeriod(period); err != nil {
+		return Report{}, err
+	}
+
+	report := Report{Organization: organization, Period: period}
+	for _, zakatType := range reportTypes {
+		aggregate, err := readAggregate(ctx, organization, period, zakatType)
+		if err != nil {
+			return Report{}, err
+		}
+		report.Types = append(report.Types, aggregate)
+
+		pool, err := readPool(ctx, poolID(org, zakatType, unitIDR))
+		if err != nil {
+			return Report{}, err
+		}
+		if pool != nil {
+			report.PoolBalance += pool.Balance
+		}
+	}
+
+	total, err := readAggregate(ctx, organization, period, allTypes)
+	if err != nil {
+		return Report{}, err
+	}
+	report.Total = total
+
+	return report, nil
+}
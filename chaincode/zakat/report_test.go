@@ -0,0 +1,68 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/hyperledger/fabric-contract-api-go/contractapi"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGetReport(t *testing.T) {
+	chaincodeStub := new(MockStub)
+	transactionContext := new(contractapi.TransactionContext)
+	transactionContext.SetStub(chaincodeStub)
+	newWorldState(chaincodeStub)
+
+	smartContract := new(SmartContract)
+	require.NoError(t, smartContract.AddZakat(transactionContext, "ZKT-YDSF-MLG-202311-0001", "John Doe", 1000000, "maal", "YDSF Malang", "2023-11-01T10:00:00Z"))
+	require.NoError(t, smartContract.AddZakat(transactionContext, "ZKT-YDSF-MLG-202311-0002", "John Doe", 500000, "maal", "YDSF Malang", "2023-11-15T10:00:00Z"))
+	require.NoError(t, smartContract.AddZakat(transactionContext, "ZKT-YDSF-MLG-202311-0003", "Jane Doe", 45000, "fitrah", "YDSF Malang", "2023-11-20T10:00:00Z"))
+	require.NoError(t, smartContract.AddZakat(transactionContext, "ZKT-YDSF-MLG-202312-0001", "Jane Doe", 200000, "maal", "YDSF Malang", "2023-12-01T10:00:00Z"))
+	require.NoError(t, smartContract.DistributeZakat(transactionContext, "DST-YDSF-MLG-202311-0001", "POOL-YDSF-MLG-MAAL", "", "Mustahik1", 600000, "2023-11-25T10:00:00Z"))
+	openPool(t, transactionContext, "YDSF Jatim", "maal")
+	require.NoError(t, smartContract.TransferFunds(transactionContext, "TRF-YDSF-MLG-JTM-202311-0001", "YDSF Malang", "YDSF Jatim", "maal", 400000, "Bantuan Banjir Lumajang", "2023-11-26T10:00:00Z"))
+
+	t.Run("Month", func(t *testing.T) {
+		report, err := smartContract.GetReport(transactionContext, "YDSF Malang", "202311")
+		require.NoError(t, err)
+
+		require.Equal(t, Aggregate{
+			Organization:   "YDSF Malang",
+			Period:         "202311",
+			Type:           "maal",
+			Collected:      1500000,
+			Distributed:    600000,
+			TransferredOut: 400000,
+			Outstanding:    500000,
+			Donations:      2,
+			Donors:         1,
+		}, report.Types[1])
+		require.Equal(t, float64(45000), report.Types[0].Collected)
+		require.Equal(t, float64(1545000), report.Total.Collected)
+		require.Equal(t, 3, report.Total.Donations)
+		require.Equal(t, 2, report.Total.Donors)
+		require.Equal(t, float64(745000), report.PoolBalance)
+	})
+
+	t.Run("Year", func(t *testing.T) {
+		report, err := smartContract.GetReport(transactionContext, "YDSF Malang", "2023")
+		require.NoError(t, err)
+		require.Equal(t, float64(1745000), report.Total.Collected)
+		require.Equal(t, 4, report.Total.Donations)
+		require.Equal(t, 2, report.Total.Donors)
+		require.Equal(t, float64(745000), report.Total.Outstanding)
+	})
+
+	t.Run("Receiving organization", func(t *testing.T) {
+		report, err := smartContract.GetReport(transactionContext, "YDSF Jatim", "202311")
+		require.NoError(t, err)
+		require.Equal(t, float64(400000), report.Total.TransferredIn)
+		require.Equal(t, float64(400000), report.PoolBalance)
+	})
+
+	t.Run("Invalid period", func(t *testing.T) {
+		_, err := smartContract.GetReport(transactionContext, "YDSF Malang", "2023-11")
+		require.Error(t, err)
+		require.Contains(t, err.Error(), "invalid period format")
+	})
+}
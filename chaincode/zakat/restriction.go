@@ -0,0 +1,168 @@
+package main
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/hyperledger/fabric-contract-api-go/contractapi"
+)
+
+// Kinds of purpose a donor can restrict a donation to
+const (
+	restrictionProgram = "program"
+	restrictionAsnaf   = "asnaf"
+	restrictionRegion  = "region"
+)
+
+// maxRegionLength bounds the region of restrictions and distributions, which is free text
+const maxRegionLength = 64
+
+// Restriction is the purpose a donor restricted a donation to, e.g. "for orphans" as
+// the program that serves them, or "for the Lumajang flood" as the region. Restricted
+// funds are only spent by distributions matching the purpose.
+type Restriction struct {
+	Kind  string `json:"kind"`  // "program", "asnaf" or "region"
+	Value string `json:"value"` // Program ID, asnaf or region, e.g. "Lumajang"
+}
+
+// spending is what a movement out of a pool spends funds on: the program, asnaf and
+// region of a distribution. Transfers spend on nothing in particular and only move
+// unrestricted funds.
+type spending struct {
+	program string
+	asnaf   string
+	region  string
+}
+
+// allows reports whether funds with the restriction may be spent
+func (p spending) allows(restriction *Restriction) bool {
+	if restriction == nil {
+		return true
+	}
+	switch restriction.Kind {
+	case restrictionProgram:
+		return p.program != "" && p.program == restriction.Value
+	case restrictionAsnaf:
+		return p.asnaf != "" && p.asnaf == restriction.Value
+	case restrictionRegion:
+		return p.region != "" && strings.EqualFold(p.region, restriction.Value)
+	}
+	return false
+}
+
+// validateRegion checks a region, which may be empty
+func validateRegion(region string) error {
+	if len(region) > maxRegionLength {
+		return fmt.Errorf("invalid region. Must be at most %d characters", maxRegionLength)
+	}
+	return nil
+}
+
+// validateRestriction normalizes a donation's restriction and checks it against the
+// organization receiving the donation: a program must be one of its own
+func (s *SmartContract) validateRestriction(ctx contractapi.TransactionContextInterface, restriction Restriction, organization string) (Restriction, error) {
+	restriction.Kind = strings.ToLower(strings.TrimSpace(restriction.Kind))
+	restriction.Value = strings.TrimSpace(restriction.Value)
+	switch restriction.Kind {
+	case restrictionProgram:
+		program, err := s.QueryProgram(ctx, restriction.Value)
+		if err != nil {
+			return Restriction{}, err
+		}
+		if program.Organization != organization {
+			return Restriction{}, fmt.Errorf("program %s does not belong to organization %s", program.ID, organization)
+		}
+	case restrictionAsnaf:
+		restriction.Value = strings.ToLower(restriction.Value)
+		if err := validateAsnaf(restriction.Value); err != nil {
+			return Restriction{}, err
+		}
+		if restriction.Value == amilAsnaf {
+			return Restriction{}, fmt.Errorf("a donation cannot be restricted to the amil")
+		}
+	case restrictionRegion:
+		if restriction.Value == "" {
+			return Restriction{}, fmt.Errorf("invalid restriction. The region must not be empty")
+		}
+		if err := validateRegion(restriction.Value); err != nil {
+			return Restriction{}, err
+		}
+	default:
+		return Restriction{}, fmt.Errorf("invalid restriction kind. Must be 'program', 'asnaf' or 'region'")
+	}
+	return restriction, nil
+}
+
+// AddZakatRestricted adds a donation like AddZakat that the donor restricted to a
+// program, asnaf or region, e.g. AddZakatRestricted(..., "region", "Lumajang"). The
+// donation is credited to its pool as usual, but only distributions for that purpose
+// can spend it.
+func (s *SmartContract) AddZakatRestricted(ctx contractapi.TransactionContextInterface, id string, muzakki string, amount float64, zakatType string, organization string, timestamp string, kind string, value string) error {
+	return s.addZakat(ctx, ZakatInput{
+		ID:           id,
+		Muzakki:      muzakki,
+		Amount:       amount,
+		Type:         zakatType,
+		Organization: organization,
+		Timestamp:    timestamp,
+		Restriction:  &Restriction{Kind: kind, Value: value},
+	})
+}
+
+// RestrictedBalance is the part of a pool restricted to one purpose and not yet spent
+type RestrictedBalance struct {
+	Restriction Restriction `json:"restriction"`
+	PoolID      string      `json:"poolId"`
+	Unit        string      `json:"unit,omitempty"` // In-kind unit of the balance, empty for IDR
+	Balance     float64     `json:"balance"`        // Amount held for the purpose
+	Donations   int         `json:"donations"`      // Donations the balance is held from
+}
+
+// GetRestrictedBalances returns the balances an organization holds for restricted
+// purposes, per restriction and pool, ordered by restriction
+func (s *SmartContract) GetRestrictedBalances(ctx contractapi.TransactionContextInterface, organization string) ([]RestrictedBalance, error) {
+	if _, err := getOrganization(ctx, organization); err != nil {
+		return nil, err
+	}
+	pools, err := s.GetAllPools(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	balances := []RestrictedBalance{}
+	index := map[string]int{}
+	for _, pool := range pools {
+		if pool.Organization != organization {
+			continue
+		}
+		for _, source := range pool.Sources {
+			if source.Restriction == nil {
+				continue
+			}
+			key := pool.ID + "\x00" + source.Restriction.Kind + "\x00" + source.Restriction.Value
+			i, ok := index[key]
+			if !ok {
+				i = len(balances)
+				index[key] = i
+				balances = append(balances, RestrictedBalance{Restriction: *source.Restriction, PoolID: pool.ID, Unit: pool.Unit})
+			}
+			balances[i].Balance += source.Remaining
+			balances[i].Donations++
+		}
+	}
+	sort.Slice(balances, func(i, j int) bool {
+		a, b := balances[i], balances[j]
+		if a.Restriction != b.Restriction {
+			if a.Restriction.Kind != b.Restriction.Kind {
+				return a.Restriction.Kind < b.Restriction.Kind
+			}
+			return a.Restriction.Value < b.Restriction.Value
+		}
+		return a.PoolID < b.PoolID
+	})
+	for i := range balances {
+		balances[i].Balance = roundIDR(balances[i].Balance)
+	}
+	return balances, nil
+}
@@ -0,0 +1,113 @@
+package main
+
+import (
+	"testing"
+	"time"
+
+	"github.com/hyperledger/fabric-contract-api-go/contractapi"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+func TestRestrictedFunds(t *testing.T) {
+	chaincodeStub := new(MockStub)
+	transactionContext := new(contractapi.TransactionContext)
+	transactionContext.SetStub(chaincodeStub)
+	transactionContext.SetClientIdentity(&MockClientIdentity{MSPID: "YDSFMalangMSP", OUs: []string{"admin"}})
+	newWorldState(chaincodeStub)
+	now := time.Date(2024, 3, 1, 3, 0, 0, 0, time.UTC)
+	chaincodeStub.On("GetTxTimestamp").Return(timestamppb.New(now), nil).Maybe()
+
+	smartContract := new(SmartContract)
+	openPool(t, transactionContext, "YDSF Jatim", "maal")
+	require.NoError(t, smartContract.CreateProgram(transactionContext, "PRG-YDSF-MLG-2024-0001", "Beasiswa Yatim", "YDSF Malang", "miskin", 5000000, "2024-01-01T00:00:00Z", "2024-12-31T23:59:59Z"))
+	require.NoError(t, smartContract.CreateProgram(transactionContext, "PRG-YDSF-JTM-2024-0001", "Bantuan Banjir", "YDSF Jatim", "fakir", 5000000, "2024-01-01T00:00:00Z", "2024-12-31T23:59:59Z"))
+
+	for name, test := range map[string]struct {
+		restriction Restriction
+		err         string
+	}{
+		"Kind":          {restriction: Restriction{Kind: "mosque", Value: "Masjid Jami"}, err: "invalid restriction kind"},
+		"Unknown":       {restriction: Restriction{Kind: "program", Value: "PRG-YDSF-MLG-2024-0099"}, err: "does not exist"},
+		"Other program": {restriction: Restriction{Kind: "program", Value: "PRG-YDSF-JTM-2024-0001"}, err: "does not belong to organization YDSF Malang"},
+		"Asnaf":         {restriction: Restriction{Kind: "asnaf", Value: "orphans"}, err: "invalid asnaf"},
+		"Amil":          {restriction: Restriction{Kind: "asnaf", Value: "amil"}, err: "cannot be restricted to the amil"},
+		"Empty region":  {restriction: Restriction{Kind: "region", Value: " "}, err: "region must not be empty"},
+	} {
+		t.Run(name, func(t *testing.T) {
+			restriction := test.restriction
+			err := smartContract.addZakat(transactionContext, ZakatInput{
+				ID: "ZKT-YDSF-MLG-202404-0001", Muzakki: "John Doe", Amount: 100000, Type: "maal",
+				Organization: "YDSF Malang", Timestamp: "2024-04-01T10:00:00Z", Restriction: &restriction,
+			})
+			require.Error(t, err)
+			require.Contains(t, err.Error(), test.err)
+		})
+	}
+
+	require.NoError(t, smartContract.AddZakatRestricted(transactionContext, "ZKT-YDSF-MLG-202404-0001", "John Doe", 200000, "maal", "YDSF Malang", "2024-04-01T10:00:00Z", " Region", " Lumajang "))
+	require.NoError(t, smartContract.AddZakat(transactionContext, "ZKT-YDSF-MLG-202404-0002", "Jane Doe", 100000, "maal", "YDSF Malang", "2024-04-02T10:00:00Z"))
+	require.NoError(t, smartContract.AddZakatRestricted(transactionContext, "ZKT-YDSF-MLG-202404-0003", "Ahmad", 150000, "maal", "YDSF Malang", "2024-04-03T10:00:00Z", "program", "PRG-YDSF-MLG-2024-0001"))
+	require.NoError(t, smartContract.AddZakatRestricted(transactionContext, "ZKT-YDSF-MLG-202404-0004", "Fatimah", 50000, "maal", "YDSF Malang", "2024-04-04T10:00:00Z", "asnaf", "Miskin"))
+
+	zakat, err := smartContract.QueryZakat(transactionContext, "ZKT-YDSF-MLG-202404-0001")
+	require.NoError(t, err)
+	require.Equal(t, &Restriction{Kind: "region", Value: "Lumajang"}, zakat.Restriction)
+
+	t.Run("Balances", func(t *testing.T) {
+		balances, err := smartContract.GetRestrictedBalances(transactionContext, "YDSF Malang")
+		require.NoError(t, err)
+		require.Equal(t, []RestrictedBalance{
+			{Restriction: Restriction{Kind: "asnaf", Value: "miskin"}, PoolID: "POOL-YDSF-MLG-MAAL", Balance: 50000, Donations: 1},
+			{Restriction: Restriction{Kind: "program", Value: "PRG-YDSF-MLG-2024-0001"}, PoolID: "POOL-YDSF-MLG-MAAL", Balance: 150000, Donations: 1},
+			{Restriction: Restriction{Kind: "region", Value: "Lumajang"}, PoolID: "POOL-YDSF-MLG-MAAL", Balance: 200000, Donations: 1},
+		}, balances)
+	})
+
+	t.Run("Unrestricted purposes", func(t *testing.T) {
+		err := smartContract.DistributeZakat(transactionContext, "DST-YDSF-MLG-202404-0001", "POOL-YDSF-MLG-MAAL", "", "Mustahik1", 150000, "2024-04-05T10:00:00Z")
+		require.Error(t, err)
+		require.Contains(t, err.Error(), "400000.000000 is restricted to other purposes")
+
+		err = smartContract.TransferFunds(transactionContext, "TRF-YDSF-MLG-JTM-202404-0001", "YDSF Malang", "YDSF Jatim", "maal", 150000, "Bantuan Banjir Lumajang", "2024-04-05T10:00:00Z")
+		require.Error(t, err)
+		require.Contains(t, err.Error(), "restricted to other purposes")
+	})
+
+	t.Run("Region", func(t *testing.T) {
+		require.NoError(t, smartContract.DistributeZakatInRegion(transactionContext, "DST-YDSF-MLG-202404-0001", "POOL-YDSF-MLG-MAAL", "", "Mustahik1", "lumajang", 250000, "2024-04-05T10:00:00Z"))
+
+		distribution, err := smartContract.QueryDistribution(transactionContext, "DST-YDSF-MLG-202404-0001")
+		require.NoError(t, err)
+		require.Equal(t, "lumajang", distribution.Region)
+		// Restricted funds for the purpose are spent before unrestricted ones
+		require.Equal(t, []Allocation{
+			{ZakatID: "ZKT-YDSF-MLG-202404-0001", Amount: 200000, Restriction: &Restriction{Kind: "region", Value: "Lumajang"}},
+			{ZakatID: "ZKT-YDSF-MLG-202404-0002", Amount: 50000},
+		}, distribution.Sources)
+
+		err = smartContract.DistributeZakatInRegion(transactionContext, "DST-YDSF-MLG-202404-0002", "POOL-YDSF-MLG-MAAL", "", "Mustahik2", "", 10000, "2024-04-05T10:00:00Z")
+		require.Error(t, err)
+		require.Contains(t, err.Error(), "invalid region. Must not be empty")
+	})
+
+	t.Run("Program and asnaf", func(t *testing.T) {
+		require.NoError(t, smartContract.DistributeZakat(transactionContext, "DST-YDSF-MLG-202404-0002", "POOL-YDSF-MLG-MAAL", "PRG-YDSF-MLG-2024-0001", "Mustahik2", 220000, "2024-04-06T10:00:00Z"))
+
+		distribution, err := smartContract.QueryDistribution(transactionContext, "DST-YDSF-MLG-202404-0002")
+		require.NoError(t, err)
+		require.Equal(t, []Allocation{
+			{ZakatID: "ZKT-YDSF-MLG-202404-0003", Amount: 150000, Restriction: &Restriction{Kind: "program", Value: "PRG-YDSF-MLG-2024-0001"}},
+			{ZakatID: "ZKT-YDSF-MLG-202404-0004", Amount: 50000, Restriction: &Restriction{Kind: "asnaf", Value: "miskin"}},
+			{ZakatID: "ZKT-YDSF-MLG-202404-0002", Amount: 20000},
+		}, distribution.Sources)
+
+		balances, err := smartContract.GetRestrictedBalances(transactionContext, "YDSF Malang")
+		require.NoError(t, err)
+		require.Empty(t, balances)
+	})
+
+	t.Run("Transfer of unrestricted funds", func(t *testing.T) {
+		require.NoError(t, smartContract.TransferFunds(transactionContext, "TRF-YDSF-MLG-JTM-202404-0001", "YDSF Malang", "YDSF Jatim", "maal", 30000, "Bantuan Banjir Lumajang", "2024-04-07T10:00:00Z"))
+	})
+}
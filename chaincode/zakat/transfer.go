@@ -0,0 +1,232 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"regexp"
+
+	"github.com/hyperledger/fabric-chaincode-go/shim"
+	"github.com/hyperledger/fabric-contract-api-go/contractapi"
+)
+
+const (
+	// transferObjectType is the composite key namespace for transfers
+	transferObjectType = "transfer"
+	// transferOrganizationIndex indexes transfers by each organization taking part
+	transferOrganizationIndex = "transfer~organization"
+)
+
+// Transfer moves funds from one organization's pool to another's. It is recorded as
+// a paired debit of the sending pool and credit of the receiving pool.
+type Transfer struct {
+	ID               string       `json:"ID"`               // Format: TRF-YDSF-{FROM}-{TO}-{YYYY}{MM}-{COUNTER}
+	FromPoolID       string       `json:"fromPoolId"`       // Pool debited
+	ToPoolID         string       `json:"toPoolId"`         // Pool credited
+	FromOrganization string       `json:"fromOrganization"` // Sending organization
+	ToOrganization   string       `json:"toOrganization"`   // Receiving organization
+	Type             string       `json:"type"`             // "fitrah" or "maal"
+	Amount           float64      `json:"amount"`           // Amount in IDR
+	Purpose          string       `json:"purpose"`          // Reason for the transfer, e.g. a relief program
+	Timestamp        string       `json:"timestamp"`        // ISO 8601 format
+	Sources          []Allocation `json:"sources"`          // Donations the amount was drawn from, oldest first
+	Hijri            *HijriDate   `json:"hijri,omitempty"`  // Hijri date of the timestamp
+}
+
+// validateTransferID checks if the provided ID follows the required format and
+// names the sending and receiving organizations
+func validateTransferID(id string, fromOrganization Organization, toOrganization Organization) error {
+	pattern := `^TRF-YDSF-([A-Z]{3})-([A-Z]{3})-\d{6}-\d{4}$`
+	matches := regexp.MustCompile(pattern).FindStringSubmatch(id)
+	if matches == nil {
+		return fmt.Errorf("invalid transfer ID format. Expected format: TRF-YDSF-{FROM}-{TO}-YYYYMM-NNNN (e.g., TRF-YDSF-MLG-JTM-202311-0001)")
+	}
+	if fromOrganization.Code != matches[1] || toOrganization.Code != matches[2] {
+		return fmt.Errorf("transfer ID %s does not match organizations %s and %s", id, fromOrganization.Name, toOrganization.Name)
+	}
+	return nil
+}
+
+// transferKey returns the world state key of the transfer with the given ID
+func transferKey(id string) (string, error) {
+	return shim.CreateCompositeKey(transferObjectType, []string{id})
+}
+
+// TransferFunds moves an amount of one zakat type from the sending organization's pool
+// to the receiving organization's pool. Both pools carry their owner's endorsement
+// policy, so the transfer must be endorsed by peers of both organizations. The funds
+// keep their link to the original donations, which are consumed oldest first.
+func (s *SmartContract) TransferFunds(ctx contractapi.TransactionContextInterface, id string, fromOrganization string, toOrganization string, zakatType string, amount float64, purpose string, timestamp string) error {
+	// Validate input parameters
+	fromOrg, err := getOrganization(ctx, fromOrganization)
+	if err != nil {
+		return err
+	}
+	toOrg, err := validateOrganization(ctx, toOrganization)
+	if err != nil {
+		return err
+	}
+	if fromOrganization == toOrganization {
+		return fmt.Errorf("cannot transfer funds from %s to itself", fromOrganization)
+	}
+	if err := validateTransferID(id, fromOrg, toOrg); err != nil {
+		return err
+	}
+	if err := validateZakatType(zakatType); err != nil {
+		return err
+	}
+	if err := validateAmount(amount); err != nil {
+		return err
+	}
+	if err := validateTimestamp(timestamp); err != nil {
+		return err
+	}
+
+	exists, err := s.TransferExists(ctx, id)
+	if err != nil {
+		return err
+	}
+	if exists {
+		return fmt.Errorf("the transfer %s already exists", id)
+	}
+
+	fromPool, err := s.QueryPool(ctx, poolID(fromOrg, zakatType, unitIDR))
+	if err != nil {
+		return err
+	}
+	// A pool's endorsement policy only applies once the transaction creating it has
+	// committed, so a transfer may not create the receiving pool on its own
+	toPool, err := readPool(ctx, poolID(toOrg, zakatType, unitIDR))
+	if err != nil {
+		return err
+	}
+	if toPool == nil {
+		return fmt.Errorf("%s has no %s pool yet: it must collect zakat %s or open the pool with OpenPool before receiving a transfer", toOrganization, zakatType, zakatType)
+	}
+
+	// Debit the sending pool; funds restricted by their donors stay with the organization
+	allocations, err := takeFromPool(&fromPool, amount, spending{})
+	if err != nil {
+		return err
+	}
+	fromPool.TransferredOut += amount
+
+	// Credit the receiving pool with the same donations
+	for _, allocation := range allocations {
+		toPool.Sources = append(toPool.Sources, PoolSource{ZakatID: allocation.ZakatID, TransferID: id, Remaining: allocation.Amount})
+	}
+	toPool.Balance += amount
+	toPool.TransferredIn += amount
+
+	transfer := Transfer{
+		ID:               id,
+		FromPoolID:       fromPool.ID,
+		ToPoolID:         toPool.ID,
+		FromOrganization: fromOrganization,
+		ToOrganization:   toOrganization,
+		Type:             zakatType,
+		Amount:           amount,
+		Purpose:          purpose,
+		Timestamp:        timestamp,
+		Sources:          allocations,
+	}
+	if transfer.Hijri, err = hijriOf(ctx, timestamp); err != nil {
+		return err
+	}
+
+	transferJSON, err := json.Marshal(transfer)
+	if err != nil {
+		return err
+	}
+	key, err := transferKey(id)
+	if err != nil {
+		return fmt.Errorf("failed to create transfer key: %v", err)
+	}
+	if err := ctx.GetStub().PutState(key, transferJSON); err != nil {
+		return err
+	}
+
+	// Index the transfer under both organizations
+	for _, organization := range []string{fromOrganization, toOrganization} {
+		indexKey, err := shim.CreateCompositeKey(transferOrganizationIndex, []string{organization, id})
+		if err != nil {
+			return fmt.Errorf("failed to create transfer index key: %v", err)
+		}
+		if err := ctx.GetStub().PutState(indexKey, []byte(id)); err != nil {
+			return err
+		}
+	}
+
+	if err := recordTransfer(ctx, transfer); err != nil {
+		return err
+	}
+	if err := journalTransfer(ctx, transfer); err != nil {
+		return err
+	}
+
+	if err := writePool(ctx, &fromPool); err != nil {
+		return err
+	}
+	return writePool(ctx, toPool)
+}
+
+// QueryTransfer returns the transfer stored in the world state with given id
+func (s *SmartContract) QueryTransfer(ctx contractapi.TransactionContextInterface, id string) (Transfer, error) {
+	key, err := transferKey(id)
+	if err != nil {
+		return Transfer{}, fmt.Errorf("failed to create transfer key: %v", err)
+	}
+	transferJSON, err := ctx.GetStub().GetState(key)
+	if err != nil {
+		return Transfer{}, fmt.Errorf("failed to read from world state: %v", err)
+	}
+	if transferJSON == nil {
+		return Transfer{}, fmt.Errorf("the transfer %s does not exist", id)
+	}
+
+	var transfer Transfer
+	err = json.Unmarshal(transferJSON, &transfer)
+	if err != nil {
+		return Transfer{}, fmt.Errorf("failed to unmarshal JSON: %v", err)
+	}
+
+	return transfer, nil
+}
+
+// GetTransfersByOrganization returns all transfers the organization sent or received
+func (s *SmartContract) GetTransfersByOrganization(ctx contractapi.TransactionContextInterface, organization string) ([]Transfer, error) {
+	resultsIterator, err := ctx.GetStub().GetStateByPartialCompositeKey(transferOrganizationIndex, []string{organization})
+	if err != nil {
+		return nil, err
+	}
+	defer resultsIterator.Close()
+
+	var transfers []Transfer
+	for resultsIterator.HasNext() {
+		queryResponse, err := resultsIterator.Next()
+		if err != nil {
+			return nil, err
+		}
+
+		transfer, err := s.QueryTransfer(ctx, string(queryResponse.Value))
+		if err != nil {
+			return nil, err
+		}
+		transfers = append(transfers, transfer)
+	}
+
+	return transfers, nil
+}
+
+// TransferExists returns true when a transfer with given ID exists in world state
+func (s *SmartContract) TransferExists(ctx contractapi.TransactionContextInterface, id string) (bool, error) {
+	key, err := transferKey(id)
+	if err != nil {
+		return false, fmt.Errorf("failed to create transfer key: %v", err)
+	}
+	transferJSON, err := ctx.GetStub().GetState(key)
+	if err != nil {
+		return false, fmt.Errorf("failed to read from world state: %v", err)
+	}
+
+	return transferJSON != nil, nil
+}
@@ -0,0 +1,225 @@
+package main
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/hyperledger/fabric-chaincode-go/shim"
+	"github.com/hyperledger/fabric-contract-api-go/contractapi"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTransferFunds(t *testing.T) {
+	chaincodeStub := new(MockStub)
+	transactionContext := new(contractapi.TransactionContext)
+	transactionContext.SetStub(chaincodeStub)
+	expectOrganizations(chaincodeStub)
+
+	fromPool := Pool{
+		ID:           "POOL-YDSF-MLG-MAAL",
+		Organization: "YDSF Malang",
+		Type:         "maal",
+		Balance:      1500000,
+		Collected:    1500000,
+		Sources: []PoolSource{
+			{ZakatID: "ZKT-YDSF-MLG-202311-0001", Remaining: 1000000},
+			{ZakatID: "ZKT-YDSF-MLG-202311-0002", Remaining: 500000},
+		},
+	}
+	fromPoolJSON, err := json.Marshal(fromPool)
+	require.NoError(t, err)
+	toPoolJSON, err := json.Marshal(Pool{ID: "POOL-YDSF-JTM-MAAL", Organization: "YDSF Jatim", Type: "maal", Sources: []PoolSource{}})
+	require.NoError(t, err)
+
+	transferID := "TRF-YDSF-MLG-JTM-202311-0001"
+	transferStateKey, err := transferKey(transferID)
+	require.NoError(t, err)
+	fromPoolKey, err := poolKey(fromPool.ID)
+	require.NoError(t, err)
+	toPoolKey, err := poolKey("POOL-YDSF-JTM-MAAL")
+	require.NoError(t, err)
+
+	written := map[string][]byte{}
+	chaincodeStub.On("GetState", transferStateKey).Return(nil, nil)
+	chaincodeStub.On("GetState", fromPoolKey).Return(fromPoolJSON, nil)
+	chaincodeStub.On("GetState", toPoolKey).Return(toPoolJSON, nil)
+	expectBookkeeping(chaincodeStub)
+	chaincodeStub.On("PutState", mock.Anything, mock.Anything).Return(nil).Run(func(args mock.Arguments) {
+		written[args.String(0)] = args.Get(1).([]byte)
+	})
+
+	smartContract := new(SmartContract)
+	err = smartContract.TransferFunds(transactionContext, transferID, "YDSF Malang", "YDSF Jatim", "maal", 1200000, "Bantuan Banjir Lumajang", "2023-11-10T08:00:00Z")
+	require.NoError(t, err)
+
+	var transfer Transfer
+	require.NoError(t, json.Unmarshal(written[transferStateKey], &transfer))
+	require.Equal(t, "POOL-YDSF-JTM-MAAL", transfer.ToPoolID)
+	require.Equal(t, []Allocation{
+		{ZakatID: "ZKT-YDSF-MLG-202311-0001", Amount: 1000000},
+		{ZakatID: "ZKT-YDSF-MLG-202311-0002", Amount: 200000},
+	}, transfer.Sources)
+
+	var debited, credited Pool
+	require.NoError(t, json.Unmarshal(written[fromPoolKey], &debited))
+	require.NoError(t, json.Unmarshal(written[toPoolKey], &credited))
+	require.Equal(t, float64(300000), debited.Balance)
+	require.Equal(t, float64(1200000), debited.TransferredOut)
+	require.Equal(t, float64(1200000), credited.Balance)
+	require.Equal(t, float64(1200000), credited.TransferredIn)
+	require.Equal(t, []PoolSource{
+		{ZakatID: "ZKT-YDSF-MLG-202311-0001", TransferID: transferID, Remaining: 1000000},
+		{ZakatID: "ZKT-YDSF-MLG-202311-0002", TransferID: transferID, Remaining: 200000},
+	}, credited.Sources)
+
+	for _, organization := range []string{"YDSF Malang", "YDSF Jatim"} {
+		indexKey, err := shim.CreateCompositeKey(transferOrganizationIndex, []string{organization, transferID})
+		require.NoError(t, err)
+		require.Equal(t, []byte(transferID), written[indexKey])
+	}
+
+	t.Run("Same organization", func(t *testing.T) {
+		err := smartContract.TransferFunds(transactionContext, "TRF-YDSF-MLG-MLG-202311-0001", "YDSF Malang", "YDSF Malang", "maal", 100000, "", "2023-11-10T08:00:00Z")
+		require.Error(t, err)
+		require.Contains(t, err.Error(), "to itself")
+	})
+
+	t.Run("ID does not match organizations", func(t *testing.T) {
+		err := smartContract.TransferFunds(transactionContext, "TRF-YDSF-JTM-MLG-202311-0001", "YDSF Malang", "YDSF Jatim", "maal", 100000, "", "2023-11-10T08:00:00Z")
+		require.Error(t, err)
+		require.Contains(t, err.Error(), "does not match organizations")
+	})
+
+	t.Run("Exceeds balance", func(t *testing.T) {
+		err := smartContract.TransferFunds(transactionContext, transferID, "YDSF Malang", "YDSF Jatim", "maal", 2000000, "", "2023-11-10T08:00:00Z")
+		require.Error(t, err)
+		require.Contains(t, err.Error(), "exceeds pool")
+	})
+
+	chaincodeStub.AssertExpectations(t)
+}
+
+func TestDistributeTransferredTwice(t *testing.T) {
+	chaincodeStub := new(MockStub)
+	transactionContext := new(contractapi.TransactionContext)
+	transactionContext.SetStub(chaincodeStub)
+	worldState := newWorldState(chaincodeStub)
+
+	smartContract := new(SmartContract)
+	require.NoError(t, smartContract.AddZakat(transactionContext, "ZKT-YDSF-MLG-202311-0001", "John Doe", 1000000, "maal", "YDSF Malang", "2023-11-01T10:00:00Z"))
+	openPool(t, transactionContext, "YDSF Jatim", "maal")
+	require.NoError(t, smartContract.TransferFunds(transactionContext, "TRF-YDSF-MLG-JTM-202311-0001", "YDSF Malang", "YDSF Jatim", "maal", 500000, "", "2023-11-10T08:00:00Z"))
+	require.NoError(t, smartContract.TransferFunds(transactionContext, "TRF-YDSF-MLG-JTM-202311-0002", "YDSF Malang", "YDSF Jatim", "maal", 500000, "", "2023-11-11T08:00:00Z"))
+
+	// The donation is two sources of the receiving pool; a distribution spending both
+	// updates it once, as on a peer where the transaction does not read its own writes
+	worldState.DeferWrites()
+	require.NoError(t, smartContract.DistributeZakat(transactionContext, "DST-YDSF-JTM-202311-0001", "POOL-YDSF-JTM-MAAL", "", "Mustahik1", 1000000, "2023-11-12T08:00:00Z"))
+	worldState.Commit()
+
+	zakat, err := smartContract.QueryZakat(transactionContext, "ZKT-YDSF-MLG-202311-0001")
+	require.NoError(t, err)
+	require.Equal(t, float64(1000000), zakat.Distribution)
+	require.Equal(t, []string{"DST-YDSF-JTM-202311-0001"}, zakat.Distributions)
+	require.Equal(t, "distributed", zakat.Status)
+
+	distribution, err := smartContract.QueryDistribution(transactionContext, "DST-YDSF-JTM-202311-0001")
+	require.NoError(t, err)
+	require.Equal(t, []Allocation{
+		{ZakatID: "ZKT-YDSF-MLG-202311-0001", TransferID: "TRF-YDSF-MLG-JTM-202311-0001", Amount: 500000},
+		{ZakatID: "ZKT-YDSF-MLG-202311-0001", TransferID: "TRF-YDSF-MLG-JTM-202311-0002", Amount: 500000},
+	}, distribution.Sources)
+}
+
+func TestGetTransfersByOrganization(t *testing.T) {
+	chaincodeStub := new(MockStub)
+	transactionContext := new(contractapi.TransactionContext)
+	transactionContext.SetStub(chaincodeStub)
+
+	expected := Transfer{
+		ID:               "TRF-YDSF-MLG-JTM-202311-0001",
+		FromPoolID:       "POOL-YDSF-MLG-MAAL",
+		ToPoolID:         "POOL-YDSF-JTM-MAAL",
+		FromOrganization: "YDSF Malang",
+		ToOrganization:   "YDSF Jatim",
+		Type:             "maal",
+		Amount:           500000,
+		Purpose:          "Bantuan Banjir Lumajang",
+		Timestamp:        "2023-11-10T08:00:00Z",
+		Sources:          []Allocation{{ZakatID: "ZKT-YDSF-MLG-202311-0001", Amount: 500000}},
+	}
+	transferJSON, err := json.Marshal(expected)
+	require.NoError(t, err)
+	key, err := transferKey(expected.ID)
+	require.NoError(t, err)
+
+	iterator := &MockQueryIterator{
+		Current: -1,
+		Items:   []QueryResult{{Key: "index", Value: []byte(expected.ID)}},
+	}
+	chaincodeStub.On("GetStateByPartialCompositeKey", transferOrganizationIndex, []string{"YDSF Jatim"}).Return(iterator, nil)
+	chaincodeStub.On("GetState", key).Return(transferJSON, nil)
+
+	smartContract := new(SmartContract)
+	transfers, err := smartContract.GetTransfersByOrganization(transactionContext, "YDSF Jatim")
+	require.NoError(t, err)
+	require.Equal(t, []Transfer{expected}, transfers)
+
+	chaincodeStub.AssertExpectations(t)
+}
+
+func TestOpenPool(t *testing.T) {
+	chaincodeStub := new(MockStub)
+	transactionContext := new(contractapi.TransactionContext)
+	transactionContext.SetStub(chaincodeStub)
+	newWorldState(chaincodeStub)
+
+	smartContract := new(SmartContract)
+	require.NoError(t, smartContract.AddZakat(transactionContext, "ZKT-YDSF-MLG-202311-0001", "John Doe", 1000000, "maal", "YDSF Malang", "2023-11-01T10:00:00Z"))
+
+	t.Run("Receiving pool not open", func(t *testing.T) {
+		err := smartContract.TransferFunds(transactionContext, "TRF-YDSF-MLG-JTM-202311-0001", "YDSF Malang", "YDSF Jatim", "maal", 500000, "", "2023-11-10T08:00:00Z")
+		require.Error(t, err)
+		require.Contains(t, err.Error(), "YDSF Jatim has no maal pool yet")
+	})
+
+	t.Run("Not a member", func(t *testing.T) {
+		transactionContext.SetClientIdentity(&MockClientIdentity{MSPID: "YDSFMalangMSP", OUs: []string{"client"}})
+		err := smartContract.OpenPool(transactionContext, "YDSF Jatim", "maal")
+		require.Error(t, err)
+		require.Contains(t, err.Error(), "only members of YDSF Jatim")
+	})
+
+	t.Run("Success", func(t *testing.T) {
+		openPool(t, transactionContext, "YDSF Jatim", "maal")
+		pool, err := smartContract.QueryPool(transactionContext, "POOL-YDSF-JTM-MAAL")
+		require.NoError(t, err)
+		require.Equal(t, Pool{ID: "POOL-YDSF-JTM-MAAL", Organization: "YDSF Jatim", Type: "maal", Sources: []PoolSource{}}, pool)
+
+		require.NoError(t, smartContract.TransferFunds(transactionContext, "TRF-YDSF-MLG-JTM-202311-0001", "YDSF Malang", "YDSF Jatim", "maal", 500000, "", "2023-11-10T08:00:00Z"))
+	})
+
+	t.Run("Already open", func(t *testing.T) {
+		transactionContext.SetClientIdentity(&MockClientIdentity{MSPID: "YDSFJatimMSP", OUs: []string{"client"}})
+		err := smartContract.OpenPool(transactionContext, "YDSF Jatim", "maal")
+		require.Error(t, err)
+		require.Contains(t, err.Error(), "already exists")
+	})
+}
+
+// openPool opens the empty rupiah pool of a default organization as one of its
+// members, so it can receive transfers, and restores the client identity
+func openPool(t *testing.T, transactionContext *contractapi.TransactionContext, organization string, zakatType string) {
+	for _, registered := range defaultOrganizations {
+		if registered.Name != organization {
+			continue
+		}
+		identity := transactionContext.GetClientIdentity()
+		defer transactionContext.SetClientIdentity(identity)
+		transactionContext.SetClientIdentity(&MockClientIdentity{MSPID: registered.MSPID, OUs: []string{"client"}})
+		require.NoError(t, new(SmartContract).OpenPool(transactionContext, organization, zakatType))
+		return
+	}
+	t.Fatalf("%s is not a default organization", organization)
+}
@@ -0,0 +1,80 @@
+package main
+
+import (
+	"sort"
+
+	"github.com/hyperledger/fabric-chaincode-go/shim"
+	"github.com/hyperledger/fabric-contract-api-go/contractapi"
+)
+
+// txCache buffers the state writes of a transaction so that later reads in the same
+// transaction see them, which the peer's stub does not do. Transactions that update
+// the same pool or aggregate several times, such as batches, run against a txCache
+// and flush it once at the end. Range queries still read the committed state.
+type txCache struct {
+	shim.ChaincodeStubInterface
+	writes     map[string][]byte
+	parameters map[string][]byte
+}
+
+// newTxCache returns a cache in front of the given stub
+func newTxCache(stub shim.ChaincodeStubInterface) *txCache {
+	return &txCache{
+		ChaincodeStubInterface: stub,
+		writes:                 map[string][]byte{},
+		parameters:             map[string][]byte{},
+	}
+}
+
+// GetState returns the buffered value of the key, or the stub's value if it was not written
+func (c *txCache) GetState(key string) ([]byte, error) {
+	if value, ok := c.writes[key]; ok {
+		return value, nil
+	}
+	return c.ChaincodeStubInterface.GetState(key)
+}
+
+// PutState buffers a write
+func (c *txCache) PutState(key string, value []byte) error {
+	c.writes[key] = value
+	return nil
+}
+
+// SetStateValidationParameter buffers a key-level endorsement policy
+func (c *txCache) SetStateValidationParameter(key string, ep []byte) error {
+	c.parameters[key] = ep
+	return nil
+}
+
+// flush writes the buffered values and policies to the underlying stub in key order
+func (c *txCache) flush() error {
+	for _, key := range sortedKeys(c.parameters) {
+		if err := c.ChaincodeStubInterface.SetStateValidationParameter(key, c.parameters[key]); err != nil {
+			return err
+		}
+	}
+	for _, key := range sortedKeys(c.writes) {
+		if err := c.ChaincodeStubInterface.PutState(key, c.writes[key]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// context returns a transaction context that reads and writes through the cache
+func (c *txCache) context(ctx contractapi.TransactionContextInterface) *contractapi.TransactionContext {
+	cached := new(contractapi.TransactionContext)
+	cached.SetStub(c)
+	cached.SetClientIdentity(ctx.GetClientIdentity())
+	return cached
+}
+
+// sortedKeys returns the keys of the map in sorted order
+func sortedKeys(values map[string][]byte) []string {
+	keys := make([]string, 0, len(values))
+	for key := range values {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -16,31 +16,57 @@ type SmartContract struct {
 
 // Zakat describes basic details of what makes up a zakat transaction
 type Zakat struct {
-	ID            string  `json:"ID"`            // Format: ZKT-{ORG}-{YYYY}{MM}-{COUNTER}
-	Muzakki       string  `json:"muzakki"`       // Zakat donor's name
-	Amount        float64 `json:"amount"`        // Amount in IDR
-	Type          string  `json:"type"`          // "fitrah" or "maal"
-	Status        string  `json:"status"`        // "collected" or "distributed"
-	Organization  string  `json:"organization"`   // Collecting organization
-	Timestamp     string  `json:"timestamp"`     // ISO 8601 format
-	Mustahik      string  `json:"mustahik"`      // Recipient's name (if distributed)
-	Distribution  float64 `json:"distribution"`   // Distributed amount
-	DistributedAt string  `json:"distributedAt"` // Distribution timestamp (ISO 8601)
+	ID            string       `json:"ID"`                      // Format: ZKT-{ORG}-{YYYY}{MM}-{COUNTER}
+	Muzakki       string       `json:"muzakki"`                 // Zakat donor's name, "Hamba Allah" if anonymous
+	Amount        float64      `json:"amount"`                  // Amount in IDR, or quantity in Unit
+	Unit          string       `json:"unit,omitempty"`          // "IDR", the default, or an in-kind unit such as "kg_beras"
+	Value         float64      `json:"value,omitempty"`         // IDR valuation of an in-kind donation at the reference price, 0 if none was set
+	Type          string       `json:"type"`                    // "fitrah" or "maal"
+	Status        string       `json:"status"`                  // "collected" or "distributed"
+	Organization  string       `json:"organization"`            // Collecting organization
+	Timestamp     string       `json:"timestamp"`               // ISO 8601 format
+	Mustahik      string       `json:"mustahik"`                // Recipient's name (per-donation distributions only)
+	Distribution  float64      `json:"distribution"`            // Amount distributed from the pool so far
+	DistributedAt string       `json:"distributedAt"`           // Timestamp the donation was fully distributed (ISO 8601)
+	Distributions []string     `json:"distributions,omitempty"` // Distributions that drew on this donation
+	Payment       *Payment     `json:"payment,omitempty"`       // Payment the donation was received by, if recorded
+	Hijri         *HijriDate   `json:"hijri,omitempty"`         // Hijri date of the timestamp, stamped when recorded
+	Persons       []string     `json:"persons,omitempty"`       // Fitrah only: names of the persons covered, if given
+	Jiwa          int          `json:"jiwa,omitempty"`          // Fitrah only: number of persons covered, if given
+	Conversion    *Conversion  `json:"conversion,omitempty"`    // Foreign currency the donation was given in, if any
+	Restriction   *Restriction `json:"restriction,omitempty"`   // Purpose the donor restricted the donation to, if any
+	PledgeID      string       `json:"pledgeId,omitempty"`      // Pledge the donation was paid towards, if any
+	// Anonymous donations only: hash of the zakat ID and the donor's secret
+	DonorCommitment string `json:"donorCommitment,omitempty"`
 }
 
-// validateZakatID checks if the provided ID follows the required format
-func validateZakatID(id string) error {
-	pattern := `^ZKT-YDSF-(MLG|JTM)-\d{6}-\d{4}$`
-	matched, err := regexp.MatchString(pattern, id)
+// validateZakatID checks if the provided ID follows the required format and carries
+// the code of the given organization
+func validateZakatID(ctx contractapi.TransactionContextInterface, id string, organization string) error {
+	code, err := zakatIDCode(id)
 	if err != nil {
-		return fmt.Errorf("error validating zakat ID format: %v", err)
+		return err
+	}
+	registered, err := organizationByCode(ctx, code)
+	if err != nil {
+		return fmt.Errorf("invalid zakat ID %s: %v", id, err)
 	}
-	if !matched {
-		return fmt.Errorf("invalid zakat ID format. Expected format: ZKT-YDSF-{MLG|JTM}-YYYYMM-NNNN (e.g., ZKT-YDSF-MLG-202311-0001)")
+	if registered.Name != organization {
+		return fmt.Errorf("zakat ID %s does not belong to organization %s", id, organization)
 	}
 	return nil
 }
 
+// zakatIDCode checks the format of a zakat ID and returns its organization code
+func zakatIDCode(id string) (string, error) {
+	pattern := `^ZKT-YDSF-([A-Z]{3})-\d{6}-\d{4}$`
+	matches := regexp.MustCompile(pattern).FindStringSubmatch(id)
+	if matches == nil {
+		return "", fmt.Errorf("invalid zakat ID format. Expected format: ZKT-YDSF-{ORG}-YYYYMM-NNNN (e.g., ZKT-YDSF-MLG-202311-0001)")
+	}
+	return matches[1], nil
+}
+
 // validateTimestamp checks if the provided timestamp is in ISO 8601 format
 func validateTimestamp(timestamp string) error {
 	_, err := time.Parse(time.RFC3339, timestamp)
@@ -66,14 +92,6 @@ func validateAmount(amount float64) error {
 	return nil
 }
 
-// validateOrganization checks if the provided organization is authorized
-func validateOrganization(org string) error {
-	if org != "YDSF Malang" && org != "YDSF Jatim" {
-		return fmt.Errorf("invalid organization. Must be either 'YDSF Malang' or 'YDSF Jatim'")
-	}
-	return nil
-}
-
 // validateStatus checks if the provided status is valid
 func validateStatus(status string) error {
 	if status != "collected" && status != "distributed" {
@@ -84,7 +102,8 @@ func validateStatus(status string) error {
 
 // InitLedger adds a base set of zakat transactions to the ledger.
 // This function is called when the chaincode is instantiated.
-// It creates an initial zakat transaction with predefined values.
+// It registers the default organizations and creates an initial zakat
+// transaction with predefined values.
 // If the initial zakat already exists, it returns an error.
 // All fields are validated using the standard validation functions.
 func (s *SmartContract) InitLedger(ctx contractapi.TransactionContextInterface) error {
@@ -98,6 +117,19 @@ func (s *SmartContract) InitLedger(ctx contractapi.TransactionContextInterface)
 		return fmt.Errorf("initial zakat already exists")
 	}
 
+	// Register the default organizations, as one of their admins
+	registry, err := readRegistry(ctx)
+	if err != nil {
+		return err
+	}
+	if err := requireAdmin(ctx, registry); err != nil {
+		return err
+	}
+	registry, err = seedOrganizations(ctx, registry)
+	if err != nil {
+		return fmt.Errorf("failed to register default organizations: %v", err)
+	}
+
 	// Get current timestamp
 	timestamp := time.Now().Format(time.RFC3339)
 
@@ -112,22 +144,31 @@ func (s *SmartContract) InitLedger(ctx contractapi.TransactionContextInterface)
 		Timestamp:    timestamp,
 	}
 
-	// Validate the initial zakat data
-	if err := validateZakatID(zakat.ID); err != nil {
+	// Validate the initial zakat data against the seeded registry
+	i := registry.find(zakat.Organization)
+	if i < 0 {
+		return fmt.Errorf("invalid initial zakat organization: %s is not registered", zakat.Organization)
+	}
+	organization := registry.Organizations[i]
+	code, err := zakatIDCode(zakat.ID)
+	if err != nil {
 		return fmt.Errorf("invalid initial zakat ID: %v", err)
 	}
+	if code != organization.Code {
+		return fmt.Errorf("invalid initial zakat ID: %s does not belong to %s", zakat.ID, organization.Name)
+	}
 	if err := validateAmount(zakat.Amount); err != nil {
 		return fmt.Errorf("invalid initial zakat amount: %v", err)
 	}
 	if err := validateZakatType(zakat.Type); err != nil {
 		return fmt.Errorf("invalid initial zakat type: %v", err)
 	}
-	if err := validateOrganization(zakat.Organization); err != nil {
-		return fmt.Errorf("invalid initial zakat organization: %v", err)
-	}
 	if err := validateTimestamp(zakat.Timestamp); err != nil {
 		return fmt.Errorf("invalid initial zakat timestamp: %v", err)
 	}
+	if zakat.Hijri, err = hijriOf(ctx, zakat.Timestamp); err != nil {
+		return fmt.Errorf("invalid initial zakat timestamp: %v", err)
+	}
 
 	zakatJSON, err := json.Marshal(zakat)
 	if err != nil {
@@ -138,46 +179,187 @@ func (s *SmartContract) InitLedger(ctx contractapi.TransactionContextInterface)
 		return fmt.Errorf("failed to put initial zakat to world state: %v", err)
 	}
 
+	if err := creditPool(ctx, organization, zakat); err != nil {
+		return fmt.Errorf("failed to credit initial zakat to its pool: %v", err)
+	}
+	if err := recordCollection(ctx, zakat); err != nil {
+		return fmt.Errorf("failed to record initial zakat in the reports: %v", err)
+	}
+	if err := journalCollection(ctx, zakat); err != nil {
+		return fmt.Errorf("failed to journal initial zakat: %v", err)
+	}
+
 	return nil
 }
 
+// ZakatInput holds the details of a donation to be recorded
+type ZakatInput struct {
+	ID           string   `json:"ID"`
+	Muzakki      string   `json:"muzakki"`
+	Amount       float64  `json:"amount"`
+	Unit         string   `json:"unit,omitempty"`
+	Type         string   `json:"type"`
+	Organization string   `json:"organization"`
+	Timestamp    string   `json:"timestamp"`
+	Payment      *Payment `json:"payment,omitempty"`
+	Persons      []string `json:"persons,omitempty"` // Fitrah only: names of the persons covered
+	Jiwa         int      `json:"jiwa,omitempty"`    // Fitrah only: number of persons covered
+	// Foreign currency the donation was given in; the amount, if given, must be its IDR value
+	Conversion *Conversion `json:"conversion,omitempty"`
+	// Purpose the donor restricted the donation to, if any
+	Restriction *Restriction `json:"restriction,omitempty"`
+	// Pledge the donation is paid towards; the muzakki, type and organization may be
+	// omitted and must otherwise match the pledge
+	PledgeID string `json:"pledgeId,omitempty"`
+	// Commitment of an anonymous donor, recorded as Hamba Allah instead of a name
+	DonorCommitment string `json:"donorCommitment,omitempty"`
+}
+
 // AddZakat adds a new zakat transaction to the world state with given details
+// and credits it to the fund pool of its organization and type
 func (s *SmartContract) AddZakat(ctx contractapi.TransactionContextInterface, id string, muzakki string, amount float64, zakatType string, organization string, timestamp string) error {
+	return s.addZakat(ctx, ZakatInput{
+		ID:           id,
+		Muzakki:      muzakki,
+		Amount:       amount,
+		Type:         zakatType,
+		Organization: organization,
+		Timestamp:    timestamp,
+	})
+}
+
+// addZakat validates and records a donation, for AddZakat and AddZakatBatch
+func (s *SmartContract) addZakat(ctx contractapi.TransactionContextInterface, input ZakatInput) error {
 	// Validate input parameters
-	if err := validateZakatID(id); err != nil {
+	if input.DonorCommitment != "" {
+		if err := checkAnonymous(&input); err != nil {
+			return err
+		}
+	}
+	var pledge *Pledge
+	if input.PledgeID != "" {
+		checked, err := s.checkPledge(ctx, &input)
+		if err != nil {
+			return err
+		}
+		pledge = &checked
+	}
+	// A pledge payment may leave the organization to the pledge
+	if err := validateZakatID(ctx, input.ID, input.Organization); err != nil {
+		return err
+	}
+	var conversion *Conversion
+	if input.Conversion != nil {
+		normalized, amount, err := convertToIDR(*input.Conversion, input.Amount, input.Unit)
+		if err != nil {
+			return err
+		}
+		conversion, input.Amount = &normalized, amount
+	}
+	if err := validateAmount(input.Amount); err != nil {
 		return err
 	}
-	if err := validateAmount(amount); err != nil {
+	unit := normalizeUnit(input.Unit)
+	if err := validateUnit(unit); err != nil {
 		return err
 	}
-	if err := validateZakatType(zakatType); err != nil {
+	if err := validateQuantity(input.Amount, unit); err != nil {
 		return err
 	}
-	if err := validateOrganization(organization); err != nil {
+	if err := validateZakatType(input.Type); err != nil {
 		return err
 	}
-	if err := validateTimestamp(timestamp); err != nil {
+	org, err := validateOrganization(ctx, input.Organization)
+	if err != nil {
+		return err
+	}
+	if err := validateTimestamp(input.Timestamp); err != nil {
+		return err
+	}
+	var payment *Payment
+	if input.Payment != nil {
+		if unit != unitIDR {
+			return fmt.Errorf("a donation in %s cannot carry a payment", unit)
+		}
+		normalized := normalizePayment(*input.Payment)
+		if err := validatePayment(normalized); err != nil {
+			return err
+		}
+		payment = &normalized
+	}
+	if pledge != nil && unit != unitIDR {
+		return fmt.Errorf("a donation in %s cannot be paid towards a pledge", unit)
+	}
+	var restriction *Restriction
+	if input.Restriction != nil {
+		validated, err := s.validateRestriction(ctx, *input.Restriction, input.Organization)
+		if err != nil {
+			return err
+		}
+		restriction = &validated
+	}
+	jiwa := 0
+	if len(input.Persons) > 0 || input.Jiwa != 0 {
+		if input.Type != "fitrah" {
+			return fmt.Errorf("only zakat fitrah covers persons")
+		}
+		if jiwa, err = validatePersons(input.Persons, input.Jiwa); err != nil {
+			return err
+		}
+	}
+	hijri, err := hijriOf(ctx, input.Timestamp)
+	if err != nil {
 		return err
 	}
+	if jiwa > 0 {
+		if err := checkFitrahAmount(ctx, input.Organization, input.Amount, unit, jiwa, hijri); err != nil {
+			return err
+		}
+	}
+	var value float64
+	if unit != unitIDR {
+		if value, err = valueInKind(ctx, input.Amount, unit, input.Timestamp); err != nil {
+			return err
+		}
+	} else {
+		// Rupiah donations keep the records they had before units
+		unit = ""
+	}
 
 	// Check if zakat already exists
-	exists, err := s.ZakatExists(ctx, id)
+	exists, err := s.ZakatExists(ctx, input.ID)
 	if err != nil {
 		return err
 	}
 	if exists {
-		return fmt.Errorf("the zakat %s already exists", id)
+		return fmt.Errorf("the zakat %s already exists", input.ID)
+	}
+	if payment != nil {
+		if err := claimPayment(ctx, *payment, input.ID); err != nil {
+			return err
+		}
 	}
 
 	// Create the zakat
 	zakat := Zakat{
-		ID:           id,
-		Muzakki:      muzakki,
-		Amount:       amount,
-		Type:         zakatType,
+		ID:           input.ID,
+		Muzakki:      input.Muzakki,
+		Amount:       input.Amount,
+		Unit:         unit,
+		Value:        value,
+		Type:         input.Type,
 		Status:       "collected", // Initial status is always collected
-		Organization: organization,
-		Timestamp:    timestamp,
+		Organization: input.Organization,
+		Timestamp:    input.Timestamp,
+		Payment:      payment,
+		Hijri:        hijri,
+		Persons:      input.Persons,
+		Jiwa:         jiwa,
+		Conversion:   conversion,
+		Restriction:  restriction,
+		PledgeID:     input.PledgeID,
+		// Anonymous donations carry the donor's commitment instead of a name
+		DonorCommitment: input.DonorCommitment,
 	}
 
 	// Validate status
@@ -190,7 +372,29 @@ func (s *SmartContract) AddZakat(ctx contractapi.TransactionContextInterface, id
 		return err
 	}
 
-	return ctx.GetStub().PutState(id, zakatJSON)
+	if err := ctx.GetStub().PutState(zakat.ID, zakatJSON); err != nil {
+		return err
+	}
+
+	if err := creditPool(ctx, org, zakat); err != nil {
+		return err
+	}
+
+	if err := recordCollection(ctx, zakat); err != nil {
+		return err
+	}
+
+	if err := recordMaalPayment(ctx, org, zakat); err != nil {
+		return err
+	}
+
+	if pledge != nil {
+		if err := fulfilPledge(ctx, *pledge, zakat); err != nil {
+			return err
+		}
+	}
+
+	return journalCollection(ctx, zakat)
 }
 
 // QueryZakat returns the zakat transaction stored in the world state with given id
@@ -212,6 +416,49 @@ func (s *SmartContract) QueryZakat(ctx contractapi.TransactionContextInterface,
 	return zakat, nil
 }
 
+// ZakatHistory is one committed version of a zakat transaction
+type ZakatHistory struct {
+	TxID      string `json:"txId"`      // Transaction that wrote this version
+	Timestamp string `json:"timestamp"` // Commit timestamp of the transaction (RFC 3339)
+	Zakat     Zakat  `json:"zakat"`     // The zakat transaction as written
+}
+
+// GetZakatHistory returns every committed version of a zakat transaction, oldest first
+func (s *SmartContract) GetZakatHistory(ctx contractapi.TransactionContextInterface, id string) ([]ZakatHistory, error) {
+	resultsIterator, err := ctx.GetStub().GetHistoryForKey(id)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read history of %s: %v", id, err)
+	}
+	defer resultsIterator.Close()
+
+	var history []ZakatHistory
+	for resultsIterator.HasNext() {
+		modification, err := resultsIterator.Next()
+		if err != nil {
+			return nil, err
+		}
+		if modification.IsDelete {
+			continue
+		}
+
+		var zakat Zakat
+		err = json.Unmarshal(modification.Value, &zakat)
+		if err != nil {
+			return nil, fmt.Errorf("failed to unmarshal JSON: %v", err)
+		}
+		history = append(history, ZakatHistory{
+			TxID:      modification.TxId,
+			Timestamp: modification.Timestamp.AsTime().UTC().Format(time.RFC3339),
+			Zakat:     zakat,
+		})
+	}
+	if history == nil {
+		return nil, fmt.Errorf("the zakat transaction %s does not exist", id)
+	}
+
+	return history, nil
+}
+
 // GetAllZakat returns all zakat transactions found in world state
 func (s *SmartContract) GetAllZakat(ctx contractapi.TransactionContextInterface) ([]Zakat, error) {
 	resultsIterator, err := ctx.GetStub().GetStateByRange("", "")
@@ -238,34 +485,6 @@ func (s *SmartContract) GetAllZakat(ctx contractapi.TransactionContextInterface)
 	return zakats, nil
 }
 
-// DistributeZakat updates a zakat transaction to mark it as distributed
-func (s *SmartContract) DistributeZakat(ctx contractapi.TransactionContextInterface, id string, mustahik string, amount float64, timestamp string) error {
-	zakat, err := s.QueryZakat(ctx, id)
-	if err != nil {
-		return err
-	}
-
-	if zakat.Status == "distributed" {
-		return fmt.Errorf("zakat transaction %s has already been distributed", id)
-	}
-
-	if amount > zakat.Amount {
-		return fmt.Errorf("distribution amount %f exceeds available amount %f", amount, zakat.Amount)
-	}
-
-	zakat.Status = "distributed"
-	zakat.Mustahik = mustahik
-	zakat.Distribution = amount
-	zakat.DistributedAt = timestamp
-
-	zakatJSON, err := json.Marshal(zakat)
-	if err != nil {
-		return err
-	}
-
-	return ctx.GetStub().PutState(id, zakatJSON)
-}
-
 // ZakatExists returns true when zakat with given ID exists in world state
 func (s *SmartContract) ZakatExists(ctx contractapi.TransactionContextInterface, id string) (bool, error) {
 	zakatJSON, err := ctx.GetStub().GetState(id)
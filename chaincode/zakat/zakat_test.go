@@ -1,8 +1,12 @@
 package main
 
 import (
+	"crypto/x509"
+	"crypto/x509/pkix"
 	"encoding/json"
 	"fmt"
+	"sort"
+	"strings"
 	"testing"
 	"time"
 
@@ -263,11 +267,211 @@ func (m *MockQueryIterator) Close() error {
 	return nil
 }
 
+// MockHistoryIterator implements shim.HistoryQueryIteratorInterface for testing
+type MockHistoryIterator struct {
+	Current int
+	Items   []*queryresult.KeyModification
+}
+
+func (m *MockHistoryIterator) HasNext() bool {
+	return m.Current+1 < len(m.Items)
+}
+
+func (m *MockHistoryIterator) Next() (*queryresult.KeyModification, error) {
+	if !m.HasNext() {
+		return nil, nil
+	}
+	m.Current++
+	return m.Items[m.Current], nil
+}
+
+func (m *MockHistoryIterator) Close() error {
+	return nil
+}
+
+// MockClientIdentity implements cid.ClientIdentity for a fixed MSP and organizational units
+type MockClientIdentity struct {
+	MSPID string
+	OUs   []string
+}
+
+func (m *MockClientIdentity) GetID() (string, error) {
+	return "x509::CN=" + m.MSPID, nil
+}
+
+func (m *MockClientIdentity) GetMSPID() (string, error) {
+	return m.MSPID, nil
+}
+
+func (m *MockClientIdentity) GetAttributeValue(attrName string) (string, bool, error) {
+	return "", false, nil
+}
+
+func (m *MockClientIdentity) AssertAttributeValue(attrName, attrValue string) error {
+	return fmt.Errorf("attribute %s was not found", attrName)
+}
+
+func (m *MockClientIdentity) GetX509Certificate() (*x509.Certificate, error) {
+	return &x509.Certificate{Subject: pkix.Name{OrganizationalUnit: m.OUs}}, nil
+}
+
+// expectBookkeeping lets the stub read and write report aggregates that start out empty
+// and journal entries, and read an empty Hijri calendar, for tests that do not check them
+func expectBookkeeping(chaincodeStub *MockStub) {
+	isBookkeepingKey := mock.MatchedBy(func(key string) bool {
+		return strings.HasPrefix(key, compositeKeyNamespace+aggregateObjectType) ||
+			strings.HasPrefix(key, compositeKeyNamespace+journalObjectType)
+	})
+	isCalendarKey := mock.MatchedBy(func(key string) bool {
+		return strings.HasPrefix(key, compositeKeyNamespace+calendarObjectType)
+	})
+	chaincodeStub.On("GetState", isBookkeepingKey).Return(nil, nil)
+	chaincodeStub.On("GetState", isCalendarKey).Return(nil, nil)
+	chaincodeStub.On("PutState", isBookkeepingKey, mock.Anything).Return(nil)
+}
+
+// compositeKeyNamespace is the prefix shim.CreateCompositeKey puts before every key
+const compositeKeyNamespace = "\x00"
+
+// expectOrganizations lets the stub read the default organizations from the registry
+func expectOrganizations(chaincodeStub *MockStub) {
+	for _, organization := range defaultOrganizations {
+		organizationJSON, _ := json.Marshal(organization)
+		key, _ := organizationKey(organization.Name)
+		codeKey, _ := shim.CreateCompositeKey(organizationCodeIndex, []string{organization.Code})
+		chaincodeStub.On("GetState", key).Return(organizationJSON, nil).Maybe()
+		chaincodeStub.On("GetState", codeKey).Return([]byte(organization.Name), nil).Maybe()
+	}
+}
+
+// seedRegistry stores the default organizations in the world state, as InitLedger does
+func (w *WorldState) seedRegistry() {
+	for _, organization := range defaultOrganizations {
+		organizationJSON, _ := json.Marshal(organization)
+		key, _ := organizationKey(organization.Name)
+		codeKey, _ := shim.CreateCompositeKey(organizationCodeIndex, []string{organization.Code})
+		w.State[key] = organizationJSON
+		w.State[codeKey] = []byte(organization.Name)
+	}
+	registryJSON, _ := json.Marshal(Registry{Organizations: defaultOrganizations})
+	key, _ := registryKey()
+	w.State[key] = registryJSON
+}
+
+// WorldState backs the state methods of a MockStub with an in-memory map, for tests
+// that follow the ledger across several contract calls
+type WorldState struct {
+	State    map[string][]byte
+	Pending  map[string][]byte // Writes not yet visible to reads, see DeferWrites
+	Events   map[string][]byte // Last payload of each chaincode event set
+	Policies map[string][]byte // Key-level endorsement policy set on each key
+}
+
+// newWorldState wires GetState, PutState, GetStateByPartialCompositeKey,
+// GetStateByRange, SetStateValidationParameter and SetEvent of the stub to a new
+// in-memory world state holding the default organizations
+func newWorldState(chaincodeStub *MockStub) *WorldState {
+	worldState := &WorldState{State: map[string][]byte{}, Events: map[string][]byte{}, Policies: map[string][]byte{}}
+	worldState.seedRegistry()
+
+	getState := chaincodeStub.On("GetState", mock.Anything)
+	getState.Run(func(args mock.Arguments) {
+		getState.ReturnArguments = mock.Arguments{worldState.State[args.String(0)], nil}
+	})
+	chaincodeStub.On("PutState", mock.Anything, mock.Anything).Return(nil).Run(func(args mock.Arguments) {
+		if worldState.Pending != nil {
+			worldState.Pending[args.String(0)] = args.Get(1).([]byte)
+			return
+		}
+		worldState.State[args.String(0)] = args.Get(1).([]byte)
+	})
+	partialKey := chaincodeStub.On("GetStateByPartialCompositeKey", mock.Anything, mock.Anything)
+	partialKey.Run(func(args mock.Arguments) {
+		prefix, err := shim.CreateCompositeKey(args.String(0), args.Get(1).([]string))
+		if err != nil {
+			panic(err)
+		}
+		partialKey.ReturnArguments = mock.Arguments{worldState.iterator(prefix), nil}
+	})
+	keyRange := chaincodeStub.On("GetStateByRange", mock.Anything, mock.Anything)
+	keyRange.Run(func(args mock.Arguments) {
+		keyRange.ReturnArguments = mock.Arguments{worldState.rangeIterator(args.String(0), args.String(1)), nil}
+	})
+	chaincodeStub.On("SetStateValidationParameter", mock.Anything, mock.Anything).Return(nil).Maybe().Run(func(args mock.Arguments) {
+		worldState.Policies[args.String(0)] = args.Get(1).([]byte)
+	})
+	chaincodeStub.On("SetEvent", mock.Anything, mock.Anything).Return(nil).Maybe().Run(func(args mock.Arguments) {
+		worldState.Events[args.String(0)] = args.Get(1).([]byte)
+	})
+
+	return worldState
+}
+
+// DeferWrites makes writes invisible to reads until Commit, as on a peer where a
+// transaction does not read its own writes
+func (w *WorldState) DeferWrites() {
+	w.Pending = map[string][]byte{}
+}
+
+// Commit applies the deferred writes
+func (w *WorldState) Commit() {
+	for key, value := range w.Pending {
+		w.State[key] = value
+	}
+	w.Pending = map[string][]byte{}
+}
+
+// rangeIterator returns the simple (non-composite) keys in [startKey, endKey), in key
+// order. Empty bounds are open, as in the peer.
+func (w *WorldState) rangeIterator(startKey string, endKey string) *MockQueryIterator {
+	var keys []string
+	for key := range w.State {
+		if strings.HasPrefix(key, compositeKeyNamespace) {
+			continue
+		}
+		if key >= startKey && (endKey == "" || key < endKey) {
+			keys = append(keys, key)
+		}
+	}
+	sort.Strings(keys)
+
+	iterator := &MockQueryIterator{Current: -1, Items: []QueryResult{}}
+	for _, key := range keys {
+		iterator.Items = append(iterator.Items, QueryResult{Key: key, Value: w.State[key]})
+	}
+	return iterator
+}
+
+// iterator returns the entries whose keys start with prefix, in key order
+func (w *WorldState) iterator(prefix string) *MockQueryIterator {
+	var keys []string
+	for key := range w.State {
+		if strings.HasPrefix(key, prefix) {
+			keys = append(keys, key)
+		}
+	}
+	sort.Strings(keys)
+
+	iterator := &MockQueryIterator{Current: -1, Items: []QueryResult{}}
+	for _, key := range keys {
+		iterator.Items = append(iterator.Items, QueryResult{Key: key, Value: w.State[key]})
+	}
+	return iterator
+}
+
+// Get unmarshals the value stored under key into v
+func (w *WorldState) Get(t *testing.T, key string, v interface{}) {
+	value, ok := w.State[key]
+	require.True(t, ok, "no state for key %q", key)
+	require.NoError(t, json.Unmarshal(value, v))
+}
+
 func TestInitLedger(t *testing.T) {
 	t.Run("Success", func(t *testing.T) {
 		chaincodeStub := new(MockStub)
 		transactionContext := new(contractapi.TransactionContext)
 		transactionContext.SetStub(chaincodeStub)
+		transactionContext.SetClientIdentity(&MockClientIdentity{MSPID: "YDSFMalangMSP", OUs: []string{"admin"}})
 
 		now := time.Now()
 		ts := &timestamppb.Timestamp{
@@ -279,6 +483,31 @@ func TestInitLedger(t *testing.T) {
 		chaincodeStub.On("GetTxTimestamp").Return(ts, nil).Maybe()
 		chaincodeStub.On("GetState", "ZKT-YDSF-MLG-202311-0001").Return(nil, nil)
 
+		// Set up expectations for registering the default organizations
+		registryStateKey, err := registryKey()
+		require.NoError(t, err)
+		isOrganizationKey := mock.MatchedBy(func(key string) bool {
+			return strings.HasPrefix(key, compositeKeyNamespace+organizationObjectType)
+		})
+		chaincodeStub.On("GetState", registryStateKey).Return(nil, nil)
+		chaincodeStub.On("SetStateValidationParameter", isOrganizationKey, mock.Anything).Return(nil).Times(4)
+		chaincodeStub.On("PutState", isOrganizationKey, mock.Anything).Return(nil).Times(4)
+		chaincodeStub.On("SetStateValidationParameter", registryStateKey, mock.Anything).Return(nil)
+		chaincodeStub.On("PutState", registryStateKey, mock.Anything).Return(nil).Run(func(args mock.Arguments) {
+			var registry Registry
+			err := json.Unmarshal(args.Get(1).([]byte), &registry)
+			require.NoError(t, err)
+			require.Equal(t, defaultOrganizations, registry.Organizations)
+		})
+
+		// Set up expectations for the pool the initial zakat is credited to
+		poolStateKey, err := poolKey("POOL-YDSF-MLG-MAAL")
+		require.NoError(t, err)
+		chaincodeStub.On("GetState", poolStateKey).Return(nil, nil)
+		chaincodeStub.On("SetStateValidationParameter", poolStateKey, mock.Anything).Return(nil)
+		chaincodeStub.On("PutState", poolStateKey, mock.Anything).Return(nil)
+		expectBookkeeping(chaincodeStub)
+
 		// Set up expectation for state update with mock.Anything for timestamp
 		chaincodeStub.On("PutState", "ZKT-YDSF-MLG-202311-0001", mock.Anything).Return(nil).Run(func(args mock.Arguments) {
 			// Verify the JSON structure
@@ -300,7 +529,7 @@ func TestInitLedger(t *testing.T) {
 		})
 
 		smartContract := new(SmartContract)
-		err := smartContract.InitLedger(transactionContext)
+		err = smartContract.InitLedger(transactionContext)
 		require.NoError(t, err)
 
 		chaincodeStub.AssertExpectations(t)
@@ -338,10 +567,23 @@ func TestInitLedger(t *testing.T) {
 		chaincodeStub.AssertExpectations(t)
 	})
 
+	t.Run("Not an admin of a default organization", func(t *testing.T) {
+		chaincodeStub := new(MockStub)
+		transactionContext := new(contractapi.TransactionContext)
+		transactionContext.SetStub(chaincodeStub)
+		transactionContext.SetClientIdentity(&MockClientIdentity{MSPID: "YDSFSurabayaMSP", OUs: []string{"admin"}})
+		newWorldState(chaincodeStub).State = map[string][]byte{}
+
+		err := new(SmartContract).InitLedger(transactionContext)
+		require.Error(t, err)
+		require.Contains(t, err.Error(), "client MSP YDSFSurabayaMSP is not an active registered organization")
+	})
+
 	t.Run("PutState error", func(t *testing.T) {
 		chaincodeStub := new(MockStub)
 		transactionContext := new(contractapi.TransactionContext)
 		transactionContext.SetStub(chaincodeStub)
+		transactionContext.SetClientIdentity(&MockClientIdentity{MSPID: "YDSFMalangMSP", OUs: []string{"admin"}})
 
 		now := time.Now()
 		ts := &timestamppb.Timestamp{
@@ -352,7 +594,10 @@ func TestInitLedger(t *testing.T) {
 		// Set up expectations
 		chaincodeStub.On("GetTxTimestamp").Return(ts, nil).Maybe()
 		chaincodeStub.On("GetState", "ZKT-YDSF-MLG-202311-0001").Return(nil, nil)
+		chaincodeStub.On("GetState", mock.Anything).Return(nil, nil)
 		chaincodeStub.On("PutState", "ZKT-YDSF-MLG-202311-0001", mock.Anything).Return(fmt.Errorf("PutState error"))
+		chaincodeStub.On("PutState", mock.Anything, mock.Anything).Return(nil)
+		chaincodeStub.On("SetStateValidationParameter", mock.Anything, mock.Anything).Return(nil)
 
 		smartContract := new(SmartContract)
 		err := smartContract.InitLedger(transactionContext)
@@ -367,6 +612,7 @@ func TestAddZakat(t *testing.T) {
 	chaincodeStub := new(MockStub)
 	transactionContext := new(contractapi.TransactionContext)
 	transactionContext.SetStub(chaincodeStub)
+	expectOrganizations(chaincodeStub)
 
 	now := time.Now()
 	ts := &timestamppb.Timestamp{
@@ -388,8 +634,31 @@ func TestAddZakat(t *testing.T) {
 	chaincodeStub.On("GetState", zakat.ID).Return(nil, nil) // Zakat doesn't exist yet
 	chaincodeStub.On("PutState", zakat.ID, mock.Anything).Return(nil)
 
+	poolStateKey, err := poolKey("POOL-YDSF-MLG-MAAL")
+	require.NoError(t, err)
+	chaincodeStub.On("GetState", poolStateKey).Return(nil, nil)
+	chaincodeStub.On("SetStateValidationParameter", poolStateKey, mock.Anything).Return(nil)
+	chaincodeStub.On("PutState", poolStateKey, mock.Anything).Return(nil).Run(func(args mock.Arguments) {
+		var pool Pool
+		err := json.Unmarshal(args.Get(1).([]byte), &pool)
+		require.NoError(t, err)
+		require.Equal(t, float64(1000000), pool.Balance)
+		require.Equal(t, []PoolSource{{ZakatID: zakat.ID, Remaining: 1000000}}, pool.Sources)
+	})
+	donorStateKey, err := donorKey("YDSF Malang", "John Doe")
+	require.NoError(t, err)
+	chaincodeStub.On("GetState", donorStateKey).Return(nil, nil)
+	chaincodeStub.On("SetStateValidationParameter", donorStateKey, mock.Anything).Return(nil)
+	chaincodeStub.On("PutState", donorStateKey, mock.Anything).Return(nil).Run(func(args mock.Arguments) {
+		var donor Donor
+		err := json.Unmarshal(args.Get(1).([]byte), &donor)
+		require.NoError(t, err)
+		require.Equal(t, Donor{Muzakki: "John Doe", Organization: "YDSF Malang", HaulStart: zakat.Timestamp, LastMaalPayment: zakat.Timestamp, LastMaalZakatID: zakat.ID}, donor)
+	})
+	expectBookkeeping(chaincodeStub)
+
 	smartContract := new(SmartContract)
-	err := smartContract.AddZakat(transactionContext, zakat.ID, zakat.Muzakki, zakat.Amount, zakat.Type, zakat.Organization, zakat.Timestamp)
+	err = smartContract.AddZakat(transactionContext, zakat.ID, zakat.Muzakki, zakat.Amount, zakat.Type, zakat.Organization, zakat.Timestamp)
 	require.NoError(t, err)
 
 	chaincodeStub.AssertExpectations(t)
@@ -491,7 +760,7 @@ func TestGetAllZakat(t *testing.T) {
 	chaincodeStub.AssertExpectations(t)
 }
 
-func TestDistributeZakat(t *testing.T) {
+func TestZakatExists(t *testing.T) {
 	chaincodeStub := new(MockStub)
 	transactionContext := new(contractapi.TransactionContext)
 	transactionContext.SetStub(chaincodeStub)
@@ -509,7 +778,7 @@ func TestDistributeZakat(t *testing.T) {
 		Type:         "maal",
 		Organization: "YDSF Malang",
 		Status:       "collected",
-		Timestamp:    "2023-11-01T10:00:00Z",
+		Timestamp:    now.Format(time.RFC3339),
 	}
 
 	zakatJSON, err := json.Marshal(zakat)
@@ -517,51 +786,61 @@ func TestDistributeZakat(t *testing.T) {
 
 	chaincodeStub.On("GetTxTimestamp").Return(ts, nil).Maybe()
 	chaincodeStub.On("GetState", zakat.ID).Return(zakatJSON, nil)
-	chaincodeStub.On("PutState", zakat.ID, mock.Anything).Return(nil)
 
 	smartContract := new(SmartContract)
-	err = smartContract.DistributeZakat(transactionContext, zakat.ID, "Mustahik1", 1000000, now.Format(time.RFC3339))
+	exists, err := smartContract.ZakatExists(transactionContext, zakat.ID)
 	require.NoError(t, err)
-
-	chaincodeStub.On("GetState", "non-existent-id").Return(nil, nil)
-	err = smartContract.DistributeZakat(transactionContext, "non-existent-id", "Mustahik2", 1000000, now.Format(time.RFC3339))
-	require.Error(t, err)
-	require.Contains(t, err.Error(), "does not exist")
+	require.True(t, exists)
 
 	chaincodeStub.AssertExpectations(t)
 }
 
-func TestZakatExists(t *testing.T) {
+func TestGetZakatHistory(t *testing.T) {
 	chaincodeStub := new(MockStub)
 	transactionContext := new(contractapi.TransactionContext)
 	transactionContext.SetStub(chaincodeStub)
 
-	now := time.Now()
-	ts := &timestamppb.Timestamp{
-		Seconds: now.Unix(),
-		Nanos:   int32(now.Nanosecond()),
-	}
-
-	zakat := Zakat{
+	collected := Zakat{
 		ID:           "ZKT-YDSF-MLG-202311-0001",
 		Muzakki:      "John Doe",
 		Amount:       1000000,
 		Type:         "maal",
 		Organization: "YDSF Malang",
 		Status:       "collected",
-		Timestamp:    now.Format(time.RFC3339),
+		Timestamp:    "2023-11-01T08:00:00Z",
 	}
+	distributed := collected
+	distributed.Status = "distributed"
+	distributed.Distribution = 1000000
+	distributed.DistributedAt = "2023-11-20T10:00:00Z"
+	distributed.Distributions = []string{"DST-YDSF-MLG-202311-0001"}
 
-	zakatJSON, err := json.Marshal(zakat)
+	collectedJSON, err := json.Marshal(collected)
+	require.NoError(t, err)
+	distributedJSON, err := json.Marshal(distributed)
 	require.NoError(t, err)
 
-	chaincodeStub.On("GetTxTimestamp").Return(ts, nil).Maybe()
-	chaincodeStub.On("GetState", zakat.ID).Return(zakatJSON, nil)
+	iterator := &MockHistoryIterator{
+		Current: -1,
+		Items: []*queryresult.KeyModification{
+			{TxId: "tx1", Value: collectedJSON, Timestamp: timestamppb.New(time.Date(2023, 11, 1, 8, 0, 1, 0, time.UTC))},
+			{TxId: "tx2", Value: distributedJSON, Timestamp: timestamppb.New(time.Date(2023, 11, 20, 10, 0, 1, 0, time.UTC))},
+		},
+	}
+	chaincodeStub.On("GetHistoryForKey", collected.ID).Return(iterator, nil)
 
 	smartContract := new(SmartContract)
-	exists, err := smartContract.ZakatExists(transactionContext, zakat.ID)
+	history, err := smartContract.GetZakatHistory(transactionContext, collected.ID)
 	require.NoError(t, err)
-	require.True(t, exists)
+	require.Equal(t, []ZakatHistory{
+		{TxID: "tx1", Timestamp: "2023-11-01T08:00:01Z", Zakat: collected},
+		{TxID: "tx2", Timestamp: "2023-11-20T10:00:01Z", Zakat: distributed},
+	}, history)
+
+	// A key that was never written has no history
+	chaincodeStub.On("GetHistoryForKey", "ZKT-YDSF-MLG-202311-0099").Return(&MockHistoryIterator{Current: -1}, nil)
+	_, err = smartContract.GetZakatHistory(transactionContext, "ZKT-YDSF-MLG-202311-0099")
+	require.ErrorContains(t, err, "does not exist")
 
 	chaincodeStub.AssertExpectations(t)
 }
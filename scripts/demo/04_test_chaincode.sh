@@ -12,6 +12,28 @@ format_json() {
   fi
 }
 
+# Test 0: Initializing the ledger and the organization registry
+echo "Test 0: Initializing the ledger and the organization registry..."
+echo " Invoking chaincode on YDSFMalang..."
+echo " Command to be executed:"
+echo " peer chaincode invoke -C zakat-channel -n zakat -c '{\"function\":\"InitLedger\",\"Args\":[]}'"
+echo
+RESULT=$(docker run --rm \
+  -v ${FABRIC_ZAKAT_PATH}:/opt/fabric-zakat \
+  -w /opt/fabric-zakat/scripts \
+  --network fabric_test \
+  -e CORE_PEER_TLS_ENABLED=true \
+  -e CORE_PEER_LOCALMSPID="YDSFMalangMSP" \
+  -e CORE_PEER_TLS_ROOTCERT_FILE=/opt/fabric-zakat/organizations/peerOrganizations/ydsfmalang.example.local/peers/peer0.ydsfmalang.example.local/tls/ca.crt \
+  -e CORE_PEER_MSPCONFIGPATH=/opt/fabric-zakat/organizations/peerOrganizations/ydsfmalang.example.local/users/Admin@ydsfmalang.example.local/msp \
+  -e CORE_PEER_ADDRESS=peer0.ydsfmalang.example.local:7051 \
+  hyperledger/fabric-tools:2.4 \
+  peer chaincode invoke -o orderer.example.local:7050 --tls --cafile /opt/fabric-zakat/organizations/ordererOrganizations/example.local/orderers/orderer.example.local/msp/tlscacerts/tlsca.example.local-cert.pem -C zakat-channel -n zakat -c '{"function":"InitLedger","Args":[]}')
+format_json "$RESULT"
+
+# Wait for transaction to be committed
+sleep 5
+
 # Test 1: Adding a new zakat transaction
 echo "Test 1: Adding a new zakat transaction..."
 echo " Invoking chaincode on YDSFMalang..."
@@ -58,21 +80,21 @@ sleep 2
 
 # Test 3: Distributing zakat
 echo -e "\nTest 3: Distributing zakat..."
-echo " Invoking chaincode on YDSFJatim..."
+echo " Invoking chaincode on YDSFMalang (pools can only be changed by their own organization)..."
 echo " Command to be executed:"
-echo " peer chaincode invoke -C zakat-channel -n zakat -c '{\"function\":\"DistributeZakat\",\"Args\":[\"ZKT-YDSF-MLG-202401-0001\", \"ahmad\", \"500000\", \"2024-01-26T12:00:00Z\"]}'"
+echo " peer chaincode invoke -C zakat-channel -n zakat -c '{\"function\":\"DistributeZakat\",\"Args\":[\"DST-YDSF-MLG-202401-0001\", \"POOL-YDSF-MLG-MAAL\", \"\", \"ahmad\", \"500000\", \"2024-01-26T12:00:00Z\"]}'"
 echo
 RESULT=$(docker run --rm \
   -v ${FABRIC_ZAKAT_PATH}:/opt/fabric-zakat \
   -w /opt/fabric-zakat/scripts \
   --network fabric_test \
   -e CORE_PEER_TLS_ENABLED=true \
-  -e CORE_PEER_LOCALMSPID="YDSFJatimMSP" \
-  -e CORE_PEER_TLS_ROOTCERT_FILE=/opt/fabric-zakat/organizations/peerOrganizations/ydsfjatim.example.local/peers/peer0.ydsfjatim.example.local/tls/ca.crt \
-  -e CORE_PEER_MSPCONFIGPATH=/opt/fabric-zakat/organizations/peerOrganizations/ydsfjatim.example.local/users/Admin@ydsfjatim.example.local/msp \
-  -e CORE_PEER_ADDRESS=peer0.ydsfjatim.example.local:8051 \
+  -e CORE_PEER_LOCALMSPID="YDSFMalangMSP" \
+  -e CORE_PEER_TLS_ROOTCERT_FILE=/opt/fabric-zakat/organizations/peerOrganizations/ydsfmalang.example.local/peers/peer0.ydsfmalang.example.local/tls/ca.crt \
+  -e CORE_PEER_MSPCONFIGPATH=/opt/fabric-zakat/organizations/peerOrganizations/ydsfmalang.example.local/users/Admin@ydsfmalang.example.local/msp \
+  -e CORE_PEER_ADDRESS=peer0.ydsfmalang.example.local:7051 \
   hyperledger/fabric-tools:2.4 \
-  peer chaincode invoke -o orderer.example.local:7050 --tls --cafile /opt/fabric-zakat/organizations/ordererOrganizations/example.local/orderers/orderer.example.local/msp/tlscacerts/tlsca.example.local-cert.pem -C zakat-channel -n zakat -c '{"function":"DistributeZakat","Args":["ZKT-YDSF-MLG-202401-0001", "ahmad", "500000", "2024-01-26T12:00:00Z"]}')
+  peer chaincode invoke -o orderer.example.local:7050 --tls --cafile /opt/fabric-zakat/organizations/ordererOrganizations/example.local/orderers/orderer.example.local/msp/tlscacerts/tlsca.example.local-cert.pem -C zakat-channel -n zakat -c '{"function":"DistributeZakat","Args":["DST-YDSF-MLG-202401-0001", "POOL-YDSF-MLG-MAAL", "", "ahmad", "500000", "2024-01-26T12:00:00Z"]}')
 format_json "$RESULT"
 
 # Wait for distribution to be committed